@@ -0,0 +1,95 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"strings"
+)
+
+// A section header in the file read, and so the start of a block of values.
+type sectionBlock struct {
+	section    string // as written
+	subSection string
+	origin     ValueOrigin
+}
+
+// A section header given more than once in the file read.
+type DuplicateSection struct {
+	Section    string        // lower case
+	SubSection string        // empty for the section itself
+	Origins    []ValueOrigin // of each header, in file order
+}
+
+func (self *Config) addBlock(section, subSection string, origin ValueOrigin) *sectionBlock {
+	b := &sectionBlock{section: section, subSection: subSection, origin: origin}
+	self.blocks = append(self.blocks, b)
+	if self.blockCount == nil {
+		self.blockCount = make(map[string]int, 10)
+	}
+	self.blockCount[lazySectionId(section, subSection)]++
+	return b
+}
+
+// Gets the sections whose header was given more than once when the config
+// was read, in the order first seen. The result is empty if there were none.
+func (self *Config) DuplicateSections() []DuplicateSection {
+	var out []DuplicateSection
+	index := make(map[string]int, 5)
+	for _, b := range self.blocks {
+		id := lazySectionId(b.section, b.subSection)
+		if self.blockCount[id] < 2 {
+			continue
+		}
+		i, ok := index[id]
+		if !ok {
+			i = len(out)
+			index[id] = i
+			out = append(out, DuplicateSection{Section: strings.ToLower(b.section), SubSection: b.subSection})
+		}
+		out[i].Origins = append(out[i].Origins, b.origin)
+	}
+	return out
+}
+
+// Writes the config with each section block where it was read, for
+// DuplicateSectionsKeep. Values not read from a block go in the last block
+// of their section, sections added since reading go at the end.
+func (self *Config) blocksString() string {
+	own := make(map[*sectionBlock]bool, len(self.blocks))
+	last := make(map[string]*sectionBlock, len(self.blocks))
+	for _, b := range self.blocks {
+		own[b] = true
+		last[lazySectionId(b.section, b.subSection)] = b
+	}
+	keepQuotes := self.options.KeepQuoting
+	out := self.BaseValues.entriesString(nil, keepQuotes)
+	for _, b := range self.blocks {
+		values := self.LookupValueSet(b.section, b.subSection)
+		if values == nil {
+			continue // removed since
+		}
+		isLast := last[lazySectionId(b.section, b.subSection)] == b
+		out += sectionHeader(b.section, b.subSection) + "\n" + values.entriesString(func(e *ValueEntry) bool {
+			return e.block == b || (isLast && !own[e.block])
+		}, keepQuotes)
+	}
+	for _, name := range self.SectionNames() {
+		s := self.Sections[name]
+		if last[lazySectionId(name, "")] == nil {
+			if values := s.Values.entriesString(nil, keepQuotes); values != "" {
+				out += sectionHeader(s.OrigCaseName, "") + "\n" + values
+			}
+		}
+		for _, ssName := range s.subSectionNames() {
+			if last[lazySectionId(name, ssName)] != nil {
+				continue
+			}
+			if values := s.SubSections[ssName].Values.entriesString(nil, keepQuotes); values != "" {
+				out += sectionHeader(s.OrigCaseName, ssName) + "\n" + values
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const duplicateConfig = "[user]\n\tname = Joe\n[core]\n\tbare\n[User]\n\temail = joe@example.com\n\tname = Joseph\n"
+
+func TestDuplicateSections(t *testing.T) {
+	config, err := NewConfigFromString(duplicateConfig)
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	expect := []DuplicateSection{{Section: "user", Origins: []ValueOrigin{{Line: 1}, {Line: 5}}}}
+	if got := config.DuplicateSections(); !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expect duplicates %v, but got %v", expect, got)
+	}
+	if v, _ := config.GetKeyValueAsString("user.name"); v != "Joseph" {
+		t.Errorf("Expect user.name from the last block, but got '%s'", v)
+	}
+	merged := "[user]\n\temail = joe@example.com\n\tname = Joe\n\tname = Joseph\n[core]\n\tbare\n"
+	if out := config.String(); out != merged {
+		t.Errorf("Expect merged output:\n%s\nbut got:\n%s", merged, out)
+	}
+
+	config, _ = NewConfigFromString("[user]\n\tname = Joe\n[user \"x\"]\n\tname = X\n")
+	if got := config.DuplicateSections(); len(got) != 0 {
+		t.Errorf("Expect no duplicates, but got %v", got)
+	}
+}
+
+func TestDuplicateSectionsKeep(t *testing.T) {
+	config, err := NewConfigFromStringOptions(duplicateConfig, ParseOptions{DuplicateSections: DuplicateSectionsKeep})
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	if out := config.String(); out != duplicateConfig {
+		t.Errorf("Expect blocks kept:\n%s\nbut got:\n%s", duplicateConfig, out)
+	}
+	config.AddKeyValue("user", "", "signingkey", nil)
+	config.Set("alias.st", "status")
+	config.Unset("core.bare")
+	expect := "[user]\n\tname = Joe\n[core]\n[User]\n\temail = joe@example.com\n\tname = Joseph\n\tsigningkey\n[alias]\n\tst = status\n"
+	if out := config.String(); out != expect {
+		t.Errorf("Expect edited blocks:\n%s\nbut got:\n%s", expect, out)
+	}
+	config.Canonicalize()
+	expect = "[alias]\n\tst = status\n[user]\n\temail = joe@example.com\n\tname = Joe\n\tname = Joseph\n\tsigningkey\n"
+	if out := config.String(); out != expect {
+		t.Errorf("Expect canonical output to merge blocks:\n%s\nbut got:\n%s", expect, out)
+	}
+}
+
+func TestDuplicateSectionsError(t *testing.T) {
+	_, err := NewConfigFromStringOptions(duplicateConfig, ParseOptions{DuplicateSections: DuplicateSectionsError})
+	var perr *ParseError
+	if !errors.Is(err, ErrDuplicateSection) || !errors.As(err, &perr) || perr.LineNo != 5 {
+		t.Errorf("Expect duplicate section error on line 5, but got: %v", err)
+	}
+	if _, err := NewConfigFromStringOptions("[a]\nx=1\n[a \"b\"]\ny=2\n", ParseOptions{DuplicateSections: DuplicateSectionsError}); err != nil {
+		t.Errorf("Expect sub-section not to count as a duplicate, but got: %s", err)
+	}
+}
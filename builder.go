@@ -0,0 +1,116 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+)
+
+// A Builder constructs a Config in code, checking each section, key and
+// value as it is given. Calls chain, and the first error stops any further
+// changes and is returned by Build:
+//
+//	cfg, err := gitconfig.NewBuilder().
+//		Section("remote", "origin").Set("url", u).Add("fetch", spec).
+//		Section("core", "").Set("bare", "false").
+//		Build()
+type Builder struct {
+	cfg        *Config
+	section    string
+	subSection string
+	inSection  bool
+	provenance string // see Provenance
+	err        error
+}
+
+func NewBuilder() *Builder {
+	return &Builder{cfg: NewConfig()}
+}
+
+// Makes following keys go into the given section, and sub-section unless
+// that is empty.
+func (self *Builder) Section(name, subSection string) *Builder {
+	if self.err != nil {
+		return self
+	}
+	if err := validateSectionName(name); err != nil {
+		return self.fail(err)
+	}
+	if err := validateSubSectionName(subSection); err != nil {
+		return self.fail(err)
+	}
+	self.section, self.subSection, self.inSection = name, subSection, true
+	// so empty sections are kept too
+	self.cfg.EnsureValueSet(name, subSection)
+	return self
+}
+
+// Replaces any values of the key in the current section with value.
+func (self *Builder) Set(key, value string) *Builder {
+	if cvs := self.values(key, value); cvs != nil {
+		cvs.Entries = []ValueEntry{self.entry(value)}
+		cvs.syncValue()
+	}
+	return self
+}
+
+// Adds a value to the key in the current section, after any it has.
+func (self *Builder) Add(key, value string) *Builder {
+	if cvs := self.values(key, value); cvs != nil {
+		cvs.Entries = append(cvs.Entries, self.entry(value))
+		cvs.syncValue()
+	}
+	return self
+}
+
+// Adds the key to the current section without a value, e.g. "[core] bare",
+// which git reads as boolean true.
+func (self *Builder) Flag(key string) *Builder {
+	if cvs := self.values(key, ""); cvs != nil {
+		cvs.Entries = append(cvs.Entries, ValueEntry{})
+		cvs.syncValue()
+	}
+	return self
+}
+
+// Gets the built Config, or the first error found while building it.
+func (self *Builder) Build() (*Config, error) {
+	if self.err != nil {
+		return nil, self.err
+	}
+	return self.cfg, nil
+}
+
+// Validates the key and value, returning where the value should go.
+func (self *Builder) values(key, value string) *ConfigValue {
+	if self.err != nil {
+		return nil
+	}
+	if !self.inSection {
+		self.fail(fmt.Errorf("Key '%s' given before any section: %w", key, ErrSectionNotFound))
+		return nil
+	}
+	if err := validateKeyName(key, ""); err != nil {
+		self.fail(err)
+		return nil
+	}
+	if err := validateValue(key, value); err != nil {
+		self.fail(err)
+		return nil
+	}
+	return self.cfg.EnsureValues(self.section, self.subSection, key)
+}
+
+func (self *Builder) fail(err error) *Builder {
+	if self.inSection {
+		name := self.section
+		if self.subSection != "" {
+			name += " \"" + self.subSection + "\""
+		}
+		err = fmt.Errorf("In section [%s]: %w", name, err)
+	}
+	self.err = err
+	return self
+}
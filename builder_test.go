@@ -0,0 +1,47 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	cfg, err := NewBuilder().
+		Section("remote", "origin").Set("url", "a").Set("url", "b").Add("fetch", "x").Add("fetch", "y").
+		Section("core", "").Flag("bare").Set("editor", "vim").
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build: %s", err)
+	}
+	testValue(t, cfg, "remote.origin.url", "b", true)
+	if got := cfg.GetKeyValuesStrings("remote.origin.fetch"); len(got) != 2 || got[0] != "x" || got[1] != "y" {
+		t.Errorf("Expect fetch values x, y but got %v", got)
+	}
+	if b, ok, err := cfg.GetKeyValueAsBool("core.bare"); !b || !ok || err != nil {
+		t.Errorf("Expect flag core.bare to be true but got %v (exists: %v, err: %v)", b, ok, err)
+	}
+
+	tests := map[string]*Builder{
+		"before any section":        NewBuilder().Set("url", "a"),
+		"in section name":           NewBuilder().Section("re mote", ""),
+		"starting key":              NewBuilder().Section("core", "").Set("1st", "a"),
+		"[core]: Unexpected '_'":    NewBuilder().Section("core", "").Set("a_b", "a"),
+		"must not contain NUL":      NewBuilder().Section("core", "").Set("a", "x\x00y"),
+		"newlines or NUL":           NewBuilder().Section("remote", "a\nb"),
+		"[remote \"origin\"]: Key ": NewBuilder().Section("remote", "origin").Set("", "a").Section("core", ""),
+	}
+	for expect, b := range tests {
+		_, err := b.Build()
+		if err == nil || !strings.Contains(err.Error(), expect) {
+			t.Errorf("Expect build error containing '%s' but got %v", expect, err)
+		}
+		if !errors.Is(err, ErrInvalidKey) && !errors.Is(err, ErrInvalidValue) && !errors.Is(err, ErrSectionNotFound) {
+			t.Errorf("Expect build error to wrap a sentinel error but got %v", err)
+		}
+	}
+}
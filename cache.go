@@ -0,0 +1,97 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+// A CachingLoader reads and merges config files, remembering each file's
+// modification time and size so that files which have not changed since
+// they were last read are not parsed again.
+// A file rewritten with the same size within the file system's timestamp
+// granularity will not be noticed; call Forget after such writes.
+// It is safe for concurrent use.
+type CachingLoader struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	stamp fileStamp
+	cfg   *Config
+}
+
+func NewCachingLoader() *CachingLoader {
+	return &CachingLoader{
+		entries: make(map[string]cacheEntry, 10),
+	}
+}
+
+// Returns a new Config with the values of all the files merged in order,
+// so later files take precedence. Files which do not exist are skipped.
+// The returned Config shares nothing with the cache, so may be modified.
+func (self *CachingLoader) Load(files ...string) (*Config, error) {
+	return self.LoadContext(context.Background(), files...)
+}
+
+// Like Load, but gives up once ctx is done, returning its error.
+func (self *CachingLoader) LoadContext(ctx context.Context, files ...string) (*Config, error) {
+	out := NewConfig()
+	for _, file := range files {
+		cfg, err := self.get(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+		if cfg != nil {
+			out.Merge(cfg)
+		}
+	}
+	return out, nil
+}
+
+// Gets the parsed config for a single file, or nil if it does not exist.
+func (self *CachingLoader) get(ctx context.Context, file string) (*Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	stamp := statFile(file)
+	self.mu.Lock()
+	entry, ok := self.entries[file]
+	self.mu.Unlock()
+	if ok && entry.stamp == stamp {
+		reportCache(CacheFiles, true)
+		return entry.cfg, nil
+	}
+	reportCache(CacheFiles, false)
+	var cfg *Config
+	if stamp.exists {
+		var err error
+		cfg, err = NewConfigFromFileContext(ctx, file)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	self.mu.Lock()
+	self.entries[file] = cacheEntry{stamp: stamp, cfg: cfg}
+	self.mu.Unlock()
+	return cfg, nil
+}
+
+// Drops any cached copy of the file, so the next Load re-reads it.
+func (self *CachingLoader) Forget(file string) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	delete(self.entries, file)
+}
+
+// Drops every cached file.
+func (self *CachingLoader) Clear() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.entries = make(map[string]cacheEntry, 10)
+}
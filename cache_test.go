@@ -0,0 +1,47 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCachingLoader(t *testing.T) {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "global")
+	local := filepath.Join(dir, "local")
+	if err := os.WriteFile(global, []byte("[user]\n    name = Joe\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	loader := NewCachingLoader()
+	config, err := loader.Load(global, local)
+	if err != nil {
+		t.Fatalf("Failed to load: %s", err)
+	}
+	testValue(t, config, "user.name", "Joe", true)
+	cached := loader.entries[global].cfg
+
+	config.Set("user.name", "Changed")
+	if err := os.WriteFile(local, []byte("[user]\n    email = j@example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err = loader.Load(global, local)
+	if err != nil {
+		t.Fatalf("Failed to load: %s", err)
+	}
+	testValue(t, config, "user.name", "Joe", true)
+	testValue(t, config, "user.email", "j@example.com", true)
+	if loader.entries[global].cfg != cached {
+		t.Errorf("Expect unchanged global file to not be parsed again\n")
+	}
+
+	if err := os.WriteFile(global, []byte("[user]\n    name = Joanne Bloggs\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, _ = loader.Load(global, local)
+	testValue(t, config, "user.name", "Joanne Bloggs", true)
+}
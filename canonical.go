@@ -0,0 +1,105 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+)
+
+// Puts the config in canonical form, so that String writes it the same way
+// whatever the layout of the file it was read from: section and key names
+// lower case, sections and sub-sections sorted by name (keys always are),
+// one block per section and one key per line indented by a tab.
+// Sub-section names are case sensitive and kept as they are. Values, and
+// their order within a key, are not changed.
+// Repeated blocks of the same section come out as one, even if read with
+// DuplicateSectionsKeep. Comments are not kept.
+func (self *Config) Canonicalize() {
+	self.options.DuplicateSections = DuplicateSectionsMerge
+	canonicalizeValues(self.BaseValues)
+	names := make([]string, 0, len(self.Sections))
+	for name := range self.Sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	self.seq = 0
+	for _, name := range names {
+		s := self.Sections[name]
+		s.OrigCaseName = s.Name
+		self.seq++
+		s.seq = self.seq
+		canonicalizeValues(s.Values)
+		subNames := make([]string, 0, len(s.SubSections))
+		for ssName := range s.SubSections {
+			subNames = append(subNames, ssName)
+		}
+		sort.Strings(subNames)
+		for _, ssName := range subNames {
+			ss := s.SubSections[ssName]
+			self.seq++
+			ss.seq = self.seq
+			canonicalizeValues(ss.Values)
+		}
+	}
+}
+
+func canonicalizeValues(values ConfigValueSet) {
+	for _, cv := range values {
+		cv.OrigCaseName = cv.Name
+		for i := range cv.Entries {
+			cv.Entries[i].Comment = ""
+		}
+	}
+}
+
+// Parses the config text and gives it back in canonical form, see
+// Config.Canonicalize.
+func CanonicalString(data string) (string, error) {
+	cfg, err := NewConfigFromString(data)
+	if err != nil {
+		return "", err
+	}
+	cfg.Canonicalize()
+	return cfg.String(), nil
+}
+
+// Gets a fingerprint of the config's values, as a hex encoded SHA-256,
+// so configs on different machines can be compared without sending them
+// whole. It depends only on the values of each key and their order: the
+// layout, comments, case of section and key names and the order of keys
+// and sections make no difference. Two configs hash the same exactly when
+// Diff gives no changes between them.
+func (self *Config) Hash() string {
+	values := self.keyValues()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	var buf [binary.MaxVarintLen64]byte
+	// length prefix everything so no two configs give the same stream
+	write := func(s string) {
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(s)))])
+		h.Write([]byte(s))
+	}
+	for _, key := range keys {
+		cv := values[key]
+		write(key)
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(cv.Entries)))])
+		for _, e := range cv.Entries {
+			if !e.HasValue {
+				h.Write([]byte{0})
+				continue
+			}
+			h.Write([]byte{1})
+			write(e.Value)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
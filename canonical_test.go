@@ -0,0 +1,69 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	in := "[User]\n  Name=Joe\n[Remote \"Origin\"] URL = x\n" +
+		"[core]\nbare\n[user]\n\temail   =   joe@example.com # mail\n" +
+		"[alias]\n\tlg = \"log --oneline \"\n\tnl = a\\nb\n[Remote \"A\"]\n\tfetch = 1\n\tFetch = 2\n"
+	expect := "[alias]\n\tlg = \"log --oneline \"\n\tnl = a\\nb\n" +
+		"[core]\n\tbare\n" +
+		"[remote \"A\"]\n\tfetch = 1\n\tfetch = 2\n" +
+		"[remote \"Origin\"]\n\turl = x\n" +
+		"[user]\n\temail = joe@example.com\n\tname = Joe\n"
+	out, err := CanonicalString(in)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize config: %s", err)
+	}
+	if out != expect {
+		t.Errorf("Expect canonical form:\n%s\nbut got:\n%s", expect, out)
+	}
+	again, err := CanonicalString(out)
+	if err != nil {
+		t.Fatalf("Failed to parse canonical output: %s", err)
+	}
+	if again != out {
+		t.Errorf("Expect canonical form to be stable, but got:\n%s", again)
+	}
+	config, _ := NewConfigFromString(out)
+	if v, _ := config.GetKeyValueAsString("alias.lg"); v != "log --oneline " {
+		t.Errorf("Expect alias.lg to keep its trailing space, but got '%s'", v)
+	}
+	if v, _ := config.GetKeyValueAsString("alias.nl"); v != "a\nb" {
+		t.Errorf("Expect alias.nl to keep its newline, but got '%s'", v)
+	}
+}
+
+func TestHash(t *testing.T) {
+	parse := func(s string) *Config {
+		cfg, err := NewConfigFromString(s)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %s", s, err)
+		}
+		return cfg
+	}
+	base := parse("[user]\n\tname = Joe\n[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n[core]\n\tbare\n")
+	same := parse("# copy\n[Core]\nBare\n[remote \"origin\"]\nfetch=a\n[USER] NAME = \"Joe\"\n[remote \"origin\"]\n\tfetch = b\n")
+	if base.Hash() != same.Hash() {
+		t.Errorf("Expect the same hash for the same values in another layout")
+	}
+	if len(base.Hash()) != 64 {
+		t.Errorf("Expect a hex SHA-256, but got %s", base.Hash())
+	}
+	for _, s := range []string{
+		"[user]\n\tname = Joe\n[remote \"origin\"]\n\tfetch = b\n\tfetch = a\n[core]\n\tbare\n",
+		"[user]\n\tname = Joe\n[remote \"Origin\"]\n\tfetch = a\n\tfetch = b\n[core]\n\tbare\n",
+		"[user]\n\tname = Joe\n[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n[core]\n\tbare = \n",
+		"[user]\n\tname = Joe\n[remote \"origin\"]\n\tfetch = ab\n[core]\n\tbare\n",
+	} {
+		if parse(s).Hash() == base.Hash() {
+			t.Errorf("Expect a different hash for %q", s)
+		}
+	}
+}
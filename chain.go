@@ -0,0 +1,149 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// Reads and merges config files in order, so later files take precedence.
+// The files are parsed concurrently and merged once all are read.
+// Files which do not exist are skipped.
+// If ctx is cancelled before every file is read its error is returned,
+// without waiting for reads still blocked on the file system.
+func LoadFilesContext(ctx context.Context, files ...string) (*Config, error) {
+	cfgs, err := parseFilesContext(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	out := NewConfig()
+	for _, cfg := range cfgs {
+		if cfg != nil {
+			out.Merge(cfg)
+		}
+	}
+	return out, nil
+}
+
+// Reads the file for each scope concurrently into a ConfigSet.
+// Scopes whose file does not exist are left out of the set, but are still
+// written to by ConfigSet.Set.
+func NewConfigSetFromFilesContext(ctx context.Context, files map[Scope]string) (*ConfigSet, error) {
+	scopes := make([]Scope, 0, len(files))
+	names := make([]string, 0, len(files))
+	for scope, file := range files {
+		scopes = append(scopes, scope)
+		names = append(names, file)
+	}
+	cfgs, err := parseFilesContext(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	set := NewConfigSet()
+	for i, cfg := range cfgs {
+		set.SetFile(scopes[i], names[i])
+		if cfg != nil {
+			set.Add(scopes[i], cfg)
+		}
+	}
+	return set, nil
+}
+
+// Like NewConfigFromFile, but gives up once ctx is done, returning its error.
+// A read stuck on the file system (e.g. a hung network mount) is abandoned
+// rather than waited for.
+func NewConfigFromFileContext(ctx context.Context, file string) (*Config, error) {
+	type result struct {
+		cfg *Config
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		cfg, err := parseFileContext(ctx, file)
+		ch <- result{cfg, err}
+	}()
+	select {
+	case r := <-ch:
+		return r.cfg, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Parses each file in its own goroutine, the result for a file that does not
+// exist is nil. The first error (by file order) is returned.
+func parseFilesContext(ctx context.Context, files []string) ([]*Config, error) {
+	cfgs := make([]*Config, len(files))
+	errs := make([]error, len(files))
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfgs[i], errs[i] = parseFileContext(ctx, file)
+			if os.IsNotExist(errs[i]) {
+				errs[i] = nil
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cfgs, nil
+}
+
+// Parses a single file. Reading stops as soon as ctx is cancelled.
+func parseFileContext(ctx context.Context, file string) (*Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fh, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	p := Parser{
+		Reader: bufio.NewScanner(&contextReader{ctx: ctx, r: fh}),
+		Config: NewConfig(),
+		file:   file,
+	}
+	if err := p.Read(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	p.Config.source = file
+	return p.Config, nil
+}
+
+// Fails reads once the context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (self *contextReader) Read(p []byte) (int, error) {
+	if err := self.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return self.r.Read(p)
+}
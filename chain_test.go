@@ -0,0 +1,70 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFilesContext(t *testing.T) {
+	dir := t.TempDir()
+	files := make([]string, 0, 6)
+	for i := 0; i < 5; i++ {
+		file := filepath.Join(dir, fmt.Sprintf("config%d", i))
+		if err := os.WriteFile(file, []byte(fmt.Sprintf("[user]\n    name = name%d\n    all = %d\n", i, i)), 0600); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	files = append(files, filepath.Join(dir, "missing"))
+	cfg, err := LoadFilesContext(context.Background(), files...)
+	if err != nil {
+		t.Fatalf("Failed to load files: %s", err)
+	}
+	testValue(t, cfg, "user.name", "name4", true)
+	if got := cfg.GetKeyValuesStrings("user.all"); fmt.Sprint(got) != "[0 1 2 3 4]" {
+		t.Errorf("Expect values merged in file order but got %v\n", got)
+	}
+
+	set, err := NewConfigSetFromFilesContext(context.Background(), map[Scope]string{
+		ScopeSystem: files[0],
+		ScopeGlobal: files[5],
+		ScopeLocal:  files[2],
+	})
+	if err != nil {
+		t.Fatalf("Failed to load config set: %s", err)
+	}
+	if scopes := set.Scopes(); len(scopes) != 2 || scopes[0] != ScopeSystem || scopes[1] != ScopeLocal {
+		t.Errorf("Expect system and local scopes only but got %v\n", scopes)
+	}
+	testValue(t, set.Merged(), "user.name", "name2", true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := LoadFilesContext(ctx, files...); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expect cancelled load to fail with context.Canceled but got %v\n", err)
+	}
+	if _, err := NewConfigFromFileContext(context.Background(), files[5]); !os.IsNotExist(err) {
+		t.Errorf("Expect not exist error for missing file but got %v\n", err)
+	}
+	if cfg, err := NewConfigFromFileContext(context.Background(), files[1]); err != nil {
+		t.Errorf("Failed to load file: %s", err)
+	} else {
+		testValue(t, cfg, "user.name", "name1", true)
+	}
+	bad := filepath.Join(dir, "bad")
+	if err := os.WriteFile(bad, []byte("[user\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var perr *ParseError
+	if _, err := LoadFilesContext(context.Background(), files[0], bad); !errors.As(err, &perr) {
+		t.Errorf("Expect parse error for bad file but got %v\n", err)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package gitconfig
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// A fifo with no writer blocks on open, standing in for a hung mount.
+func TestNewConfigFromFileContextHung(t *testing.T) {
+	dir := t.TempDir()
+	fifo := filepath.Join(dir, "fifo")
+	if err := syscall.Mkfifo(fifo, 0600); err != nil {
+		t.Skipf("Cannot make fifo: %s", err)
+	}
+	defer func() {
+		// release the abandoned reader
+		if fh, err := os.OpenFile(fifo, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+			fh.Close()
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := NewConfigFromFileContext(ctx, fifo); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expect hung read to time out but got %v\n", err)
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("Expect timeout to be prompt but waited %s\n", waited)
+	}
+
+	loader := NewCachingLoader()
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel2()
+	if _, err := loader.LoadContext(ctx2, fifo); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expect hung cached load to time out but got %v\n", err)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+// gitconfig-fmt rewrites git config files in the canonical form given by
+// gitconfig's Config.Canonicalize: names lower case, sections and keys
+// sorted, repeated sections merged and one tab indented key per line.
+//
+//	gitconfig-fmt [-l] [-w] [file ...]
+//
+// With no files it reads standard input and writes the result to standard
+// output. By default the canonical form of each file is printed; -w writes
+// it back to the file instead and -l lists the files whose form differs.
+// Comments are not kept, so check what -w would do before using it on
+// commented files.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/misatosangel/gitconfig"
+)
+
+func main() {
+	list := flag.Bool("l", false, "list files whose formatting differs from gitconfig-fmt's")
+	write := flag.Bool("w", false, "write result to (source) file instead of stdout")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gitconfig-fmt [-l] [-w] [file ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		if *write {
+			fmt.Fprintf(os.Stderr, "gitconfig-fmt: cannot use -w with standard input\n")
+			os.Exit(2)
+		}
+		if err := formatFile("<standard input>", os.Stdin, os.Stdout, *list, false); err != nil {
+			fmt.Fprintf(os.Stderr, "gitconfig-fmt: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	failed := false
+	for _, file := range flag.Args() {
+		if err := formatPath(file, os.Stdout, *list, *write); err != nil {
+			fmt.Fprintf(os.Stderr, "gitconfig-fmt: %s\n", err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func formatPath(file string, out io.Writer, list, write bool) error {
+	fh, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	return formatFile(file, fh, out, list, write)
+}
+
+// Formats the config read from in. If list is set the name is written to
+// out when the formatting differs, if write is set the file is replaced
+// (see Config.SaveToFile), otherwise the formatted config is written to out.
+func formatFile(name string, in io.Reader, out io.Writer, list, write bool) error {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	cfg, err := gitconfig.NewConfigFromString(string(src))
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	cfg.Canonicalize()
+	res := cfg.String()
+	changed := !bytes.Equal(src, []byte(res))
+	if list && changed {
+		fmt.Fprintln(out, name)
+	}
+	if write {
+		if !changed {
+			return nil
+		}
+		// locked and renamed into place as git does, keeping the mode
+		return cfg.SaveToFile(name)
+	}
+	if !list {
+		_, err = io.WriteString(out, res)
+	}
+	return err
+}
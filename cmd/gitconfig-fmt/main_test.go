@@ -0,0 +1,58 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatFile(t *testing.T) {
+	src := "[User]\n  Name = Joe\n"
+	expect := "[user]\n\tname = Joe\n"
+	var out strings.Builder
+	if err := formatFile("x", strings.NewReader(src), &out, false, false); err != nil {
+		t.Fatalf("Failed to format: %s", err)
+	}
+	if out.String() != expect {
+		t.Errorf("Expect:\n%s\nbut got:\n%s", expect, out.String())
+	}
+
+	out.Reset()
+	if err := formatFile("x", strings.NewReader(expect), &out, true, false); err != nil {
+		t.Fatalf("Failed to format: %s", err)
+	}
+	if out.String() != "" {
+		t.Errorf("Expect canonical file not to be listed, but got '%s'", out.String())
+	}
+
+	file := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(file, []byte(src), 0600); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := formatPath(file, &out, true, true); err != nil {
+		t.Fatalf("Failed to format: %s", err)
+	}
+	if out.String() != file+"\n" {
+		t.Errorf("Expect changed file to be listed, but got '%s'", out.String())
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != expect {
+		t.Errorf("Expect file rewritten as:\n%s\nbut got:\n%s", expect, data)
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0600 {
+		t.Errorf("Expect mode 0600 kept, but got %v", info.Mode())
+	}
+	if _, err := os.Stat(file + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("Expect no lock file left behind, but got %v", err)
+	}
+
+	if err := formatFile("bad", strings.NewReader("[user\n"), &out, false, false); err == nil || !strings.HasPrefix(err.Error(), "bad: ") {
+		t.Errorf("Expect error naming the file, but got: %v", err)
+	}
+}
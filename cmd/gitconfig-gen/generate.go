@@ -0,0 +1,308 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type generator struct {
+	fset    *token.FileSet
+	structs map[string]*ast.StructType // all named structs in the file
+	pkg     string                     // package qualifier for gitconfig
+	buf     bytes.Buffer
+	usesFmt bool
+}
+
+// Generate produces the source of a file holding LoadFromConfig methods for
+// the named struct types (and any structs in the same file they contain).
+func Generate(fileName string, src []byte, typeNames []string, pkgPath string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, fileName, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		fset:    fset,
+		structs: make(map[string]*ast.StructType, 10),
+		pkg:     pkgPath[strings.LastIndex(pkgPath, "/")+1:],
+	}
+	if file.Name.Name == g.pkg {
+		return nil, fmt.Errorf("cannot generate loaders inside package %s itself", g.pkg)
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ts, ok := n.(*ast.TypeSpec); ok {
+			if st, ok := ts.Type.(*ast.StructType); ok {
+				g.structs[ts.Name.Name] = st
+			}
+		}
+		return true
+	})
+
+	// work out every struct needing a loader, including nested ones
+	todo := make([]string, 0, len(typeNames))
+	for _, name := range typeNames {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if g.structs[name] == nil {
+			return nil, fmt.Errorf("struct type %s not found in %s", name, fileName)
+		}
+		todo = append(todo, name)
+	}
+	seen := make(map[string]bool, len(todo))
+	order := make([]string, 0, len(todo))
+	for len(todo) > 0 {
+		name := todo[0]
+		todo = todo[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		order = append(order, name)
+		for _, f := range g.structs[name].Fields.List {
+			ast.Inspect(f.Type, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && g.structs[id.Name] != nil && !seen[id.Name] {
+					todo = append(todo, id.Name)
+				}
+				return true
+			})
+		}
+	}
+	for _, name := range order {
+		if err := g.genStruct(name); err != nil {
+			return nil, err
+		}
+	}
+
+	var head bytes.Buffer
+	fmt.Fprintf(&head, "// Code generated by gitconfig-gen; DO NOT EDIT.\n\npackage %s\n\nimport (\n", file.Name.Name)
+	if g.usesFmt {
+		fmt.Fprintf(&head, "\t\"fmt\"\n\n")
+	}
+	fmt.Fprintf(&head, "\t%q\n)\n", pkgPath)
+	head.Write(g.buf.Bytes())
+	out, err := format.Source(head.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid code: %s\n%s", err, head.String())
+	}
+	return out, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) exprString(e ast.Expr) string {
+	var b bytes.Buffer
+	format.Node(&b, g.fset, e)
+	return b.String()
+}
+
+func (g *generator) genStruct(name string) error {
+	g.printf("\n// LoadFromConfig loads %s's gcKey tagged fields from cfg without reflection.\n", name)
+	g.printf("func (self *%s) LoadFromConfig(cfg *%s.Config) error {\n", name, g.pkg)
+	g.printf("\treturn self.loadFromConfigNS(cfg, \"\")\n}\n\n")
+	g.printf("func (self *%s) loadFromConfigNS(cfg *%s.Config, ns string) error {\n", name, g.pkg)
+	g.printf("\terrs := %s.LoadError{}\n", g.pkg)
+	for _, f := range g.structs[name].Fields.List {
+		if f.Tag == nil {
+			continue
+		}
+		tagStr, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			return err
+		}
+		tag := reflect.StructTag(tagStr)
+		key := tag.Get("gcKey")
+		if key == "" {
+			continue
+		}
+		for _, part := range strings.Fields(tagStr) {
+			tagName := part[:strings.Index(part+":", ":")]
+			switch tagName {
+			case "gcKey", "gcDefault", "gcRequired", "gcSecret":
+			default:
+				if strings.HasPrefix(tagName, "gc") {
+					return fmt.Errorf("%s: tag %s is not supported by generated loaders", name, tagName)
+				}
+			}
+		}
+		opts := fmt.Sprintf("%s.FieldOptions{}", g.pkg)
+		required := false
+		if req := tag.Get("gcRequired"); req != "" {
+			if required, err = strconv.ParseBool(req); err != nil {
+				return fmt.Errorf("%s: could not parse required:%q as boolean", name, req)
+			}
+		}
+		if required {
+			opts = fmt.Sprintf("%s.FieldOptions{Required: true}", g.pkg)
+		} else if def, ok := tag.Lookup("gcDefault"); ok {
+			opts = fmt.Sprintf("%s.FieldOptions{Default: %q, HaveDefault: true}", g.pkg, def)
+		}
+		secret := false
+		if sec := tag.Get("gcSecret"); sec != "" {
+			if secret, err = strconv.ParseBool(sec); err != nil {
+				return fmt.Errorf("%s: could not parse secret:%q as boolean", name, sec)
+			}
+		}
+		if len(f.Names) == 0 {
+			return fmt.Errorf("%s: embedded field with gcKey %q is not supported", name, key)
+		}
+		for _, id := range f.Names {
+			if !id.IsExported() {
+				return fmt.Errorf("%s.%s: field has a gcKey but is unexported, so cannot be set", name, id.Name)
+			}
+			if err := g.genField(name, id.Name, f.Type, key, opts, required, secret); err != nil {
+				return err
+			}
+		}
+	}
+	g.printf("\tif len(errs) == 0 {\n\t\treturn nil\n\t}\n\treturn errs\n}\n")
+	return nil
+}
+
+// The type each helper function returns.
+var helperTypes = map[string]string{
+	"StringField":   "string",
+	"BoolField":     "bool",
+	"IntField":      "int64",
+	"UintField":     "uint64",
+	"DurationField": "time.Duration",
+	"StringsField":  "string",
+	"IntsField":     "int64",
+}
+
+// Returns an expression converting v from the helper's type to tp.
+func convert(helper, tp, v string) string {
+	if helperTypes[helper] == tp {
+		return v
+	}
+	return tp + "(" + v + ")"
+}
+
+// Returns the helper function for a basic field type.
+func basicHelper(tp string) (string, bool) {
+	switch tp {
+	case "string":
+		return "StringField", true
+	case "bool":
+		return "BoolField", true
+	case "int", "int8", "int16", "int32", "int64":
+		return "IntField", true
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		return "UintField", true
+	case "time.Duration":
+		return "DurationField", true
+	}
+	return "", false
+}
+
+// Whether the type is an instantiated KV, e.g. gitconfig.KV[string, T].
+func isKV(expr ast.Expr) bool {
+	var generic ast.Expr
+	switch t := expr.(type) {
+	case *ast.IndexListExpr:
+		generic = t.X
+	case *ast.IndexExpr:
+		generic = t.X
+	default:
+		return false
+	}
+	switch t := generic.(type) {
+	case *ast.SelectorExpr:
+		return t.Sel.Name == "KV"
+	case *ast.Ident:
+		return t.Name == "KV"
+	}
+	return false
+}
+
+func (g *generator) genField(structName, field string, expr ast.Expr, key, opts string, required, secret bool) error {
+	tp := g.exprString(expr)
+	fail := ""
+	if secret {
+		fail = fmt.Sprintf("errs[key] = %s.SecretFieldError(%q, %q, err)", g.pkg, tp, field)
+	} else {
+		g.usesFmt = true
+		fail = fmt.Sprintf("errs[key] = fmt.Errorf(\"Could not populate %%s field %%q: %%w\", %q, %q, err)", tp, field)
+	}
+	g.printf("\t{\n\t\tkey := %s.FieldKey(ns, %q)\n", g.pkg, key)
+	defer g.printf("\t}\n")
+
+	if helper, ok := basicHelper(tp); ok {
+		g.printf("\t\tif v, ok, err := %s.%s(cfg, key, %q, %s); err != nil {\n\t\t\t%s\n", g.pkg, helper, tp, opts, fail)
+		g.printf("\t\t} else if ok {\n\t\t\tself.%s = %s\n\t\t}\n", field, convert(helper, tp, "v"))
+		return nil
+	}
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if g.structs[t.Name] != nil {
+			g.printf("\t\t")
+			if required {
+				g.printf("if err := %s.StructField(cfg, key, %q, %s); err != nil {\n\t\t\t%s\n\t\t} else ", g.pkg, tp, opts, fail)
+			}
+			g.printf("if err := self.%s.loadFromConfigNS(cfg, key); err != nil {\n\t\t\t%s\n\t\t}\n", field, fail)
+			return nil
+		}
+	case *ast.ArrayType:
+		if t.Len != nil {
+			break
+		}
+		if isKV(t.Elt) {
+			return fmt.Errorf("%s.%s: []KV fields are not supported by generated loaders, use a map or Load", structName, field)
+		}
+		elem := g.exprString(t.Elt)
+		helper := ""
+		switch h, _ := basicHelper(elem); h {
+		case "StringField":
+			helper = "StringsField"
+		case "IntField":
+			helper = "IntsField"
+		}
+		if helper == "" {
+			break
+		}
+		g.printf("\t\tif vals, ok, err := %s.%s(cfg, key, %q, %s); err != nil {\n\t\t\t%s\n", g.pkg, helper, tp, opts, fail)
+		g.printf("\t\t} else if ok {\n\t\t\tfor _, v := range vals {\n\t\t\t\tself.%s = append(self.%s, %s)\n\t\t\t}\n\t\t}\n", field, field, convert(helper, elem, "v"))
+		return nil
+	case *ast.MapType:
+		if g.exprString(t.Key) != "string" {
+			break
+		}
+		elem := g.exprString(t.Value)
+		id, isIdent := t.Value.(*ast.Ident)
+		isStruct := isIdent && g.structs[id.Name] != nil
+		helper, isBasic := basicHelper(elem)
+		if !isStruct && !isBasic {
+			break
+		}
+		g.usesFmt = true
+		g.printf("\t\tif sName, sKey, names, err := %s.SubSectionsField(cfg, key, %q, %s); err != nil {\n\t\t\t%s\n", g.pkg, tp, opts, fail)
+		g.printf("\t\t} else if names != nil {\n\t\t\tself.%s = make(%s, len(names))\n\t\t\tfor _, name := range names {\n", field, tp)
+		if isStruct {
+			g.printf("\t\t\t\tif sKey != \"\" {\n\t\t\t\t\terr = fmt.Errorf(\"cannot populate field %%s of type %%s. Key must be of form '<section>' or '<section>.*'\", key, %q)\n\t\t\t\t\t%s\n\t\t\t\t\tbreak\n\t\t\t\t}\n", tp, fail)
+			g.printf("\t\t\t\tvar v %s\n\t\t\t\tif err := v.loadFromConfigNS(cfg, sName+\".\"+name); err != nil {\n\t\t\t\t\t%s\n\t\t\t\t\tbreak\n\t\t\t\t}\n", elem, fail)
+			g.printf("\t\t\t\tself.%s[name] = v\n", field)
+		} else {
+			g.printf("\t\t\t\tif sKey == \"\" {\n\t\t\t\t\terr = fmt.Errorf(\"cannot populate field %%s of type %%s. Key must be of form '<section>.*.<key>'\", key, %q)\n\t\t\t\t\t%s\n\t\t\t\t\tbreak\n\t\t\t\t}\n", tp, fail)
+			g.printf("\t\t\t\tv, _, err := %s.%s(cfg, sName+\".\"+name+\".\"+sKey, %q, %s)\n\t\t\t\tif err != nil {\n\t\t\t\t\t%s\n\t\t\t\t\tbreak\n\t\t\t\t}\n", g.pkg, helper, elem, opts, fail)
+			g.printf("\t\t\t\tself.%s[name] = %s\n", field, convert(helper, elem, "v"))
+		}
+		g.printf("\t\t\t}\n\t\t}\n")
+		return nil
+	}
+	return fmt.Errorf("%s.%s: field type %s is not supported by generated loaders", structName, field, tp)
+}
@@ -0,0 +1,64 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateRejectsUnsupported(t *testing.T) {
+	tests := map[string]string{
+		"type T struct {\n\tX float64 `gcKey:\"a.x\"`\n}\n":                          "field type float64 is not supported",
+		"type T struct {\n\tX string `gcKey:\"a.x\" gcScope:\"local\"`\n}\n":         "tag gcScope is not supported",
+		"type T struct {\n\tX map[int]string `gcKey:\"a.*.x\"`\n}\n":                 "field type map[int]string is not supported",
+		"type T struct {\n\tX string `gcKey:\"a.x\" gcRequired:\"maybe\"`\n}\n":      "could not parse required",
+		"type U struct {\n\tX string `gcKey:\"a.x\"`\n}\n":                           "struct type T not found",
+		"type T struct {\n\tx string `gcKey:\"a.x\"`\n}\n":                           "T.x: field has a gcKey but is unexported",
+		"type T struct {\n\tX []gitconfig.KV[string, string] `gcKey:\"a.*.x\"`\n}\n": "T.X: []KV fields are not supported",
+	}
+	for src, expect := range tests {
+		_, err := Generate("x.go", []byte("package x\n\n"+src), []string{"T"}, "github.com/misatosangel/gitconfig")
+		if err == nil || !strings.Contains(err.Error(), expect) {
+			t.Errorf("Expect error containing '%s' for:\n%s\nbut got: %v\n", expect, src, err)
+		}
+	}
+}
+
+func TestGenerateNested(t *testing.T) {
+	src := "package x\n\n" +
+		"type T struct {\n\tInner U `gcKey:\"a\" gcRequired:\"true\"`\n\tskipped string\n}\n\n" +
+		"type U struct {\n\tName string `gcKey:\"name\" gcRequired:\"true\"`\n}\n"
+	out, err := Generate("x.go", []byte(src), []string{"T"}, "github.com/misatosangel/gitconfig")
+	if err != nil {
+		t.Fatalf("Failed to generate: %s", err)
+	}
+	code := string(out)
+	for _, expect := range []string{
+		"func (self *T) LoadFromConfig(cfg *gitconfig.Config) error",
+		"func (self *U) LoadFromConfig(cfg *gitconfig.Config) error",
+		"self.Inner.loadFromConfigNS(cfg, key)",
+		`gitconfig.StructField(cfg, key, "U", gitconfig.FieldOptions{Required: true})`,
+		"gitconfig.FieldOptions{Required: true}",
+	} {
+		if !strings.Contains(code, expect) {
+			t.Errorf("Expect generated code to contain '%s' but got:\n%s\n", expect, code)
+		}
+	}
+	if strings.Contains(code, "skipped") {
+		t.Errorf("Expect untagged fields to be skipped but got:\n%s\n", code)
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	src := "package x\n\ntype T struct {\n\tToken int `gcKey:\"a.token\" gcSecret:\"true\"`\n}\n"
+	out, err := Generate("x.go", []byte(src), []string{"T"}, "github.com/misatosangel/gitconfig")
+	if err != nil {
+		t.Fatalf("Failed to generate: %s", err)
+	}
+	if code := string(out); !strings.Contains(code, `gitconfig.SecretFieldError("int", "Token", err)`) || strings.Contains(code, `"fmt"`) {
+		t.Errorf("Expect secret field errors to be withheld without fmt but got:\n%s\n", code)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+// gitconfig-gen generates reflection-free loaders for structs annotated with
+// gitconfig's gcKey tags. For each named type it emits a method
+//
+//	func (self *T) LoadFromConfig(cfg *gitconfig.Config) error
+//
+// which behaves like cfg.Load(&t) for the supported field types. It is meant
+// to be run from go:generate:
+//
+//	//go:generate gitconfig-gen -type Person,People
+//
+// Supported fields are strings, booleans, (unsigned) integers, time.Duration,
+// slices of strings or integers, named structs declared in the same file and
+// maps keyed by string of those structs or of basic values. Only the gcKey,
+// gcDefault and gcRequired tags are understood, anything else is an error so
+// generated loaders never silently differ from Load.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of struct type names (required)")
+	output := flag.String("output", "", "output file name (default <file>_gitconfig.go)")
+	pkgPath := flag.String("import", "github.com/misatosangel/gitconfig", "import path of the gitconfig package")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gitconfig-gen -type T[,T...] [-output file] [file.go]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	input := os.Getenv("GOFILE")
+	if flag.NArg() > 0 {
+		input = flag.Arg(0)
+	}
+	if input == "" {
+		fmt.Fprintf(os.Stderr, "gitconfig-gen: no input file given and $GOFILE is not set\n")
+		os.Exit(2)
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(input, ".go") + "_gitconfig.go"
+	}
+
+	src, err := os.ReadFile(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gitconfig-gen: %s\n", err)
+		os.Exit(1)
+	}
+	out, err := Generate(filepath.Base(input), src, strings.Split(*typeNames, ","), *pkgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gitconfig-gen: %s\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, out, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "gitconfig-gen: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -6,10 +6,16 @@ package gitconfig
 import (
 	"bufio"
 	"fmt"
+	"maps"
+	"net/netip"
 	"os"
 	"reflect"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unicode"
 	"unicode/utf8"
@@ -19,6 +25,21 @@ type Config struct {
 	Sections   map[string]*ConfigSection
 	BaseValues ConfigValueSet
 	Imports    []string
+	// When true sub-section names are matched ignoring case if there is no
+	// exact match, as git does for the deprecated [section.subsection] syntax.
+	FoldSubSections bool
+	set             *ConfigSet      // the layers this was merged from, if any
+	hooks           *changeHooks    // OnChange listeners
+	logger          *mutationLogger // see SetLogger
+	source          string          // file this was read from, if any
+	seq             int             // creation counter, giving sections their file order
+	options         ParseOptions    // how this was read
+	sources         []string        // files values were read from, see SourceFiles
+	includes        *includeLoader  // if includes were followed, for reloading
+	blocks          []*sectionBlock
+	blockCount      map[string]int               // blocks by lazySectionId
+	warnings        []Warning                    // found while parsing, see Warnings
+	deprecations    atomic.Pointer[deprecations] // see DeprecateKey
 }
 
 type ConfigSection struct {
@@ -26,36 +47,108 @@ type ConfigSection struct {
 	OrigCaseName string
 	SubSections  map[string]*ConfigSubSection
 	Values       ConfigValueSet
+	seq          int // order first seen, see Config.seq
 }
 
 type ConfigSubSection struct {
 	Name   string
 	Values ConfigValueSet
+	seq    int
 }
 
 type ConfigValue struct {
 	Name         string
 	OrigCaseName string
-	Value        []*string
+	Entries      []ValueEntry
+	// The values as pointers, nil for keys given without a value, as they
+	// were held before Entries. The package keeps it up to date as it
+	// changes Entries, but changes made to it are not read back.
+	//
+	// Deprecated: use Entries.
+	Value []*string
+}
+
+// A single value given for a key. A key given without '=' (e.g. "[core] bare")
+// has no value at all, which is not the same as an empty one.
+type ValueEntry struct {
+	Value       string
+	HasValue    bool
+	Quoted      bool   // the value was written in quotes, all or in part, see ParseOptions.KeepQuoting
+	Comment     string // trailing comment on the value's line, without its comment character
+	Origin      ValueOrigin
+	block       *sectionBlock // section header read under, nil if added by code
+	commentChar byte          // the Comment's comment character, 0 for '#'
+}
+
+// Where a value was read from. Values added by code have a zero origin.
+type ValueOrigin struct {
+	File string // empty if not read from a file
+	Line uint64 // 1-based, 0 if unknown
 }
 
 type ConfigValueSet map[string]*ConfigValue
 
 var durationType = reflect.TypeOf((*time.Duration)(nil)).Elem()
+var timeType = reflect.TypeOf((*time.Time)(nil)).Elem()
+var regexpType = reflect.TypeOf((*regexp.Regexp)(nil)).Elem()
+var addrType = reflect.TypeOf((*netip.Addr)(nil)).Elem()
+var prefixType = reflect.TypeOf((*netip.Prefix)(nil)).Elem()
+var configValueType = reflect.TypeOf((*ConfigValue)(nil)).Elem()
+var configValueSetType = reflect.TypeOf((*ConfigValueSet)(nil)).Elem()
+
+// Extra per-field settings read from struct tags, passed down when loading
+// values into (possibly nested) fields.
+type fieldTags struct {
+	layout     string // gcLayout, time.Time parse layout
+	minEntries int    // gcMinEntries, least number of entries a map must have
+	intBase    int    // gcIntBase, base of integers as for strconv.ParseInt, 10 if not given
+	unit       string // gcUnit, unit of bare numbers given for durations and integers
+	path       string // gcPath, how to expand strings holding paths, see checkPathTag
+	field      string // Go path of the field, e.g. "Remotes[origin].URL", see FieldError
+}
 
 func NewConfig() *Config {
 	return &Config{
 		Sections:   make(map[string]*ConfigSection, 10),
 		BaseValues: make(ConfigValueSet, 10),
 		Imports:    make([]string, 0, 5),
+		hooks:      &changeHooks{},
 	}
 }
 
+// Empties the config, keeping the storage of its top level maps and the
+// FoldSubSections setting, as the parser keeps its Options.
+func (self *Config) reset() {
+	clear(self.Sections)
+	clear(self.BaseValues)
+	self.Imports = self.Imports[:0]
+	self.seq = 0
+	self.options = ParseOptions{}
+	self.sources = self.sources[:0]
+	self.includes = nil
+	self.blocks = self.blocks[:0]
+	clear(self.blockCount)
+	self.warnings = self.warnings[:0]
+	self.set = nil
+	self.hooks = &changeHooks{}
+	self.deprecations.Store(nil)
+	self.logger = nil
+	self.source = ""
+}
+
 func NewConfigFromString(data string) (*Config, error) {
+	return NewConfigFromStringOptions(data, ParseOptions{})
+}
+
+func NewConfigFromStringOptions(data string, opts ParseOptions) (*Config, error) {
+	if opts.Includes != nil {
+		return newIncludeLoader(opts).loadString(data)
+	}
 	r := strings.NewReader(data)
 	p := Parser{
-		Reader: bufio.NewScanner(r),
-		Config: NewConfig(),
+		Reader:  bufio.NewScanner(r),
+		Config:  NewConfig(),
+		Options: opts,
 	}
 	err := p.Read()
 	if err != nil {
@@ -65,6 +158,13 @@ func NewConfigFromString(data string) (*Config, error) {
 }
 
 func NewConfigFromFile(file string) (*Config, error) {
+	return NewConfigFromFileOptions(file, ParseOptions{})
+}
+
+func NewConfigFromFileOptions(file string, opts ParseOptions) (*Config, error) {
+	if opts.Includes != nil {
+		return newIncludeLoader(opts).load(file)
+	}
 	if _, err := os.Stat(file); os.IsNotExist(err) {
 		return nil, err
 	}
@@ -72,46 +172,126 @@ func NewConfigFromFile(file string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer fh.Close()
 	p := Parser{
-		Reader: bufio.NewScanner(fh),
-		Config: NewConfig(),
+		Reader:  bufio.NewScanner(fh),
+		Config:  NewConfig(),
+		Options: opts,
+		file:    file,
 	}
 
 	err = p.Read()
 	if err != nil {
 		return nil, err
 	}
+	p.Config.source = file
 	return p.Config, nil
 }
 
+// Re-reads the file this Config was created from, replacing the contents
+// in place so that existing holders of the Config see the new values.
+// OnChange listeners are told of the changes, which are also returned.
+// If the file cannot be read or parsed the Config is left unchanged.
+// This must not be called while other goroutines are reading the Config.
+func (self *Config) ReloadFromFile() ([]KeyChange, error) {
+	if self.source == "" {
+		return nil, fmt.Errorf("Cannot reload config: %w", ErrNoSource)
+	}
+	start := time.Now()
+	var fresh *Config
+	var err error
+	if self.includes != nil {
+		// only files changed since are parsed again
+		fresh, err = self.includes.load(self.source)
+	} else {
+		fresh, err = NewConfigFromFileOptions(self.source, self.options)
+	}
+	if err != nil {
+		reportReload([]string{self.source}, 0, start, err)
+		return nil, err
+	}
+	changes := Diff(self, fresh)
+	self.Sections = fresh.Sections
+	self.BaseValues = fresh.BaseValues
+	self.Imports = fresh.Imports
+	self.seq = fresh.seq
+	self.blocks = fresh.blocks
+	self.blockCount = fresh.blockCount
+	self.sources = fresh.sources
+	self.warnings = fresh.warnings
+	reportReload([]string{self.source}, len(changes), start, nil)
+	self.notify(changes)
+	return changes, nil
+}
+
 func (self *Config) String() string {
-	out := self.BaseValues.String()
-	for _, s := range self.Sections {
-		out += s.String()
+	if self.options.DuplicateSections == DuplicateSectionsKeep && len(self.blocks) > 0 {
+		return self.blocksString()
+	}
+	keepQuotes := self.options.KeepQuoting
+	out := self.BaseValues.entriesString(nil, keepQuotes)
+	for _, name := range self.SectionNames() {
+		out += self.Sections[name].format(keepQuotes)
 	}
 	return out
 }
 
 // Load loads git config values to a struct annotated with "gitconfig" tags.
 func (self *Config) Load(v interface{}) error {
+	rv, err := loadTarget(v)
+	if err != nil {
+		return err
+	}
+	return self.loadStruct(rv, "", "")
+}
+
+// Loads one section or sub-section, e.g. "remote.origin", into a struct
+// whose gcKey tags are relative to it, as for a struct nested in another,
+// so the same struct can be used for any remote or branch. Fails with
+// ErrSectionNotFound if it does not exist.
+func (self *Config) LoadSection(section string, v interface{}) error {
+	rv, err := loadTarget(v)
+	if err != nil {
+		return err
+	}
+	s, ss := splitSectionPath(section)
+	if s == "" || self.LookupValueSet(s, ss) == nil {
+		return fmt.Errorf("Cannot load section '%s': %w", section, ErrSectionNotFound)
+	}
+	return self.loadStruct(rv, section, "")
+}
+
+// Checks v is a pointer to a struct Load can fill, returning the struct.
+func loadTarget(v interface{}) (reflect.Value, error) {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr {
-		return fmt.Errorf("Passed a non-pointer: %v\n", v)
+		return rv, fmt.Errorf("Passed a non-pointer: %v: %w\n", v, ErrUnsupportedType)
 	}
 	rv = rv.Elem()
 	if rv.Kind() != reflect.Struct {
-		return fmt.Errorf("Passed a pointer to a non-struct: %v\n", v)
+		return rv, fmt.Errorf("Passed a pointer to a non-struct: %v: %w\n", v, ErrUnsupportedType)
 	}
-	return self.loadStruct(rv, "")
+	if err := checkStructType(rv.Type()); err != nil {
+		return rv, err
+	}
+	return rv, nil
+}
+
+// LoadAs creates a new T and loads git config values into it, as Load.
+// T must be a struct type annotated with "gitconfig" tags.
+func LoadAs[T any](cfg *Config) (T, error) {
+	var out T
+	err := cfg.Load(&out)
+	return out, err
 }
 
-func (self *Config) loadSetValue(retval reflect.Value, key, defVal string, confVal *ConfigValue, required, haveDefault bool) error {
+func (self *Config) loadSetValue(retval reflect.Value, key, defVal string, confVal *ConfigValue, required, haveDefault bool, tags fieldTags) error {
 	tp := retval.Type()
 	if tp == durationType {
 		var s string
 		if confVal == nil || !confVal.HasValues() {
 			if required {
-				return fmt.Errorf("Could not populate required %s no value for %s", tp.String(), key)
+				return fmt.Errorf("Could not populate required %s no value for %s: %w", tp.String(), key, missingKey(tp.String(), key))
 			}
 			if !haveDefault {
 				// leave existing value (if any) untouched
@@ -121,21 +301,87 @@ func (self *Config) loadSetValue(retval reflect.Value, key, defVal string, confV
 		} else {
 			s, _ = confVal.GetString()
 		}
-		parsed, err := time.ParseDuration(s)
+		parsed, err := time.ParseDuration(tags.durationString(s))
 
 		if err != nil {
-			return fmt.Errorf("Could not parse value '%s' as duration for %s: %s\n", s, key, err.Error())
+			return fmt.Errorf("Could not parse value '%s' as duration for %s: %w: %w\n", s, key, ErrTypeMismatch, err)
 		}
 
 		retval.SetInt(int64(parsed))
 		return nil
 	}
+	if tp == configValueType {
+		if confVal == nil {
+			if required {
+				return fmt.Errorf("Could not populate required %s no value for %s: %w", tp.String(), key, missingKey(tp.String(), key))
+			}
+			if !haveDefault {
+				// leave existing value (if any) untouched
+				return nil
+			}
+			confVal = &ConfigValue{Entries: []ValueEntry{{Value: defVal, HasValue: true}}}
+		}
+		retval.Set(reflect.ValueOf(*confVal.copy()))
+		return nil
+	}
+	if tp == timeType {
+		s, ok, err := scalarString(tp, key, defVal, confVal, required, haveDefault)
+		if !ok || err != nil {
+			return err
+		}
+		layout := tags.layout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		parsed, err := time.Parse(layout, s)
+		if err != nil {
+			return fmt.Errorf("Could not parse value '%s' as time with layout '%s' for %s: %w: %w\n", s, layout, key, ErrTypeMismatch, err)
+		}
+		retval.Set(reflect.ValueOf(parsed))
+		return nil
+	}
+	if tp == regexpType {
+		s, ok, err := scalarString(tp, key, defVal, confVal, required, haveDefault)
+		if !ok || err != nil {
+			return err
+		}
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return fmt.Errorf("Could not compile value '%s' as regular expression for %s: %w: %w\n", s, key, ErrTypeMismatch, err)
+		}
+		retval.Set(reflect.ValueOf(re).Elem())
+		return nil
+	}
+	if tp == addrType {
+		s, ok, err := scalarString(tp, key, defVal, confVal, required, haveDefault)
+		if !ok || err != nil {
+			return err
+		}
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return fmt.Errorf("Could not parse value '%s' as IP address for %s: %w: %w\n", s, key, ErrTypeMismatch, err)
+		}
+		retval.Set(reflect.ValueOf(addr))
+		return nil
+	}
+	if tp == prefixType {
+		s, ok, err := scalarString(tp, key, defVal, confVal, required, haveDefault)
+		if !ok || err != nil {
+			return err
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return fmt.Errorf("Could not parse value '%s' as IP prefix for %s: %w: %w\n", s, key, ErrTypeMismatch, err)
+		}
+		retval.Set(reflect.ValueOf(prefix))
+		return nil
+	}
 	switch tp.Kind() {
 	case reflect.String:
 		var s string
 		if confVal == nil || !confVal.HasValues() {
 			if required {
-				return fmt.Errorf("Could not populate required %s no value for %s", tp.String(), key)
+				return fmt.Errorf("Could not populate required %s no value for %s: %w", tp.String(), key, missingKey(tp.String(), key))
 			}
 			if !haveDefault {
 				// leave existing value (if any) untouched
@@ -145,7 +391,11 @@ func (self *Config) loadSetValue(retval reflect.Value, key, defVal string, confV
 		} else {
 			s, _ = confVal.GetString()
 		}
-		retval.SetString(s)
+		expanded, err := tags.pathString(s)
+		if err != nil {
+			return fmt.Errorf("Could not expand path '%s' for %s: %w\n", s, key, err)
+		}
+		retval.SetString(expanded)
 		return nil
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -153,18 +403,23 @@ func (self *Config) loadSetValue(retval reflect.Value, key, defVal string, confV
 		var err error
 		if confVal == nil || !confVal.HasValues() {
 			if required {
-				return fmt.Errorf("Could not populate required %s no value for %s", tp.String(), key)
+				return fmt.Errorf("Could not populate required %s no value for %s: %w", tp.String(), key, missingKey(tp.String(), key))
 			}
 			if !haveDefault {
 				// leave existing value (if any) untouched
 				return nil
 			}
-			i, err = strconv.ParseUint(defVal, 10, 64)
+			i, err = tags.parseUint(defVal)
 			if err != nil {
-				return fmt.Errorf("Could not populate default %s field, default value %q did not parse as an Int", tp.String(), defVal)
+				return fmt.Errorf("Could not populate default %s field, default value %q did not parse as %s: %w: %w", tp.String(), defVal, tp.String(), ErrTypeMismatch, err)
+			}
+		} else if tags.unit != "" {
+			s, _ := confVal.GetString()
+			if i, err = tags.parseUint(s); err != nil {
+				return fmt.Errorf("%w: %w", ErrTypeMismatch, err)
 			}
 		} else {
-			i, _, err = confVal.GetUint()
+			i, _, err = confVal.GetUintBase(tags.intBase)
 			if err != nil {
 				return err
 			}
@@ -176,18 +431,23 @@ func (self *Config) loadSetValue(retval reflect.Value, key, defVal string, confV
 		var err error
 		if confVal == nil || !confVal.HasValues() {
 			if required {
-				return fmt.Errorf("Could not populate required %s no value for %s", tp.String(), key)
+				return fmt.Errorf("Could not populate required %s no value for %s: %w", tp.String(), key, missingKey(tp.String(), key))
 			}
 			if !haveDefault {
 				// leave existing value (if any) untouched
 				return nil
 			}
-			i, err = strconv.ParseInt(defVal, 10, 64)
+			i, err = tags.parseInt(defVal)
 			if err != nil {
-				return fmt.Errorf("Could not populate default %s field, default value %q did not parse as an Int", tp.String(), defVal)
+				return fmt.Errorf("Could not populate default %s field, default value %q did not parse as %s: %w: %w", tp.String(), defVal, tp.String(), ErrTypeMismatch, err)
+			}
+		} else if tags.unit != "" {
+			s, _ := confVal.GetString()
+			if i, err = tags.parseInt(s); err != nil {
+				return fmt.Errorf("%w: %w", ErrTypeMismatch, err)
 			}
 		} else {
-			i, _, err = confVal.GetInt()
+			i, _, err = confVal.GetIntBase(tags.intBase)
 			if err != nil {
 				return err
 			}
@@ -199,7 +459,7 @@ func (self *Config) loadSetValue(retval reflect.Value, key, defVal string, confV
 		var err error
 		if confVal == nil || !confVal.HasValues() {
 			if required {
-				return fmt.Errorf("Could not populate required %s no value for %s", tp.String(), key)
+				return fmt.Errorf("Could not populate required %s no value for %s: %w", tp.String(), key, missingKey(tp.String(), key))
 			}
 			if !haveDefault {
 				// leave existing value (if any) untouched
@@ -207,7 +467,7 @@ func (self *Config) loadSetValue(retval reflect.Value, key, defVal string, confV
 			}
 			b, err = strconv.ParseBool(defVal)
 			if err != nil {
-				return fmt.Errorf("Could not populate default %s field, default value %q did not parse as an Int", tp.String(), defVal)
+				return fmt.Errorf("Could not populate default %s field, default value %q did not parse as %s: %w: %w", tp.String(), defVal, tp.String(), ErrTypeMismatch, err)
 			}
 		} else {
 			b, _, err = confVal.GetBool()
@@ -218,31 +478,32 @@ func (self *Config) loadSetValue(retval reflect.Value, key, defVal string, confV
 		retval.SetBool(b)
 
 	case reflect.Slice:
+		if _, _, ok := kvTypes(tp.Elem()); ok {
+			return self.loadKVSlice(retval, key, defVal, required, haveDefault, tags)
+		}
 		if confVal == nil || !confVal.HasValues() {
 			if required {
-				return fmt.Errorf("Could not populate required %s no value for %s", tp.String(), key)
+				return fmt.Errorf("Could not populate required %s no value for %s: %w", tp.String(), key, missingKey(tp.String(), key))
 			}
 			if !haveDefault {
 				// leave existing value (if any) untouched
 				return nil
 			}
-			confVal = &ConfigValue{Value: []*string{&defVal}}
+			confVal = &ConfigValue{Entries: []ValueEntry{{Value: defVal, HasValue: true}}}
 		}
 		elemtp := tp.Elem()
-		switch elemtp.Kind() {
-		case reflect.Array, reflect.Slice, reflect.Map:
-			// slice of hash maps is not supported
-			return fmt.Errorf("cannot populate field %s of type %s. Slices can only contain basic types.", key, elemtp.String())
+		if !listElemSupported(reflect.Slice, elemtp) {
+			return fmt.Errorf("cannot populate field %s of type %s. Slices can only contain basic types: %w", key, elemtp.String(), ErrUnsupportedType)
 		}
 
-		for _, stringPtr := range confVal.Value {
-			if stringPtr == nil {
-				return fmt.Errorf("Could not populate %s null value for %s", tp.String(), key)
+		for _, entry := range confVal.Entries {
+			if !entry.HasValue {
+				return fmt.Errorf("Could not populate %s null value for %s: %w", tp.String(), key, ErrTypeMismatch)
 			}
 			elemvalptr := reflect.New(elemtp)
 			elemval := reflect.Indirect(elemvalptr)
-			passConfVal := &ConfigValue{Value: []*string{stringPtr}}
-			if err := self.loadSetValue(elemval, key, defVal, passConfVal, required, haveDefault); err != nil {
+			passConfVal := &ConfigValue{Entries: []ValueEntry{entry}}
+			if err := self.loadSetValue(elemval, key, defVal, passConfVal, required, haveDefault, tags); err != nil {
 				return err
 			}
 			retval.Set(reflect.Append(retval, elemval))
@@ -252,126 +513,322 @@ func (self *Config) loadSetValue(retval reflect.Value, key, defVal string, confV
 	case reflect.Ptr:
 		if confVal == nil || !confVal.HasValues() {
 			if required {
-				return fmt.Errorf("Could not populate required %s no value for %s", tp.String(), key)
+				return fmt.Errorf("Could not populate required %s no value for %s: %w", tp.String(), key, missingKey(tp.String(), key))
 			}
 			if !haveDefault {
 				// leave existing value (if any) untouched
 				return nil
 			}
-			confVal = &ConfigValue{Value: []*string{&defVal}}
+			confVal = &ConfigValue{Entries: []ValueEntry{{Value: defVal, HasValue: true}}}
 		}
 		if retval.IsNil() {
 			retval.Set(reflect.New(retval.Type().Elem()))
 		}
-		return self.loadSetValue(reflect.Indirect(retval), key, defVal, confVal, required, haveDefault)
+		return self.loadSetValue(reflect.Indirect(retval), key, defVal, confVal, required, haveDefault, tags)
 
 	case reflect.Array:
 		elemtp := tp.Elem()
-		switch elemtp.Kind() {
-		case reflect.Array, reflect.Slice, reflect.Map, reflect.Struct:
-			// slice of hash maps is not supported
-			return fmt.Errorf("cannot populate field %s of type %s. Arrays can only contain basic types.", key, elemtp.String())
+		if !listElemSupported(reflect.Array, elemtp) {
+			return fmt.Errorf("cannot populate field %s of type %s. Arrays can only contain basic types: %w", key, elemtp.String(), ErrUnsupportedType)
 		}
 		aLen := tp.Len()
-		setLen := len(confVal.Value)
+		setLen := 0
+		if confVal != nil {
+			setLen = len(confVal.Entries)
+		}
 		if aLen < setLen {
 			// get the last max values of the slice
-			confVal = &ConfigValue{Name: confVal.Name, OrigCaseName: confVal.OrigCaseName, Value: confVal.Value[setLen-aLen:]}
-			setLen = len(confVal.Value)
+			confVal = &ConfigValue{Name: confVal.Name, OrigCaseName: confVal.OrigCaseName, Entries: confVal.Entries[setLen-aLen:]}
+			setLen = len(confVal.Entries)
 		}
 		for i := 0; i < aLen; i++ {
 			var passConfVal *ConfigValue
 			if i < setLen {
-				passConfVal = &ConfigValue{Value: []*string{confVal.Value[i]}}
+				passConfVal = &ConfigValue{Entries: confVal.Entries[i : i+1]}
 			}
 			valPtr := retval.Index(i)
-			if err := self.loadSetValue(valPtr, key, defVal, passConfVal, required, haveDefault); err != nil {
+			if err := self.loadSetValue(valPtr, key, defVal, passConfVal, required, haveDefault, tags); err != nil {
 				return err
 			}
 		}
 		return nil
 
 	case reflect.Map:
-		elemtp := tp.Elem()
-		kTp := tp.Key()
-		switch kTp.Kind() {
+		return self.loadSubSections(key, tp.String(), tp.Key(), tp.Elem(), defVal, required, haveDefault, tags,
+			func(int) { retval.Set(reflect.MakeMap(tp)) },
+			func(k, v reflect.Value) { retval.SetMapIndex(k, v) })
+
+	case reflect.Struct:
+		if required {
+			s, ss := splitSectionPath(key)
+			if self.LookupValueSet(s, ss) == nil {
+				return fmt.Errorf("cannot populate field %s of type struct %s. Required section '%s' was not present: %w: %w", key, tp.String(), key, missingSection(tp.String(), key), ErrSectionNotFound)
+			}
+		}
+		if err := self.loadStruct(retval, key, tags.field); err != nil {
+			return fmt.Errorf("cannot populate field %s of type struct %s: %w\n", key, tp.String(), err)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("cannot populate field %s of type %s: %w", key, tp.String(), ErrUnsupportedType)
+	}
+	return nil
+}
+
+// Fills a map, or a slice of KV, of type tpName with key type kTp and value
+// type elemtp from the sub-sections of the section the key names, in the
+// order they were declared. reset is called with the number of entries
+// before the first is added.
+func (self *Config) loadSubSections(key, tpName string, kTp, elemtp reflect.Type, defVal string, required, haveDefault bool, tags fieldTags, reset func(n int), add func(k, v reflect.Value)) error {
+	switch kTp.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map, reflect.Struct:
+		return fmt.Errorf("cannot populate field %s of type %s. Keys can only contain basic types: %w", key, tpName, ErrUnsupportedType)
+	}
+	amStruct := false
+	amStructSlice := false
+	amNestedMap := false
+	sName := ""
+	sKey := ""
+	switch elemtp.Kind() {
+	case reflect.Map:
+		innerKTp := elemtp.Key()
+		innerTp := elemtp.Elem()
+		switch innerKTp.Kind() {
 		case reflect.Array, reflect.Slice, reflect.Map, reflect.Struct:
-			return fmt.Errorf("cannot populate field %s of type map[%s]%s. Map keys can only contain basic types.", key, kTp.String(), elemtp.String())
-		}
-		amStruct := false
-		sName := ""
-		sKey := ""
-		switch elemtp.Kind() {
-		case reflect.Map:
-			return fmt.Errorf("cannot populate field %s of type map[%s]%s. Map values cannot be another maps.", key, kTp.String(), elemtp.String())
-		case reflect.Struct:
-			amStruct = true
-			keyLen := len(key)
-			if strings.HasSuffix(key, ".*.") {
-				sName = key[0 : keyLen-3]
-			} else if strings.HasSuffix(key, ".*") {
-				sName = key[0 : keyLen-2]
-			} else if strings.Contains(key, ".*.") {
-				return fmt.Errorf("cannot populate field %s of type map[%s]%s. Key must be of form '<section>' or '<setion>.*'.", key, kTp.String(), elemtp.String())
-			} else {
-				sName = key
-			}
-			if sName == "" {
-				return fmt.Errorf("cannot populate field %s of type map[%s]%s. Key must be of form '<section>' or '<setion>.*'. <section> must be non-zero length.", key, kTp.String(), elemtp.String())
+			return fmt.Errorf("cannot populate field %s of type %s. Inner map keys can only contain basic types: %w", key, tpName, ErrUnsupportedType)
+		}
+		switch innerTp.Kind() {
+		case reflect.Map, reflect.Struct:
+			return fmt.Errorf("cannot populate field %s of type %s. Inner map values cannot be maps or structs: %w", key, tpName, ErrUnsupportedType)
+		}
+		amNestedMap = true
+		var err error
+		if sName, err = mapSectionName(key); err != nil {
+			return fmt.Errorf("cannot populate field %s of type %s. %w", key, tpName, err)
+		}
+	case reflect.Struct:
+		amStruct = true
+		var err error
+		if sName, err = mapSectionName(key); err != nil {
+			return fmt.Errorf("cannot populate field %s of type %s. %w", key, tpName, err)
+		}
+	case reflect.Slice:
+		if elemtp.Elem().Kind() == reflect.Struct {
+			amStructSlice = true
+			var err error
+			if sName, err = mapSectionName(key); err != nil {
+				return fmt.Errorf("cannot populate field %s of type %s. %w", key, tpName, err)
+			}
+			break
+		}
+		fallthrough
+	default:
+		out := strings.Split(key, ".*.")
+		if len(out) != 2 || out[0] == "" || out[1] == "" {
+			return fmt.Errorf("cannot populate field %s of type %s. Key must be of form '<section>.*.<key>'. Both <section> and <key> must be non-zero length: %w", key, tpName, ErrInvalidTag)
+		}
+		sName = out[0]
+		sKey = out[1]
+	}
+	section := self.LookupSection(sName)
+	if section == nil {
+		if required {
+			return fmt.Errorf("cannot populate field %s of type %s. Required section '%s' was not present: %w: %w", key, tpName, sName, missingSection(tpName, sName), ErrSectionNotFound)
+		}
+		if tags.minEntries > 0 {
+			return fmt.Errorf("cannot populate field %s of type %s. At least %d entries required but section '%s' was not present: %w", key, tpName, tags.minEntries, sName, ErrSectionNotFound)
+		}
+		return nil
+	}
+	if cnt := len(section.SubSections); cnt < tags.minEntries {
+		return fmt.Errorf("cannot populate field %s of type %s. At least %d entries required but section '%s' only has %d sub-sections: %w", key, tpName, tags.minEntries, sName, cnt, ErrSectionNotFound)
+	}
+	reset(len(section.SubSections))
+	for _, subSectName := range section.subSectionNames() {
+		subSection := section.SubSections[subSectName]
+		kValPtr := reflect.New(kTp)
+		kVal := reflect.Indirect(kValPtr)
+		passConfVal := &ConfigValue{Entries: []ValueEntry{{Value: subSectName, HasValue: true}}}
+		if err := self.loadSetValue(kVal, key, "", passConfVal, false, false, tags); err != nil {
+			return fmt.Errorf("cannot populate field %s of type %s. Sub-section name '%s' could not be parsed as required key-type: %w", key, tpName, subSectName, err)
+		}
+		vValPtr := reflect.New(elemtp)
+		vVal := reflect.Indirect(vValPtr)
+		x := sName + "." + subSectName
+		switch {
+		case amStruct:
+			if err := self.loadStruct(vVal, x, tags.field+"["+subSectName+"]"); err != nil {
+				return fmt.Errorf("cannot populate field %s of type %s. Contents of sub-section name '%s' could not be parsed as required value-type: %w", key, tpName, subSectName, err)
+			}
+		case amNestedMap:
+			if err := self.loadNestedMap(vVal, x, defVal, subSection, haveDefault, tags); err != nil {
+				return fmt.Errorf("cannot populate field %s of type %s. Contents of sub-section name '%s' could not be parsed as required value-type: %w", key, tpName, subSectName, err)
+			}
+		case amStructSlice:
+			if err := self.loadStructSlice(vVal, sName, subSection, tags.field+"["+subSectName+"]"); err != nil {
+				return fmt.Errorf("cannot populate field %s of type %s. Contents of sub-section name '%s' could not be parsed as required value-type: %w", key, tpName, subSectName, err)
 			}
 		default:
-			out := strings.Split(key, ".*.")
-			if len(out) != 2 || out[0] == "" || out[1] == "" {
-				return fmt.Errorf("cannot populate field %s of type map[%s]%s. Key must be of form '<section>.*.<key>'. Both <section> and <key> must be non-zero length.", key, kTp.String(), elemtp.String())
+			passConfVal = subSection.GetKeyValuesRaw(sKey)
+			if err := self.loadSetValue(vVal, x+"."+sKey, defVal, passConfVal, required, haveDefault, tags); err != nil {
+				return fmt.Errorf("cannot populate field %s of type %s. Contents of sub-section name '%s' could not be parsed as required value-type: %w", key, tpName, subSectName, err)
 			}
-			sName = out[0]
-			sKey = out[1]
 		}
-		section := self.GetSection(sName, false)
-		if section == nil {
-			if required {
-				return fmt.Errorf("cannot populate field %s of type map[%s]%s. Required section '%s' was not present.", key, kTp.String(), elemtp.String(), sName)
-			}
-			return nil
-		}
-		retval.Set(reflect.MakeMap(tp))
-		for subSectName, subSection := range section.SubSections {
-			kValPtr := reflect.New(kTp)
-			kVal := reflect.Indirect(kValPtr)
-			passConfVal := &ConfigValue{Value: []*string{&subSectName}}
-			if err := self.loadSetValue(kVal, key, "", passConfVal, false, false); err != nil {
-				return fmt.Errorf("cannot populate field %s of type map[%s]%s. Sub-section name '%s' could not be parsed as required key-type: %s", key, kTp.String(), elemtp.String(), subSectName, err.Error())
-			}
-			vValPtr := reflect.New(elemtp)
-			vVal := reflect.Indirect(vValPtr)
-			if amStruct {
-				x := sName + "." + subSectName
-				if err := self.loadStruct(vVal, x); err != nil {
-					return fmt.Errorf("cannot populate field %s of type map[%s]%s. Contents of sub-section name '%s' could not be parsed as required value-type: %s", key, kTp.String(), elemtp.String(), subSectName, err.Error())
-				}
-			} else {
-				passConfVal = subSection.GetKeyValuesRaw(sKey)
-				if err := self.loadSetValue(vVal, sName+"."+subSectName+"."+sKey, defVal, passConfVal, required, haveDefault); err != nil {
-					return fmt.Errorf("cannot populate field %s of type map[%s]%s. Contents of sub-section name '%s' could not be parsed as required value-type: %s", key, kTp.String(), elemtp.String(), subSectName, err.Error())
-				}
+		add(kVal, vVal)
+	}
+	return nil
+}
+
+// Gets the last string value for a key, or the default if there is none.
+// The second return value is false if the field should be left untouched.
+func scalarString(tp reflect.Type, key, defVal string, confVal *ConfigValue, required, haveDefault bool) (string, bool, error) {
+	if confVal == nil || !confVal.HasValues() {
+		if required {
+			return "", false, fmt.Errorf("Could not populate required %s no value for %s: %w", tp.String(), key, missingKey(tp.String(), key))
+		}
+		if !haveDefault {
+			// leave existing value (if any) untouched
+			return "", false, nil
+		}
+		return defVal, true, nil
+	}
+	s, _ := confVal.GetString()
+	return s, true, nil
+}
+
+// Works out the section name from a map key of the form '<section>' or '<section>.*'
+func mapSectionName(key string) (string, error) {
+	sName := ""
+	keyLen := len(key)
+	if strings.HasSuffix(key, ".*.") {
+		sName = key[0 : keyLen-3]
+	} else if strings.HasSuffix(key, ".*") {
+		sName = key[0 : keyLen-2]
+	} else if strings.Contains(key, ".*.") {
+		return "", fmt.Errorf("Key must be of form '<section>' or '<setion>.*': %w", ErrInvalidTag)
+	} else {
+		sName = key
+	}
+	if sName == "" {
+		return "", fmt.Errorf("Key must be of form '<section>' or '<setion>.*'. <section> must be non-zero length: %w", ErrInvalidTag)
+	}
+	return sName, nil
+}
+
+// Fills a map of key name -> value(s) from every key in a single sub-section
+// Keys are given in their canonical (lowercase) form.
+func (self *Config) loadNestedMap(retval reflect.Value, ns, defVal string, subSection *ConfigSubSection, haveDefault bool, tags fieldTags) error {
+	tp := retval.Type()
+	kTp := tp.Key()
+	elemtp := tp.Elem()
+	retval.Set(reflect.MakeMap(tp))
+	for name, confVal := range subSection.Values {
+		kVal := reflect.Indirect(reflect.New(kTp))
+		passConfVal := &ConfigValue{Entries: []ValueEntry{{Value: name, HasValue: true}}}
+		if err := self.loadSetValue(kVal, ns, "", passConfVal, false, false, tags); err != nil {
+			return fmt.Errorf("Key name '%s' could not be parsed as required key-type: %w", name, err)
+		}
+		vVal := reflect.Indirect(reflect.New(elemtp))
+		if err := self.loadSetValue(vVal, ns+"."+name, defVal, confVal, false, haveDefault, tags); err != nil {
+			return err
+		}
+		retval.SetMapIndex(kVal, vVal)
+	}
+	return nil
+}
+
+// Fills a slice of structs from a single sub-section.
+// Multi-valued keys are treated as parallel lists, so the Nth struct is
+// loaded using the Nth value of each key.
+func (self *Config) loadStructSlice(retval reflect.Value, sName string, subSection *ConfigSubSection, fieldPath string) error {
+	elemtp := retval.Type().Elem()
+	cnt := 0
+	for _, confVal := range subSection.Values {
+		if l := len(confVal.Entries); l > cnt {
+			cnt = l
+		}
+	}
+	retval.Set(reflect.MakeSlice(retval.Type(), 0, cnt))
+	for i := 0; i < cnt; i++ {
+		tmp := NewConfig()
+		self.shareSettings(tmp)
+		tmpSub := tmp.ensureSubSection(sName, subSection.Name)
+		for name, confVal := range subSection.Values {
+			if i >= len(confVal.Entries) {
+				continue
+			}
+			tmpSub.Values[name] = &ConfigValue{
+				Name:         confVal.Name,
+				OrigCaseName: confVal.OrigCaseName,
+				Entries:      confVal.Entries[i : i+1],
 			}
-			retval.SetMapIndex(kVal, vVal)
 		}
-		return nil
+		elemval := reflect.Indirect(reflect.New(elemtp))
+		if err := tmp.loadStruct(elemval, sName+"."+subSection.Name, fmt.Sprintf("%s[%d]", fieldPath, i)); err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+		retval.Set(reflect.Append(retval, elemval))
+	}
+	return nil
+}
 
-	case reflect.Struct:
-		if err := self.loadStruct(retval, key); err != nil {
-			return fmt.Errorf("cannot populate field %s of type struct %s: %s\n", key, tp.String(), err.Error())
+// Splits a section path of the form section[.subsection] into its parts.
+// Unlike ParseSectionKey there is no trailing key, so everything after the
+// first '.' is the subsection.
+func splitSectionPath(path string) (string, string) {
+	if path == "" {
+		return "", ""
+	}
+	parts := strings.SplitN(path, ".", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// Fills a ConfigValueSet field with copies of the raw values of a section.
+// A key of "*" takes the values of the struct's own section (ns) which are
+// not claimed by the struct's other fields, otherwise the key names a
+// section or section.subsection.
+func (self *Config) loadValueSet(fv reflect.Value, t reflect.Type, ns, key string, required bool) error {
+	var claimed map[string]bool
+	section := ns
+	if key == "*" {
+		claimed = make(map[string]bool, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			sibling := t.Field(i).Tag.Get("gcKey")
+			if sibling != "" && !strings.Contains(sibling, ".") {
+				claimed[strings.ToLower(sibling)] = true
+			}
+		}
+	} else if ns != "" {
+		section = ns + "." + key
+	} else {
+		section = key
+	}
+	s, ss := splitSectionPath(section)
+	valSet := self.LookupValueSet(s, ss)
+	if valSet == nil {
+		if required {
+			return fmt.Errorf("Could not populate required %s no section %s: %w: %w", fv.Type().String(), section, missingSection(fv.Type().String(), section), ErrSectionNotFound)
 		}
 		return nil
-
-	default:
-		return fmt.Errorf("cannot populate field %s of type %s", key, tp.String())
 	}
+	out := make(ConfigValueSet, len(*valSet))
+	for name, cv := range *valSet {
+		if claimed[name] {
+			continue
+		}
+		out[name] = cv.copy()
+	}
+	fv.Set(reflect.ValueOf(out))
 	return nil
 }
 
-func (self *Config) loadStruct(rv reflect.Value, ns string) error {
+// Loads the fields of a struct, whose own Go field path (empty for the
+// struct passed to Load) is fieldPath.
+func (self *Config) loadStruct(rv reflect.Value, ns, fieldPath string) error {
 	t := rv.Type()
 
 	errs := LoadError{}
@@ -387,9 +844,11 @@ func (self *Config) loadStruct(rv reflect.Value, ns string) error {
 		if key == "" {
 			continue
 		}
+		relKey := key
 		if ns != "" {
 			key = ns + "." + key
 		}
+		path := joinFieldPath(fieldPath, ft.Name)
 		req := ft.Tag.Get("gcRequired")
 		required := false
 		haveDefault := false
@@ -398,15 +857,42 @@ func (self *Config) loadStruct(rv reflect.Value, ns string) error {
 			var err error
 			required, err = strconv.ParseBool(req)
 			if err != nil {
-				return fmt.Errorf("Could not parse required:\"%s\" as boolean in field %q\n", req, ft.Name)
+				return fmt.Errorf("Could not parse required:\"%s\" as boolean in field %q: %w\n", req, ft.Name, ErrInvalidTag)
 			}
 		}
 		if !required {
 			def, haveDefault = ft.Tag.Lookup("gcDefault")
 		}
-		confValue := self.GetKeyValuesRaw(key)
-		if err := self.loadSetValue(fv, key, def, confValue, required, haveDefault); err != nil {
-			errs[key] = fmt.Errorf("Could not populate %s field %q: %s", ft.Type.String(), ft.Name, err.Error())
+		target := self
+		if scope := ft.Tag.Get("gcScope"); scope != "" && self.set != nil {
+			var err error
+			if target, err = self.set.scopedConfig(scope, key, ft.Type); err != nil {
+				errs.add(key, path, self.lastOrigin(key), fmt.Errorf("Could not populate %s field %q: %w", ft.Type.String(), ft.Name, err))
+				continue
+			}
+		}
+		if ft.Type == configValueSetType {
+			if err := target.loadValueSet(fv, t, ns, relKey, required); err != nil {
+				errs.add(key, path, target.lastOrigin(key), fmt.Errorf("Could not populate %s field %q: %w", ft.Type.String(), ft.Name, err))
+			}
+			continue
+		}
+		secret, err := secretTag(ft)
+		if err != nil {
+			return err
+		}
+		tags, err := parseFieldTags(ft)
+		if err != nil {
+			return err
+		}
+		tags.field = path
+		confValue := target.GetKeyValuesRaw(key)
+		if err := target.loadSetValue(fv, key, def, confValue, required, haveDefault, tags); err != nil {
+			if secret {
+				errs.add(key, path, target.lastOrigin(key), withholdSecret(ft.Type.String(), ft.Name, err))
+				continue
+			}
+			errs.add(key, path, target.lastOrigin(key), fmt.Errorf("Could not populate %s field %q: %w", ft.Type.String(), ft.Name, err))
 		}
 	}
 
@@ -417,123 +903,726 @@ func (self *Config) loadStruct(rv reflect.Value, ns string) error {
 	return errs
 }
 
-// Get a section by name (case insensitive) optionally creating it if not there
+// Parses the tags of a field which change how its values are read.
+func parseFieldTags(ft reflect.StructField) (fieldTags, error) {
+	tags := fieldTags{
+		layout:  ft.Tag.Get("gcLayout"),
+		intBase: 10,
+	}
+	if min := ft.Tag.Get("gcMinEntries"); min != "" {
+		var err error
+		tags.minEntries, err = strconv.Atoi(min)
+		if err != nil {
+			return tags, fmt.Errorf("Could not parse minEntries:\"%s\" as integer in field %q: %w\n", min, ft.Name, ErrInvalidTag)
+		}
+	}
+	if base := ft.Tag.Get("gcIntBase"); base != "" {
+		var err error
+		tags.intBase, err = strconv.Atoi(base)
+		if err != nil || tags.intBase == 1 || tags.intBase < 0 || tags.intBase > 36 {
+			return tags, fmt.Errorf("Could not parse gcIntBase:\"%s\" as 0 or a base from 2 to 36 in field %q: %w\n", base, ft.Name, ErrInvalidTag)
+		}
+	}
+	if unit := ft.Tag.Get("gcUnit"); unit != "" {
+		if err := checkUnitTag(ft.Type, unit); err != nil {
+			return tags, fmt.Errorf("Could not use unit:\"%s\" in field %q: %w\n", unit, ft.Name, err)
+		}
+		tags.unit = unit
+	}
+	if path := ft.Tag.Get("gcPath"); path != "" {
+		if err := checkPathTag(ft.Type, path); err != nil {
+			return tags, fmt.Errorf("Could not use gcPath:\"%s\" in field %q: %w\n", path, ft.Name, err)
+		}
+		tags.path = path
+	}
+	return tags, nil
+}
+
+// Gets the character new comments should start with: that given by
+// core.commentChar if it is one a config file can use ('#' or ';'), '#'
+// otherwise (including for "auto").
+func (self *Config) CommentChar() byte {
+	if v, ok := self.GetKeyValueAsString("core.commentchar"); ok && (v == "#" || v == ";") {
+		return v[0]
+	}
+	return '#'
+}
+
+// Get a section by name (case insensitive) optionally creating it if not
+// there. Prefer LookupSection and EnsureSection, which make plain whether
+// the config may change.
 func (self *Config) GetSection(section string, createEmpty bool) *ConfigSection {
-	slc := strings.ToLower(section)
-	s := self.Sections[slc]
-	if s != nil || !createEmpty {
+	if createEmpty {
+		return self.EnsureSection(section)
+	}
+	return self.LookupSection(section)
+}
+
+// Gets a section by name (case insensitive), or nil if it does not exist.
+// The Lookup* methods never change the config, so any number of
+// goroutines may call them at once, as long as none changes it.
+func (self *Config) LookupSection(section string) *ConfigSection {
+	return self.Sections[strings.ToLower(section)]
+}
+
+// Gets a section by name (case insensitive), creating it empty if it does
+// not exist.
+func (self *Config) EnsureSection(section string) *ConfigSection {
+	if s := self.LookupSection(section); s != nil {
 		return s
 	}
+	self.seq++
 	sect := &ConfigSection{
-		Name:         slc,
+		Name:         strings.ToLower(section),
 		OrigCaseName: section,
 		SubSections:  make(map[string]*ConfigSubSection, 5),
 		Values:       make(ConfigValueSet, 5),
+		seq:          self.seq,
 	}
-	self.Sections[slc] = sect
+	self.Sections[sect.Name] = sect
 	return sect
 }
 
-// Get a subsection by name (main section case insensitive), optionally creating if not there
+// Get a subsection by name (main section case insensitive), optionally
+// creating if not there, see EnsureSubSection. Prefer LookupSubSection and
+// EnsureSubSection.
 func (self *Config) GetSubSection(section, subSection string, createEmpty bool) *ConfigSubSection {
-	s := self.GetSection(section, createEmpty)
+	if createEmpty {
+		return self.EnsureSubSection(section, subSection)
+	}
+	return self.LookupSubSection(section, subSection)
+}
+
+// Gets a subsection by name (main section case insensitive), or nil if it
+// does not exist.
+func (self *Config) LookupSubSection(section, subSection string) *ConfigSubSection {
+	s := self.LookupSection(section)
 	if s == nil {
 		return nil
 	}
-	ss := s.SubSections[subSection]
-	if ss != nil || !createEmpty {
+	return s.subSection(subSection, self.FoldSubSections)
+}
+
+// Gets a subsection by name (main section case insensitive), creating it
+// and its section empty if they do not exist. Names holding a newline or
+// NUL cannot be written so are never created, giving nil; EnsureSubSectionE
+// says why.
+func (self *Config) EnsureSubSection(section, subSection string) *ConfigSubSection {
+	if validateSubSectionName(subSection) != nil {
+		return nil
+	}
+	return self.ensureSubSection(section, subSection)
+}
+
+// As EnsureSubSection, without checking the name can be written.
+func (self *Config) ensureSubSection(section, subSection string) *ConfigSubSection {
+	s := self.EnsureSection(section)
+	if ss := s.subSection(subSection, self.FoldSubSections); ss != nil {
 		return ss
 	}
-	ss = &ConfigSubSection{
+	self.seq++
+	ss := &ConfigSubSection{
 		Name:   subSection,
 		Values: make(ConfigValueSet, 5),
+		seq:    self.seq,
 	}
 	s.SubSections[subSection] = ss
 	return ss
 }
 
-// Attempts to get the value store for the given section/subSection
-func (self *Config) GetConfigValueSet(section, subSection string, createEmpty bool) *ConfigValueSet {
-	if section == "" {
-		return &self.BaseValues
+// Gets a subsection as EnsureSubSection does, but a name which cannot be
+// written gives a *KeyError rather than nil.
+func (self *Config) EnsureSubSectionE(section, subSection string) (*ConfigSubSection, error) {
+	if err := checkSubSection(section+"."+subSection, subSection); err != nil {
+		return nil, err
 	}
-	if subSection == "" {
-		s := self.GetSection(section, createEmpty)
-		if s == nil {
-			return nil
-		}
-		return &s.Values
+	return self.EnsureSubSection(section, subSection), nil
+}
+
+func (self *ConfigSection) subSection(name string, fold bool) *ConfigSubSection {
+	ss := self.SubSections[name]
+	if ss == nil && fold {
+		ss = self.foldedSubSection(name)
 	}
-	ss := self.GetSubSection(section, subSection, createEmpty)
-	if ss == nil {
-		return nil
+	return ss
+}
+
+// Reports whether the section (case insensitive) exists, even if empty.
+func (self *Config) HasSection(section string) bool {
+	return self.LookupSection(section) != nil
+}
+
+// Reports whether the sub-section exists, even if empty.
+func (self *Config) HasSubSection(section, subSection string) bool {
+	return self.LookupSubSection(section, subSection) != nil
+}
+
+// Gets the (lower case) names of every section, in the order first seen.
+func (self *Config) SectionNames() []string {
+	sections := self.orderedSections()
+	out := make([]string, len(sections))
+	for i, s := range sections {
+		out[i] = s.Name
 	}
-	return &ss.Values
+	return out
 }
 
-// Convert a key in format section.subsection.key to relevant strings
-// Will presume section.key (if only one '.')
-// Or just key (if no '.')
-// The last isn't valid gitconfig for acess, but gitconfig does allow storage
-// and values stored with no section can be seen with e.g. `gitconfig --get-regexp .`
-// This will also lowercase section and key names.
-func ParseSectionKey(full_key string) (string, string, string) {
-	out := strings.Split(full_key, ".")
-	cnt := len(out)
-	switch cnt {
-	case 1:
-		return "", "", strings.ToLower(out[0])
-	case 2:
-		return strings.ToLower(out[0]), "", strings.ToLower(out[1])
-	case 3:
-		return strings.ToLower(out[0]), out[1], strings.ToLower(out[2])
-	default:
-		return strings.ToLower(out[0]), strings.Join(out[1:cnt-1], "."), strings.ToLower(out[cnt-1])
+// Gets the names of every section as first written, in the order first seen.
+func (self *Config) OrigCaseSectionNames() []string {
+	sections := self.orderedSections()
+	out := make([]string, len(sections))
+	for i, s := range sections {
+		out[i] = s.OrigCaseName
 	}
+	return out
 }
 
-// Attempts to get the value store for the given section/subSection
-func (self *Config) GetConfigValues(section, subSection, key string, createEmpty bool) *ConfigValue {
-	valSet := self.GetConfigValueSet(section, subSection, createEmpty)
-	if valSet == nil {
-		return nil
+func (self *Config) orderedSections() []*ConfigSection {
+	sections := make([]*ConfigSection, 0, len(self.Sections))
+	for _, s := range self.Sections {
+		sections = append(sections, s)
 	}
-	return valSet.GetConfigValues(key, createEmpty)
+	sort.Slice(sections, func(i, j int) bool {
+		if sections[i].seq != sections[j].seq {
+			return sections[i].seq < sections[j].seq
+		}
+		return sections[i].Name < sections[j].Name
+	})
+	return sections
 }
 
-func (self *Config) AddKeyValue(section, subSection, key string, value *string) {
-	cvs := self.GetConfigValues(section, subSection, key, true)
-	cvs.Value = append(cvs.Value, value)
+// Gets the names of the section's sub-sections, in the order first seen.
+// The result is nil if the section does not exist.
+func (self *Config) SubSectionNames(section string) []string {
+	s := self.LookupSection(section)
+	if s == nil {
+		return nil
+	}
+	return s.subSectionNames()
 }
 
-// Getters go here, first raw
-func (self *Config) GetKeyValuesRaw(key string) *ConfigValue {
-	s, ss, k := ParseSectionKey(key)
-	if k == "" {
+// Gets the (lower case) names of the keys in the section or sub-section,
+// sorted. The result is nil if it does not exist.
+func (self *Config) KeyNames(section, subSection string) []string {
+	values := self.LookupValueSet(section, subSection)
+	if values == nil {
 		return nil
 	}
-	return self.GetConfigValues(s, ss, k, false)
+	out := make([]string, 0, len(*values))
+	for name := range *values {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
 }
 
-// Get a set of strings of all the values as an array
-// The array will be nil if the key does not exist
-func (self *Config) GetKeyValuesStrings(key string) []string {
-	cvs := self.GetKeyValuesRaw(key)
-	if cvs == nil {
+// Gets the names of the keys in the section or sub-section as first
+// written, in the same order as KeyNames.
+func (self *Config) OrigCaseKeyNames(section, subSection string) []string {
+	names := self.KeyNames(section, subSection)
+	if names == nil {
 		return nil
 	}
-	return cvs.ValuesAsStrings()
+	values := *self.LookupValueSet(section, subSection)
+	for i, name := range names {
+		names[i] = values[name].OrigCaseName
+	}
+	return names
 }
 
-// Get a set of ints of all the values as an array
-// The array will be nil if the key does not exist.
-// If any value is not parseable as an int, an error will be thrown.
-func (self *Config) GetKeyValuesInts(key string) ([]int64, error) {
-	cvs := self.GetKeyValuesRaw(key)
-	if cvs == nil {
-		return nil, nil
+// Gets a key the way it was first written, e.g. "Remote.origin.pushURL"
+// for "remote.origin.pushurl". The key may be given in any case.
+// If the key does not exist, the second return value will be false.
+func (self *Config) OrigCaseKey(key string) (string, bool) {
+	s, ss, k := ParseSectionKey(key)
+	cv := self.LookupValues(s, ss, k)
+	if cv == nil {
+		return "", false
 	}
-	return cvs.ValuesAsInts()
+	if s == "" {
+		return cv.OrigCaseName, true
+	}
+	section := self.LookupSection(s)
+	if ss != "" {
+		ss = self.LookupSubSection(s, ss).Name // may differ if folded
+	}
+	return joinKey(section.OrigCaseName, ss, cv.OrigCaseName), true
+}
+
+func (self *ConfigSection) subSectionNames() []string {
+	subs := make([]*ConfigSubSection, 0, len(self.SubSections))
+	for _, ss := range self.SubSections {
+		subs = append(subs, ss)
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].seq != subs[j].seq {
+			return subs[i].seq < subs[j].seq
+		}
+		return subs[i].Name < subs[j].Name
+	})
+	out := make([]string, len(subs))
+	for i, ss := range subs {
+		out[i] = ss.Name
+	}
+	return out
+}
+
+// Finds a sub-section whose name matches ignoring case. If there are several
+// the first in name order is used, so the choice does not vary.
+func (self *ConfigSection) foldedSubSection(subSection string) *ConfigSubSection {
+	var found *ConfigSubSection
+	for name, ss := range self.SubSections {
+		if strings.EqualFold(name, subSection) && (found == nil || name < found.Name) {
+			found = ss
+		}
+	}
+	return found
+}
+
+// Attempts to get the value store for the given section/subSection.
+// Prefer LookupValueSet and EnsureValueSet.
+func (self *Config) GetConfigValueSet(section, subSection string, createEmpty bool) *ConfigValueSet {
+	if createEmpty {
+		return self.EnsureValueSet(section, subSection)
+	}
+	return self.LookupValueSet(section, subSection)
+}
+
+// Gets the values of the section, or sub-section unless that is empty, or
+// nil if it does not exist. An empty section gives the values outside any.
+func (self *Config) LookupValueSet(section, subSection string) *ConfigValueSet {
+	if section == "" {
+		return &self.BaseValues
+	}
+	if subSection == "" {
+		if s := self.LookupSection(section); s != nil {
+			return &s.Values
+		}
+		return nil
+	}
+	if ss := self.LookupSubSection(section, subSection); ss != nil {
+		return &ss.Values
+	}
+	return nil
+}
+
+// Gets the values of the section, or sub-section unless that is empty,
+// creating them empty if they do not exist, see EnsureSubSection.
+func (self *Config) EnsureValueSet(section, subSection string) *ConfigValueSet {
+	if section == "" {
+		return &self.BaseValues
+	}
+	if subSection == "" {
+		return &self.EnsureSection(section).Values
+	}
+	if ss := self.EnsureSubSection(section, subSection); ss != nil {
+		return &ss.Values
+	}
+	return nil
+}
+
+// Convert a key in format section.subsection.key to relevant strings
+// Will presume section.key (if only one '.')
+// Or just key (if no '.')
+// The last isn't valid gitconfig for acess, but gitconfig does allow storage
+// and values stored with no section can be seen with e.g. `gitconfig --get-regexp .`
+// This will also lowercase section and key names.
+// Keys which are already lower case are split without allocating.
+// See ParseQuotedKey for keys giving the sub-section in quotes.
+func ParseSectionKey(full_key string) (string, string, string) {
+	first := strings.IndexByte(full_key, '.')
+	if first < 0 {
+		return "", "", strings.ToLower(full_key)
+	}
+	last := strings.LastIndexByte(full_key, '.')
+	section := strings.ToLower(full_key[:first])
+	key := strings.ToLower(full_key[last+1:])
+	if first == last {
+		return section, "", key
+	}
+	return section, full_key[first+1 : last], key
+}
+
+// Attempts to get the value store for the given section/subSection.
+// Prefer LookupValues and EnsureValues.
+func (self *Config) GetConfigValues(section, subSection, key string, createEmpty bool) *ConfigValue {
+	if createEmpty {
+		return self.EnsureValues(section, subSection, key)
+	}
+	return self.LookupValues(section, subSection, key)
+}
+
+// Gets the values of the key (case insensitive) in the section or
+// sub-section, or nil if it does not exist. The sub-section is matched
+// exactly (or ignoring case when FoldSubSections is set and there is no
+// exact match), as git does, and an empty section looks up a key outside
+// any section. An unset key deprecated with DeprecateKey gives the values
+// of the other key instead.
+func (self *Config) LookupValues(section, subSection, key string) *ConfigValue {
+	_, cvs := self.resolveValues(section, subSection, key)
+	return cvs
+}
+
+// Gets the values of the key as LookupValues does, and the canonical key
+// they were read from if that is not the one given.
+func (self *Config) resolveValues(section, subSection, key string) (string, *ConfigValue) {
+	cvs := self.lookupValues(section, subSection, key)
+	if (cvs == nil || len(cvs.Entries) == 0) && self.deprecations.Load() != nil {
+		full := joinKey(strings.ToLower(section), subSection, strings.ToLower(key))
+		if other, ocvs := self.deprecatedValues(full); ocvs != nil {
+			return other, ocvs
+		}
+	}
+	return "", cvs
+}
+
+// Gets the values of the key itself, never those of a deprecated one.
+func (self *Config) lookupValues(section, subSection, key string) *ConfigValue {
+	valSet := self.LookupValueSet(section, subSection)
+	if valSet == nil {
+		return nil
+	}
+	return valSet.LookupConfigValues(key)
+}
+
+// Gets the values of the key in the section or sub-section, creating the
+// key with no values, and where it goes, if it does not exist. The result
+// is nil for a sub-section name which cannot be written.
+func (self *Config) EnsureValues(section, subSection, key string) *ConfigValue {
+	valSet := self.EnsureValueSet(section, subSection)
+	if valSet == nil {
+		return nil
+	}
+	return valSet.EnsureConfigValues(key)
+}
+
+// Adds a value after any existing ones. A nil value adds the key with no value.
+// Nothing is checked: a sub-section name holding a newline or NUL, or a
+// value holding a control character, is added even though git cannot read
+// it back once written. Use AddKeyValueE to have these refused.
+func (self *Config) AddKeyValue(section, subSection, key string, value *string) {
+	entry := ValueEntry{}
+	if value != nil {
+		entry.Value = *value
+		entry.HasValue = true
+	}
+	valSet := &self.BaseValues
+	if subSection != "" && section != "" {
+		valSet = &self.ensureSubSection(section, subSection).Values
+	} else if section != "" {
+		valSet = &self.EnsureSection(section).Values
+	}
+	cvs := valSet.EnsureConfigValues(key)
+	cvs.Entries = append(cvs.Entries, entry)
+	cvs.syncValue()
+}
+
+// Adds a value as AddKeyValue does, but a sub-section name which cannot be
+// written gives a *KeyError, and a value holding NUL or another control
+// character besides tab and newline a *ValueError, and nothing is added.
+func (self *Config) AddKeyValueE(section, subSection, key string, value *string) error {
+	joined := joinKey(section, subSection, key)
+	if err := checkSubSection(joined, subSection); err != nil {
+		return err
+	}
+	if value != nil {
+		if err := validateValue(joined, *value); err != nil {
+			return err
+		}
+	}
+	self.AddKeyValue(section, subSection, key, value)
+	return nil
+}
+
+func (self *Config) addEntry(section, subSection, key string, entry ValueEntry) {
+	if cvs := self.EnsureValues(section, subSection, key); cvs != nil {
+		cvs.Entries = append(cvs.Entries, entry)
+		cvs.Value = append(cvs.Value, entry.pointer())
+	}
+}
+
+// Replaces all values of the key with the single value given. The comment
+// of the last value replaced is kept if it had the same value, and dropped
+// otherwise as it described the old value. A value holding NUL or another
+// control character besides tab and newline gives a *ValueError.
+func (self *Config) Set(key, value string) error {
+	s, ss, k := ParseSectionKey(key)
+	if k == "" {
+		return fmt.Errorf("Cannot set key '%s': %w", key, ErrInvalidKey)
+	}
+	if err := checkSubSection(key, ss); err != nil {
+		return err
+	}
+	if err := validateValue(key, value); err != nil {
+		return err
+	}
+	cvs := self.EnsureValues(s, ss, k)
+	var old []string
+	if cvs.HasValues() {
+		old = cvs.ValuesAsStrings()
+	}
+	entry := ValueEntry{Value: value, HasValue: true}
+	if n := len(cvs.Entries); n > 0 {
+		if last := cvs.Entries[n-1]; last.HasValue && last.Value == value {
+			entry.Comment, entry.commentChar = last.Comment, last.commentChar
+		}
+	}
+	cvs.Entries = []ValueEntry{entry}
+	cvs.syncValue()
+	if len(old) != 1 || old[0] != value {
+		key := joinKey(s, ss, k)
+		self.record(MutationSet, KeyChange{Key: key, Old: old, New: []string{value}})
+	}
+	return nil
+}
+
+// Sets the comment written after the last value of the key, e.g. "yubikey"
+// for `signingkey = ABC123 # yubikey`; an empty comment removes it. The
+// comment must fit on one line, and keys given with no value cannot have
+// one, as git would not read them.
+func (self *Config) SetValueComment(key, comment string) error {
+	s, ss, k := ParseSectionKey(key)
+	if k == "" {
+		return fmt.Errorf("Cannot set key '%s': %w", key, ErrInvalidKey)
+	}
+	cvs := self.lookupValues(s, ss, k)
+	if cvs == nil || len(cvs.Entries) == 0 {
+		return fmt.Errorf("Cannot comment key '%s': %w", key, ErrKeyNotFound)
+	}
+	if strings.ContainsAny(comment, "\n\r\x00") {
+		return fmt.Errorf("Cannot comment key '%s' over several lines: %w", key, ErrInvalidValue)
+	}
+	last := &cvs.Entries[len(cvs.Entries)-1]
+	if !last.HasValue && comment != "" {
+		return fmt.Errorf("Cannot comment key '%s' which has no value: %w", key, ErrInvalidValue)
+	}
+	last.Comment = strings.TrimSpace(comment)
+	last.commentChar = self.CommentChar()
+	return nil
+}
+
+// Adds a value to the key at the given position among its existing values,
+// 0 putting it first and the number of values putting it last. Order matters
+// to git for multi-valued keys such as remote.<name>.fetch.
+func (self *Config) InsertKeyValue(key, value string, position int) error {
+	s, ss, k := ParseSectionKey(key)
+	if k == "" {
+		return fmt.Errorf("Cannot set key '%s': %w", key, ErrInvalidKey)
+	}
+	if err := checkSubSection(key, ss); err != nil {
+		return err
+	}
+	if err := validateValue(key, value); err != nil {
+		return err
+	}
+	cvs := self.lookupValues(s, ss, k)
+	cnt := 0
+	if cvs != nil {
+		cnt = len(cvs.Entries)
+	}
+	if position < 0 || position > cnt {
+		return fmt.Errorf("Cannot insert into key '%s' at position %d, it has %d values: %w", key, position, cnt, ErrOutOfRange)
+	}
+	if cvs == nil {
+		cvs = self.EnsureValues(s, ss, k)
+	}
+	old := cvs.ValuesAsStrings()
+	cvs.Entries = slices.Insert(cvs.Entries, position, ValueEntry{Value: value, HasValue: true})
+	cvs.syncValue()
+	if cnt == 0 {
+		old = nil
+	}
+	self.record(MutationAdd, KeyChange{Key: joinKey(s, ss, k), Old: old, New: cvs.ValuesAsStrings()})
+	return nil
+}
+
+// Adds a value to the key before any existing values.
+func (self *Config) PrependKeyValue(key, value string) error {
+	return self.InsertKeyValue(key, value, 0)
+}
+
+// Removes all values of the key, returning false if it had none.
+func (self *Config) Unset(key string) bool {
+	s, ss, k := ParseSectionKey(key)
+	valSet := self.LookupValueSet(s, ss)
+	if k == "" || valSet == nil {
+		return false
+	}
+	cvs := (*valSet)[k]
+	if cvs == nil {
+		return false
+	}
+	delete(*valSet, k)
+	if !cvs.HasValues() {
+		return false
+	}
+	key = joinKey(s, ss, k)
+	self.record(MutationUnset, KeyChange{Key: key, Old: cvs.ValuesAsStrings()})
+	return true
+}
+
+// Tidies the config the way git does when unsetting, removing keys with
+// no values, then sub-sections and sections with no keys left. If
+// removeValueless is true values given without '=' (e.g. "[core] bare")
+// are removed first. Returns the number of keys, sub-sections and sections
+// removed.
+func (self *Config) Prune(removeValueless bool) int {
+	removed := 0
+	var changes []KeyChange
+	pruneValues := func(section, subSection string, values ConfigValueSet) {
+		for name, cv := range values {
+			if removeValueless {
+				old := cv.ValuesAsStrings()
+				kept := cv.Entries[:0]
+				for _, e := range cv.Entries {
+					if e.HasValue {
+						kept = append(kept, e)
+					}
+				}
+				if len(kept) != len(old) {
+					cv.Entries = kept
+					cv.syncValue()
+					var new []string
+					if len(kept) > 0 {
+						new = cv.ValuesAsStrings()
+					}
+					changes = append(changes, KeyChange{Key: joinKey(section, subSection, name), Old: old, New: new})
+				}
+			}
+			if len(cv.Entries) == 0 {
+				delete(values, name)
+				removed++
+			}
+		}
+	}
+	pruneValues("", "", self.BaseValues)
+	for sName, s := range self.Sections {
+		pruneValues(sName, "", s.Values)
+		for ssName, ss := range s.SubSections {
+			pruneValues(sName, ssName, ss.Values)
+			if len(ss.Values) == 0 {
+				delete(s.SubSections, ssName)
+				removed++
+			}
+		}
+		if len(s.Values) == 0 && len(s.SubSections) == 0 {
+			delete(self.Sections, sName)
+			removed++
+		}
+	}
+	if len(changes) > 0 {
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+		self.record(MutationPrune, changes...)
+	}
+	return removed
+}
+
+// Adds all the values from other after any existing values, so that other's
+// values take precedence. Section, subsection and key names keep the case
+// they were first seen with.
+// New sections are added in the order other has them.
+func (self *Config) Merge(other *Config) {
+	mergeValueSet(self, "", "", other.BaseValues)
+	for _, s := range other.orderedSections() {
+		self.EnsureSection(s.OrigCaseName)
+		mergeValueSet(self, s.OrigCaseName, "", s.Values)
+		for _, ssName := range s.subSectionNames() {
+			self.EnsureSubSection(s.OrigCaseName, ssName)
+			mergeValueSet(self, s.OrigCaseName, ssName, s.SubSections[ssName].Values)
+		}
+	}
+	self.Imports = append(self.Imports, other.Imports...)
+	self.warnings = append(self.warnings, other.warnings...)
+	for _, file := range other.SourceFiles() {
+		if !slices.Contains(self.sources, file) {
+			self.sources = append(self.sources, file)
+		}
+	}
+}
+
+// Gets a copy of the sections and values which shares no storage with the
+// original and keeps its order. Listeners, layers and source are not copied.
+func (self *Config) clone() *Config {
+	out := NewConfig()
+	out.FoldSubSections = self.FoldSubSections
+	out.seq = self.seq
+	out.options = self.options
+	// the blocks themselves never change, so entries may keep pointing at them
+	out.blocks = slices.Clone(self.blocks)
+	out.blockCount = maps.Clone(self.blockCount)
+	out.Imports = append(out.Imports, self.Imports...)
+	out.warnings = append(out.warnings, self.warnings...)
+	if d := self.deprecations.Load(); d != nil {
+		out.deprecations.Store(d.clone())
+	}
+	out.BaseValues = self.BaseValues.clone()
+	for name, s := range self.Sections {
+		cs := *s
+		cs.Values = s.Values.clone()
+		cs.SubSections = make(map[string]*ConfigSubSection, len(s.SubSections))
+		for ssName, ss := range s.SubSections {
+			css := *ss
+			css.Values = ss.Values.clone()
+			cs.SubSections[ssName] = &css
+		}
+		out.Sections[name] = &cs
+	}
+	return out
+}
+
+// Gives a config made from some of this one's values the settings which
+// change how they are looked up: FoldSubSections, the parse options and
+// the deprecated keys, which are shared so fallbacks are warned about once.
+func (self *Config) shareSettings(out *Config) {
+	out.FoldSubSections = self.FoldSubSections
+	out.options = self.options
+	out.deprecations.Store(self.deprecations.Load())
+}
+
+func (self ConfigValueSet) clone() ConfigValueSet {
+	out := make(ConfigValueSet, len(self))
+	for name, cv := range self {
+		out[name] = cv.copy()
+	}
+	return out
+}
+
+func mergeValueSet(self *Config, section, subSection string, values ConfigValueSet) {
+	for _, cv := range values {
+		dst := self.EnsureValues(section, subSection, cv.OrigCaseName)
+		dst.Entries = append(dst.Entries, cv.Entries...)
+		dst.syncValue()
+	}
+}
+
+// Getters go here, first raw
+func (self *Config) GetKeyValuesRaw(key string) *ConfigValue {
+	s, ss, k := ParseSectionKey(key)
+	if k == "" {
+		return nil
+	}
+	return self.LookupValues(s, ss, k)
+}
+
+// Get a set of strings of all the values as an array
+// The array will be nil if the key does not exist
+func (self *Config) GetKeyValuesStrings(key string) []string {
+	cvs := self.GetKeyValuesRaw(key)
+	if cvs == nil {
+		return nil
+	}
+	return cvs.ValuesAsStrings()
+}
+
+// Get a set of ints of all the values as an array
+// The array will be nil if the key does not exist.
+// If any value is not parseable as an int, an error will be thrown.
+func (self *Config) GetKeyValuesInts(key string) ([]int64, error) {
+	cvs := self.GetKeyValuesRaw(key)
+	if cvs == nil {
+		return nil, nil
+	}
+	return cvs.ValuesAsInts()
 }
 
 // Get a set of bools of all the values as an array
@@ -547,6 +1636,50 @@ func (self *Config) GetKeyValuesBools(key string) ([]bool, error) {
 	return cvs.ValuesAsBools()
 }
 
+// Get a set of unsigned ints of all the values as an array
+// The array will be nil if the key does not exist.
+// If any value is not parseable as an unsigned int, an error will be thrown.
+func (self *Config) GetKeyValuesUints(key string) ([]uint64, error) {
+	cvs := self.GetKeyValuesRaw(key)
+	if cvs == nil {
+		return nil, nil
+	}
+	return cvs.ValuesAsUints()
+}
+
+// Get a set of floats of all the values as an array
+// The array will be nil if the key does not exist.
+// If any value is not parseable as a float, an error will be thrown.
+func (self *Config) GetKeyValuesFloats(key string) ([]float64, error) {
+	cvs := self.GetKeyValuesRaw(key)
+	if cvs == nil {
+		return nil, nil
+	}
+	return cvs.ValuesAsFloats()
+}
+
+// Get a set of durations (e.g. "1h30m") of all the values as an array
+// The array will be nil if the key does not exist.
+// If any value is not parseable as a duration, an error will be thrown.
+func (self *Config) GetKeyValuesDurations(key string) ([]time.Duration, error) {
+	cvs := self.GetKeyValuesRaw(key)
+	if cvs == nil {
+		return nil, nil
+	}
+	return cvs.ValuesAsDurations()
+}
+
+// Get the items of a list held as several values, or as comma or space
+// separated items within values, or both, see SplitList.
+// The array will be nil if the key does not exist.
+func (self *Config) GetKeyValuesList(key string) ([]string, error) {
+	cvs := self.GetKeyValuesRaw(key)
+	if cvs == nil {
+		return nil, nil
+	}
+	return cvs.ValuesAsList()
+}
+
 // Get the last specified value of the key as a string.
 // The empty/unset value is the same as an empty string.
 // If the *key* does not exist, the second return value will be false.
@@ -569,6 +1702,58 @@ func (self *Config) GetKeyValueAsInt(key string) (int64, bool, error) {
 	return cvs.GetInt()
 }
 
+// Like GetKeyValueAsInt, but in the given base as for strconv.ParseInt,
+// e.g. 0 to read file modes such as 0644.
+func (self *Config) GetKeyValueAsIntBase(key string, base int) (int64, bool, error) {
+	cvs := self.GetKeyValuesRaw(key)
+	if cvs == nil {
+		return 0, false, nil
+	}
+	return cvs.GetIntBase(base)
+}
+
+// Get the last specified value of the key as an unsigned integer.
+// The empty/unset value will cause an error.
+// If the *key* does not exist, the second return value will be false.
+func (self *Config) GetKeyValueAsUint(key string) (uint64, bool, error) {
+	cvs := self.GetKeyValuesRaw(key)
+	if cvs == nil {
+		return 0, false, nil
+	}
+	return cvs.GetUint()
+}
+
+// Like GetKeyValueAsUint, but in the given base as for strconv.ParseUint.
+func (self *Config) GetKeyValueAsUintBase(key string, base int) (uint64, bool, error) {
+	cvs := self.GetKeyValuesRaw(key)
+	if cvs == nil {
+		return 0, false, nil
+	}
+	return cvs.GetUintBase(base)
+}
+
+// Get the last specified value of the key as a float.
+// The empty/unset value will cause an error.
+// If the *key* does not exist, the second return value will be false.
+func (self *Config) GetKeyValueAsFloat(key string) (float64, bool, error) {
+	cvs := self.GetKeyValuesRaw(key)
+	if cvs == nil {
+		return 0, false, nil
+	}
+	return cvs.GetFloat()
+}
+
+// Get the last specified value of the key as a duration.
+// The empty/unset value will cause an error.
+// If the *key* does not exist, the second return value will be false.
+func (self *Config) GetKeyValueAsDuration(key string) (time.Duration, bool, error) {
+	cvs := self.GetKeyValuesRaw(key)
+	if cvs == nil {
+		return 0, false, nil
+	}
+	return cvs.GetDuration()
+}
+
 // Get the last specified value of the key as a bool.
 // The empty/unset value is the same as false.
 // If the *key* does not exist, the second return value will be false.
@@ -580,27 +1765,38 @@ func (self *Config) GetKeyValueAsBool(key string) (bool, bool, error) {
 	return cvs.GetBool()
 }
 
+// Writes the keys sorted by name, so the output does not vary.
 func (self *ConfigValueSet) String() string {
+	return self.entriesString(nil, false)
+}
+
+// Writes the entries for which keep returns true, or all if it is nil.
+// With keepQuotes values read in quotes are written in quotes.
+func (self *ConfigValueSet) entriesString(keep func(*ValueEntry) bool, keepQuotes bool) string {
+	names := make([]string, 0, len(*self))
+	for name := range *self {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 	out := ""
-	for _, cv := range *self {
-		values := cv.Value
-		if len(values) == 0 {
-			continue
-		}
+	for _, name := range names {
+		cv := (*self)[name]
 		key := cv.OrigCaseName
-		for _, v := range values {
+		for i := range cv.Entries {
+			v := &cv.Entries[i]
+			if keep != nil && !keep(v) {
+				continue
+			}
 			out += "\t" + key
-			if v != nil {
-				escaped := EscapeValueString(*v)
-				l := len(escaped)
-				if l > 1 {
-					// requote if trailing space or containing special chars
-					last, _ := utf8.DecodeLastRuneInString(escaped)
-					if unicode.IsSpace(last) || strings.ContainsAny(escaped, "#;!$`") {
-						escaped = "\"" + escaped + "\""
-					}
+			if v.HasValue {
+				value := formatValue(v.Value)
+				if keepQuotes && v.Quoted && !strings.HasPrefix(value, "\"") {
+					value = "\"" + value + "\""
+				}
+				out += " = " + value
+				if v.Comment != "" {
+					out += " " + v.commentStart() + " " + v.Comment
 				}
-				out += " = " + escaped
 			}
 			out += "\n"
 		}
@@ -608,53 +1804,194 @@ func (self *ConfigValueSet) String() string {
 	return out
 }
 
+// Gets the comment character the entry's comment is written with.
+func (self *ValueEntry) commentStart() string {
+	if self.commentChar == ';' {
+		return ";"
+	}
+	return "#"
+}
+
+// Gets a value as written after "key = ", escaped and quoted as needed.
+func formatValue(value string) string {
+	escaped := EscapeValueString(value)
+	if escaped != "" {
+		// requote if leading or trailing space or containing special chars
+		first, _ := utf8.DecodeRuneInString(escaped)
+		last, _ := utf8.DecodeLastRuneInString(escaped)
+		if unicode.IsSpace(first) || unicode.IsSpace(last) || strings.ContainsAny(escaped, "#;!$`") {
+			escaped = "\"" + escaped + "\""
+		}
+	}
+	return escaped
+}
+
 func (self *ConfigSubSection) GetKeyValuesRaw(key string) *ConfigValue {
-	return self.Values.GetConfigValues(key, false)
+	return self.Values.LookupConfigValues(key)
 }
 
 func (self *ConfigSection) String() string {
-	out := self.Values.String()
+	return self.format(false)
+}
+
+func (self *ConfigSection) format(keepQuotes bool) string {
+	out := self.Values.entriesString(nil, keepQuotes)
 	if out != "" {
-		out = "[" + self.OrigCaseName + "]\n" + out
+		out = sectionHeader(self.OrigCaseName, "") + "\n" + out
 	}
-	for _, ss := range self.SubSections {
-		ssOut := ss.Values.String()
+	for _, name := range self.subSectionNames() {
+		ss := self.SubSections[name]
+		ssOut := ss.Values.entriesString(nil, keepQuotes)
 		if ssOut != "" {
-			out += "[" + self.OrigCaseName + " \"" + EscapeValueString(ss.Name) + "\"]\n" + ssOut
+			out += sectionHeader(self.OrigCaseName, ss.Name) + "\n" + ssOut
 		}
 	}
 	return out
 }
 
+// Gets the header line (without newline) for a section or sub-section.
+func sectionHeader(section, subSection string) string {
+	if subSection == "" {
+		return "[" + section + "]"
+	}
+	return "[" + section + " \"" + escapeSubSection(subSection) + "\"]"
+}
+
+// Escapes a sub-section name for a section header. git reads a backslash
+// there as keeping the next character whatever it is, so only '"' and '\'
+// are escaped; tabs are written as they are.
+func escapeSubSection(name string) string {
+	if !strings.ContainsAny(name, "\\\"") {
+		return name
+	}
+	escaped := strings.Replace(name, "\\", "\\\\", -1)
+	return strings.Replace(escaped, "\"", "\\\"", -1)
+}
+
+// Escapes a value for writing after "key = ": backslashes and double
+// quotes are backslashed, and tabs and newlines written as \t and \n so
+// git reads them back as they were. It adds no quotes, which values with
+// leading or trailing spaces, '#' or ';' also need.
 func EscapeValueString(in string) string {
 	quoted := strings.Replace(in, "\\", "\\\\", -1)
 	quoted = strings.Replace(quoted, "\"", "\\\"", -1)
-	quoted = strings.Replace(quoted, "\t", "\\\t", -1)
-	quoted = strings.Replace(quoted, "\n", "\\\n", -1)
+	quoted = strings.Replace(quoted, "\t", "\\t", -1)
+	quoted = strings.Replace(quoted, "\n", "\\n", -1)
 	return quoted
 }
 
+// Gets the values of the key (case insensitive), optionally creating it
+// with no values if not there. Prefer LookupConfigValues and
+// EnsureConfigValues, which make plain whether the set may change.
 func (self *ConfigValueSet) GetConfigValues(key string, createEmpty bool) *ConfigValue {
+	if createEmpty {
+		return self.EnsureConfigValues(key)
+	}
+	return self.LookupConfigValues(key)
+}
+
+// Gets the values of the key (case insensitive), or nil if it is not
+// there. As for Config.LookupValues this never changes the set.
+func (self *ConfigValueSet) LookupConfigValues(key string) *ConfigValue {
+	return (*self)[strings.ToLower(key)]
+}
+
+// Gets the values of the key (case insensitive), creating it with no values
+// if it is not there.
+func (self *ConfigValueSet) EnsureConfigValues(key string) *ConfigValue {
 	lcKey := strings.ToLower(key)
 	vals := (*self)[lcKey]
-	if vals != nil || !createEmpty {
+	if vals != nil {
 		return vals
 	}
 	vals = &ConfigValue{
 		Name:         lcKey,
 		OrigCaseName: key,
-		Value:        make([]*string, 0, 10),
+		Entries:      make([]ValueEntry, 0, 4),
 	}
 	(*self)[lcKey] = vals
 	return vals
 }
 
+// Gets the i'th value of the key, 0 being the first given.
+// The second return value is false if there is no such value.
+func (self *ConfigValue) At(i int) (ValueEntry, bool) {
+	if i < 0 || i >= len(self.Entries) {
+		return ValueEntry{}, false
+	}
+	return self.Entries[i], true
+}
+
+// Removes the i'th value of the key, keeping the order of the others.
+// As with changing Entries directly, OnChange listeners are not told.
+func (self *ConfigValue) Remove(i int) error {
+	if i < 0 || i >= len(self.Entries) {
+		return fmt.Errorf("Cannot remove value %d of key '%s', it has %d values: %w", i, self.OrigCaseName, len(self.Entries), ErrOutOfRange)
+	}
+	self.Entries = slices.Delete(self.Entries, i, i+1)
+	self.syncValue()
+	return nil
+}
+
+// Replaces the i'th value of the key, which keeps its place among the
+// others. Its origin is cleared as the value no longer comes from there.
+// As with changing Entries directly, OnChange listeners are not told.
+// A value Set would refuse gives a *ValueError.
+func (self *ConfigValue) ReplaceAt(i int, value string) error {
+	if i < 0 || i >= len(self.Entries) {
+		return fmt.Errorf("Cannot replace value %d of key '%s', it has %d values: %w", i, self.OrigCaseName, len(self.Entries), ErrOutOfRange)
+	}
+	if err := validateValue(self.OrigCaseName, value); err != nil {
+		return err
+	}
+	e := &self.Entries[i]
+	e.Value = value
+	e.HasValue = true
+	e.Quoted = false
+	e.Origin = ValueOrigin{}
+	self.syncValue()
+	return nil
+}
+
+// Returns a copy of the value which shares no storage with the original.
+func (self *ConfigValue) copy() *ConfigValue {
+	out := *self
+	out.Entries = append([]ValueEntry(nil), self.Entries...)
+	out.syncValue()
+	return &out
+}
+
+// Refills Value from Entries, after they change.
+func (self *ConfigValue) syncValue() {
+	self.Value = self.ValuePointers()
+}
+
+// Gets the value as a pointer to a copy, nil if there is none.
+func (self ValueEntry) pointer() *string {
+	if !self.HasValue {
+		return nil
+	}
+	return &self.Value
+}
+
+// Gets the values as pointers, nil for keys given without a value.
+// The pointers are to copies, changing them does not alter the config.
+//
+// Deprecated: use Entries, which says the same without the allocations.
+func (self *ConfigValue) ValuePointers() []*string {
+	out := make([]*string, len(self.Entries))
+	for i, v := range self.Entries {
+		out[i] = v.pointer()
+	}
+	return out
+}
+
 func (self *ConfigValue) CountValues() uint64 {
-	return uint64(len(self.Value))
+	return uint64(len(self.Entries))
 }
 
 func (self *ConfigValue) HasValues() bool {
-	cnt := len(self.Value)
+	cnt := len(self.Entries)
 	if cnt == 0 {
 		return false
 	}
@@ -671,7 +2008,13 @@ func (self *ConfigValue) GetString() (string, bool) {
 }
 
 func (self *ConfigValue) GetInt() (int64, bool, error) {
-	out, err := self.ValuesAsInts()
+	return self.GetIntBase(10)
+}
+
+// Like GetInt, but in the given base as for strconv.ParseInt, so base 0
+// reads prefixed values such as 0644, 0o644 or 0x1A.
+func (self *ConfigValue) GetIntBase(base int) (int64, bool, error) {
+	out, err := self.ValuesAsIntsBase(base)
 	if err != nil {
 		return 0, false, err
 	}
@@ -683,7 +2026,36 @@ func (self *ConfigValue) GetInt() (int64, bool, error) {
 }
 
 func (self *ConfigValue) GetUint() (uint64, bool, error) {
-	out, err := self.ValuesAsUints()
+	return self.GetUintBase(10)
+}
+
+// Like GetUint, but in the given base as for strconv.ParseUint.
+func (self *ConfigValue) GetUintBase(base int) (uint64, bool, error) {
+	out, err := self.ValuesAsUintsBase(base)
+	if err != nil {
+		return 0, false, err
+	}
+	l := len(out)
+	if l == 0 {
+		return 0, false, nil
+	}
+	return out[l-1], true, nil
+}
+
+func (self *ConfigValue) GetFloat() (float64, bool, error) {
+	out, err := self.ValuesAsFloats()
+	if err != nil {
+		return 0, false, err
+	}
+	l := len(out)
+	if l == 0 {
+		return 0, false, nil
+	}
+	return out[l-1], true, nil
+}
+
+func (self *ConfigValue) GetDuration() (time.Duration, bool, error) {
+	out, err := self.ValuesAsDurations()
 	if err != nil {
 		return 0, false, err
 	}
@@ -707,33 +2079,73 @@ func (self *ConfigValue) GetBool() (bool, bool, error) {
 }
 
 func (self *ConfigValue) ValuesAsStrings() []string {
-	cnt := len(self.Value)
+	cnt := len(self.Entries)
 	if cnt == 0 {
 		return []string{}
 	}
 	out := make([]string, cnt)
-	for i, v := range self.Value {
-		if v == nil {
-			continue
-		}
-		out[i] = *v
+	for i, v := range self.Entries {
+		out[i] = v.Value
 	}
 	return out
 }
 
 func (self *ConfigValue) ValuesAsUints() ([]uint64, error) {
-	cnt := len(self.Value)
+	return self.ValuesAsUintsBase(10)
+}
+
+// Like ValuesAsUints, but in the given base as for strconv.ParseUint.
+func (self *ConfigValue) ValuesAsUintsBase(base int) ([]uint64, error) {
+	cnt := len(self.Entries)
 	if cnt == 0 {
 		return []uint64{}, nil
 	}
 	out := make([]uint64, cnt)
-	for i, v := range self.Value {
-		if v == nil {
-			return out, fmt.Errorf("Cannot convert empty value to int\n")
+	for i, v := range self.Entries {
+		if !v.HasValue {
+			return out, fmt.Errorf("Cannot convert empty value to int: %w\n", ErrTypeMismatch)
 		}
-		val, err := strconv.ParseUint(*v, 10, 64)
+		val, err := strconv.ParseUint(v.Value, base, 64)
 		if err != nil {
-			return out, err
+			return out, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+		}
+		out[i] = val
+	}
+	return out, nil
+}
+
+func (self *ConfigValue) ValuesAsFloats() ([]float64, error) {
+	cnt := len(self.Entries)
+	if cnt == 0 {
+		return []float64{}, nil
+	}
+	out := make([]float64, cnt)
+	for i, v := range self.Entries {
+		if !v.HasValue {
+			return out, fmt.Errorf("Cannot convert empty value to float: %w\n", ErrTypeMismatch)
+		}
+		val, err := strconv.ParseFloat(v.Value, 64)
+		if err != nil {
+			return out, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+		}
+		out[i] = val
+	}
+	return out, nil
+}
+
+func (self *ConfigValue) ValuesAsDurations() ([]time.Duration, error) {
+	cnt := len(self.Entries)
+	if cnt == 0 {
+		return []time.Duration{}, nil
+	}
+	out := make([]time.Duration, cnt)
+	for i, v := range self.Entries {
+		if !v.HasValue {
+			return out, fmt.Errorf("Cannot convert empty value to duration: %w\n", ErrTypeMismatch)
+		}
+		val, err := time.ParseDuration(v.Value)
+		if err != nil {
+			return out, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
 		}
 		out[i] = val
 	}
@@ -741,18 +2153,23 @@ func (self *ConfigValue) ValuesAsUints() ([]uint64, error) {
 }
 
 func (self *ConfigValue) ValuesAsInts() ([]int64, error) {
-	cnt := len(self.Value)
+	return self.ValuesAsIntsBase(10)
+}
+
+// Like ValuesAsInts, but in the given base as for strconv.ParseInt.
+func (self *ConfigValue) ValuesAsIntsBase(base int) ([]int64, error) {
+	cnt := len(self.Entries)
 	if cnt == 0 {
 		return []int64{}, nil
 	}
 	out := make([]int64, cnt)
-	for i, v := range self.Value {
-		if v == nil {
-			return out, fmt.Errorf("Cannot convert empty value to int\n")
+	for i, v := range self.Entries {
+		if !v.HasValue {
+			return out, fmt.Errorf("Cannot convert empty value to int: %w\n", ErrTypeMismatch)
 		}
-		val, err := strconv.ParseInt(*v, 10, 64)
+		val, err := strconv.ParseInt(v.Value, base, 64)
 		if err != nil {
-			return out, err
+			return out, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
 		}
 		out[i] = val
 	}
@@ -760,43 +2177,155 @@ func (self *ConfigValue) ValuesAsInts() ([]int64, error) {
 }
 
 // gitconfig treats all integers as true, except 0
+// a key with no value at all (e.g. "[core] bare") is true
 // empty and 0-length values are false
-// also recognises yes and no
+// also recognises yes/no and on/off
 func (self *ConfigValue) ValuesAsBools() ([]bool, error) {
-	cnt := len(self.Value)
+	cnt := len(self.Entries)
 	if cnt == 0 {
 		return []bool{}, nil
 	}
 	out := make([]bool, cnt)
-	for i, v := range self.Value {
-		if v == nil {
-			out[i] = false
+	for i, entry := range self.Entries {
+		v := entry.Value
+		if !entry.HasValue {
+			out[i] = true
 			continue
 		}
 		// check zero len
-		if l := len(*v); l == 0 {
+		if l := len(v); l == 0 {
 			out[i] = false
 			continue
 		}
 		// check integer
-		val, err := strconv.ParseInt(*v, 10, 32)
-		if err != nil {
-			if val == 0 {
-				out[i] = false
-			} else {
-				out[i] = true
-			}
+		if val, err := strconv.ParseInt(v, 10, 64); err == nil {
+			out[i] = val != 0
 			continue
 		}
-		lc := strings.ToLower(*v)
+		lc := strings.ToLower(v)
 		switch lc {
-		case "true", "yes":
+		case "true", "yes", "on":
 			out[i] = true
-		case "false", "no":
+		case "false", "no", "off":
 			out[i] = false
 		default:
-			return out, fmt.Errorf("Cannot convert '%s' to bool. Can deal with <empty>/<numeric>/true/yes/false/no\n", *v)
+			return out, fmt.Errorf("Cannot convert '%s' to bool. Can deal with <empty>/<numeric>/true/yes/false/no: %w\n", v, ErrTypeMismatch)
+		}
+	}
+	return out, nil
+}
+
+// Gets the items of every value in order, see SplitList. Values with no
+// value or only separators add nothing.
+func (self *ConfigValue) ValuesAsList() ([]string, error) {
+	out := make([]string, 0, len(self.Entries))
+	for _, v := range self.Entries {
+		items, err := SplitList(v.Value)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, items...)
+	}
+	return out, nil
+}
+
+// Splits a value into items separated by commas and/or white space.
+// An item may be double quoted, C style as git quotes paths, to hold
+// separators or escapes such as \t, \" or octal \303\251; quotes may
+// also be only part of an item, e.g. dir/"a b".
+func SplitList(value string) ([]string, error) {
+	out := make([]string, 0, 4)
+	var item strings.Builder
+	inItem := false
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == ',' || c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inItem {
+				out = append(out, item.String())
+				item.Reset()
+				inItem = false
+			}
+		case c == '"':
+			end, err := unquoteC(value, i, &item)
+			if err != nil {
+				return out, err
+			}
+			i = end
+			inItem = true
+		default:
+			item.WriteByte(c)
+			inItem = true
 		}
 	}
+	if inItem {
+		out = append(out, item.String())
+	}
 	return out, nil
 }
+
+// Writes the C quoted string starting at the quote at value[start] to out,
+// returning the index of the closing quote.
+func unquoteC(value string, start int, out *strings.Builder) (int, error) {
+	for i := start + 1; i < len(value); i++ {
+		c := value[i]
+		if c == '"' {
+			return i, nil
+		}
+		if c != '\\' {
+			out.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(value) {
+			break
+		}
+		switch c = value[i]; c {
+		case '"', '\\':
+			out.WriteByte(c)
+		case 'a':
+			out.WriteByte('\a')
+		case 'b':
+			out.WriteByte('\b')
+		case 'f':
+			out.WriteByte('\f')
+		case 'n':
+			out.WriteByte('\n')
+		case 'r':
+			out.WriteByte('\r')
+		case 't':
+			out.WriteByte('\t')
+		case 'v':
+			out.WriteByte('\v')
+		case '0', '1', '2', '3':
+			if i+2 >= len(value) || !isOctal(value[i+1]) || !isOctal(value[i+2]) {
+				return 0, fmt.Errorf("Bad octal escape in '%s': %w", value, ErrInvalidValue)
+			}
+			out.WriteByte((c-'0')<<6 | (value[i+1]-'0')<<3 | (value[i+2] - '0'))
+			i += 2
+		default:
+			return 0, fmt.Errorf("Unknown escape '\\%c' in '%s': %w", c, value, ErrInvalidValue)
+		}
+	}
+	return 0, fmt.Errorf("Unterminated quote in '%s': %w", value, ErrInvalidValue)
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+// Adds a field name to a Go field path.
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// Gets where the value in effect for the key was set, if anywhere.
+func (self *Config) lastOrigin(key string) ValueOrigin {
+	if cv := self.GetKeyValuesRaw(key); cv != nil && len(cv.Entries) > 0 {
+		return cv.Entries[len(cv.Entries)-1].Origin
+	}
+	return ValueOrigin{}
+}
@@ -5,8 +5,14 @@
 package gitconfig
 
 import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -100,6 +106,7 @@ func TestParseConfig(t *testing.T) {
 		"    another-key = another-value\n" +
 		"[something \"Some\\\"Quote.and random\"]\n" +
 		"    a = b\n" +
+		"[other \"Sub\"] key = sub value\n" + // check key after a subsection
 		"[arrays]\n" +
 		"    key1 = a\n" +
 		"    key1 = b\n" +
@@ -126,8 +133,17 @@ func TestParseConfig(t *testing.T) {
 	testValue(t, config, "something.Somewhere.some-key", "some-value", true)
 	testValue(t, config, "something.somewhere.some-key", "", false)
 	testValue(t, config, "something.Some\"Quote.and random.a", "b", true)
+	testValue(t, config, "other.Sub.key", "sub value", true)
 	testValue(t, config, "arrays.key1", "c", true)
 
+	if _, err := NewConfigFromString("[foo \"bar\"\n"); err == nil {
+		t.Errorf("Expected unterminated section header to fail")
+	}
+	for _, in := range []string{"[foo \"bar\" baz]\n", "[foo \"bar\" ]\n", "[foo \"bar\"\"baz\"]\n"} {
+		if _, err := NewConfigFromString(in); err == nil {
+			t.Errorf("Expected text after the subsection of %q to fail", in)
+		}
+	}
 }
 
 func TestLoadStructs(t *testing.T) {
@@ -441,6 +457,62 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestLoadAs(t *testing.T) {
+	config, err := NewConfigFromString("[user]\n    name = Joe Bloggs\n    favouriteColour = Blue\n")
+	if err != nil {
+		t.Errorf("Failed to parse config: %s", err.Error())
+		return
+	}
+	p, err := LoadAs[Person](config)
+	if err != nil {
+		t.Errorf("Failed to load Person: %s", err.Error())
+		return
+	}
+	if p.Name != "Joe Bloggs" || p.Age != 5 {
+		t.Errorf("Unexpected Person loaded: %+v\n", p)
+	}
+	if _, err := LoadAs[string](config); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expect LoadAs of a non-struct to fail with ErrUnsupportedType but got: %v\n", err)
+	}
+}
+
+func TestLoadSection(t *testing.T) {
+	config, err := NewConfigFromString("[remote \"origin\"]\n\turl = https://host/a\n\tprune = true\n\tmirror = x\n[remote \"fork\"]\n\turl = https://host/b\n[Branch]\n\tautoSetupMerge = always\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	type remote struct {
+		URL    string         `gcKey:"url"`
+		Prune  bool           `gcKey:"prune" gcDefault:"false"`
+		Others ConfigValueSet `gcKey:"*"`
+	}
+	var r remote
+	if err := config.LoadSection("remote.origin", &r); err != nil {
+		t.Fatalf("Failed to load remote.origin: %s", err)
+	}
+	if r.URL != "https://host/a" || !r.Prune || len(r.Others) != 1 || r.Others["mirror"] == nil {
+		t.Errorf("Unexpected remote loaded: %+v", r)
+	}
+	r = remote{}
+	if err := config.LoadSection("remote.fork", &r); err != nil || r.URL != "https://host/b" || r.Prune {
+		t.Errorf("Expect fork loaded with defaults, but got %+v (%v)", r, err)
+	}
+	var branch struct {
+		AutoSetupMerge string `gcKey:"autosetupmerge"`
+	}
+	if err := config.LoadSection("branch", &branch); err != nil || branch.AutoSetupMerge != "always" {
+		t.Errorf("Expect a section loaded, but got %+v (%v)", branch, err)
+	}
+	for _, section := range []string{"remote.Origin", "remote.none", "user", ""} {
+		if err := config.LoadSection(section, &r); !errors.Is(err, ErrSectionNotFound) {
+			t.Errorf("Expect ErrSectionNotFound for '%s', but got %v", section, err)
+		}
+	}
+	if err := config.LoadSection("remote.origin", r); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expect ErrUnsupportedType for a non-pointer, but got %v", err)
+	}
+}
+
 func testValue(t *testing.T, config *Config, key, value string, exists bool) {
 	got, existed := config.GetKeyValueAsString(key)
 	if existed != exists {
@@ -456,3 +528,905 @@ func testValue(t *testing.T, config *Config, key, value string, exists bool) {
 	}
 
 }
+
+type TestNested struct {
+	AllKeys   map[string]map[string]string   `gcKey:"remote.*"`
+	AllMulti  map[string]map[string][]string `gcKey:"remote"`
+	Endpoints map[string][]TestEndpoint      `gcKey:"remote.*"`
+}
+
+type TestEndpoint struct {
+	URL   string `gcKey:"url"`
+	Fetch string `gcKey:"fetch" gcDefault:"<none>"`
+}
+
+func TestLoadNestedMaps(t *testing.T) {
+	configStr := "[remote \"origin\"]\n" +
+		"    url = a\n" +
+		"    fetch = x\n" +
+		"    url = b\n" +
+		"[remote \"fork\"]\n" +
+		"    URL = c\n"
+	config, err := NewConfigFromString(configStr)
+	if err != nil {
+		t.Errorf("Failed to parse config:\n===\n%s\n===\n%s", configStr, err.Error())
+		return
+	}
+	var tn TestNested
+	err = config.Load(&tn)
+	if err != nil {
+		t.Errorf("Failed to test nested maps from:\n===\n%s\n===\n%s", configStr, err.Error())
+		return
+	}
+	checkStringHashVal(t, tn.AllKeys["origin"], "AllKeys (remote.*)", "url", "b", true)
+	checkStringHashVal(t, tn.AllKeys["origin"], "AllKeys (remote.*)", "fetch", "x", true)
+	checkStringHashVal(t, tn.AllKeys["fork"], "AllKeys (remote.*)", "url", "c", true)
+	checkStringHashVal(t, tn.AllKeys["fork"], "AllKeys (remote.*)", "fetch", "", false)
+	checkStringAHashVal(t, tn.AllMulti["origin"], "AllMulti (remote)", "url", []string{"a", "b"}, true)
+
+	origin := tn.Endpoints["origin"]
+	if len(origin) != 2 {
+		t.Errorf("Expected 2 origin endpoints but got %d\n", len(origin))
+		return
+	}
+	if origin[0].URL != "a" || origin[0].Fetch != "x" || origin[1].URL != "b" || origin[1].Fetch != "<none>" {
+		t.Errorf("Unexpected origin endpoints: %+v\n", origin)
+	}
+	if fork := tn.Endpoints["fork"]; len(fork) != 1 || fork[0].URL != "c" {
+		t.Errorf("Unexpected fork endpoints: %+v\n", fork)
+	}
+}
+
+type TestTimes struct {
+	Expiry   time.Time   `gcKey:"account.expiry" gcLayout:"2006-01-02"`
+	Created  time.Time   `gcKey:"account.created"`
+	Reviewed time.Time   `gcKey:"account.reviewed" gcLayout:"2006-01-02" gcDefault:"2019-01-01"`
+	Logins   []time.Time `gcKey:"account.login" gcLayout:"2006-01-02"`
+}
+
+func TestLoadTimes(t *testing.T) {
+	configStr := "[account]\n" +
+		"    expiry = 2020-03-04\n" +
+		"    created = 2018-05-06T07:08:09Z\n" +
+		"    login = 2019-02-01\n" +
+		"    login = 2019-02-02\n"
+	config, err := NewConfigFromString(configStr)
+	if err != nil {
+		t.Errorf("Failed to parse config:\n===\n%s\n===\n%s", configStr, err.Error())
+		return
+	}
+	var tt TestTimes
+	err = config.Load(&tt)
+	if err != nil {
+		t.Errorf("Failed to load times from:\n===\n%s\n===\n%s", configStr, err.Error())
+		return
+	}
+	if expect := time.Date(2020, 3, 4, 0, 0, 0, 0, time.UTC); !tt.Expiry.Equal(expect) {
+		t.Errorf("Expect Expiry '%s' but got '%s'\n", expect, tt.Expiry)
+	}
+	if expect := time.Date(2018, 5, 6, 7, 8, 9, 0, time.UTC); !tt.Created.Equal(expect) {
+		t.Errorf("Expect Created '%s' but got '%s'\n", expect, tt.Created)
+	}
+	if expect := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC); !tt.Reviewed.Equal(expect) {
+		t.Errorf("Expect Reviewed default '%s' but got '%s'\n", expect, tt.Reviewed)
+	}
+	if len(tt.Logins) != 2 || tt.Logins[1].Day() != 2 {
+		t.Errorf("Expect two Logins ending on the 2nd but got %v\n", tt.Logins)
+	}
+
+	config, _ = NewConfigFromString("[account]\n    expiry = next tuesday\n")
+	if err = config.Load(&tt); err == nil {
+		t.Errorf("Expect error on unparseable expiry, but no error given\n")
+	}
+}
+
+type TestNetwork struct {
+	Match   *regexp.Regexp `gcKey:"service.match"`
+	Listen  netip.Addr     `gcKey:"service.listen" gcDefault:"127.0.0.1"`
+	Allowed []netip.Prefix `gcKey:"service.allow"`
+}
+
+func TestLoadNetworkTypes(t *testing.T) {
+	configStr := "[service]\n" +
+		"    match = ^feature/.*$\n" +
+		"    allow = 10.0.0.0/8\n" +
+		"    allow = fd00::/8\n"
+	config, err := NewConfigFromString(configStr)
+	if err != nil {
+		t.Errorf("Failed to parse config:\n===\n%s\n===\n%s", configStr, err.Error())
+		return
+	}
+	var tn TestNetwork
+	err = config.Load(&tn)
+	if err != nil {
+		t.Errorf("Failed to load network types from:\n===\n%s\n===\n%s", configStr, err.Error())
+		return
+	}
+	if tn.Match == nil || !tn.Match.MatchString("feature/x") || tn.Match.MatchString("main") {
+		t.Errorf("Expect Match to compile '^feature/.*$' but got %v\n", tn.Match)
+	}
+	if tn.Listen.String() != "127.0.0.1" {
+		t.Errorf("Expect Listen default '127.0.0.1' but got '%s'\n", tn.Listen)
+	}
+	if len(tn.Allowed) != 2 || tn.Allowed[0].String() != "10.0.0.0/8" || tn.Allowed[1].String() != "fd00::/8" {
+		t.Errorf("Unexpected Allowed prefixes: %v\n", tn.Allowed)
+	}
+
+	config, _ = NewConfigFromString("[service]\n    match = (unclosed\n    listen = 300.1.1.1\n")
+	err = config.Load(&tn)
+	loadErr, ok := err.(LoadError)
+	if !ok {
+		t.Errorf("Expect gitconfig.LoadError on return, but got %T\n", err)
+		return
+	}
+	if loadErr["service.match"] == nil || loadErr["service.listen"] == nil {
+		t.Errorf("Expect errors on both service.match and service.listen but got: %s\n", err.Error())
+	}
+}
+
+type TestRawRemote struct {
+	URL    string         `gcKey:"url"`
+	Fetch  *ConfigValue   `gcKey:"fetch"`
+	Others ConfigValueSet `gcKey:"*"`
+}
+
+type TestRaw struct {
+	Remotes map[string]TestRawRemote `gcKey:"remote.*"`
+	Core    ConfigValueSet           `gcKey:"core"`
+	Missing *ConfigValue             `gcKey:"core.missing"`
+}
+
+func TestLoadRaw(t *testing.T) {
+	configStr := "[core]\n" +
+		"    Bare = false\n" +
+		"[remote \"origin\"]\n" +
+		"    url = a\n" +
+		"    fetch = x\n" +
+		"    Fetch = y\n" +
+		"    pushURL = b\n" +
+		"    mirror\n"
+	config, err := NewConfigFromString(configStr)
+	if err != nil {
+		t.Errorf("Failed to parse config:\n===\n%s\n===\n%s", configStr, err.Error())
+		return
+	}
+	var tr TestRaw
+	err = config.Load(&tr)
+	if err != nil {
+		t.Errorf("Failed to load raw values from:\n===\n%s\n===\n%s", configStr, err.Error())
+		return
+	}
+	if tr.Missing != nil {
+		t.Errorf("Expect Missing to stay nil but got %v\n", tr.Missing)
+	}
+	if bare := tr.Core["bare"]; bare == nil || bare.OrigCaseName != "Bare" {
+		t.Errorf("Expect Core to contain 'Bare' but got %v\n", tr.Core)
+	}
+	origin := tr.Remotes["origin"]
+	if origin.Fetch == nil || strings.Join(origin.Fetch.ValuesAsStrings(), ",") != "x,y" {
+		t.Errorf("Expect origin Fetch to have values x,y but got %v\n", origin.Fetch)
+	}
+	if _, ok := origin.Others["url"]; ok {
+		t.Errorf("Expect origin Others to not contain claimed key 'url'\n")
+	}
+	if len(origin.Others) != 2 || origin.Others["pushurl"] == nil || origin.Others["mirror"] == nil {
+		t.Errorf("Expect origin Others to contain pushurl and mirror but got %v\n", origin.Others)
+	}
+	origin.Fetch.Entries[0].Value = "changed"
+	if got, _ := config.GetKeyValueAsString("remote.origin.url"); got != "a" {
+		t.Errorf("Expect loaded raw values to be copies\n")
+	}
+	if got := config.GetKeyValuesStrings("remote.origin.fetch"); got[0] != "x" {
+		t.Errorf("Expect loaded raw values to be copies, but original now '%s'\n", got[0])
+	}
+}
+
+type TestRequiredSections struct {
+	Department struct {
+		Name string `gcKey:"name"`
+	} `gcKey:"department" gcRequired:"true"`
+	Remotes map[string]TestEndpoint `gcKey:"remote.*" gcMinEntries:"1"`
+}
+
+func TestLoadRequiredSections(t *testing.T) {
+	configStr := "[department]\n" +
+		"    name = Somewhere\n" +
+		"[remote \"origin\"]\n" +
+		"    url = a\n"
+	config, err := NewConfigFromString(configStr)
+	if err != nil {
+		t.Errorf("Failed to parse config:\n===\n%s\n===\n%s", configStr, err.Error())
+		return
+	}
+	var tr TestRequiredSections
+	if err = config.Load(&tr); err != nil {
+		t.Errorf("Failed to load required sections from:\n===\n%s\n===\n%s", configStr, err.Error())
+		return
+	}
+	if tr.Department.Name != "Somewhere" || tr.Remotes["origin"].URL != "a" {
+		t.Errorf("Unexpected load result: %+v\n", tr)
+	}
+
+	config, _ = NewConfigFromString("[remote]\n    url = a\n")
+	err = config.Load(&tr)
+	loadErr, ok := err.(LoadError)
+	if !ok {
+		t.Errorf("Expect gitconfig.LoadError on return, but got %T\n", err)
+		return
+	}
+	if loadErr["department"] == nil {
+		t.Errorf("Expect error on missing required department section but got: %s\n", err.Error())
+	}
+	if loadErr["remote.*"] == nil || !strings.Contains(loadErr["remote.*"].Error(), "At least 1 entries") {
+		t.Errorf("Expect error on too few remotes but got: %s\n", err.Error())
+	}
+}
+
+func TestLoadErrorsIs(t *testing.T) {
+	configStr := "[user]\n" +
+		"    age = old\n"
+	config, err := NewConfigFromString(configStr)
+	if err != nil {
+		t.Errorf("Failed to parse config:\n===\n%s\n===\n%s", configStr, err.Error())
+		return
+	}
+	var p Person
+	err = config.Load(&p)
+	if !errors.Is(err, ErrRequiredMissing) {
+		t.Errorf("Expect missing favourite colour to match ErrRequiredMissing, but got: %s\n", err)
+	}
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expect unparseable age to match ErrTypeMismatch, but got: %s\n", err)
+	}
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) {
+		t.Errorf("Expect unparseable age to wrap a *strconv.NumError, but got: %s\n", err)
+	}
+	if errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("Expect no ErrSectionNotFound, but got: %s\n", err)
+	}
+	if err = config.Load(p); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expect loading a non-pointer to match ErrUnsupportedType, but got: %s\n", err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	configStr := "[core]\n" +
+		"    depth = 3\n" +
+		"    name = x\n"
+	config, err := NewConfigFromString(configStr)
+	if err != nil {
+		t.Errorf("Failed to parse config:\n===\n%s\n===\n%s", configStr, err.Error())
+		return
+	}
+	if s, err := config.LookupString("core.name"); err != nil || s != "x" {
+		t.Errorf("Expect core.name = 'x' but got '%s' (error: %v)\n", s, err)
+	}
+	if i, err := config.LookupInt("core.depth"); err != nil || i != 3 {
+		t.Errorf("Expect core.depth = 3 but got %d (error: %v)\n", i, err)
+	}
+	if _, err := config.LookupString("core.missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expect ErrKeyNotFound for core.missing but got: %v\n", err)
+	} else if !strings.Contains(err.Error(), "core.missing") {
+		t.Errorf("Expect error to name the key but got: %s\n", err)
+	}
+	if _, err := config.LookupInt("core.name"); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expect ErrTypeMismatch for core.name as int but got: %v\n", err)
+	}
+}
+
+func TestPluralGetters(t *testing.T) {
+	config, err := NewConfigFromString("[t]\n\tn = 1\n\tn = 20\n\tf = 1.5\n\tf = -2\n\td = 90s\n\td = 1h\n\tbad = x\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	if got, err := config.GetKeyValuesUints("t.n"); err != nil || !reflect.DeepEqual(got, []uint64{1, 20}) {
+		t.Errorf("Expect uints [1 20], but got %v (%v)", got, err)
+	}
+	if got, err := config.GetKeyValuesFloats("t.f"); err != nil || !reflect.DeepEqual(got, []float64{1.5, -2}) {
+		t.Errorf("Expect floats [1.5 -2], but got %v (%v)", got, err)
+	}
+	if got, err := config.GetKeyValuesDurations("t.d"); err != nil || !reflect.DeepEqual(got, []time.Duration{90 * time.Second, time.Hour}) {
+		t.Errorf("Expect durations [1m30s 1h0m0s], but got %v (%v)", got, err)
+	}
+	if got, ok, err := config.GetKeyValueAsUint("t.n"); !ok || err != nil || got != 20 {
+		t.Errorf("Expect last uint 20, but got %v (%v)", got, err)
+	}
+	if got, ok, err := config.GetKeyValueAsFloat("t.f"); !ok || err != nil || got != -2 {
+		t.Errorf("Expect last float -2, but got %v (%v)", got, err)
+	}
+	if got, ok, err := config.GetKeyValueAsDuration("t.d"); !ok || err != nil || got != time.Hour {
+		t.Errorf("Expect last duration 1h, but got %v (%v)", got, err)
+	}
+	if got, err := config.GetKeyValuesFloats("t.missing"); got != nil || err != nil {
+		t.Errorf("Expect nil for a missing key, but got %v (%v)", got, err)
+	}
+	if _, err := config.GetKeyValuesUints("t.f"); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expect type mismatch for float as uint, but got %v", err)
+	}
+	if _, _, err := config.GetKeyValueAsDuration("t.bad"); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expect type mismatch for bad duration, but got %v", err)
+	}
+}
+
+func TestValueEntries(t *testing.T) {
+	config, err := NewConfigFromString("[core]\n\tbare\n\tempty =\n\tflag = true\n\tnum = 0\n\tlong = a\\\n b\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err.Error())
+	}
+	bare := config.GetKeyValuesRaw("core.bare")
+	if len(bare.Entries) != 1 || bare.Entries[0].HasValue {
+		t.Errorf("Expect core.bare to have a single entry without a value but got %v\n", bare.Entries)
+	}
+	if ptrs := bare.ValuePointers(); len(ptrs) != 1 || ptrs[0] != nil {
+		t.Errorf("Expect core.bare value pointers to be [nil] but got %v\n", ptrs)
+	}
+	// the old Value field follows the entries
+	if len(bare.Value) != 1 || bare.Value[0] != nil {
+		t.Errorf("Expect core.bare Value to be [nil] but got %v\n", bare.Value)
+	}
+	config.Set("core.bare", "false")
+	config.AddKeyValue("core", "", "bare", nil)
+	if v := config.GetKeyValuesRaw("core.bare").Value; len(v) != 2 || v[0] == nil || *v[0] != "false" || v[1] != nil {
+		t.Errorf("Expect core.bare Value to be [false nil] after changes but got %v\n", v)
+	}
+	empty := config.GetKeyValuesRaw("core.empty")
+	if len(empty.Entries) != 1 || !empty.Entries[0].HasValue || empty.Entries[0].Value != "" {
+		t.Errorf("Expect core.empty to have a single empty value but got %v\n", empty.Entries)
+	}
+	if long := config.GetKeyValuesRaw("core.long"); long.Entries[0].Origin.Line != 6 {
+		t.Errorf("Expect core.long origin to be the line the key starts on (6) but got %d\n", long.Entries[0].Origin.Line)
+	}
+	for key, expect := range map[string]bool{"core.bare": true, "core.empty": false, "core.flag": true, "core.num": false} {
+		b, ok, err := config.GetKeyValueAsBool(key)
+		if err != nil || !ok || b != expect {
+			t.Errorf("Expect %s to be %v but got %v (exists: %v, err: %v)\n", key, expect, b, ok, err)
+		}
+	}
+	if out := config.String(); !strings.Contains(out, "\tbare\n") || !strings.Contains(out, "\tempty = \n") {
+		t.Errorf("Expect valueless and empty keys to be written differently but got:\n%s", out)
+	}
+}
+
+func TestStats(t *testing.T) {
+	config, err := NewConfigFromString("top = level\n[core]\n\tbare\n\tname = a\n[remote \"a\"]\n\tfetch = x\n\tfetch = y\n[remote \"b\"]\n\turl = z\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	stats := config.Stats()
+	if stats.Sections != 2 || stats.SubSections != 2 || stats.Keys != 5 || stats.Values != 6 {
+		t.Errorf("Expect 2 sections, 2 sub-sections, 5 keys and 6 values but got %+v\n", stats)
+	}
+	config.Set("core.name", strings.Repeat("x", 1000))
+	if bigger := config.Stats(); bigger.Bytes < stats.Bytes+999 {
+		t.Errorf("Expect footprint to grow with value size, was %d now %d\n", stats.Bytes, bigger.Bytes)
+	}
+}
+
+func TestRedactedString(t *testing.T) {
+	config, err := NewConfigFromString("[user]\n\tname = Joe\n\tpassword = hunter2 # was s3cret\n[http \"https://example.com\"]\n\textraHeader = Authorization: token\n\tproxy = p\n[credential]\n\thelper\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	out := config.RedactedString("*.password", "http.*.extraheader", "credential.helper")
+	for _, secret := range []string{"hunter2", "token", "s3cret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("Expect '%s' to be redacted but got:\n%s", secret, out)
+		}
+	}
+	secret := newKeyPatterns([]string{"*.password"}).match
+	if text := redactText("[user]\n\tpassword = hunter2 ; was s3cret\n", "", secret); text != "[user]\n\tpassword = "+RedactedValue+"\n" {
+		t.Errorf("Expect the value and comment redacted from text, but got:\n%s", text)
+	}
+	for _, expect := range []string{"name = Joe", "proxy = p", "password = " + RedactedValue, "extraHeader = " + RedactedValue, "\thelper\n"} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("Expect output to contain '%s' but got:\n%s", expect, out)
+		}
+	}
+	testValue(t, config, "user.password", "hunter2", true)
+}
+
+type TestSecrets struct {
+	User    string                  `gcKey:"user.name"`
+	Token   int                     `gcKey:"user.token" gcSecret:"true"`
+	Remotes map[string]SecretRemote `gcKey:"remote.*"`
+	Creds   ConfigValueSet          `gcKey:"credential" gcSecret:"true"`
+}
+
+type SecretRemote struct {
+	URL      string `gcKey:"url"`
+	Password string `gcKey:"password" gcSecret:"true"`
+}
+
+func TestSecretFields(t *testing.T) {
+	config, err := NewConfigFromString("[user]\n\tname = Joe\n\ttoken = s3cr3t\n[remote \"origin\"]\n\turl = a\n\tpassword = hunter2\n[credential]\n\thelper = store\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	var ts TestSecrets
+	err = config.Load(&ts)
+	if err == nil {
+		t.Fatalf("Expect non-integer token to fail to load")
+	}
+	if strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("Expect secret value to be withheld from error but got: %s", err)
+	}
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expect withheld error to still wrap ErrTypeMismatch but got: %s", err)
+	}
+	keys, err := SecretKeys(&ts)
+	if err != nil || strings.Join(keys, ",") != "user.token,remote.*.password,credential.*" {
+		t.Errorf("Expect secret keys user.token,remote.*.password,credential.* but got %v (err: %v)", keys, err)
+	}
+	out, err := config.RedactedStringFor(ts)
+	if err != nil {
+		t.Fatalf("Failed to redact: %s", err)
+	}
+	for _, secret := range []string{"s3cr3t", "hunter2", "store"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("Expect '%s' to be redacted but got:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "name = Joe") {
+		t.Errorf("Expect non-secret values to be kept but got:\n%s", out)
+	}
+	// a '*' sub-section also matches base sections and values
+	config, _ = NewConfigFromString("[remote]\n\tpassword = hunter2\n")
+	config.BaseValues["pw"] = &ConfigValue{Name: "pw", Entries: []ValueEntry{{Value: "s3cr3t", HasValue: true}}}
+	if out = config.RedactedString("*.*"); strings.Contains(out, "hunter2") || strings.Contains(out, "s3cr3t") {
+		t.Errorf("Expect everything redacted but got:\n%s", out)
+	}
+	if out = config.RedactedString("remote.*.password"); !strings.Contains(out, "s3cr3t") || strings.Contains(out, "hunter2") {
+		t.Errorf("Expect only remote.password redacted but got:\n%s", out)
+	}
+}
+
+func TestValueAtRemoveReplace(t *testing.T) {
+	config, err := NewConfigFromString("[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n\tfetch\n\tfetch = d\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	cv := config.GetKeyValuesRaw("remote.origin.fetch")
+	if e, ok := cv.At(1); !ok || e.Value != "b" || e.Origin.Line != 3 {
+		t.Errorf("Expect value 1 to be b from line 3, but got %+v", e)
+	}
+	if e, ok := cv.At(2); !ok || e.HasValue {
+		t.Errorf("Expect value 2 to have no value, but got %+v", e)
+	}
+	if _, ok := cv.At(4); ok {
+		t.Errorf("Expect no value 4")
+	}
+	if err := cv.Remove(2); err != nil {
+		t.Fatalf("Failed to remove value: %s", err)
+	}
+	if err := cv.ReplaceAt(0, "z"); err != nil {
+		t.Fatalf("Failed to replace value: %s", err)
+	}
+	if got := strings.Join(cv.ValuesAsStrings(), ","); got != "z,b,d" {
+		t.Errorf("Expect values z,b,d but got %s", got)
+	}
+	if e, _ := cv.At(0); e.Origin != (ValueOrigin{}) {
+		t.Errorf("Expect replaced value to have no origin, but got %+v", e.Origin)
+	}
+	for _, err := range []error{cv.Remove(3), cv.Remove(-1), cv.ReplaceAt(3, "x")} {
+		if !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("Expect out of range error, but got: %v", err)
+		}
+	}
+}
+
+func TestInsertKeyValue(t *testing.T) {
+	config, err := NewConfigFromString("[remote \"origin\"]\n\tfetch = b\n\tfetch = d\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	var changes [][]string
+	config.OnChange("remote.origin.fetch", func(key string, old, new []string) {
+		changes = append(changes, new)
+	})
+	if err := config.PrependKeyValue("remote.origin.fetch", "a"); err != nil {
+		t.Errorf("Failed to prepend: %s", err)
+	}
+	if err := config.InsertKeyValue("remote.origin.fetch", "c", 2); err != nil {
+		t.Errorf("Failed to insert: %s", err)
+	}
+	if err := config.InsertKeyValue("remote.origin.fetch", "e", 4); err != nil {
+		t.Errorf("Failed to insert at end: %s", err)
+	}
+	if got := strings.Join(config.GetKeyValuesStrings("remote.origin.fetch"), ","); got != "a,b,c,d,e" {
+		t.Errorf("Expect fetch values a,b,c,d,e but got %s", got)
+	}
+	if len(changes) != 3 || strings.Join(changes[0], ",") != "a,b,d" {
+		t.Errorf("Expect a change notification per insert but got %v", changes)
+	}
+	for _, pos := range []int{-1, 6} {
+		if err := config.InsertKeyValue("remote.origin.fetch", "x", pos); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("Expect ErrOutOfRange inserting at %d but got %v", pos, err)
+		}
+	}
+	if err := config.PrependKeyValue("remote.other.fetch", "x"); err != nil {
+		t.Errorf("Failed to prepend to new key: %s", err)
+	}
+	testValue(t, config, "remote.other.fetch", "x", true)
+}
+
+func TestFoldSubSections(t *testing.T) {
+	config, err := NewConfigFromString("[Remote.Origin]\n\turl = a\n[branch \"Main\"]\n\tremote = origin\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	// dotted syntax gives a lower case sub-section
+	testValue(t, config, "remote.origin.url", "a", true)
+	testValue(t, config, "remote.Origin.url", "", false)
+	testValue(t, config, "branch.main.remote", "", false)
+
+	config.FoldSubSections = true
+	testValue(t, config, "remote.Origin.url", "a", true)
+	testValue(t, config, "branch.main.remote", "origin", true)
+	if got, ok := config.GetStringForKey(MustParseKey("branch.MAIN.remote")); !ok || got != "origin" {
+		t.Errorf("Expect Key lookup to fold sub-sections but got '%s' (exists: %v)", got, ok)
+	}
+	config.Set("branch.MAIN.merge", "refs/heads/main")
+	if names := len(config.GetSection("branch", false).SubSections); names != 1 {
+		t.Errorf("Expect Set to reuse the folded sub-section but there are %d", names)
+	}
+}
+
+func TestSectionNames(t *testing.T) {
+	config, err := NewConfigFromString("[remote \"b\"]\n\turl = 1\n[Core]\n[remote \"a\"]\n\turl = 2\n[branch \"main\"]\n[remote \"b\"]\n\tfetch = x\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	if got := strings.Join(config.SectionNames(), ","); got != "remote,core,branch" {
+		t.Errorf("Expect sections remote,core,branch but got %s", got)
+	}
+	if got := strings.Join(config.SubSectionNames("REMOTE"), ","); got != "b,a" {
+		t.Errorf("Expect remote sub-sections b,a but got %s", got)
+	}
+	if config.SubSectionNames("nope") != nil {
+		t.Errorf("Expect no sub-sections for missing section")
+	}
+	if !config.HasSection("core") || !config.HasSection("CORE") || config.HasSection("user") {
+		t.Errorf("Expect HasSection to find (empty) core only")
+	}
+	if !config.HasSubSection("branch", "main") || config.HasSubSection("branch", "Main") {
+		t.Errorf("Expect HasSubSection to find branch.main only")
+	}
+	if out := config.String(); strings.Index(out, "[remote \"b\"]") > strings.Index(out, "[remote \"a\"]") {
+		t.Errorf("Expect String to write sections in file order but got:\n%s", out)
+	}
+}
+
+func TestOrigCaseNames(t *testing.T) {
+	config, err := NewConfigFromString("[Remote \"Origin\"]\n\tpushURL = 1\n[core]\n\tfileMode = false\n[REMOTE \"Origin\"]\n\tPUSHURL = 2\n\turl = 3\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	if got := strings.Join(config.OrigCaseSectionNames(), ","); got != "Remote,core" {
+		t.Errorf("Expect sections as first written Remote,core but got %s", got)
+	}
+	if got := strings.Join(config.KeyNames("remote", "Origin"), ","); got != "pushurl,url" {
+		t.Errorf("Expect keys pushurl,url but got %s", got)
+	}
+	if got := strings.Join(config.OrigCaseKeyNames("REMOTE", "Origin"), ","); got != "pushURL,url" {
+		t.Errorf("Expect keys as first written pushURL,url but got %s", got)
+	}
+	if config.KeyNames("remote", "origin") != nil || config.OrigCaseKeyNames("nope", "") != nil {
+		t.Errorf("Expect no keys for missing sections")
+	}
+	for _, key := range []string{"remote.Origin.pushurl", "Remote.Origin.pushURL", "REMOTE.Origin.PUSHURL"} {
+		if got, ok := config.OrigCaseKey(key); !ok || got != "Remote.Origin.pushURL" {
+			t.Errorf("Expect %s as first written Remote.Origin.pushURL, but got '%s'", key, got)
+		}
+	}
+	if _, ok := config.OrigCaseKey("remote.origin.pushurl"); ok {
+		t.Errorf("Expect sub-section names to be case sensitive")
+	}
+	config.FoldSubSections = true
+	if got, _ := config.OrigCaseKey("remote.origin.pushurl"); got != "Remote.Origin.pushURL" {
+		t.Errorf("Expect folded sub-section to give its real name, but got '%s'", got)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	config, err := NewConfigFromString("[core]\n\tbare\n\tname = a\n[empty]\n[remote \"gone\"]\n\turl = x\n[remote \"kept\"]\n\turl = y\n\tmirror\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	config.Unset("remote.gone.url")
+	config.GetConfigValues("core", "", "novalues", true)
+	if removed := config.Prune(false); removed != 3 {
+		t.Errorf("Expect key core.novalues, sub-section remote.gone and section empty to be pruned but %d things were", removed)
+	}
+	if config.HasSection("empty") || config.HasSubSection("remote", "gone") || config.GetKeyValuesRaw("core.novalues") != nil {
+		t.Errorf("Expect empty things to be pruned but got:\n%s", config)
+	}
+	testValue(t, config, "core.bare", "", true)
+
+	var changed []string
+	config.OnChange("*", func(key string, old, new []string) {
+		changed = append(changed, key)
+	})
+	if removed := config.Prune(true); removed != 2 {
+		t.Errorf("Expect keys core.bare and remote.kept.mirror to be pruned but %d things were", removed)
+	}
+	testValue(t, config, "core.bare", "", false)
+	testValue(t, config, "remote.kept.mirror", "", false)
+	testValue(t, config, "remote.kept.url", "y", true)
+	if strings.Join(changed, ",") != "core.bare,remote.kept.mirror" {
+		t.Errorf("Expect changes to core.bare and remote.kept.mirror but got %v", changed)
+	}
+}
+
+func TestGetKeyValuesList(t *testing.T) {
+	config, _ := NewConfigFromString("[transfer]\n\thideRefs = refs/pull\n\thideRefs = refs/a, refs/b  refs/c\n\thideRefs\n\thideRefs = \\\"dir/with space\\\",x\\\"y z\\\"\n")
+	got, err := config.GetKeyValuesList("transfer.hiderefs")
+	expect := []string{"refs/pull", "refs/a", "refs/b", "refs/c", "dir/with space", "xy z"}
+	if err != nil || !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expect list %q, but got %q (%v)", expect, got, err)
+	}
+	if got, err := config.GetKeyValuesList("transfer.none"); got != nil || err != nil {
+		t.Errorf("Expect missing key to give nil, but got %q (%v)", got, err)
+	}
+	tests := map[string][]string{
+		"":                       {},
+		" , ,":                   {},
+		`"" b`:                   {"", "b"},
+		`"tab\there" "\303\251"`: {"tab\there", "é"},
+		`"q\"uote\\"`:            {"q\"uote\\"},
+	}
+	for in, expect := range tests {
+		if got, err := SplitList(in); err != nil || !reflect.DeepEqual(got, expect) {
+			t.Errorf("Expect '%s' to split to %q, but got %q (%v)", in, expect, got, err)
+		}
+	}
+	for _, in := range []string{`"open`, `"bad \x"`, `"\39"`} {
+		if _, err := SplitList(in); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Expect splitting '%s' to fail, but got: %v", in, err)
+		}
+	}
+}
+
+func TestIntBase(t *testing.T) {
+	config, _ := NewConfigFromString("[perm]\n\tmode = 0644\n\tmask = 0x1F\n\tsize = 10\n\tbits = 0o17\n\tbits = 0b101\n")
+	type Perm struct {
+		Mode    uint32  `gcKey:"mode" gcIntBase:"0"`
+		Decimal int     `gcKey:"mode"`
+		Mask    int     `gcKey:"mask" gcIntBase:"0"`
+		Size    int     `gcKey:"size" gcIntBase:"0"`
+		Bits    []int   `gcKey:"bits" gcIntBase:"0"`
+		Umask   *uint16 `gcKey:"umask" gcIntBase:"8" gcDefault:"022"`
+		Hex     int     `gcKey:"missing" gcIntBase:"16" gcDefault:"ff"`
+	}
+	var got struct {
+		Perm Perm `gcKey:"perm"`
+	}
+	if err := config.Load(&got); err != nil {
+		t.Fatalf("Failed to load: %s", err)
+	}
+	umask := uint16(0o22)
+	expect := Perm{Mode: 0o644, Decimal: 644, Mask: 0x1f, Size: 10, Bits: []int{0o17, 5}, Umask: &umask, Hex: 255}
+	if !reflect.DeepEqual(got.Perm, expect) {
+		t.Errorf("Expect %+v, but got %+v", expect, got.Perm)
+	}
+	if v, ok, err := config.GetKeyValueAsIntBase("perm.mode", 0); v != 0o644 || !ok || err != nil {
+		t.Errorf("Expect perm.mode to be 0644, but got %o (%v)", v, err)
+	}
+	if v, ok, err := config.GetKeyValueAsUintBase("perm.mask", 16); v != 0 || ok || !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expect 0x prefix in base 16 to fail, but got %d (%v)", v, err)
+	}
+	for _, base := range []string{"1", "37", "-2", "hex"} {
+		tag := reflect.StructTag(`gcKey:"perm.mode" gcIntBase:"` + base + `"`)
+		tp := reflect.StructOf([]reflect.StructField{{Name: "Mode", Type: reflect.TypeOf(0), Tag: tag}})
+		if err := config.Load(reflect.New(tp).Interface()); !errors.Is(err, ErrInvalidTag) || !strings.Contains(err.Error(), `gcIntBase:"`+base) {
+			t.Errorf("Expect gcIntBase:\"%s\" to fail naming the tag, but got: %v", base, err)
+		}
+	}
+}
+
+func TestRequiredError(t *testing.T) {
+	type Remote struct {
+		URL  string `gcKey:"url" gcRequired:"true"`
+		Push string `gcKey:"pushurl"`
+	}
+	type Required struct {
+		Name    string            `gcKey:"user.name" gcRequired:"true"`
+		Email   string            `gcKey:"user.email" gcRequired:"true"`
+		Age     int               `gcKey:"user.age"`
+		Token   string            `gcKey:"credential.token" gcRequired:"true" gcSecret:"true"`
+		Remotes map[string]Remote `gcKey:"remote.*"`
+		Dept    struct {
+			Name string `gcKey:"name"`
+		} `gcKey:"department" gcRequired:"true"`
+	}
+	config, _ := NewConfigFromString("[user]\n\tage = old\n[remote \"origin\"]\n\tpushurl = x\n")
+	err := config.Load(&Required{})
+	var reqErr *RequiredError
+	if !errors.As(err, &reqErr) {
+		t.Fatalf("Expect a RequiredError, but got: %v", err)
+	}
+	expect := []MissingKey{
+		{Key: "credential.token", Section: "credential", Name: "token", Type: "string"},
+		{Key: "department", Section: "department", Type: "struct { Name string \"gcKey:\\\"name\\\"\" }"},
+		{Key: "remote.origin.url", Section: "remote", SubSection: "origin", Name: "url", Type: "string"},
+		{Key: "user.email", Section: "user", Name: "email", Type: "string"},
+		{Key: "user.name", Section: "user", Name: "name", Type: "string"},
+	}
+	if !reflect.DeepEqual(reqErr.Missing, expect) {
+		t.Errorf("Expect missing keys %+v, but got %+v", expect, reqErr.Missing)
+	}
+	msg := "The following 5 required settings are missing:\n" +
+		"  [credential]\n    token (string)\n" +
+		"  [department] (the whole section, for struct { Name string \"gcKey:\\\"name\\\"\" })\n" +
+		"  [remote \"origin\"]\n    url (string)\n" +
+		"  [user]\n    email (string)\n    name (string)\n"
+	if reqErr.Error() != msg {
+		t.Errorf("Expect message:\n%s\nbut got:\n%s", msg, reqErr.Error())
+	}
+	if !errors.Is(err, ErrTypeMismatch) || !errors.Is(err, ErrRequiredMissing) {
+		t.Errorf("Expect other errors to be kept, but got: %v", err)
+	}
+
+	config, _ = NewConfigFromString("[user]\n\tname = Joe\n\temail = joe@example.com\n[credential]\n\ttoken = x\n[department]\n\tname = Y\n")
+	if err := config.Load(&Required{}); err != nil {
+		t.Errorf("Expect nothing missing, but got: %v", err)
+	}
+	if (LoadError{"a": ErrTypeMismatch}).Required() != nil {
+		t.Errorf("Expect no RequiredError without missing keys")
+	}
+}
+
+func TestLoadErrorByField(t *testing.T) {
+	type Remote struct {
+		URL   string `gcKey:"url" gcRequired:"true"`
+		Prune bool   `gcKey:"prune"`
+	}
+	type Server struct {
+		Port int `gcKey:"port"`
+	}
+	type Settings struct {
+		Age     int                 `gcKey:"user.age"`
+		Server  Server              `gcKey:"server"`
+		Remotes map[string]Remote   `gcKey:"remote.*"`
+		Mirrors map[string][]Remote `gcKey:"mirror.*"`
+	}
+	config, _ := NewConfigFromString("[user]\n\tage = old\n[server]\n\tport = http\n[remote \"origin\"]\n\tprune = true\n[mirror \"eu\"]\n\turl = a\n\tprune = maybe\n")
+	err := config.Load(&Settings{})
+	var loadErr LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("Expect a LoadError, but got: %v", err)
+	}
+	byField := loadErr.ByField()
+	expect := map[string]string{
+		"Age":                  "user.age",
+		"Server.Port":          "server.port",
+		"Remotes[origin].URL":  "remote.origin.url",
+		"Mirrors[eu][0].Prune": "mirror.eu.prune",
+	}
+	if len(byField) != len(expect) {
+		t.Errorf("Expect errors for %v, but got %v", expect, byField)
+	}
+	for path, key := range expect {
+		var fe *FieldError
+		if !errors.As(byField[path], &fe) || fe.Key != key || fe.FieldPath != path {
+			t.Errorf("Expect error for field %s with key %s, but got: %+v", path, key, byField[path])
+		}
+	}
+	if fe, ok := loadErr["user.age"].(*FieldError); !ok || fe.FieldPath != "Age" || !errors.Is(fe, ErrTypeMismatch) {
+		t.Errorf("Expect user.age error to be a FieldError for Age, but got: %+v", loadErr["user.age"])
+	}
+	// the errors held before FieldError are still reached through it
+	var nested LoadError
+	if !errors.As(loadErr["server"], &nested) || !errors.Is(nested["server.port"], ErrTypeMismatch) {
+		t.Errorf("Expect the server error to hold the nested LoadError, but got: %+v", loadErr["server"])
+	}
+}
+
+func TestEscapeValueString(t *testing.T) {
+	if got := EscapeValueString("a\tb\nc\\d\"e"); got != "a\\tb\\nc\\\\d\\\"e" {
+		t.Errorf("Expect tab and newline written as \\t and \\n, but got %q", got)
+	}
+	for _, value := range []string{"tab\there", "multi\nline", "C:\\dir\\file", "say \"hi\"", "mixed\t\\n\n"} {
+		config, err := NewConfigFromString("[a]\n\tk = " + EscapeValueString(value) + "\n")
+		if err != nil {
+			t.Errorf("Failed to parse escaped %q: %s", value, err)
+			continue
+		}
+		testValue(t, config, "a.k", value, true)
+	}
+}
+
+func TestValueComments(t *testing.T) {
+	config, err := NewConfigFromString("[user]\n\tsigningKey = ABC123  # yubikey\n\tname = \"Joe ; Bloggs\" ; full name\n\temail = a \\\n\t\tb;tight\n\tbare\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	for key, expect := range map[string]string{"user.signingkey": "yubikey", "user.name": "full name", "user.email": "tight", "user.bare": ""} {
+		if got := config.GetKeyValuesRaw(key).Entries[0].Comment; got != expect {
+			t.Errorf("Expect comment '%s' on %s, but got '%s'", expect, key, got)
+		}
+	}
+	testValue(t, config, "user.name", "Joe ; Bloggs", true)
+
+	if err := config.Set("user.signingkey", "ABC123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetValueComment("user.email", "work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetValueComment("user.signingkey", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetValueComment("user.signingkey", "yubikey"); err != nil {
+		t.Fatal(err)
+	}
+	expect := "[user]\n\tbare\n\temail = a \\t\\tb # work\n\tname = \"Joe ; Bloggs\" ; full name\n\tsigningKey = ABC123 # yubikey\n"
+	if config.String() != expect {
+		t.Errorf("Expect config written as:\n%s\nbut got:\n%s", expect, config.String())
+	}
+	reread, err := NewConfigFromString(config.String())
+	if err != nil || reread.String() != expect {
+		t.Errorf("Expect comments to survive reading back, but got:\n%s (%v)", reread, err)
+	}
+	config.Set("user.email", "c")
+	if e, _ := config.GetKeyValuesRaw("user.email").At(0); e.Comment != "" {
+		t.Errorf("Expect the comment dropped when the value changes, but got '%s'", e.Comment)
+	}
+
+	for key, comment := range map[string]string{"user.bare": "x", "user.email": "a\nb", "user.missing": "x", "nokey": "x"} {
+		if err := config.SetValueComment(key, comment); err == nil {
+			t.Errorf("Expect error setting comment %q on %s", comment, key)
+		}
+	}
+}
+
+func TestLookupEnsure(t *testing.T) {
+	config, err := NewConfigFromString("[core]\n\tbare = true\n[remote \"origin\"]\n\turl = x\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	before := config.String()
+	if config.LookupSection("user") != nil || config.LookupSubSection("remote", "other") != nil || config.LookupValueSet("branch", "main") != nil || config.LookupValues("core", "", "editor") != nil {
+		t.Errorf("Expect lookups of missing names to give nil")
+	}
+	if cv := config.LookupValues("Remote", "origin", "URL"); cv == nil || cv.Entries[0].Value != "x" {
+		t.Errorf("Expect remote.origin.url found, but got %+v", cv)
+	}
+	values := config.LookupValueSet("core", "")
+	if values.LookupConfigValues("editor") != nil || values.LookupConfigValues("Bare") == nil {
+		t.Errorf("Expect value set lookups to find only what is there")
+	}
+	if config.String() != before || len(config.SectionNames()) != 2 {
+		t.Errorf("Expect lookups not to change the config, but got:\n%s", config.String())
+	}
+
+	// readers may share a config, as nothing they call changes it
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config.GetKeyValueAsBool("core.bare")
+			config.GetKeyValuesRaw("user.name")
+			config.KeyNames("branch", "main")
+			config.LookupString("remote.other.url")
+		}()
+	}
+	wg.Wait()
+	if config.String() != before {
+		t.Errorf("Expect reads not to change the config, but got:\n%s", config.String())
+	}
+	if cv := values.EnsureConfigValues("Editor"); cv == nil || cv.OrigCaseName != "Editor" || values.LookupConfigValues("editor") != cv {
+		t.Errorf("Expect EnsureConfigValues to add the key, but got %+v", cv)
+	}
+
+	if cv := config.EnsureValues("branch", "main", "remote"); cv == nil || len(cv.Entries) != 0 {
+		t.Errorf("Expect an empty key created, but got %+v", cv)
+	}
+	if !config.HasSubSection("branch", "main") || config.EnsureSection("CORE") != config.LookupSection("core") {
+		t.Errorf("Expect Ensure to create what is missing and keep what is there")
+	}
+	if config.EnsureSubSection("remote", "a\nb") != nil || config.EnsureValueSet("remote", "a\nb") != nil || config.HasSubSection("remote", "a\nb") {
+		t.Errorf("Expect sub-sections which cannot be written not created")
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// The scope a Config was read from, in increasing order of precedence.
+type Scope int
+
+const (
+	ScopeSystem Scope = iota
+	ScopeGlobal
+	ScopeLocal
+	ScopeWorktree
+	ScopeCommand
+)
+
+var scopeNames = []string{"system", "global", "local", "worktree", "command"}
+
+func (self Scope) String() string {
+	if int(self) < 0 || int(self) >= len(scopeNames) {
+		return fmt.Sprintf("Scope(%d)", int(self))
+	}
+	return scopeNames[self]
+}
+
+// Converts a scope name (as used by gcScope tags and git's --show-scope)
+// to a Scope.
+func ParseScope(name string) (Scope, error) {
+	lc := strings.ToLower(strings.TrimSpace(name))
+	for i, n := range scopeNames {
+		if n == lc {
+			return Scope(i), nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown scope '%s', expected one of %s: %w", name, strings.Join(scopeNames, ", "), ErrInvalidTag)
+}
+
+// A ConfigSet layers several Configs, one per scope, the way git combines
+// the system, global and local files. Values in higher precedence scopes
+// override those in lower ones.
+type ConfigSet struct {
+	layers map[Scope]*Config
+	files  map[Scope]string // where Set writes each scope, see SetFile
+}
+
+func NewConfigSet() *ConfigSet {
+	return &ConfigSet{
+		layers: make(map[Scope]*Config, len(scopeNames)),
+		files:  make(map[Scope]string, len(scopeNames)),
+	}
+}
+
+// Sets the Config for a scope, replacing any previous one.
+func (self *ConfigSet) Add(scope Scope, cfg *Config) {
+	self.layers[scope] = cfg
+}
+
+// Gets the Config for a scope, or nil if there isn't one.
+func (self *ConfigSet) Get(scope Scope) *Config {
+	return self.layers[scope]
+}
+
+// Sets the file Set writes values for the scope to, which need not exist
+// yet. Without one the file the scope's Config was read from is used.
+func (self *ConfigSet) SetFile(scope Scope, path string) {
+	self.files[scope] = path
+}
+
+// Gets the file Set would write values for the scope to, or an error
+// wrapping ErrNoSource if it is not known. The global scope defaults to the
+// file `git config --global` would change.
+func (self *ConfigSet) File(scope Scope) (string, error) {
+	if path := self.files[scope]; path != "" {
+		return path, nil
+	}
+	if cfg := self.layers[scope]; cfg != nil && cfg.source != "" {
+		return cfg.source, nil
+	}
+	if scope == ScopeGlobal {
+		return GlobalConfigWritePath()
+	}
+	return "", fmt.Errorf("No file known for %s scope: %w", scope, ErrNoSource)
+}
+
+// Sets a key in the file for the scope, as `git config --global` or
+// `--local` do, creating the file (and its directory) if needed, then
+// re-reads it so the scope's Config, and so Merged, see the change.
+// See SetInFile for the options and how the file is changed.
+func (self *ConfigSet) Set(key, value string, scope Scope, opts ...SetOption) error {
+	if scope == ScopeCommand {
+		return fmt.Errorf("Cannot set %s in %s scope, it has no file: %w", key, scope, ErrInvalidScope)
+	}
+	path, err := self.File(scope)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := SetInFile(path, key, value, opts...); err != nil {
+		return err
+	}
+	if cfg := self.layers[scope]; cfg != nil && cfg.source == path {
+		_, err := cfg.ReloadFromFile()
+		return err
+	}
+	cfg, err := NewConfigFromFile(path)
+	if err != nil {
+		return err
+	}
+	self.layers[scope] = cfg
+	return nil
+}
+
+// Lists the files git reads for the global scope, in the order it reads
+// them, so values in later files take precedence:
+// $XDG_CONFIG_HOME/git/config (~/.config/git/config if that is not set)
+// then ~/.gitconfig. If $GIT_CONFIG_GLOBAL is set it is the only file.
+// The files need not exist. The home directory is found as by ExpandPath,
+// so on Windows %USERPROFILE% is used if $HOME is not set.
+func GlobalConfigPaths() ([]string, error) {
+	return hostPaths.globalPaths()
+}
+
+// Gets the global config file `git config --global` changes: ~/.gitconfig,
+// unless it does not exist but the XDG file does, or $GIT_CONFIG_GLOBAL if
+// set. See GlobalConfigPaths.
+func GlobalConfigWritePath() (string, error) {
+	paths, err := hostPaths.globalPaths()
+	if err != nil {
+		return "", err
+	}
+	dot := paths[len(paths)-1]
+	if len(paths) > 1 {
+		if _, err := os.Stat(dot); os.IsNotExist(err) {
+			if _, err := os.Stat(paths[0]); err == nil {
+				return paths[0], nil
+			}
+		}
+	}
+	return dot, nil
+}
+
+func (self pathEnv) globalPaths() ([]string, error) {
+	if global := self.getenv("GIT_CONFIG_GLOBAL"); global != "" {
+		return []string{global}, nil
+	}
+	home, err := self.home()
+	if err != nil {
+		return nil, err
+	}
+	xdgHome := self.getenv("XDG_CONFIG_HOME")
+	if xdgHome == "" {
+		xdgHome = filepath.Join(home, ".config")
+	}
+	return []string{filepath.Join(xdgHome, "git", "config"), filepath.Join(home, ".gitconfig")}, nil
+}
+
+// Lists the scopes which have a Config, lowest precedence first.
+func (self *ConfigSet) Scopes() []Scope {
+	out := make([]Scope, 0, len(self.layers))
+	for i := range scopeNames {
+		if self.layers[Scope(i)] != nil {
+			out = append(out, Scope(i))
+		}
+	}
+	return out
+}
+
+// Returns a single Config holding the values of every scope, added in
+// precedence order so the last value of each key is the effective one.
+func (self *ConfigSet) Merged() *Config {
+	return self.mergeScopes(self.Scopes())
+}
+
+// Load fills a gcKey annotated struct from the merged scopes.
+// Fields may carry a gcScope tag listing (comma separated) the only scopes
+// they may be read from. If the key is set in any other scope the field
+// is not loaded and an error describing the offending scope is returned.
+func (self *ConfigSet) Load(v interface{}) error {
+	return self.Merged().Load(v)
+}
+
+func (self *ConfigSet) mergeScopes(scopes []Scope) *Config {
+	out := NewConfig()
+	out.set = self
+	for _, scope := range scopes {
+		if cfg := self.layers[scope]; cfg != nil {
+			out.Merge(cfg)
+		}
+	}
+	return out
+}
+
+// Works out the Config a field restricted by a gcScope tag should be read
+// from, or an error if the field's key is set in a scope not allowed.
+func (self *ConfigSet) scopedConfig(scopeTag, key string, tp reflect.Type) (*Config, error) {
+	allowed := make(map[Scope]bool, len(scopeNames))
+	for _, name := range strings.Split(scopeTag, ",") {
+		scope, err := ParseScope(name)
+		if err != nil {
+			return nil, err
+		}
+		allowed[scope] = true
+	}
+	scopes := make([]Scope, 0, len(allowed))
+	for _, scope := range self.Scopes() {
+		if allowed[scope] {
+			scopes = append(scopes, scope)
+			continue
+		}
+		if self.layers[scope].definesKey(key, tp) {
+			return nil, fmt.Errorf("%s may only be set in %s scope but is set in %s scope: %w", key, scopeTag, scope, ErrInvalidScope)
+		}
+	}
+	return self.mergeScopes(scopes), nil
+}
+
+// Checks if anything a field of the given type and key would be loaded
+// from is present.
+func (self *Config) definesKey(key string, tp reflect.Type) bool {
+	for tp.Kind() == reflect.Ptr {
+		tp = tp.Elem()
+	}
+	switch {
+	case tp == configValueSetType, tp.Kind() == reflect.Struct && tp != timeType && tp != regexpType && tp != addrType && tp != prefixType && tp != configValueType:
+		s, ss := splitSectionPath(key)
+		return self.LookupValueSet(s, ss) != nil
+	case tp.Kind() == reflect.Map:
+		if parts := strings.Split(key, ".*."); len(parts) == 2 {
+			section := self.LookupSection(parts[0])
+			if section == nil {
+				return false
+			}
+			for _, ss := range section.SubSections {
+				if ss.GetKeyValuesRaw(parts[1]) != nil {
+					return true
+				}
+			}
+			return false
+		}
+		sName, err := mapSectionName(key)
+		if err != nil {
+			return false
+		}
+		section := self.LookupSection(sName)
+		return section != nil && len(section.SubSections) > 0
+	}
+	return self.GetKeyValuesRaw(key) != nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type TestScoped struct {
+	Name     string            `gcKey:"user.name"`
+	Token    string            `gcKey:"credential.token" gcScope:"local"`
+	Helpers  map[string]string `gcKey:"credential.*.helper" gcScope:"local,global"`
+	Location string            `gcKey:"user.location" gcScope:"system"`
+}
+
+func testConfigSet(t *testing.T, system, global, local string) *ConfigSet {
+	set := NewConfigSet()
+	for scope, configStr := range map[Scope]string{ScopeSystem: system, ScopeGlobal: global, ScopeLocal: local} {
+		config, err := NewConfigFromString(configStr)
+		if err != nil {
+			t.Fatalf("Failed to parse %s config:\n===\n%s\n===\n%s", scope, configStr, err.Error())
+		}
+		set.Add(scope, config)
+	}
+	return set
+}
+
+func TestConfigSetMerged(t *testing.T) {
+	set := testConfigSet(t,
+		"[user]\n    name = System\n    location = Earth\n",
+		"[user]\n    name = Global\n",
+		"[core]\n    bare = false\n")
+	merged := set.Merged()
+	testValue(t, merged, "user.name", "Global", true)
+	testValue(t, merged, "user.location", "Earth", true)
+	testValue(t, merged, "core.bare", "false", true)
+	if got := strings.Join(merged.GetKeyValuesStrings("user.name"), ","); got != "System,Global" {
+		t.Errorf("Expect user.name values in precedence order 'System,Global' but got '%s'\n", got)
+	}
+}
+
+func TestConfigSetLoadScopes(t *testing.T) {
+	set := testConfigSet(t,
+		"[user]\n    name = System\n    location = Earth\n",
+		"[user]\n    name = Global\n[credential \"https://example.com\"]\n    helper = store\n",
+		"[credential]\n    token = secret\n")
+	var ts TestScoped
+	if err := set.Load(&ts); err != nil {
+		t.Errorf("Failed to load scoped struct: %s\n", err.Error())
+		return
+	}
+	if ts.Name != "Global" || ts.Token != "secret" || ts.Location != "Earth" || ts.Helpers["https://example.com"] != "store" {
+		t.Errorf("Unexpected scoped load result: %+v\n", ts)
+	}
+
+	set = testConfigSet(t,
+		"[credential]\n    token = leaked\n",
+		"[user]\n    location = Mars\n",
+		"[credential]\n    token = secret\n")
+	err := set.Load(&ts)
+	loadErr, ok := err.(LoadError)
+	if !ok {
+		t.Errorf("Expect gitconfig.LoadError on return, but got %T\n", err)
+		return
+	}
+	if !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("Expect ErrInvalidScope but got: %s\n", err)
+	}
+	if lErr := loadErr["credential.token"]; lErr == nil || !strings.Contains(lErr.Error(), "set in system scope") {
+		t.Errorf("Expect credential.token error naming the system scope but got: %s\n", err)
+	}
+	if lErr := loadErr["user.location"]; lErr == nil || !strings.Contains(lErr.Error(), "set in global scope") {
+		t.Errorf("Expect user.location error naming the global scope but got: %s\n", err)
+	}
+}
+
+func TestConfigSetSet(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+	local := filepath.Join(t.TempDir(), "repo", ".git", "config")
+	set, err := NewConfigSetFromFilesContext(context.Background(), map[Scope]string{ScopeLocal: local})
+	if err != nil {
+		t.Fatalf("Failed to read config set: %s", err)
+	}
+	if err := set.Set("user.name", "Local", ScopeLocal); err != nil {
+		t.Fatalf("Failed to set local value: %s", err)
+	}
+	if data, _ := os.ReadFile(local); string(data) != "[user]\n\tname = Local\n" {
+		t.Errorf("Expect local file created with user.name, but got:\n%s", data)
+	}
+
+	// git only writes the XDG file if it exists and ~/.gitconfig does not
+	xdg := filepath.Join(home, ".config", "git", "config")
+	os.MkdirAll(filepath.Dir(xdg), 0755)
+	os.WriteFile(xdg, []byte("[core]\n\teditor = vi\n"), 0644)
+	if err := set.Set("user.name", "Global", ScopeGlobal); err != nil {
+		t.Fatalf("Failed to set global value: %s", err)
+	}
+	if data, _ := os.ReadFile(xdg); string(data) != "[core]\n\teditor = vi\n[user]\n\tname = Global\n" {
+		t.Errorf("Expect XDG file changed, but got:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".gitconfig")); !os.IsNotExist(err) {
+		t.Errorf("Expect ~/.gitconfig not to be created, but got: %v", err)
+	}
+	if err := set.Set("user.email", "joe@example.com", ScopeGlobal); err != nil {
+		t.Fatalf("Failed to set global value: %s", err)
+	}
+	merged := set.Merged()
+	testValue(t, merged, "user.name", "Local", true)
+	testValue(t, merged, "user.email", "joe@example.com", true)
+	testValue(t, merged, "core.editor", "vi", true)
+
+	if err := set.Set("user.name", "x", ScopeCommand); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("Expect command scope to be refused, but got: %v", err)
+	}
+	if err := set.Set("user.name", "x", ScopeSystem); !errors.Is(err, ErrNoSource) {
+		t.Errorf("Expect unknown system file to be refused, but got: %v", err)
+	}
+
+	home = t.TempDir()
+	t.Setenv("HOME", home)
+	set = NewConfigSet()
+	if err := set.Set("user.name", "New", ScopeGlobal); err != nil {
+		t.Fatalf("Failed to set global value: %s", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(home, ".gitconfig")); string(data) != "[user]\n\tname = New\n" {
+		t.Errorf("Expect ~/.gitconfig created, but got:\n%s", data)
+	}
+}
+
+func TestGlobalConfigPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+	xdg, dot := filepath.Join(home, ".config", "git", "config"), filepath.Join(home, ".gitconfig")
+	if paths, err := GlobalConfigPaths(); err != nil || !reflect.DeepEqual(paths, []string{xdg, dot}) {
+		t.Errorf("Expect %v, but got %v (%v)", []string{xdg, dot}, paths, err)
+	}
+	if path, err := GlobalConfigWritePath(); err != nil || path != dot {
+		t.Errorf("Expect %s written with neither file, but got %s (%v)", dot, path, err)
+	}
+	writeFiles(t, home, map[string]string{".config/git/config": ""})
+	if path, _ := GlobalConfigWritePath(); path != xdg {
+		t.Errorf("Expect %s written when only it exists, but got %s", xdg, path)
+	}
+	writeFiles(t, home, map[string]string{".gitconfig": ""})
+	if path, _ := GlobalConfigWritePath(); path != dot {
+		t.Errorf("Expect %s written when both exist, but got %s", dot, path)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	if paths, _ := GlobalConfigPaths(); paths[0] != filepath.Join(home, "xdg", "git", "config") {
+		t.Errorf("Expect $XDG_CONFIG_HOME used, but got %v", paths)
+	}
+	override := filepath.Join(home, "other")
+	t.Setenv("GIT_CONFIG_GLOBAL", override)
+	if paths, _ := GlobalConfigPaths(); !reflect.DeepEqual(paths, []string{override}) {
+		t.Errorf("Expect only $GIT_CONFIG_GLOBAL, but got %v", paths)
+	}
+	if path, _ := GlobalConfigWritePath(); path != override {
+		t.Errorf("Expect $GIT_CONFIG_GLOBAL written, but got %s", path)
+	}
+
+	windows := pathEnv{goos: "windows", getenv: func(name string) string {
+		return map[string]string{"USERPROFILE": `C:\Users\me`}[name]
+	}}
+	if paths, err := windows.globalPaths(); err != nil || len(paths) != 2 || !strings.HasPrefix(paths[1], `C:\Users\me`) {
+		t.Errorf("Expect %%USERPROFILE%% used on windows, but got %v (%v)", paths, err)
+	}
+}
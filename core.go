@@ -0,0 +1,88 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The commonly used core.* settings, with git's defaults for those not
+// set, see Config.CoreSettings. Settings whose default git works out at
+// run time (e.g. core.editor from $GIT_EDITOR, $VISUAL or $EDITOR) are
+// left empty when not set.
+type CoreSettings struct {
+	RepositoryFormatVersion int      `gcKey:"core.repositoryformatversion" gcDefault:"0"`
+	Bare                    bool     `gcKey:"core.bare" gcDefault:"false"`
+	FileMode                bool     `gcKey:"core.filemode" gcDefault:"true"`
+	SymLinks                bool     `gcKey:"core.symlinks" gcDefault:"true"`
+	IgnoreCase              bool     `gcKey:"core.ignorecase" gcDefault:"false"`
+	PrecomposeUnicode       bool     `gcKey:"core.precomposeunicode" gcDefault:"false"`
+	TrustCTime              bool     `gcKey:"core.trustctime" gcDefault:"true"`
+	CheckStat               string   `gcKey:"core.checkstat" gcDefault:"default"`
+	QuotePath               bool     `gcKey:"core.quotepath" gcDefault:"true"`
+	AutoCRLF                AutoCRLF `gcKey:"core.autocrlf"` // see AutoCRLF
+	EOL                     string   `gcKey:"core.eol" gcDefault:"native"`
+	SafeCRLF                string   `gcKey:"core.safecrlf" gcDefault:"warn"` // "true", "false" or "warn"
+	Editor                  string   `gcKey:"core.editor"`
+	Pager                   string   `gcKey:"core.pager"`
+	SSHCommand              string   `gcKey:"core.sshcommand"`
+	HooksPath               string   `gcKey:"core.hookspath" gcPath:"true"`
+	ExcludesFile            string   `gcKey:"core.excludesfile" gcPath:"true"`
+	AttributesFile          string   `gcKey:"core.attributesfile" gcPath:"true"`
+	Worktree                string   `gcKey:"core.worktree" gcPath:"true"`
+	Whitespace              string   `gcKey:"core.whitespace"`
+	CommentChar             string   `gcKey:"core.commentchar" gcDefault:"#"`
+	Abbrev                  string   `gcKey:"core.abbrev" gcDefault:"auto"`
+	Compression             int      `gcKey:"core.compression" gcDefault:"-1"`
+	SparseCheckout          bool     `gcKey:"core.sparsecheckout" gcDefault:"false"`
+	BigFileThreshold        int64    `gcKey:"core.bigfilethreshold" gcDefault:"512m" gcUnit:"b"`
+	DeltaBaseCacheLimit     int64    `gcKey:"core.deltabasecachelimit" gcDefault:"96m" gcUnit:"b"`
+}
+
+// How core.autocrlf converts line endings.
+type AutoCRLF string
+
+const (
+	AutoCRLFFalse AutoCRLF = "false" // no conversion, the default
+	AutoCRLFTrue  AutoCRLF = "true"  // LF in the repository, CRLF in the work tree
+	AutoCRLFInput AutoCRLF = "input" // CRLF converted to LF when adding only
+)
+
+// Reads the core.* settings. core.autocrlf is given as one of the AutoCRLF
+// constants whichever way it was written (e.g. "yes" or no value at all
+// for true). Values which cannot be read as their type give an error, as
+// for Load.
+func (self *Config) CoreSettings() (*CoreSettings, error) {
+	out := &CoreSettings{}
+	if err := self.Load(out); err != nil {
+		return nil, err
+	}
+	mode, err := self.autoCRLF()
+	if err != nil {
+		return nil, err
+	}
+	out.AutoCRLF = mode
+	return out, nil
+}
+
+func (self *Config) autoCRLF() (AutoCRLF, error) {
+	cv := self.GetKeyValuesRaw("core.autocrlf")
+	if cv == nil || len(cv.Entries) == 0 {
+		return AutoCRLFFalse, nil
+	}
+	last := cv.Entries[len(cv.Entries)-1]
+	if last.HasValue && strings.EqualFold(last.Value, "input") {
+		return AutoCRLFInput, nil
+	}
+	b, _, err := (&ConfigValue{Entries: []ValueEntry{last}}).GetBool()
+	if err != nil {
+		return "", fmt.Errorf("Could not parse value '%s' as true, false or input for core.autocrlf: %w", last.Value, err)
+	}
+	if b {
+		return AutoCRLFTrue, nil
+	}
+	return AutoCRLFFalse, nil
+}
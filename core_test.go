@@ -0,0 +1,42 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCoreSettings(t *testing.T) {
+	cfg, _ := NewConfigFromString("")
+	core, err := cfg.CoreSettings()
+	if err != nil {
+		t.Fatalf("Failed to read core settings: %s", err)
+	}
+	if core.Bare || !core.FileMode || !core.SymLinks || core.AutoCRLF != AutoCRLFFalse || core.EOL != "native" ||
+		core.Compression != -1 || core.BigFileThreshold != 512<<20 || core.CommentChar != "#" || core.Editor != "" {
+		t.Errorf("Expect git's defaults, but got %+v", core)
+	}
+
+	cfg, _ = NewConfigFromString("[core]\n\tbare = yes\n\tfileMode = false\n\tautocrlf\n\teditor = vim -f\n\thooksPath = /srv/hooks\n\tbigFileThreshold = 1g\n")
+	core, err = cfg.CoreSettings()
+	if err != nil {
+		t.Fatalf("Failed to read core settings: %s", err)
+	}
+	if !core.Bare || core.FileMode || core.AutoCRLF != AutoCRLFTrue || core.Editor != "vim -f" || core.HooksPath != "/srv/hooks" || core.BigFileThreshold != 1<<30 {
+		t.Errorf("Unexpected settings %+v", core)
+	}
+
+	for value, want := range map[string]AutoCRLF{"Input": AutoCRLFInput, "off": AutoCRLFFalse, "1": AutoCRLFTrue} {
+		cfg, _ = NewConfigFromString("[core]\n\tautocrlf = " + value + "\n")
+		if core, err = cfg.CoreSettings(); err != nil || core.AutoCRLF != want {
+			t.Errorf("Expect autocrlf %s for %q, but got %v (%v)", want, value, core, err)
+		}
+	}
+	cfg, _ = NewConfigFromString("[core]\n\tautocrlf = sometimes\n")
+	if _, err = cfg.CoreSettings(); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expect ErrTypeMismatch for a bad autocrlf, but got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// The keys registered with DeprecateKey. Shared by pointer, as OnChange
+// listeners are, and locked as reads may come from several goroutines.
+type deprecations struct {
+	mu       sync.Mutex
+	fallback map[string]string // canonical key to the one read in its place when unset
+	replaced map[string]bool   // the deprecated keys
+	warned   map[string]bool   // keys already warned about
+	warnings []Warning
+}
+
+func (self *deprecations) clone() *deprecations {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return &deprecations{
+		fallback: maps.Clone(self.fallback),
+		replaced: maps.Clone(self.replaced),
+		warned:   maps.Clone(self.warned),
+		warnings: slices.Clone(self.warnings),
+	}
+}
+
+// Registers oldKey as deprecated in favour of newKey, smoothing migrations
+// for apps which rename their settings. Reading either key when it is not
+// set gives the values of the other instead, and the first time this
+// happens for a key a "deprecated-key" warning is added to Warnings: for
+// values still set under oldKey, or for code still reading it. This holds
+// for LookupValues, GetKeyValuesRaw, GetValuesForKey, the getters built on
+// them, Load and Policy, which reports and fixes the key actually set.
+// Keys must be of form section.key or section.subsection.key and a key can
+// only be given once.
+func (self *Config) DeprecateKey(oldKey, newKey string) error {
+	from, err := self.NormalizeKey(oldKey)
+	if err != nil {
+		return err
+	}
+	to, err := self.NormalizeKey(newKey)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return fmt.Errorf("Cannot deprecate key '%s' in favour of itself: %w", oldKey, ErrInvalidKey)
+	}
+	d := self.deprecations.Load()
+	if d == nil {
+		d = &deprecations{fallback: make(map[string]string, 2), replaced: make(map[string]bool, 2), warned: make(map[string]bool, 2)}
+		if !self.deprecations.CompareAndSwap(nil, d) {
+			d = self.deprecations.Load()
+		}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, k := range []string{from.String(), to.String()} {
+		if _, ok := d.fallback[k]; ok {
+			return fmt.Errorf("Key '%s' is already in a deprecation: %w", k, ErrInvalidKey)
+		}
+	}
+	d.fallback[from.String()], d.fallback[to.String()] = to.String(), from.String()
+	d.replaced[from.String()] = true
+	return nil
+}
+
+// Gets the key registered with the given canonical key by DeprecateKey,
+// in either direction, or "" if there is none.
+func (self *Config) deprecationOf(key string) string {
+	d := self.deprecations.Load()
+	if d == nil {
+		return ""
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.fallback[key]
+}
+
+// Gets the key registered with the given unset key and its values, if it
+// has any, warning of the deprecated key being used.
+func (self *Config) deprecatedValues(key string) (string, *ConfigValue) {
+	d := self.deprecations.Load()
+	if d == nil {
+		return "", nil
+	}
+	d.mu.Lock()
+	other, ok := d.fallback[key]
+	d.mu.Unlock()
+	if !ok {
+		return "", nil
+	}
+	s, ss, k := ParseSectionKey(other)
+	cvs := self.lookupValues(s, ss, k)
+	if cvs == nil || len(cvs.Entries) == 0 {
+		return "", nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.warned[key] {
+		d.warned[key] = true
+		w := Warning{Code: "deprecated-key", Origin: cvs.Entries[len(cvs.Entries)-1].Origin}
+		if d.replaced[key] {
+			w.Key = key
+			w.Message = fmt.Sprintf("Key '%s' is deprecated and was read from '%s' instead", key, other)
+		} else {
+			w.Key = other
+			w.Message = fmt.Sprintf("Key '%s' is deprecated, set '%s' instead", other, key)
+		}
+		d.warnings = append(d.warnings, w)
+	}
+	return other, cvs
+}
+
+// Gets the warnings given by deprecatedValues so far.
+func (self *deprecations) collected() []Warning {
+	if self == nil {
+		return nil
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return append([]Warning(nil), self.warnings...)
+}
@@ -0,0 +1,126 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestDeprecateKey(t *testing.T) {
+	cfg, err := NewConfigFromString("[myapp]\n\ttoken = abc\n\tuser = joe\n[myapp \"auth\"]\n\tmethod = basic\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	if err := cfg.DeprecateKey("myapp.token", "myapp.auth.token"); err != nil {
+		t.Fatalf("Failed to deprecate: %s", err)
+	}
+	if err := cfg.DeprecateKey("myApp.authMethod", "myapp.auth.method"); err != nil {
+		t.Fatalf("Failed to deprecate: %s", err)
+	}
+	if len(cfg.Warnings()) != 0 {
+		t.Errorf("Expect no warnings before keys are read, but got %v", cfg.Warnings())
+	}
+
+	// new key unset, falls back to the old one still in the file
+	testValue(t, cfg, "myapp.auth.token", "abc", true)
+	testValue(t, cfg, "myapp.auth.token", "abc", true)
+	// old key read by old code, falls back to the new one
+	if v, ok := cfg.GetKeyValueAsString("myapp.authmethod"); !ok || v != "basic" {
+		t.Errorf("Expect myapp.authmethod to read myapp.auth.method, but got '%s' (%v)", v, ok)
+	}
+	testValue(t, cfg, "myapp.user", "joe", true)
+	var loaded struct {
+		Token string `gcKey:"myapp.auth.token"`
+	}
+	if err := cfg.Load(&loaded); err != nil || loaded.Token != "abc" {
+		t.Errorf("Expect Load to fall back too, but got '%s' (%v)", loaded.Token, err)
+	}
+
+	ws := cfg.Warnings()
+	if len(ws) != 2 {
+		t.Fatalf("Expect a warning for each key read once, but got %v", ws)
+	}
+	if ws[0].Code != "deprecated-key" || ws[0].Key != "myapp.token" || ws[0].Origin.Line != 2 || ws[0].Message != "Key 'myapp.token' is deprecated, set 'myapp.auth.token' instead" {
+		t.Errorf("Unexpected warning for value under old key: %+v", ws[0])
+	}
+	if ws[1].Key != "myapp.authmethod" || ws[1].Message != "Key 'myapp.authmethod' is deprecated and was read from 'myapp.auth.method' instead" {
+		t.Errorf("Unexpected warning for reading old key: %+v", ws[1])
+	}
+
+	// once set, the new key wins
+	cfg.Set("myapp.auth.token", "def")
+	testValue(t, cfg, "myapp.auth.token", "def", true)
+
+	for _, keys := range [][2]string{{"a.b", "a.b"}, {"myapp.token", "x.y"}, {"nodot", "a.b"}} {
+		if err := cfg.DeprecateKey(keys[0], keys[1]); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expect ErrInvalidKey deprecating %s for %s, but got %v", keys[0], keys[1], err)
+		}
+	}
+}
+
+func TestDeprecateKeyEverywhere(t *testing.T) {
+	cfg, err := NewConfigFromString("[myapp]\n\ttoken = abc\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	var wg sync.WaitGroup
+	for _, keys := range [][2]string{{"myapp.token", "myapp.auth.token"}, {"myapp.user", "myapp.auth.user"}} {
+		wg.Add(1)
+		go func(from, to string) {
+			defer wg.Done()
+			if err := cfg.DeprecateKey(from, to); err != nil {
+				t.Errorf("Failed to deprecate: %s", err)
+			}
+		}(keys[0], keys[1])
+	}
+	wg.Wait()
+
+	if cv := cfg.LookupValues("myapp", "auth", "token"); cv == nil || cv.ValuesAsStrings()[0] != "abc" {
+		t.Errorf("Expect LookupValues to fall back, but got %v", cv)
+	}
+	if cv := cfg.GetValuesForKey(Key{Section: "myapp", SubSection: "auth", Name: "token"}); cv == nil || cv.ValuesAsStrings()[0] != "abc" {
+		t.Errorf("Expect GetValuesForKey to fall back, but got %v", cv)
+	}
+
+	policy := Policy{Forbidden: []string{"myapp.auth.token"}}
+	found, err := policy.Apply(cfg)
+	if err != nil || len(found) != 1 || found[0].Key != "myapp.token" {
+		t.Fatalf("Expect the violation under the key set, but got %v (%v)", found, err)
+	}
+	if cv := cfg.LookupValues("myapp", "", "token"); cv != nil && len(cv.Entries) > 0 {
+		t.Errorf("Expect Apply to unset the deprecated key, but got %v", cv.ValuesAsStrings())
+	}
+	if found, _ := policy.Check(cfg); len(found) != 0 {
+		t.Errorf("Expect the policy to hold once applied, but got %v", found)
+	}
+}
+
+func TestDeprecateKeySliceElements(t *testing.T) {
+	cfg, err := NewConfigFromString("[mirror \"eu\"]\n\thost = a\n\thost = b\n\tpushTo = ${DEST}\n\tpushTo = c\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	if err := cfg.DeprecateKey("mirror.eu.pushto", "mirror.eu.target"); err != nil {
+		t.Fatalf("Failed to deprecate: %s", err)
+	}
+	type Mirror struct {
+		Host   string `gcKey:"host"`
+		Target string `gcKey:"target"`
+	}
+	var loaded struct {
+		Mirrors map[string][]Mirror `gcKey:"mirror.*"`
+	}
+	lookup := func(name string) (string, bool) { return "dest", name == "DEST" }
+	if err := cfg.LoadExpanded(&loaded, lookup); err != nil {
+		t.Fatalf("Failed to load: %s", err)
+	}
+	expect := []Mirror{{Host: "a", Target: "dest"}, {Host: "b", Target: "c"}}
+	if !reflect.DeepEqual(loaded.Mirrors["eu"], expect) {
+		t.Errorf("Expect slice elements to fall back to the deprecated key, got %+v", loaded.Mirrors["eu"])
+	}
+}
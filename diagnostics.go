@@ -0,0 +1,159 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// How serious a Diagnostic is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// A problem found in a config, in a form for editors and CI annotations.
+type Diagnostic struct {
+	File     string   `json:"file,omitempty"`
+	Line     uint64   `json:"line,omitempty"`   // 1-based, 0 if unknown
+	Column   int      `json:"column,omitempty"` // 1-based, in characters, 0 if unknown
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`            // e.g. "type-mismatch", see DiagnosticCode
+	Key      string   `json:"key,omitempty"`   // the config key concerned, if any
+	Field    string   `json:"field,omitempty"` // the Go field path concerned, if any
+	Message  string   `json:"message"`
+}
+
+// Formats the diagnostic as "file:line:column: severity: message [code]",
+// leaving out the parts not known, which is easy to match with e.g. a
+// GitHub problem matcher.
+func (self Diagnostic) String() string {
+	var sb strings.Builder
+	if self.File != "" {
+		sb.WriteString(self.File)
+		sb.WriteByte(':')
+	}
+	if self.Line != 0 {
+		fmt.Fprintf(&sb, "%d:", self.Line)
+		if self.Column != 0 {
+			fmt.Fprintf(&sb, "%d:", self.Column)
+		}
+	}
+	if sb.Len() > 0 {
+		sb.WriteByte(' ')
+	}
+	fmt.Fprintf(&sb, "%s: %s [%s]", self.Severity, self.Message, self.Code)
+	return sb.String()
+}
+
+// The codes given to errors wrapping each sentinel, checked in order.
+var diagnosticCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrRequiredMissing, "required-missing"},
+	{ErrKeyNotFound, "key-not-found"},
+	{ErrInvalidKey, "invalid-key"},
+	{ErrSectionNotFound, "section-not-found"},
+	{ErrTypeMismatch, "type-mismatch"},
+	{ErrUnsupportedType, "unsupported-type"},
+	{ErrInvalidTag, "invalid-tag"},
+	{ErrNoSource, "no-source"},
+	{ErrInvalidScope, "invalid-scope"},
+	{ErrInvalidValue, "invalid-value"},
+	{ErrUndefinedVariable, "undefined-variable"},
+	{ErrOutOfRange, "out-of-range"},
+	{ErrDuplicateSection, "duplicate-section"},
+	{ErrMultipleValues, "multiple-values"},
+	{ErrLocked, "locked"},
+	{ErrTxDone, "tx-done"},
+	{ErrIncludeDepth, "include-depth"},
+	{ErrStalePlan, "stale-plan"},
+	{ErrLimitExceeded, "limit-exceeded"},
+	{ErrHTTPStatus, "http-status"},
+	{ErrSyntax, "syntax"},
+}
+
+// Gets the machine readable code for an error: that of the package's
+// sentinel it wraps (e.g. "type-mismatch" for ErrTypeMismatch), "syntax"
+// for other parse errors or "error" otherwise.
+func DiagnosticCode(err error) string {
+	for _, c := range diagnosticCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		return "syntax"
+	}
+	return "error"
+}
+
+// Gets the diagnostic for the error.
+func (self *ParseError) Diagnostics() []Diagnostic {
+	return []Diagnostic{{
+		File:     self.File,
+		Line:     self.LineNo,
+		Column:   self.Column(),
+		Severity: SeverityError,
+		Code:     DiagnosticCode(self),
+		Message:  strings.TrimSpace(self.Message),
+	}}
+}
+
+func (self *ParseError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(self.Diagnostics())
+}
+
+// Gets a diagnostic for each field which failed to load, including those
+// of nested structs, sorted by key. Their location is where the key's value
+// in effect was set, if it was.
+func (self LoadError) Diagnostics() []Diagnostic {
+	out := make([]Diagnostic, 0, len(self))
+	for key, err := range self {
+		var nested LoadError
+		if errors.As(err, &nested) {
+			out = append(out, nested.Diagnostics()...)
+			continue
+		}
+		d := Diagnostic{Severity: SeverityError, Code: DiagnosticCode(err), Key: key, Message: strings.TrimSpace(err.Error())}
+		var fe *FieldError
+		if errors.As(err, &fe) {
+			d.Field = fe.FieldPath
+			d.File, d.Line = fe.Origin.File, fe.Origin.Line
+		}
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+func (self LoadError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(self.Diagnostics())
+}
+
+// Gets diagnostics for any error: those of a *ParseError or LoadError it
+// wraps, or a single one for anything else. Nil gives none.
+func Diagnose(err error) []Diagnostic {
+	if err == nil {
+		return nil
+	}
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		return pe.Diagnostics()
+	}
+	var le LoadError
+	if errors.As(err, &le) {
+		return le.Diagnostics()
+	}
+	return []Diagnostic{{Severity: SeverityError, Code: DiagnosticCode(err), Message: strings.TrimSpace(err.Error())}}
+}
@@ -0,0 +1,103 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseErrorDiagnostics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	os.WriteFile(path, []byte("[core]\n\tnäme = x\n"), 0644)
+	_, err := NewConfigFromFile(path)
+	expect := []Diagnostic{{File: path, Line: 2, Column: 10, Severity: SeverityError, Code: "syntax", Message: "Unexpected 'ä' in key, expected a ascii letter, hyphen or digit"}}
+	if got := Diagnose(err); !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expect diagnostics %+v, but got %+v", expect, got)
+	}
+	if got := expect[0].String(); got != path+":2:10: error: Unexpected 'ä' in key, expected a ascii letter, hyphen or digit [syntax]" {
+		t.Errorf("Unexpected diagnostic text: %s", got)
+	}
+	data, err := json.Marshal(err)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %s", err)
+	}
+	var back []Diagnostic
+	if err := json.Unmarshal(data, &back); err != nil || !reflect.DeepEqual(back, expect) {
+		t.Errorf("Expect JSON to hold the diagnostics, but got %s (%v)", data, err)
+	}
+
+	if _, err := NewConfigFromString("[sec\nx=1\n"); !errors.Is(err, ErrSyntax) {
+		t.Errorf("Expect a syntax error to wrap ErrSyntax, but got %v", err)
+	}
+	_, err = NewConfigFromStringOptions("[a]\n[a]\n", ParseOptions{DuplicateSections: DuplicateSectionsError})
+	if got := Diagnose(err); len(got) != 1 || got[0].Code != "duplicate-section" || got[0].File != "" || got[0].Line != 2 {
+		t.Errorf("Expect a duplicate-section diagnostic on line 2, but got %+v", got)
+	}
+	if Diagnose(nil) != nil {
+		t.Errorf("Expect no diagnostics for no error")
+	}
+	if got := Diagnose(ErrLocked); len(got) != 1 || got[0].Code != "locked" || got[0].String() != "error: config file is locked [locked]" {
+		t.Errorf("Unexpected diagnostics for a plain error: %+v", got)
+	}
+	_, err = NewConfigFromStringOptions("[a]\n\tb\n\tc\n", ParseOptions{Limits: ParseLimits{MaxValues: 1}})
+	if code := DiagnosticCode(err); code != "limit-exceeded" {
+		t.Errorf("Expect limit-exceeded for a parse limit, but got %s", code)
+	}
+	if code := DiagnosticCode(fmt.Errorf("Cannot apply: %w", ErrStalePlan)); code != "stale-plan" {
+		t.Errorf("Expect stale-plan for a stale plan, but got %s", code)
+	}
+	if code := DiagnosticCode(fmt.Errorf("Fetching: %w", ErrHTTPStatus)); code != "http-status" {
+		t.Errorf("Expect http-status for a failed fetch, but got %s", code)
+	}
+}
+
+func TestLoadErrorDiagnostics(t *testing.T) {
+	type Server struct {
+		Port int    `gcKey:"port"`
+		Host string `gcKey:"host" gcRequired:"true"`
+	}
+	var settings struct {
+		Age    int    `gcKey:"user.age"`
+		Server Server `gcKey:"server"`
+	}
+	path := filepath.Join(t.TempDir(), "config")
+	os.WriteFile(path, []byte("[user]\n\tage = old\n[server]\n\tport = http\n"), 0644)
+	config, _ := NewConfigFromFile(path)
+	err := config.Load(&settings)
+	got := Diagnose(err)
+	if len(got) != 3 {
+		t.Fatalf("Expect 3 diagnostics, but got %+v", got)
+	}
+	expect := []struct {
+		key, field, code string
+		line             uint64
+	}{
+		{"server.host", "Server.Host", "required-missing", 0},
+		{"server.port", "Server.Port", "type-mismatch", 4},
+		{"user.age", "Age", "type-mismatch", 2},
+	}
+	for i, e := range expect {
+		d := got[i]
+		if d.Key != e.key || d.Field != e.field || d.Code != e.code || d.Line != e.line || d.Severity != SeverityError {
+			t.Errorf("Expect diagnostic %+v, but got %+v", e, d)
+		}
+		if e.line != 0 && d.File != path {
+			t.Errorf("Expect diagnostic in %s, but got %+v", path, d)
+		}
+	}
+	var loadErr LoadError
+	errors.As(err, &loadErr)
+	data, _ := json.Marshal(loadErr)
+	var back []Diagnostic
+	if err := json.Unmarshal(data, &back); err != nil || !reflect.DeepEqual(back, got) {
+		t.Errorf("Expect JSON to hold the diagnostics, but got %s (%v)", data, err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"sort"
+)
+
+// A key whose values differ between two Configs.
+// Old is nil if the key was added, New is nil if it was removed.
+type KeyChange struct {
+	Key string
+	Old []string
+	New []string
+}
+
+// Lists the keys whose values (or number of values) differ between old and
+// new, sorted by key. Keys are given in canonical form: section and key
+// lowercased, subsection as is.
+func Diff(old, new *Config) []KeyChange {
+	oldVals := old.keyValues()
+	newVals := new.keyValues()
+	out := make([]KeyChange, 0, 5)
+	for key, ov := range oldVals {
+		nv, ok := newVals[key]
+		if !ok {
+			out = append(out, KeyChange{Key: key, Old: ov.ValuesAsStrings()})
+			continue
+		}
+		if !sameValues(ov, nv) {
+			out = append(out, KeyChange{Key: key, Old: ov.ValuesAsStrings(), New: nv.ValuesAsStrings()})
+		}
+	}
+	for key, nv := range newVals {
+		if _, ok := oldVals[key]; !ok {
+			out = append(out, KeyChange{Key: key, New: nv.ValuesAsStrings()})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// Joins section, subsection and key names into a single dotted key.
+func joinKey(section, subSection, key string) string {
+	if section == "" {
+		return key
+	}
+	if subSection == "" {
+		return section + "." + key
+	}
+	return section + "." + subSection + "." + key
+}
+
+// Gets every key holding at least one value, by canonical full key name.
+func (self *Config) keyValues() map[string]*ConfigValue {
+	out := make(map[string]*ConfigValue, 20)
+	add := func(section, subSection string, values ConfigValueSet) {
+		for name, cv := range values {
+			if cv.HasValues() {
+				out[joinKey(section, subSection, name)] = cv
+			}
+		}
+	}
+	add("", "", self.BaseValues)
+	for sName, s := range self.Sections {
+		add(sName, "", s.Values)
+		for ssName, ss := range s.SubSections {
+			add(sName, ssName, ss.Values)
+		}
+	}
+	return out
+}
+
+func sameValues(a, b *ConfigValue) bool {
+	if len(a.Entries) != len(b.Entries) {
+		return false
+	}
+	for i, v := range a.Entries {
+		w := b.Entries[i]
+		if v.HasValue != w.HasValue || v.Value != w.Value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+// A [diff "<name>"] driver, named by the diff attribute in .gitattributes.
+type DiffDriver struct {
+	Command       string `gcKey:"command"`                         // external diff command
+	TrustExitCode bool   `gcKey:"trustexitcode" gcDefault:"false"` // a non-zero exit from Command means the files differ, not failure
+	TextConv      string `gcKey:"textconv"`                        // command giving a text version of a file to diff
+	CacheTextConv bool   `gcKey:"cachetextconv" gcDefault:"false"` // keep TextConv output in notes
+	Binary        *bool  `gcKey:"binary"`                          // true or false to force binary or text diffs, nil to detect
+	WordRegex     string `gcKey:"wordregex"`                       // what a word is for --word-diff
+	XFuncName     string `gcKey:"xfuncname"`                       // regexp for hunk headers
+	Algorithm     string `gcKey:"algorithm"`                       // e.g. "histogram"
+}
+
+// A [merge "<name>"] driver, named by the merge attribute in
+// .gitattributes.
+type MergeDriver struct {
+	Name      string `gcKey:"name"`      // human readable name
+	Driver    string `gcKey:"driver"`    // command run with %O, %A, %B, %L, %P, %S, %X and %Y replaced
+	Recursive string `gcKey:"recursive"` // driver to use for the internal merge of common ancestors
+}
+
+// Gets the diff drivers by name. The [diff] section's own settings, such as
+// diff.algorithm, are not drivers and are left out.
+func (self *Config) DiffDrivers() (map[string]DiffDriver, error) {
+	var out struct {
+		Diff map[string]DiffDriver `gcKey:"diff.*"`
+	}
+	if err := self.Load(&out); err != nil {
+		return nil, err
+	}
+	return out.Diff, nil
+}
+
+// Gets the merge drivers by name. The [merge] section's own settings are
+// left out.
+func (self *Config) MergeDrivers() (map[string]MergeDriver, error) {
+	var out struct {
+		Merge map[string]MergeDriver `gcKey:"merge.*"`
+	}
+	if err := self.Load(&out); err != nil {
+		return nil, err
+	}
+	return out.Merge, nil
+}
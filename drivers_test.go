@@ -0,0 +1,60 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDrivers(t *testing.T) {
+	cfg, err := NewConfigFromString(`[diff]
+	algorithm = patience
+[diff "image"]
+	command = imgdiff
+	trustExitCode = true
+	binary = true
+[diff "json"]
+	textconv = jq .
+	cachetextconv
+	binary = false
+[diff "plain"]
+	xfuncname = "^func .*$"
+[merge]
+	tool = vimdiff
+[merge "lockfile"]
+	name = lock file merger
+	driver = merge-lock %O %A %B %P
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	diffs, err := cfg.DiffDrivers()
+	if err != nil {
+		t.Fatalf("Failed to read diff drivers: %s", err)
+	}
+	yes, no := true, false
+	expect := map[string]DiffDriver{
+		"image": {Command: "imgdiff", TrustExitCode: true, Binary: &yes},
+		"json":  {TextConv: "jq .", CacheTextConv: true, Binary: &no},
+		"plain": {XFuncName: "^func .*$"},
+	}
+	if !reflect.DeepEqual(diffs, expect) {
+		t.Errorf("Expect diff drivers:\n%+v\nbut got:\n%+v", expect, diffs)
+	}
+	merges, err := cfg.MergeDrivers()
+	if err != nil {
+		t.Fatalf("Failed to read merge drivers: %s", err)
+	}
+	if !reflect.DeepEqual(merges, map[string]MergeDriver{"lockfile": {Name: "lock file merger", Driver: "merge-lock %O %A %B %P"}}) {
+		t.Errorf("Unexpected merge drivers %+v", merges)
+	}
+
+	cfg, _ = NewConfigFromString("[diff \"x\"]\n\tbinary = perhaps\n")
+	if _, err := cfg.DiffDrivers(); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expect ErrTypeMismatch for a bad binary flag, but got %v", err)
+	}
+}
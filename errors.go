@@ -5,28 +5,200 @@
 package gitconfig
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 )
 
+// Sentinel errors which may be tested for with errors.Is. Errors made by
+// this package wrap one (or more) of these; those from reading files, such
+// as *fs.PathError, are returned as they are so os.IsNotExist still works.
+var (
+	// A requested key does not exist
+	ErrKeyNotFound = errors.New("key not found")
+	// A key name is not valid
+	ErrInvalidKey = errors.New("invalid key")
+	// A requested section or subsection does not exist
+	ErrSectionNotFound = errors.New("section not found")
+	// A value could not be converted to the requested type
+	ErrTypeMismatch = errors.New("type mismatch")
+	// A value marked as required was not present
+	ErrRequiredMissing = errors.New("required value missing")
+	// A struct field is of a type which cannot be loaded
+	ErrUnsupportedType = errors.New("unsupported type")
+	// A struct tag could not be understood
+	ErrInvalidTag = errors.New("invalid tag")
+	// A Config was not read from a file, so cannot be reloaded
+	ErrNoSource = errors.New("config has no source file")
+	// A value was set in a scope it is not allowed to come from
+	ErrInvalidScope = errors.New("value set in disallowed scope")
+	// A value is malformed, e.g. has an unterminated ${ reference
+	ErrInvalidValue = errors.New("invalid value")
+	// A value refers to a variable which is not defined
+	ErrUndefinedVariable = errors.New("undefined variable")
+	// A position is outside the values of a key
+	ErrOutOfRange = errors.New("position out of range")
+	// A section header appears more than once, see DuplicateSectionsError
+	ErrDuplicateSection = errors.New("duplicate section")
+	// A single value was to replace a key which has several
+	ErrMultipleValues = errors.New("key has multiple values")
+	// A config file is being written by someone else
+	ErrLocked = errors.New("config file is locked")
+	// A transaction was used after being committed or rolled back
+	ErrTxDone = errors.New("transaction already finished")
+	// Includes were nested too deeply, usually as a file includes itself
+	ErrIncludeDepth = errors.New("include depth exceeded")
+	// A config file changed between planning an edit and applying it
+	ErrStalePlan = errors.New("config file changed since planned")
+	// Parsing stopped at one of its ParseLimits, see PartialParseError
+	ErrLimitExceeded = errors.New("parse limit exceeded")
+	// An HTTPSource was answered with a status other than success
+	ErrHTTPStatus = errors.New("unexpected HTTP status")
+	// Config text is not valid git config syntax, see ParseError
+	ErrSyntax = errors.New("syntax error")
+)
+
 type ParseError struct {
 	Message string
+	File    string // empty if not parsing a file
 	Line    string
 	LineNo  uint64
 	CharPos uint64
+	Err     error // sentinel for the kind of problem, ErrSyntax if no other
 }
 
+// The width tabs in Line are expanded to when shown by Error.
+const parseErrorTabWidth = 8
+
+// Shows the line with a caret under the character at fault. Tabs in the
+// line are expanded to spaces so the caret lines up whatever the width of
+// tabs where it is printed.
 func (self *ParseError) Error() string {
-	out := fmt.Sprintf("Line: %d Char: %d\n%s\n", self.LineNo, self.CharPos, self.Line)
+	out := fmt.Sprintf("Line: %d Char: %d\n%s\n", self.LineNo, self.CharPos, expandTabs(self.Line))
 	if self.CharPos != 0 {
-		out = out + strings.Repeat(" ", int(self.CharPos-1))
+		out = out + strings.Repeat(" ", self.Column()-1)
 	}
 	out = out + "^\n"
 	return out + self.Message
 }
 
+// Gets the 1-based column of the character at fault as shown by Error,
+// counting characters rather than bytes and expanding tabs. CharPos is
+// the byte position, which differs on lines with tabs or multibyte UTF-8.
+// 0 if the position is not known.
+func (self *ParseError) Column() int {
+	if self.CharPos == 0 {
+		return 0
+	}
+	end := int(self.CharPos - 1)
+	col := 0
+	for i, r := range self.Line {
+		if i >= end {
+			return col + 1
+		}
+		if r == '\t' {
+			col += parseErrorTabWidth - col%parseErrorTabWidth
+		} else {
+			col++
+		}
+	}
+	// past the end, e.g. for an unexpected end of line
+	return col + 1 + end - len(self.Line)
+}
+
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var sb strings.Builder
+	col := 0
+	for _, r := range line {
+		if r == '\t' {
+			n := parseErrorTabWidth - col%parseErrorTabWidth
+			sb.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		sb.WriteRune(r)
+		col++
+	}
+	return sb.String()
+}
+
+func (self *ParseError) Unwrap() error {
+	return self.Err
+}
+
+// A key name git would reject, from NormalizeKey. It wraps ErrInvalidKey.
+type KeyError struct {
+	Key  string // the key as given
+	Part string // "section", "subsection" or "name"
+	Err  error  // why the part is invalid
+}
+
+func (self *KeyError) Error() string {
+	return fmt.Sprintf("Invalid %s in key '%s': %s", self.Part, self.Key, self.Err)
+}
+
+func (self *KeyError) Unwrap() error {
+	return self.Err
+}
+
+// A value holding a character which cannot be written to a config file and
+// read back unchanged: NUL, or a control character other than tab and
+// newline. It wraps ErrInvalidValue.
+type ValueError struct {
+	Key  string // the key as given
+	Pos  int    // byte offset of the character in the value
+	Char byte
+}
+
+func (self *ValueError) Error() string {
+	if self.Char == 0 {
+		return fmt.Sprintf("Value of key '%s' must not contain NUL (at byte %d): %s", self.Key, self.Pos, ErrInvalidValue)
+	}
+	return fmt.Sprintf("Value of key '%s' must not contain control character %q (at byte %d): %s", self.Key, self.Char, self.Pos, ErrInvalidValue)
+}
+
+func (self *ValueError) Unwrap() error {
+	return ErrInvalidValue
+}
+
+// The errors loading the fields of a struct, keyed by config key. Each is
+// a *FieldError also giving the Go path of the field, see ByField.
+// Errors of a nested struct's fields are held in a LoadError within the
+// error for the field holding the struct. Entries were the underlying
+// errors before FieldError was added, which FieldError wraps, so use
+// errors.As rather than type assertions to reach them.
 type LoadError map[string]error
 
+func (self LoadError) add(key, fieldPath string, origin ValueOrigin, err error) {
+	self[key] = &FieldError{Key: key, FieldPath: fieldPath, Origin: origin, Err: err}
+}
+
+// Gets the errors keyed by Go field path, e.g. "Remotes[origin].URL",
+// rather than config key, for mapping errors back onto fields. Those of
+// nested structs are listed individually in place of the error of the
+// field holding them. Errors without a field path (e.g. from loaders made
+// by gitconfig-gen) are left out.
+func (self LoadError) ByField() map[string]error {
+	out := make(map[string]error, len(self))
+	for _, err := range self {
+		var nested LoadError
+		if errors.As(err, &nested) {
+			for path, err := range nested.ByField() {
+				out[path] = err
+			}
+			continue
+		}
+		if fe, ok := err.(*FieldError); ok {
+			out[fe.FieldPath] = fe
+		}
+	}
+	return out
+}
+
 func (self LoadError) HaveErrors() bool {
 	if cnt := len(self); cnt > 0 {
 		return true
@@ -34,6 +206,50 @@ func (self LoadError) HaveErrors() bool {
 	return false
 }
 
+// Returns all the contained errors, so errors.Is and errors.As can be used
+// to check for any of them.
+// The required keys missing are also given as a single *RequiredError,
+// ahead of the others, so errors.As finds that rather than one of them.
+func (self LoadError) Unwrap() []error {
+	out := make([]error, 0, len(self)+1)
+	if required := self.Required(); required != nil {
+		out = append(out, required)
+	}
+	for _, v := range self {
+		out = append(out, v)
+	}
+	return out
+}
+
+// Gathers the required keys and sections found missing, from this error
+// and those of any nested structs, or nil if none were.
+func (self LoadError) Required() *RequiredError {
+	missing := make([]MissingKey, 0, 2)
+	collectMissing(self, &missing)
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].Key < missing[j].Key })
+	return &RequiredError{Missing: missing}
+}
+
+func collectMissing(err error, out *[]MissingKey) {
+	switch e := err.(type) {
+	case LoadError:
+		for _, v := range e {
+			collectMissing(v, out)
+		}
+	case *missingError:
+		*out = append(*out, e.MissingKey)
+	case interface{ Unwrap() []error }:
+		for _, v := range e.Unwrap() {
+			collectMissing(v, out)
+		}
+	case interface{ Unwrap() error }:
+		collectMissing(e.Unwrap(), out)
+	}
+}
+
 func (self LoadError) Error() string {
 	cnt := len(self)
 	if cnt == 0 {
@@ -50,3 +266,105 @@ func (self LoadError) Error() string {
 	}
 	return out
 }
+
+// An error loading a single field, as held in a LoadError.
+type FieldError struct {
+	Key       string      // the config key, e.g. "remote.origin.url"
+	FieldPath string      // the Go path of the field, e.g. "Remotes[origin].URL"
+	Origin    ValueOrigin // where the key's value in effect was set, if anywhere
+	Err       error
+}
+
+func (self *FieldError) Error() string {
+	return self.Err.Error()
+}
+
+func (self *FieldError) Unwrap() error {
+	return self.Err
+}
+
+// A required key, or section, which had no value when loading a struct.
+type MissingKey struct {
+	Key        string // full key, e.g. "remote.origin.url", or the section for a whole section
+	Section    string // the section it belongs in, e.g. "remote"
+	SubSection string // e.g. "origin", empty if none
+	Name       string // e.g. "url", empty if a whole section is missing
+	Type       string // the Go type of the field it was to fill, empty for RequireKeys
+	Empty      bool   // the key is set, but its value is empty
+}
+
+// Every required key missing when loading a struct, see LoadError.Required,
+// or when checked by Config.RequireKeys.
+// The message lists them under their sections, ready to show to users.
+// It wraps ErrRequiredMissing.
+type RequiredError struct {
+	Missing []MissingKey // sorted by key
+}
+
+func (self *RequiredError) Error() string {
+	var sb strings.Builder
+	if len(self.Missing) == 1 {
+		sb.WriteString("The following required setting is missing:\n")
+	} else {
+		fmt.Fprintf(&sb, "The following %d required settings are missing:\n", len(self.Missing))
+	}
+	last := ""
+	for _, m := range self.Missing {
+		header := sectionHeader(m.Section, m.SubSection)
+		if m.Name == "" {
+			fmt.Fprintf(&sb, "  %s (the whole section, for %s)\n", header, m.Type)
+			last = ""
+			continue
+		}
+		if header != last {
+			fmt.Fprintf(&sb, "  %s\n", header)
+			last = header
+		}
+		switch {
+		case m.Empty:
+			fmt.Fprintf(&sb, "    %s (set, but empty)\n", m.Name)
+		case m.Type == "":
+			fmt.Fprintf(&sb, "    %s\n", m.Name)
+		default:
+			fmt.Fprintf(&sb, "    %s (%s)\n", m.Name, m.Type)
+		}
+	}
+	return sb.String()
+}
+
+func (self *RequiredError) Unwrap() error {
+	return ErrRequiredMissing
+}
+
+// The error a single missing key is reported with within a LoadError. Its
+// message is that of ErrRequiredMissing, which it wraps.
+type missingError struct {
+	MissingKey
+}
+
+func (self *missingError) Error() string {
+	return ErrRequiredMissing.Error()
+}
+
+func (self *missingError) Unwrap() error {
+	return ErrRequiredMissing
+}
+
+// Gets the error for a missing key of form section[.subsection].name.
+func missingKey(tpName, key string) error {
+	m := MissingKey{Key: key, Name: key, Type: tpName}
+	if first := strings.IndexByte(key, '.'); first >= 0 {
+		last := strings.LastIndexByte(key, '.')
+		m.Section, m.Name = key[:first], key[last+1:]
+		if first != last {
+			m.SubSection = key[first+1 : last]
+		}
+	}
+	return &missingError{m}
+}
+
+// Gets the error for a missing section, of form section[.subsection].
+func missingSection(tpName, path string) error {
+	s, ss := splitSectionPath(path)
+	return &missingError{MissingKey{Key: path, Section: s, SubSection: ss, Type: tpName}}
+}
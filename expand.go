@@ -0,0 +1,184 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Looks up a variable for expansion, returning false if it is not defined.
+// os.LookupEnv can be used for environment variables.
+type ExpandFunc func(name string) (string, bool)
+
+// Expands $NAME and ${NAME} references in s using lookup.
+// "$$" gives a literal '$', as does a '$' not followed by a name.
+// A reference to a variable lookup does not define is an error, rather
+// than silently becoming empty.
+func ExpandValue(s string, lookup ExpandFunc) (string, error) {
+	i := strings.IndexByte(s, '$')
+	if i < 0 {
+		return s, nil
+	}
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for ; i >= 0; i = strings.IndexByte(s, '$') {
+		sb.WriteString(s[:i])
+		s = s[i+1:]
+		name := ""
+		switch {
+		case strings.HasPrefix(s, "$"):
+			sb.WriteByte('$')
+			s = s[1:]
+			continue
+		case strings.HasPrefix(s, "{"):
+			end := strings.IndexByte(s, '}')
+			if end < 0 {
+				// the value may be a secret, so is left out
+				return "", fmt.Errorf("Unterminated ${: %w", ErrInvalidValue)
+			}
+			name = s[1:end]
+			if !isVariableName(name) {
+				return "", fmt.Errorf("Invalid variable name '%s': %w", name, ErrInvalidValue)
+			}
+			s = s[end+1:]
+		default:
+			n := 0
+			for n < len(s) && isVariableName(s[:n+1]) {
+				n++
+			}
+			if n == 0 {
+				sb.WriteByte('$')
+				continue
+			}
+			name = s[:n]
+			s = s[n:]
+		}
+		val, ok := lookup(name)
+		if !ok {
+			return "", fmt.Errorf("Variable '%s' is not defined: %w", name, ErrUndefinedVariable)
+		}
+		sb.WriteString(val)
+	}
+	sb.WriteString(s)
+	return sb.String(), nil
+}
+
+func isVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c != '_' && !isLetter(c) && (i == 0 || !isDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Get the last specified value of the key as a string, with variables
+// expanded by ExpandValue.
+// If the *key* does not exist, the second return value will be false.
+func (self *Config) GetKeyValueExpanded(key string, lookup ExpandFunc) (string, bool, error) {
+	s, ok := self.GetKeyValueAsString(key)
+	if !ok {
+		return "", false, nil
+	}
+	out, err := ExpandValue(s, lookup)
+	if err != nil {
+		return "", true, fmt.Errorf("key %q: %w", key, err)
+	}
+	return out, true, nil
+}
+
+// Gets a copy of the config with variables in every value expanded by
+// ExpandValue. If the config was merged from a ConfigSet its layers are
+// expanded too, so gcScope fields see expanded values.
+func (self *Config) Expanded(lookup ExpandFunc) (*Config, error) {
+	return self.expanded(lookup, nil)
+}
+
+// Expands as Expanded, but only the values of keys for which want returns
+// true, or all if it is nil.
+func (self *Config) expanded(lookup ExpandFunc, want func(key string) bool) (*Config, error) {
+	out := NewConfig()
+	out.Merge(self)
+	out.source = self.source
+	self.shareSettings(out)
+	expand := func(section, subSection string, values ConfigValueSet) error {
+		for name, cv := range values {
+			if want != nil && !want(joinKey(section, subSection, name)) {
+				continue
+			}
+			for i, e := range cv.Entries {
+				if !e.HasValue {
+					continue
+				}
+				val, err := ExpandValue(e.Value, lookup)
+				if err != nil {
+					return fmt.Errorf("key %q: %w", joinKey(section, subSection, name), err)
+				}
+				cv.Entries[i].Value = val
+			}
+			cv.syncValue()
+		}
+		return nil
+	}
+	if err := expand("", "", out.BaseValues); err != nil {
+		return nil, err
+	}
+	for sName, s := range out.Sections {
+		if err := expand(sName, "", s.Values); err != nil {
+			return nil, err
+		}
+		for ssName, ss := range s.SubSections {
+			if err := expand(sName, ssName, ss.Values); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if self.set != nil {
+		set := NewConfigSet()
+		for scope, layer := range self.set.layers {
+			expanded, err := layer.expanded(lookup, want)
+			if err != nil {
+				return nil, fmt.Errorf("%s scope: %w", scope, err)
+			}
+			set.Add(scope, expanded)
+		}
+		out.set = set
+	}
+	return out, nil
+}
+
+// Like Load, but with variables in values expanded first (see Expanded).
+// Only the values of keys v's fields are loaded from, or may fall back to
+// (see DeprecateKey), are expanded, so a bad reference elsewhere in the
+// config does not fail the load.
+func (self *Config) LoadExpanded(v interface{}, lookup ExpandFunc) error {
+	rv, err := loadTarget(v)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, 10)
+	if err := structKeys(rv.Type(), "", false, &keys, make(map[reflect.Type]bool, 5)); err != nil {
+		return err
+	}
+	match := newKeyPatterns(keys).match
+	want := func(key string) bool {
+		if match(key) {
+			return true
+		}
+		other := self.deprecationOf(key)
+		return other != "" && match(other)
+	}
+	expanded, err := self.expanded(lookup, want)
+	if err != nil {
+		return err
+	}
+	return expanded.Load(v)
+}
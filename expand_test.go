@@ -0,0 +1,84 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testLookup(name string) (string, bool) {
+	vars := map[string]string{"HOME": "/home/joe", "USER": "joe", "EMPTY": ""}
+	v, ok := vars[name]
+	return v, ok
+}
+
+func TestExpandValue(t *testing.T) {
+	tests := map[string]string{
+		"plain":       "plain",
+		"$HOME/bin":   "/home/joe/bin",
+		"${HOME}bin":  "/home/joebin",
+		"$USER@$HOME": "joe@/home/joe",
+		"cost $$5":    "cost $5",
+		"end $":       "end $",
+		"$1 and $-":   "$1 and $-",
+		"[$EMPTY]":    "[]",
+	}
+	for in, expect := range tests {
+		got, err := ExpandValue(in, testLookup)
+		if err != nil || got != expect {
+			t.Errorf("Expect '%s' to expand to '%s' but got '%s' (err: %v)", in, expect, got, err)
+		}
+	}
+	for _, in := range []string{"${USER}_$X_", "$home"} {
+		if got, err := ExpandValue(in, testLookup); !errors.Is(err, ErrUndefinedVariable) {
+			t.Errorf("Expect '%s' to fail with ErrUndefinedVariable but got '%s' (err: %v)", in, got, err)
+		}
+	}
+	for _, in := range []string{"${HOME", "${}", "${1A}"} {
+		if _, err := ExpandValue(in, testLookup); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Expect '%s' to fail with ErrInvalidValue but got %v", in, err)
+		}
+	}
+}
+
+type TestExpand struct {
+	Dir  string   `gcKey:"core.dir"`
+	Urls []string `gcKey:"remote.origin.url"`
+}
+
+func TestLoadExpanded(t *testing.T) {
+	config, err := NewConfigFromString("[core]\n\tdir = $HOME/src\n\tbare\n[remote \"origin\"]\n\turl = ssh://$USER@a\n\turl = ${USER}@b\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	var te TestExpand
+	if err := config.LoadExpanded(&te, testLookup); err != nil {
+		t.Fatalf("Failed to load expanded: %s", err)
+	}
+	if te.Dir != "/home/joe/src" || len(te.Urls) != 2 || te.Urls[0] != "ssh://joe@a" || te.Urls[1] != "joe@b" {
+		t.Errorf("Expect values to be expanded but got %+v", te)
+	}
+	testValue(t, config, "core.dir", "$HOME/src", true)
+	if got, ok, err := config.GetKeyValueExpanded("core.dir", testLookup); err != nil || !ok || got != "/home/joe/src" {
+		t.Errorf("Expect expanded getter to give /home/joe/src but got '%s' (err: %v)", got, err)
+	}
+	config.Set("core.dir", "$NOPE")
+	if err := config.LoadExpanded(&te, testLookup); !errors.Is(err, ErrUndefinedVariable) {
+		t.Errorf("Expect undefined variable error but got %v", err)
+	}
+
+	// only the values loaded are expanded, and bad ones are not quoted
+	config.Set("core.dir", "$HOME")
+	config.Set("other.token", "s3cr3t${HOME")
+	if err := config.LoadExpanded(&te, testLookup); err != nil {
+		t.Errorf("Expect a bad value not loaded to be ignored but got %v", err)
+	}
+	_, err = config.Expanded(testLookup)
+	if !errors.Is(err, ErrInvalidValue) || strings.Contains(err.Error(), "s3cr3t") || !strings.Contains(err.Error(), "other.token") {
+		t.Errorf("Expect an error naming the key but not its value, but got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// When things expire, from an expiry date such as gc.pruneExpire, see
+// ParseExpiry.
+type Expiry struct {
+	Never  bool      // "never" or "false": nothing expires
+	All    bool      // "now" or "all": everything expires
+	Before time.Time // otherwise, what is older than this expires
+}
+
+// Reports whether something last changed at t has expired.
+func (self Expiry) Expired(t time.Time) bool {
+	switch {
+	case self.Never:
+		return false
+	case self.All:
+		return true
+	}
+	return t.Before(self.Before)
+}
+
+var expiryLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+var expiryUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+}
+
+// Parses an expiry date as git does for gc.pruneExpire and the like, taking
+// relative dates back from now: "never", "now", "all", a date such as
+// "2019-01-31" or "2019-01-31 12:00:00" (in now's location), or a sum of
+// amounts of seconds, minutes, hours, days, weeks, months or years ago,
+// e.g. "2.weeks.ago", "3 months" or "1 year 6 months ago". git's other
+// approximate dates, such as "last friday", are not understood.
+func ParseExpiry(s string, now time.Time) (Expiry, error) {
+	spec := strings.ToLower(strings.TrimSpace(s))
+	switch spec {
+	case "never", "false":
+		return Expiry{Never: true}, nil
+	case "now", "all":
+		return Expiry{All: true}, nil
+	case "yesterday":
+		return Expiry{Before: now.AddDate(0, 0, -1)}, nil
+	}
+	for _, layout := range expiryLayouts {
+		if t, err := time.ParseInLocation(layout, spec, now.Location()); err == nil {
+			return Expiry{Before: t}, nil
+		}
+	}
+	words := strings.FieldsFunc(spec, func(r rune) bool { return r == '.' || r == ' ' || r == '\t' || r == ',' || r == '_' })
+	if len(words) > 0 && words[len(words)-1] == "ago" {
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 || len(words)%2 != 0 {
+		return Expiry{}, fmt.Errorf("Could not parse '%s' as an expiry date: %w", s, ErrTypeMismatch)
+	}
+	before := now
+	for i := 0; i < len(words); i += 2 {
+		n, err := strconv.Atoi(words[i])
+		unit := strings.TrimSuffix(words[i+1], "s")
+		if err != nil || n < 0 {
+			return Expiry{}, fmt.Errorf("Could not parse '%s' as an expiry date, expected an amount for '%s': %w", s, words[i+1], ErrTypeMismatch)
+		}
+		switch unit {
+		case "month":
+			before = before.AddDate(0, -n, 0)
+		case "year":
+			before = before.AddDate(-n, 0, 0)
+		default:
+			d, ok := expiryUnits[unit]
+			if !ok {
+				return Expiry{}, fmt.Errorf("Could not parse '%s' as an expiry date, unknown unit '%s': %w", s, words[i+1], ErrTypeMismatch)
+			}
+			before = before.Add(-time.Duration(n) * d)
+		}
+	}
+	return Expiry{Before: before}, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2019, 3, 31, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		spec   string
+		expect Expiry
+	}{
+		{"never", Expiry{Never: true}},
+		{"Now", Expiry{All: true}},
+		{"all", Expiry{All: true}},
+		{"2.weeks.ago", Expiry{Before: now.AddDate(0, 0, -14)}},
+		{"90 days", Expiry{Before: now.AddDate(0, 0, -90)}},
+		{"1.month.ago", Expiry{Before: now.AddDate(0, -1, 0)}},
+		{"1 year 6 months ago", Expiry{Before: now.AddDate(-1, -6, 0)}},
+		{"1.hour.30.minutes.ago", Expiry{Before: now.Add(-90 * time.Minute)}},
+		{"yesterday", Expiry{Before: now.AddDate(0, 0, -1)}},
+		{"2019-01-31", Expiry{Before: time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC)}},
+		{"2019-01-31 08:30:00", Expiry{Before: time.Date(2019, 1, 31, 8, 30, 0, 0, time.UTC)}},
+	} {
+		got, err := ParseExpiry(tc.spec, now)
+		if err != nil || got != tc.expect {
+			t.Errorf("Expect %+v for %q, but got %+v (%v)", tc.expect, tc.spec, got, err)
+		}
+	}
+	for _, spec := range []string{"", "soon", "2 fortnights ago", "weeks ago", "3"} {
+		if _, err := ParseExpiry(spec, now); !errors.Is(err, ErrTypeMismatch) {
+			t.Errorf("Expect ErrTypeMismatch for %q, but got %v", spec, err)
+		}
+	}
+
+	e, _ := ParseExpiry("2.weeks.ago", now)
+	if !e.Expired(now.AddDate(0, 0, -15)) || e.Expired(now.AddDate(0, 0, -13)) {
+		t.Errorf("Expect only things over two weeks old expired")
+	}
+	if (Expiry{Never: true}).Expired(time.Time{}) || !(Expiry{All: true}).Expired(now) {
+		t.Errorf("Expect never and all to expire nothing and everything")
+	}
+}
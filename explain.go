@@ -0,0 +1,147 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// One value given for a key, see Config.Explain.
+type ResolutionStep struct {
+	Key       string     // canonical form, as given by Diff
+	Value     ValueEntry // its Origin says where it was set
+	Scope     Scope      // where it was set, if Scoped
+	Scoped    bool       // whether the config came from a ConfigSet, so scopes are known
+	Included  bool       // read from a file included by the one being loaded
+	Effective bool       // the value in effect, or one of them for multi-valued keys
+	Message   string     // what happened, ready to show to users; secrets are redacted
+}
+
+func (self ResolutionStep) String() string {
+	return self.Message
+}
+
+// Says how the value of a key was arrived at: every value it was given, in
+// order, where each was set, and which value each overrode. For a Config
+// from ConfigSet.Merged the scope of each value is given too. Values added
+// by ApplyOverrides or from the environment have no file.
+// For keys meant to hold several values (e.g. remote.<name>.fetch, see
+// Config.Warnings) each value adds to the earlier ones rather than
+// overriding them. Values of keys matching DefaultSecretPatterns are
+// RedactedValue in the messages. Returns nil if the key is not set or not
+// valid.
+func (self *Config) Explain(key string) []ResolutionStep {
+	if self.set != nil {
+		return self.set.Explain(key)
+	}
+	k, err := self.NormalizeKey(key)
+	if err != nil {
+		return nil
+	}
+	cv := self.GetValuesForKey(k)
+	if cv == nil || !cv.HasValues() {
+		return nil
+	}
+	steps := make([]ResolutionStep, len(cv.Entries))
+	for i, e := range cv.Entries {
+		steps[i] = ResolutionStep{Key: k.String(), Value: e, Included: self.isIncluded(e.Origin)}
+	}
+	return explainSteps(steps)
+}
+
+// Says how the value of a key was arrived at across the scopes, see
+// Config.Explain.
+func (self *ConfigSet) Explain(key string) []ResolutionStep {
+	k, err := NormalizeKey(key)
+	if err != nil {
+		return nil
+	}
+	steps := make([]ResolutionStep, 0, 4)
+	for _, scope := range self.Scopes() {
+		cfg := self.layers[scope]
+		cv := cfg.GetValuesForKey(k)
+		if cv == nil {
+			continue
+		}
+		for _, e := range cv.Entries {
+			steps = append(steps, ResolutionStep{Key: k.String(), Value: e, Scope: scope, Scoped: true, Included: cfg.isIncluded(e.Origin)})
+		}
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+	return explainSteps(steps)
+}
+
+// Whether a value was read from a file other than the config's own.
+func (self *Config) isIncluded(origin ValueOrigin) bool {
+	return self.source != "" && origin.File != "" && origin.File != self.source
+}
+
+// Marks the effective values and writes the messages.
+func explainSteps(steps []ResolutionStep) []ResolutionStep {
+	multi := isMultiValued(steps[0].Key)
+	secret := newKeyPatterns(DefaultSecretPatterns()).match(steps[0].Key)
+	for i := range steps {
+		s := &steps[i]
+		s.Effective = multi || i == len(steps)-1
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s %s %s", s.Key, describeValue(s.Value, secret), s.where())
+		switch {
+		case i == 0:
+		case multi:
+			sb.WriteString(", adding to the values before it")
+		default:
+			prev := steps[i-1]
+			if prev.Value.HasValue {
+				fmt.Fprintf(&sb, ", overriding '%s' %s", shownValue(prev.Value.Value, secret), prev.where())
+			} else {
+				fmt.Fprintf(&sb, ", overriding no value %s", prev.where())
+			}
+		}
+		if s.Effective && !multi {
+			sb.WriteString(" (in effect)")
+		}
+		s.Message = sb.String()
+	}
+	return steps
+}
+
+func describeValue(e ValueEntry, secret bool) string {
+	if !e.HasValue {
+		return "given with no value"
+	}
+	return fmt.Sprintf("set to '%s'", shownValue(e.Value, secret))
+}
+
+func shownValue(value string, secret bool) string {
+	if secret {
+		return RedactedValue
+	}
+	return value
+}
+
+// Where the value was set, e.g. "at /home/joe/.gitconfig:3 (global scope)".
+func (self ResolutionStep) where() string {
+	var out string
+	switch {
+	case self.Value.Origin.File != "" && self.Value.Origin.Line > 0:
+		out = fmt.Sprintf("at %s:%d", self.Value.Origin.File, self.Value.Origin.Line)
+	case self.Value.Origin.Line > 0:
+		out = fmt.Sprintf("at line %d", self.Value.Origin.Line)
+	default:
+		out = "by an override"
+	}
+	switch {
+	case self.Scoped && self.Included:
+		out += fmt.Sprintf(" (%s scope, included)", self.Scope)
+	case self.Scoped:
+		out += fmt.Sprintf(" (%s scope)", self.Scope)
+	case self.Included:
+		out += " (included)"
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"system": "[user]\n\temail = sys@x\n",
+		"global": "[user]\n\temail = home@x\n[include]\n\tpath = work\n",
+		"work":   "[user]\n\temail = work@x\n[remote \"origin\"]\n\tfetch = a\n",
+		"local":  "[remote \"origin\"]\n\tfetch = b\n",
+	})
+	set := NewConfigSet()
+	for scope, name := range map[Scope]string{ScopeSystem: "system", ScopeGlobal: "global", ScopeLocal: "local"} {
+		cfg, err := NewConfigFromFileOptions(filepath.Join(dir, name), ParseOptions{Includes: &IncludeOptions{}})
+		if err != nil {
+			t.Fatalf("Failed to read %s: %s", name, err)
+		}
+		set.Add(scope, cfg)
+	}
+	if err := set.ApplyOverrides([]string{"user.email=cli@x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := set.Merged().Explain("User.Email")
+	expect := []string{
+		"user.email set to 'sys@x' at " + filepath.Join(dir, "system") + ":2 (system scope)",
+		"user.email set to 'home@x' at " + filepath.Join(dir, "global") + ":2 (global scope), overriding 'sys@x' at " + filepath.Join(dir, "system") + ":2 (system scope)",
+		"user.email set to 'work@x' at " + filepath.Join(dir, "work") + ":2 (global scope, included), overriding 'home@x' at " + filepath.Join(dir, "global") + ":2 (global scope)",
+		"user.email set to 'cli@x' by an override (command scope), overriding 'work@x' at " + filepath.Join(dir, "work") + ":2 (global scope, included) (in effect)",
+	}
+	if len(steps) != len(expect) {
+		t.Fatalf("Expect %d steps, but got %+v", len(expect), steps)
+	}
+	for i, s := range steps {
+		if s.Effective != (i == len(steps)-1) {
+			t.Errorf("Expect only the last step in effect, but step %d is %v", i, s.Effective)
+		}
+		if s.Included != (i == 2) {
+			t.Errorf("Expect only step 2 to be included, but step %d is %v", i, s.Included)
+		}
+	}
+	for i, s := range steps {
+		if s.String() != expect[i] {
+			t.Errorf("Expect step %d to say:\n%s\nbut got:\n%s", i, expect[i], s)
+		}
+	}
+
+	steps = set.Explain("remote.origin.fetch")
+	if len(steps) != 2 || !steps[0].Effective || !steps[1].Effective || steps[1].Scope != ScopeLocal {
+		t.Errorf("Expect both fetch values in effect, but got %+v", steps)
+	}
+	if steps := set.Explain("user.name"); steps != nil {
+		t.Errorf("Expect nil for a key not set, but got %+v", steps)
+	}
+
+	cfg, _ := NewConfigFromString("[core]\n\tbare = false\n\tbare\n")
+	steps = cfg.Explain("core.bare")
+	if len(steps) != 2 || steps[1].Message != "core.bare given with no value at line 3, overriding 'false' at line 2 (in effect)" {
+		t.Errorf("Unexpected steps for a single config: %+v", steps)
+	}
+
+	cfg, _ = NewConfigFromString("[github]\n\ttoken = one\n\ttoken = two\n")
+	steps = cfg.Explain("github.token")
+	if len(steps) != 2 || steps[1].Message != "github.token set to '<redacted>' at line 3, overriding '<redacted>' at line 2 (in effect)" {
+		t.Errorf("Expect secrets redacted, but got %+v", steps)
+	}
+}
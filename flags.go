@@ -0,0 +1,54 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+// Sets the default of each flag in fs from the key prefix.<flag name>,
+// e.g. myapp.verbose for -verbose with prefix "myapp", so that settings in
+// the config apply unless given on the command line. Call it before
+// fs.Parse. The prefix may include a sub-section, e.g. "myapp.build".
+// Boolean flags take values as git reads them, so "yes", "on" or a key
+// with no value are true. Flags with names which cannot be keys (e.g.
+// holding '_') and flags with no value in the config are left alone.
+// Flags set here still count as unset for fs.Visit.
+// Stops at the first value the flag does not accept, returning an error
+// wrapping ErrInvalidValue.
+func (self *Config) BindFlagSet(fs *flag.FlagSet, prefix string) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		key := prefix + "." + f.Name
+		k, kerr := self.NormalizeKey(key)
+		if kerr != nil {
+			return
+		}
+		cv := self.GetValuesForKey(k)
+		if cv == nil || !cv.HasValues() {
+			return
+		}
+		value, _ := cv.GetString()
+		if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && bf.IsBoolFlag() {
+			b, _, berr := cv.GetBool()
+			if berr != nil {
+				err = fmt.Errorf("Key '%s' for flag -%s: %s: %w", key, f.Name, berr, ErrInvalidValue)
+				return
+			}
+			value = strconv.FormatBool(b)
+		}
+		if serr := f.Value.Set(value); serr != nil {
+			err = fmt.Errorf("Key '%s' value '%s' for flag -%s: %s: %w", key, value, f.Name, serr, ErrInvalidValue)
+			return
+		}
+		f.DefValue = value
+	})
+	return err
+}
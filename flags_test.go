@@ -0,0 +1,59 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestBindFlagSet(t *testing.T) {
+	config, err := NewConfigFromString("[myapp]\n\tverbose\n\tdry-run = no\n\tjobs = 4\n\tname = first\n\tname = last\n[myapp \"build\"]\n\ttimeout = 1m\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	fs := flag.NewFlagSet("myapp", flag.ContinueOnError)
+	verbose := fs.Bool("verbose", false, "")
+	dryRun := fs.Bool("dry-run", true, "")
+	jobs := fs.Int("jobs", 1, "")
+	name := fs.String("name", "", "")
+	other := fs.String("other_thing", "x", "")
+	if err := config.BindFlagSet(fs, "myapp"); err != nil {
+		t.Fatalf("Failed to bind flags: %s", err)
+	}
+	if err := fs.Parse([]string{"-jobs", "8"}); err != nil {
+		t.Fatalf("Failed to parse flags: %s", err)
+	}
+	if !*verbose || *dryRun || *jobs != 8 || *name != "last" || *other != "x" {
+		t.Errorf("Unexpected flags: verbose %v, dry-run %v, jobs %d, name %s, other %s", *verbose, *dryRun, *jobs, *name, *other)
+	}
+	if f := fs.Lookup("jobs"); f.DefValue != "4" {
+		t.Errorf("Expect the default shown to be 4, but got %s", f.DefValue)
+	}
+	set := 0
+	fs.Visit(func(*flag.Flag) { set++ })
+	if set != 1 {
+		t.Errorf("Expect only -jobs to count as set, but got %d flags", set)
+	}
+
+	fs = flag.NewFlagSet("build", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", time.Second, "")
+	if err := config.BindFlagSet(fs, "myapp.build"); err != nil || *timeout != time.Minute {
+		t.Errorf("Expect timeout of 1m from sub-section, but got %s (%v)", *timeout, err)
+	}
+
+	fs = flag.NewFlagSet("bad", flag.ContinueOnError)
+	fs.Int("name", 0, "")
+	if err := config.BindFlagSet(fs, "myapp"); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expect ErrInvalidValue for a value the flag rejects, but got %v", err)
+	}
+	fs = flag.NewFlagSet("bad", flag.ContinueOnError)
+	fs.Bool("name", false, "")
+	if err := config.BindFlagSet(fs, "myapp"); !errors.Is(err, ErrInvalidValue) || errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expect ErrInvalidValue for a value a bool flag rejects, but got %v", err)
+	}
+}
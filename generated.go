@@ -0,0 +1,194 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The helpers in this file are used by the reflection-free loaders emitted
+// by cmd/gitconfig-gen. They follow the same rules as Load: a missing value
+// is an error if required, otherwise the default is used if there is one,
+// otherwise the returned bool is false and the field should be left as is.
+
+// The gcDefault/gcRequired settings of a field.
+type FieldOptions struct {
+	Default     string
+	HaveDefault bool
+	Required    bool
+}
+
+// Prefixes a relative key with the namespace of the struct being loaded.
+func FieldKey(ns, key string) string {
+	if ns == "" {
+		return key
+	}
+	return ns + "." + key
+}
+
+// Gets the string to use for a field, the last value or the default.
+func StringField(cfg *Config, key, tpName string, opts FieldOptions) (string, bool, error) {
+	confVal := cfg.GetKeyValuesRaw(key)
+	if confVal == nil || !confVal.HasValues() {
+		if opts.Required {
+			return "", false, fmt.Errorf("Could not populate required %s no value for %s: %w", tpName, key, missingKey(tpName, key))
+		}
+		return opts.Default, opts.HaveDefault, nil
+	}
+	s, _ := confVal.GetString()
+	return s, true, nil
+}
+
+// Gets the signed integer to use for a field.
+func IntField(cfg *Config, key, tpName string, opts FieldOptions) (int64, bool, error) {
+	confVal := cfg.GetKeyValuesRaw(key)
+	if confVal == nil || !confVal.HasValues() {
+		s, ok, err := StringField(cfg, key, tpName, opts)
+		if !ok || err != nil {
+			return 0, false, err
+		}
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("Could not populate default %s field, default value %q did not parse as %s: %w: %w", tpName, s, tpName, ErrTypeMismatch, err)
+		}
+		return i, true, nil
+	}
+	i, _, err := confVal.GetInt()
+	return i, err == nil, err
+}
+
+// Gets the unsigned integer to use for a field.
+func UintField(cfg *Config, key, tpName string, opts FieldOptions) (uint64, bool, error) {
+	confVal := cfg.GetKeyValuesRaw(key)
+	if confVal == nil || !confVal.HasValues() {
+		s, ok, err := StringField(cfg, key, tpName, opts)
+		if !ok || err != nil {
+			return 0, false, err
+		}
+		i, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("Could not populate default %s field, default value %q did not parse as %s: %w: %w", tpName, s, tpName, ErrTypeMismatch, err)
+		}
+		return i, true, nil
+	}
+	i, _, err := confVal.GetUint()
+	return i, err == nil, err
+}
+
+// Gets the boolean to use for a field.
+func BoolField(cfg *Config, key, tpName string, opts FieldOptions) (bool, bool, error) {
+	confVal := cfg.GetKeyValuesRaw(key)
+	if confVal == nil || !confVal.HasValues() {
+		s, ok, err := StringField(cfg, key, tpName, opts)
+		if !ok || err != nil {
+			return false, false, err
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return false, false, fmt.Errorf("Could not populate default %s field, default value %q did not parse as %s: %w: %w", tpName, s, tpName, ErrTypeMismatch, err)
+		}
+		return b, true, nil
+	}
+	b, _, err := confVal.GetBool()
+	return b, err == nil, err
+}
+
+// Gets the duration to use for a field.
+func DurationField(cfg *Config, key, tpName string, opts FieldOptions) (time.Duration, bool, error) {
+	s, ok, err := StringField(cfg, key, tpName, opts)
+	if !ok || err != nil {
+		return 0, false, err
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, false, fmt.Errorf("Could not parse value '%s' as duration for %s: %w: %w\n", s, key, ErrTypeMismatch, err)
+	}
+	return d, true, nil
+}
+
+// Gets all the strings to use for a slice field, in file order.
+func StringsField(cfg *Config, key, tpName string, opts FieldOptions) ([]string, bool, error) {
+	confVal := cfg.GetKeyValuesRaw(key)
+	if confVal == nil || !confVal.HasValues() {
+		if opts.Required {
+			return nil, false, fmt.Errorf("Could not populate required %s no value for %s: %w", tpName, key, missingKey(tpName, key))
+		}
+		if !opts.HaveDefault {
+			return nil, false, nil
+		}
+		return []string{opts.Default}, true, nil
+	}
+	for _, v := range confVal.Entries {
+		if !v.HasValue {
+			return nil, false, fmt.Errorf("Could not populate %s null value for %s: %w", tpName, key, ErrTypeMismatch)
+		}
+	}
+	return confVal.ValuesAsStrings(), true, nil
+}
+
+// Gets all the signed integers to use for a slice field, in file order.
+func IntsField(cfg *Config, key, tpName string, opts FieldOptions) ([]int64, bool, error) {
+	strs, ok, err := StringsField(cfg, key, tpName, opts)
+	if !ok || err != nil {
+		return nil, false, err
+	}
+	out := make([]int64, len(strs))
+	for i, s := range strs {
+		if out[i], err = strconv.ParseInt(s, 10, 64); err != nil {
+			return nil, false, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+		}
+	}
+	return out, true, nil
+}
+
+// Gets the section name and the names of its subsections for a map field
+// keyed on subsection name. The key must be of form '<section>' or
+// '<section>.*'; if the key is '<section>.*.<key>' the final key is also
+// returned. The names are nil if the section does not exist.
+func SubSectionsField(cfg *Config, key, tpName string, opts FieldOptions) (string, string, []string, error) {
+	sName, sKey := "", ""
+	if out := strings.Split(key, ".*."); len(out) == 2 && out[1] != "" {
+		sName, sKey = out[0], out[1]
+	} else {
+		var err error
+		if sName, err = mapSectionName(key); err != nil {
+			return "", "", nil, fmt.Errorf("cannot populate field %s of type %s. %w", key, tpName, err)
+		}
+	}
+	section := cfg.LookupSection(sName)
+	if section == nil {
+		if opts.Required {
+			return "", "", nil, fmt.Errorf("cannot populate field %s of type %s. Required section '%s' was not present: %w: %w", key, tpName, sName, missingSection(tpName, sName), ErrSectionNotFound)
+		}
+		return sName, sKey, nil, nil
+	}
+	names := make([]string, 0, len(section.SubSections))
+	for name := range section.SubSections {
+		names = append(names, name)
+	}
+	return sName, sKey, names, nil
+}
+
+// Checks the section a nested struct field is loaded from is there if
+// the field is required. The key is the section, or section.subsection.
+func StructField(cfg *Config, key, tpName string, opts FieldOptions) error {
+	if !opts.Required {
+		return nil
+	}
+	s, ss := splitSectionPath(key)
+	if cfg.LookupValueSet(s, ss) == nil {
+		return fmt.Errorf("cannot populate field %s of type struct %s. Required section '%s' was not present: %w: %w", key, tpName, key, missingSection(tpName, key), ErrSectionNotFound)
+	}
+	return nil
+}
+
+// Gets the error to report for a gcSecret field which failed to load,
+// which wraps the same sentinel errors as err but never includes the value.
+func SecretFieldError(tpName, field string, err error) error {
+	return withholdSecret(tpName, field, err)
+}
@@ -0,0 +1,151 @@
+// Code generated by gitconfig-gen; DO NOT EDIT.
+
+package gitconfig_test
+
+import (
+	"fmt"
+
+	"github.com/misatosangel/gitconfig"
+)
+
+// LoadFromConfig loads GenPeople's gcKey tagged fields from cfg without reflection.
+func (self *GenPeople) LoadFromConfig(cfg *gitconfig.Config) error {
+	return self.loadFromConfigNS(cfg, "")
+}
+
+func (self *GenPeople) loadFromConfigNS(cfg *gitconfig.Config, ns string) error {
+	errs := gitconfig.LoadError{}
+	{
+		key := gitconfig.FieldKey(ns, "department.name")
+		if v, ok, err := gitconfig.StringField(cfg, key, "string", gitconfig.FieldOptions{}); err != nil {
+			errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "string", "Department", err)
+		} else if ok {
+			self.Department = v
+		}
+	}
+	{
+		key := gitconfig.FieldKey(ns, "department.floor")
+		if vals, ok, err := gitconfig.IntsField(cfg, key, "[]int", gitconfig.FieldOptions{}); err != nil {
+			errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "[]int", "Floors", err)
+		} else if ok {
+			for _, v := range vals {
+				self.Floors = append(self.Floors, int(v))
+			}
+		}
+	}
+	{
+		key := gitconfig.FieldKey(ns, "department.manager")
+		if err := self.Manager.loadFromConfigNS(cfg, key); err != nil {
+			errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "GenPerson", "Manager", err)
+		}
+	}
+	{
+		key := gitconfig.FieldKey(ns, "person.*")
+		if sName, sKey, names, err := gitconfig.SubSectionsField(cfg, key, "map[string]GenPerson", gitconfig.FieldOptions{}); err != nil {
+			errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "map[string]GenPerson", "People", err)
+		} else if names != nil {
+			self.People = make(map[string]GenPerson, len(names))
+			for _, name := range names {
+				if sKey != "" {
+					err = fmt.Errorf("cannot populate field %s of type %s. Key must be of form '<section>' or '<section>.*'", key, "map[string]GenPerson")
+					errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "map[string]GenPerson", "People", err)
+					break
+				}
+				var v GenPerson
+				if err := v.loadFromConfigNS(cfg, sName+"."+name); err != nil {
+					errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "map[string]GenPerson", "People", err)
+					break
+				}
+				self.People[name] = v
+			}
+		}
+	}
+	{
+		key := gitconfig.FieldKey(ns, "person.*.favouriteColour")
+		if sName, sKey, names, err := gitconfig.SubSectionsField(cfg, key, "map[string]string", gitconfig.FieldOptions{Default: "grey", HaveDefault: true}); err != nil {
+			errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "map[string]string", "Colours", err)
+		} else if names != nil {
+			self.Colours = make(map[string]string, len(names))
+			for _, name := range names {
+				if sKey == "" {
+					err = fmt.Errorf("cannot populate field %s of type %s. Key must be of form '<section>.*.<key>'", key, "map[string]string")
+					errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "map[string]string", "Colours", err)
+					break
+				}
+				v, _, err := gitconfig.StringField(cfg, sName+"."+name+"."+sKey, "string", gitconfig.FieldOptions{Default: "grey", HaveDefault: true})
+				if err != nil {
+					errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "map[string]string", "Colours", err)
+					break
+				}
+				self.Colours[name] = v
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// LoadFromConfig loads GenPerson's gcKey tagged fields from cfg without reflection.
+func (self *GenPerson) LoadFromConfig(cfg *gitconfig.Config) error {
+	return self.loadFromConfigNS(cfg, "")
+}
+
+func (self *GenPerson) loadFromConfigNS(cfg *gitconfig.Config, ns string) error {
+	errs := gitconfig.LoadError{}
+	{
+		key := gitconfig.FieldKey(ns, "name")
+		if v, ok, err := gitconfig.StringField(cfg, key, "string", gitconfig.FieldOptions{}); err != nil {
+			errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "string", "Name", err)
+		} else if ok {
+			self.Name = v
+		}
+	}
+	{
+		key := gitconfig.FieldKey(ns, "email")
+		if v, ok, err := gitconfig.StringField(cfg, key, "string", gitconfig.FieldOptions{Default: "someone@example.com", HaveDefault: true}); err != nil {
+			errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "string", "Email", err)
+		} else if ok {
+			self.Email = v
+		}
+	}
+	{
+		key := gitconfig.FieldKey(ns, "age")
+		if v, ok, err := gitconfig.UintField(cfg, key, "uint8", gitconfig.FieldOptions{Default: "5", HaveDefault: true}); err != nil {
+			errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "uint8", "Age", err)
+		} else if ok {
+			self.Age = uint8(v)
+		}
+	}
+	{
+		key := gitconfig.FieldKey(ns, "active")
+		if v, ok, err := gitconfig.BoolField(cfg, key, "bool", gitconfig.FieldOptions{}); err != nil {
+			errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "bool", "Active", err)
+		} else if ok {
+			self.Active = v
+		}
+	}
+	{
+		key := gitconfig.FieldKey(ns, "serviceLength")
+		if v, ok, err := gitconfig.DurationField(cfg, key, "time.Duration", gitconfig.FieldOptions{Default: "5m", HaveDefault: true}); err != nil {
+			errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "time.Duration", "ServiceLen", err)
+		} else if ok {
+			self.ServiceLen = v
+		}
+	}
+	{
+		key := gitconfig.FieldKey(ns, "alias")
+		if vals, ok, err := gitconfig.StringsField(cfg, key, "[]string", gitconfig.FieldOptions{}); err != nil {
+			errs[key] = fmt.Errorf("Could not populate %s field %q: %w", "[]string", "Aliases", err)
+		} else if ok {
+			for _, v := range vals {
+				self.Aliases = append(self.Aliases, v)
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
@@ -0,0 +1,72 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/misatosangel/gitconfig"
+)
+
+//go:generate go run ./cmd/gitconfig-gen -type GenPeople -output generated_example_gitconfig_test.go generated_example_test.go
+
+type GenPeople struct {
+	Department string               `gcKey:"department.name"`
+	Floors     []int                `gcKey:"department.floor"`
+	Manager    GenPerson            `gcKey:"department.manager"`
+	People     map[string]GenPerson `gcKey:"person.*"`
+	Colours    map[string]string    `gcKey:"person.*.favouriteColour" gcDefault:"grey"`
+}
+
+type GenPerson struct {
+	Name       string        `gcKey:"name"`
+	Email      string        `gcKey:"email" gcDefault:"someone@example.com"`
+	Age        uint8         `gcKey:"age" gcDefault:"5"`
+	Active     bool          `gcKey:"active"`
+	ServiceLen time.Duration `gcKey:"serviceLength" gcDefault:"5m"`
+	Aliases    []string      `gcKey:"alias"`
+}
+
+func TestGeneratedLoaderMatchesLoad(t *testing.T) {
+	configStr := "[department]\n" +
+		"    name = Somewhere\n" +
+		"    floor = 1\n" +
+		"    floor = 2\n" +
+		"[department \"manager\"]\n" +
+		"    name = Boss\n" +
+		"[person \"Joe\"]\n" +
+		"    name = Joe Bloggs\n" +
+		"    age = 23\n" +
+		"    active = yes\n" +
+		"    alias = Joseph\n" +
+		"    alias = JB\n" +
+		"    favouriteColour = blue\n" +
+		"[person \"Joanne\"]\n" +
+		"    name = Joanne Bloggs\n" +
+		"    serviceLength = 1024h\n"
+	config, err := gitconfig.NewConfigFromString(configStr)
+	if err != nil {
+		t.Fatalf("Failed to parse config:\n===\n%s\n===\n%s", configStr, err.Error())
+	}
+	var viaLoad, viaGen GenPeople
+	if err := config.Load(&viaLoad); err != nil {
+		t.Fatalf("Failed to Load: %s", err)
+	}
+	if err := viaGen.LoadFromConfig(config); err != nil {
+		t.Fatalf("Failed to LoadFromConfig: %s", err)
+	}
+	if !reflect.DeepEqual(viaLoad, viaGen) {
+		t.Errorf("Generated loader differs from Load:\nLoad:          %+v\nLoadFromConfig: %+v\n", viaLoad, viaGen)
+	}
+
+	config, _ = gitconfig.NewConfigFromString("[person \"Joe\"]\n    age = old\n")
+	errLoad := config.Load(&viaLoad)
+	errGen := viaGen.LoadFromConfig(config)
+	if errLoad == nil || errGen == nil {
+		t.Errorf("Expect both loaders to fail on a bad age, got Load: %v LoadFromConfig: %v\n", errLoad, errGen)
+	}
+}
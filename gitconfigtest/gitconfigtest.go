@@ -0,0 +1,246 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+// Package gitconfigtest has helpers for testing code which reads or writes
+// git config with gitconfig: temporary config files, assertions on values
+// which show what differs, golden file comparison and a fake environment
+// (home directory and GIT_CONFIG_* variables) so tests never see the real
+// user's config.
+//
+//	func TestEditor(t *testing.T) {
+//		env := gitconfigtest.NewEnv(t)
+//		env.WriteGlobal("[core]\n\teditor = vi\n")
+//		cfg := gitconfigtest.Parse(t, "[core]\n\tpager = less\n")
+//		gitconfigtest.AssertValue(t, cfg, "core.pager", "less")
+//	}
+package gitconfigtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/misatosangel/gitconfig"
+)
+
+// Set to a non-empty value to have Golden write the files it compares
+// against rather than check them, e.g. `GITCONFIGTEST_UPDATE=1 go test`.
+const UpdateEnv = "GITCONFIGTEST_UPDATE"
+
+// Writes data to a config file in a new temporary directory, removed when
+// the test ends, and returns its path.
+func WriteConfig(t testing.TB, data string) string {
+	t.Helper()
+	dir := WriteDir(t, map[string]string{"config": data})
+	return filepath.Join(dir, "config")
+}
+
+// Creates a temporary directory holding the files, by path relative to it
+// (e.g. "repo/.git/config"), removed when the test ends.
+func WriteDir(t testing.TB, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		writeFile(t, filepath.Join(dir, filepath.FromSlash(name)), data)
+	}
+	return dir
+}
+
+func writeFile(t testing.TB, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Parses config text, failing the test if it does not parse.
+func Parse(t testing.TB, data string) *gitconfig.Config {
+	t.Helper()
+	cfg, err := gitconfig.NewConfigFromString(data)
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	return cfg
+}
+
+// Reads a config file, failing the test if it cannot be read.
+func Load(t testing.TB, path string) *gitconfig.Config {
+	t.Helper()
+	cfg, err := gitconfig.NewConfigFromFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config %s: %s", path, err)
+	}
+	return cfg
+}
+
+// Checks the value in effect for the key (its last value) is want.
+func AssertValue(t testing.TB, cfg *gitconfig.Config, key, want string) {
+	t.Helper()
+	cv := cfg.GetKeyValuesRaw(key)
+	if cv == nil || !cv.HasValues() {
+		t.Errorf("%s: want %q, but the key is not set", key, want)
+		return
+	}
+	if got, _ := cv.GetString(); got != want {
+		t.Errorf("%s: want %q, but got %q", key, want, got)
+	}
+}
+
+// Checks the key has exactly the values given, in order. With no values
+// it checks the key is not set.
+func AssertValues(t testing.TB, cfg *gitconfig.Config, key string, want ...string) {
+	t.Helper()
+	var got []string
+	if cv := cfg.GetKeyValuesRaw(key); cv != nil && cv.HasValues() {
+		got = cv.ValuesAsStrings()
+	}
+	if !equalValues(got, want) {
+		t.Errorf("%s: want %s, but got %s", key, formatValues(want), formatValues(got))
+	}
+}
+
+// Checks two configs hold the same values, listing each key which differs.
+// Comments, layout and where values were read from are not compared.
+func AssertEqual(t testing.TB, got, want *gitconfig.Config) {
+	t.Helper()
+	if msg := diffConfigs(got, want); msg != "" {
+		t.Errorf("Configs differ (- want, + got):\n%s", msg)
+	}
+}
+
+func diffConfigs(got, want *gitconfig.Config) string {
+	changes := gitconfig.Diff(want, got)
+	if len(changes) == 0 {
+		return ""
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	var sb strings.Builder
+	for _, c := range changes {
+		if c.Old != nil {
+			fmt.Fprintf(&sb, "-%s = %s\n", c.Key, formatValues(c.Old))
+		}
+		if c.New != nil {
+			fmt.Fprintf(&sb, "+%s = %s\n", c.Key, formatValues(c.New))
+		}
+	}
+	return sb.String()
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func formatValues(values []string) string {
+	if values == nil {
+		return "(not set)"
+	}
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return "[" + strings.Join(quoted, " ") + "]"
+}
+
+// Compares got with the golden file at path (usually under testdata),
+// showing the lines which differ. With UpdateEnv set the file is written
+// with got instead, to create it or accept a change.
+func Golden(t testing.TB, path, got string) {
+	t.Helper()
+	if os.Getenv(UpdateEnv) != "" {
+		writeFile(t, path, got)
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (set %s=1 to create it): %s", UpdateEnv, err)
+	}
+	if msg := gitconfig.UnifiedDiff(path, string(data), got); msg != "" {
+		t.Errorf("Output differs from %s (- want, + got; set %s=1 to update):\n%s", path, UpdateEnv, msg)
+	}
+}
+
+// A fake environment for a test: a temporary home directory, with
+// HOME (and USERPROFILE, for Windows) pointing at it and the variables
+// git reads config locations and values from cleared, so neither git nor
+// gitconfig see the real user's settings. Everything is put back when the
+// test ends. Tests using it cannot run in parallel.
+type Env struct {
+	t     testing.TB
+	Home  string
+	count int // values added with Override
+}
+
+// Sets up the fake environment, see Env.
+func NewEnv(t testing.TB) *Env {
+	t.Helper()
+	home := t.TempDir()
+	for name, value := range map[string]string{
+		"HOME":                home,
+		"USERPROFILE":         home,
+		"XDG_CONFIG_HOME":     "",
+		"GIT_CONFIG_GLOBAL":   "",
+		"GIT_CONFIG_SYSTEM":   os.DevNull,
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_CONFIG_COUNT":    "",
+	} {
+		t.Setenv(name, value)
+	}
+	return &Env{t: t, Home: home}
+}
+
+// Writes ~/.gitconfig, returning its path.
+func (self *Env) WriteGlobal(data string) string {
+	self.t.Helper()
+	path := filepath.Join(self.Home, ".gitconfig")
+	writeFile(self.t, path, data)
+	return path
+}
+
+// Writes ~/.config/git/config, the XDG global config, returning its path.
+func (self *Env) WriteXDG(data string) string {
+	self.t.Helper()
+	path := filepath.Join(self.Home, ".config", "git", "config")
+	writeFile(self.t, path, data)
+	return path
+}
+
+// Writes a file under the home directory, by path relative to it, e.g.
+// ".gitconfig.d/work", returning its path.
+func (self *Env) WriteFile(name, data string) string {
+	self.t.Helper()
+	path := filepath.Join(self.Home, filepath.FromSlash(name))
+	writeFile(self.t, path, data)
+	return path
+}
+
+// Sets an environment variable for the rest of the test.
+func (self *Env) Setenv(name, value string) {
+	self.t.Setenv(name, value)
+}
+
+// Adds a value the way `git -c` passes them on, through GIT_CONFIG_COUNT
+// and GIT_CONFIG_KEY_<n> / GIT_CONFIG_VALUE_<n>, as read by
+// gitconfig.EnvSource.
+func (self *Env) Override(key, value string) {
+	n := strconv.Itoa(self.count)
+	self.t.Setenv("GIT_CONFIG_KEY_"+n, key)
+	self.t.Setenv("GIT_CONFIG_VALUE_"+n, value)
+	self.count++
+	self.t.Setenv("GIT_CONFIG_COUNT", strconv.Itoa(self.count))
+}
@@ -0,0 +1,100 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfigtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/misatosangel/gitconfig"
+)
+
+// Records failures rather than failing the test, to check the messages.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (self *recorder) Helper() {}
+
+func (self *recorder) Errorf(format string, args ...any) {
+	self.errors = append(self.errors, fmt.Sprintf(format, args...))
+}
+
+func (self *recorder) Fatalf(format string, args ...any) {
+	self.Errorf(format, args...)
+}
+
+func TestAssertions(t *testing.T) {
+	cfg := Parse(t, "[user]\n\tname = Joe\n[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n")
+	AssertValue(t, cfg, "user.name", "Joe")
+	AssertValues(t, cfg, "remote.origin.fetch", "a", "b")
+	AssertValues(t, cfg, "user.email")
+	AssertEqual(t, cfg, Load(t, WriteConfig(t, cfg.String())))
+
+	r := &recorder{TB: t}
+	AssertValue(r, cfg, "user.name", "Jane")
+	AssertValue(r, cfg, "user.email", "x")
+	AssertValues(r, cfg, "remote.origin.fetch", "a")
+	AssertEqual(r, cfg, Parse(t, "[user]\n\tname = Jane\n\temail = j@x\n[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n"))
+	expect := []string{
+		`user.name: want "Jane", but got "Joe"`,
+		`user.email: want "x", but the key is not set`,
+		`remote.origin.fetch: want ["a"], but got ["a" "b"]`,
+		"Configs differ (- want, + got):\n-user.email = [\"j@x\"]\n-user.name = [\"Jane\"]\n+user.name = [\"Joe\"]\n",
+	}
+	if strings.Join(r.errors, "\n") != strings.Join(expect, "\n") {
+		t.Errorf("Expect failures:\n%s\nbut got:\n%s", strings.Join(expect, "\n"), strings.Join(r.errors, "\n"))
+	}
+}
+
+func TestGolden(t *testing.T) {
+	path := filepath.Join(WriteDir(t, nil), "testdata", "out.golden")
+	t.Setenv(UpdateEnv, "1")
+	Golden(t, path, "[core]\n\teditor = vi\n")
+	t.Setenv(UpdateEnv, "")
+	Golden(t, path, "[core]\n\teditor = vi\n")
+
+	r := &recorder{TB: t}
+	Golden(r, path, "[core]\n\tpager = less\n\teditor = vi\n")
+	if len(r.errors) != 1 || !strings.HasSuffix(r.errors[0], "@@ -1,2 +1,3 @@\n [core]\n+\tpager = less\n \teditor = vi\n") {
+		t.Errorf("Unexpected golden failure: %q", r.errors)
+	}
+	r.errors = nil
+	Golden(r, path+".missing", "")
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], UpdateEnv) {
+		t.Errorf("Expect missing golden file to say how to create it, but got %q", r.errors)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	env := NewEnv(t)
+	if home, _ := os.UserHomeDir(); home != env.Home {
+		t.Errorf("Expect home %s, but got %s", env.Home, home)
+	}
+	global := env.WriteGlobal("[user]\n\tname = Joe\n")
+	if path, err := gitconfig.GlobalConfigWritePath(); err != nil || path != global {
+		t.Errorf("Expect %s as the global config, but got %s (%v)", global, path, err)
+	}
+	xdg := env.WriteXDG("[core]\n\teditor = vi\n")
+	if paths, _ := gitconfig.GlobalConfigPaths(); len(paths) != 2 || paths[0] != xdg {
+		t.Errorf("Expect %s read first, but got %v", xdg, paths)
+	}
+	env.Override("user.name", "Jane")
+	env.Override("core.pager", "less")
+	cfg, err := gitconfig.EnvSource(nil).Load(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to read environment: %s", err)
+	}
+	AssertValue(t, cfg, "user.name", "Jane")
+	AssertValue(t, cfg, "core.pager", "less")
+	if path := env.WriteFile("work/config", ""); path != filepath.Join(env.Home, "work", "config") {
+		t.Errorf("Unexpected path %s", path)
+	}
+}
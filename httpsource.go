@@ -0,0 +1,114 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Options for HTTPSource.
+type HTTPOptions struct {
+	Client  *http.Client  // http.DefaultClient if nil
+	Timeout time.Duration // for each fetch, 30 seconds if zero
+	Header  http.Header   // added to each request, e.g. for authorization
+	// Bounds on the document, as for ParseOptions.Limits. MaxBytes also
+	// caps what is downloaded, 16 MiB if zero.
+	Limits ParseLimits
+}
+
+const httpMaxBytes = 16 << 20
+
+// A config document fetched over HTTP(S), e.g. a baseline distributed to
+// a fleet of machines which their own files then override (put it first
+// in a Chain). If the server gives an ETag, the document is only fetched
+// again once it has changed; until then the copy already parsed is used.
+// Any status other than 200, or 304 for a cached copy, is an error wrapping
+// ErrHTTPStatus. A larger document than allowed wraps ErrLimitExceeded.
+func HTTPSource(url string, opts HTTPOptions) Source {
+	return &httpSource{url: url, opts: opts}
+}
+
+type httpSource struct {
+	url  string
+	opts HTTPOptions
+
+	mu     sync.Mutex
+	etag   string
+	cached *Config
+}
+
+// Gets the URL without any user name or password in it.
+func (self *httpSource) Name() string {
+	u, err := url.Parse(self.url)
+	if err != nil || u.User == nil {
+		return self.url
+	}
+	u.User = nil
+	return u.String()
+}
+
+// Gives a config sharing nothing with the cached copy, so it may be
+// modified.
+func (self *httpSource) Load(ctx context.Context) (*Config, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	timeout := self.opts.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, self.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range self.opts.Header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	if self.cached != nil {
+		req.Header.Set("If-None-Match", self.etag)
+	}
+	client := self.opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && self.cached != nil:
+		return self.cached.clone(), nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("Fetching %s gave status %s: %w", self.Name(), resp.Status, ErrHTTPStatus)
+	}
+	max := int64(self.opts.Limits.MaxBytes)
+	if max <= 0 {
+		max = httpMaxBytes
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("Fetching %s gave more than %d bytes: %w", self.Name(), max, ErrLimitExceeded)
+	}
+	cfg, err := NewConfigFromStringOptions(string(data), ParseOptions{Limits: self.opts.Limits})
+	if err != nil {
+		return nil, err
+	}
+	self.etag, self.cached = resp.Header.Get("ETag"), nil
+	if self.etag != "" {
+		self.cached = cfg.clone()
+	}
+	return cfg, nil
+}
@@ -0,0 +1,485 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Settings for following includes, see ParseOptions.Includes.
+//
+// As git does, the values of an included file are read as if they were
+// in place of the include.path (or includeIf.<condition>.path) naming it,
+// so values after it override those included. Relative paths are relative
+// to the directory of the including file and a leading "~" is expanded as
+// by ExpandPath, so on Windows drive letter and UNC paths work too.
+// Included files which do not exist are skipped.
+//
+// Of the includeIf conditions "gitdir:", "gitdir/i:", "onbranch:" and
+// "hasconfig:remote.*.url:" are understood, the others are never true.
+// On Windows '\' separates in gitdir patterns too.
+type IncludeOptions struct {
+	// The repository's git directory, e.g. "/src/app/.git", for gitdir
+	// conditions, which are false if it is empty.
+	GitDir string
+	// The short name of the checked out branch, e.g. "main", for onbranch
+	// conditions, which are false if it is empty.
+	Branch string
+}
+
+// As git, which stops include loops with the same limit.
+const maxIncludeDepth = 10
+
+// Reads files with their includes, keeping each file's parse so that
+// reloading only parses again files which have changed.
+type includeLoader struct {
+	opts  ParseOptions
+	cache map[string]*fileParse
+	// the remote urls for hasconfig conditions, which are false while nil
+	remoteURLs []string
+	hasConfig  bool // a hasconfig condition was seen
+}
+
+// A file split at each include in it.
+type fileParse struct {
+	stamp fileStamp
+	parts []filePart
+}
+
+// The values read up to an include (or the end of the file), and the
+// include.
+type filePart struct {
+	cfg       *Config
+	condition string // the includeIf sub-section, empty for include.path
+	path      string // as written, empty for the values after the last include
+}
+
+func newIncludeLoader(opts ParseOptions) *includeLoader {
+	return &includeLoader{opts: opts, cache: make(map[string]*fileParse, 5)}
+}
+
+// Reads the file and everything it includes.
+func (self *includeLoader) load(file string) (*Config, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, err
+	}
+	out, err := self.twoPass(func(out *Config) error {
+		out.source = file
+		return self.include(out, file, 0, false)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// forget files no longer included
+	for cached := range self.cache {
+		if !slices.Contains(out.sources, cached) {
+			delete(self.cache, cached)
+		}
+	}
+	return out, nil
+}
+
+// Reads the config text and everything it includes. Relative include
+// paths are an error as there is no file for them to be relative to.
+func (self *includeLoader) loadString(data string) (*Config, error) {
+	fp, err := parseParts(strings.NewReader(data), "", self.opts)
+	if err != nil {
+		return nil, err
+	}
+	return self.twoPass(func(out *Config) error {
+		return self.assemble(out, fp, "", 0, false)
+	})
+}
+
+// Reads a config, then if it has hasconfig conditions reads it again with
+// them matched against the remote urls of the first read, as git does.
+// The remote urls are those of the whole config, not just those before the
+// condition, so files included by hasconfig must not set any.
+func (self *includeLoader) twoPass(read func(out *Config) error) (*Config, error) {
+	self.remoteURLs, self.hasConfig = nil, false
+	out := self.newConfig()
+	if err := read(out); err != nil {
+		return nil, err
+	}
+	if !self.hasConfig {
+		return out, nil
+	}
+	self.remoteURLs = remoteURLs(out)
+	defer func() { self.remoteURLs = nil }()
+	out = self.newConfig()
+	if err := read(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Gets the values of every remote.<name>.url.
+func remoteURLs(cfg *Config) []string {
+	out := make([]string, 0, 2)
+	s := cfg.Sections["remote"]
+	if s == nil {
+		return out
+	}
+	for _, ss := range s.SubSections {
+		if cv := ss.Values["url"]; cv != nil {
+			for _, e := range cv.Entries {
+				if e.HasValue {
+					out = append(out, e.Value)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (self *includeLoader) newConfig() *Config {
+	out := NewConfig()
+	out.options = self.opts
+	out.includes = self
+	return out
+}
+
+// Adds the values of the file, and those it includes, to out.
+// viaHasConfig is set for files included, directly or not, by a hasconfig
+// condition.
+func (self *includeLoader) include(out *Config, file string, depth int, viaHasConfig bool) error {
+	fp, err := self.parse(file)
+	if err != nil {
+		var pe *PartialParseError
+		if errors.As(err, &pe) {
+			// give what was read from every file, not just this one
+			out.Merge(pe.Config)
+			pe.Config = out
+		}
+		return err
+	}
+	if fp == nil {
+		return nil
+	}
+	if !slices.Contains(out.sources, file) {
+		out.sources = append(out.sources, file)
+	}
+	return self.assemble(out, fp, file, depth, viaHasConfig)
+}
+
+func (self *includeLoader) assemble(out *Config, fp *fileParse, file string, depth int, viaHasConfig bool) error {
+	for _, part := range fp.parts {
+		if viaHasConfig && self.remoteURLs != nil && len(remoteURLs(part.cfg)) > 0 {
+			return fmt.Errorf("Remote urls cannot be set in '%s', included by includeIf.hasconfig:remote.*.url: %w", file, ErrInvalidValue)
+		}
+		out.Merge(part.cfg)
+		if part.path == "" {
+			continue
+		}
+		if part.condition != "" && !self.matches(part.condition, file) {
+			continue
+		}
+		if depth >= maxIncludeDepth {
+			return fmt.Errorf("Cannot include '%s' from '%s', more than %d includes deep: %w", part.path, file, maxIncludeDepth, ErrIncludeDepth)
+		}
+		path, err := includePath(part.path, file)
+		if err != nil {
+			return err
+		}
+		reportInclude(file, path)
+		hasConfig := viaHasConfig || strings.HasPrefix(part.condition, "hasconfig:")
+		if err := self.include(out, path, depth+1, hasConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gets the file's parse, from the cache if it has not changed since.
+// The result is nil if the file does not exist.
+func (self *includeLoader) parse(file string) (*fileParse, error) {
+	stamp := statFile(file)
+	if !stamp.exists {
+		delete(self.cache, file)
+		return nil, nil
+	}
+	if fp := self.cache[file]; fp != nil && fp.stamp == stamp {
+		reportCache(CacheIncludes, true)
+		return fp, nil
+	}
+	reportCache(CacheIncludes, false)
+	fh, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer fh.Close()
+	fp, err := parseParts(fh, file, self.opts)
+	if err != nil {
+		return nil, err
+	}
+	fp.stamp = stamp
+	self.cache[file] = fp
+	return fp, nil
+}
+
+func parseParts(r io.Reader, file string, opts ParseOptions) (*fileParse, error) {
+	parts := make([]filePart, 0, 2)
+	p := Parser{
+		Reader:  bufio.NewScanner(r),
+		Config:  NewConfig(),
+		Options: opts,
+		file:    file,
+		parts:   &parts,
+	}
+	if err := p.Read(); err != nil {
+		var pe *PartialParseError
+		if errors.As(err, &pe) && len(parts) > 0 {
+			whole := NewConfig()
+			for _, part := range parts {
+				whole.Merge(part.cfg)
+			}
+			whole.Merge(pe.Config)
+			pe.Config = whole
+		}
+		return nil, err
+	}
+	parts = append(parts, filePart{cfg: p.Config})
+	return &fileParse{parts: parts}, nil
+}
+
+// Ends the current part if the key just read is an include.
+func (self *Parser) checkInclude(key, value string) {
+	if self.parts == nil || value == "" || !strings.EqualFold(key, "path") {
+		return
+	}
+	condition := ""
+	switch {
+	case strings.EqualFold(self.section, "include") && self.subSection == "":
+	case strings.EqualFold(self.section, "includeif") && self.subSection != "":
+		condition = self.subSection
+	default:
+		return
+	}
+	*self.parts = append(*self.parts, filePart{cfg: self.Config, condition: condition, path: value})
+	next := NewConfig()
+	next.options = self.Options
+	next.blockCount = self.Config.blockCount // duplicate sections span parts
+	self.Config = next
+}
+
+// Resolves an include path given in the file from.
+func includePath(path, from string) (string, error) {
+	path, err := ExpandPath(path)
+	if err != nil {
+		return "", err
+	}
+	if hostPaths.isAbs(path) {
+		return filepath.Clean(path), nil
+	}
+	if from == "" {
+		return "", fmt.Errorf("Cannot include relative path '%s' from config not read from a file: %w", path, ErrInvalidValue)
+	}
+	return filepath.Join(filepath.Dir(from), path), nil
+}
+
+// Reports whether an includeIf condition holds for a file included from
+// the file from.
+func (self *includeLoader) matches(condition, from string) bool {
+	kind, pattern, ok := strings.Cut(condition, ":")
+	if !ok {
+		return false
+	}
+	switch kind {
+	case "gitdir":
+		return self.gitDirMatches(pattern, from, false)
+	case "gitdir/i":
+		return self.gitDirMatches(pattern, from, true)
+	case "onbranch":
+		branch := self.opts.Includes.Branch
+		if branch == "" {
+			return false
+		}
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "**"
+		}
+		return wildmatch(pattern, branch)
+	case "hasconfig":
+		self.hasConfig = true
+		glob, ok := strings.CutPrefix(pattern, "remote.*.url:")
+		if !ok {
+			return false
+		}
+		for _, url := range self.remoteURLs {
+			if wildmatch(glob, url) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Matches a gitdir pattern as git does: "~/" is the home directory, "./"
+// the directory of the including file, a pattern not starting with either
+// or with '/' may match anywhere, and one ending in '/' matches everything
+// below. The git directory matches if it or its real path does.
+func (self *includeLoader) gitDirMatches(pattern, from string, fold bool) bool {
+	gitDir := self.opts.Includes.GitDir
+	if gitDir == "" {
+		return false
+	}
+	pattern, ok := hostPaths.gitDirPattern(pattern, from)
+	if !ok {
+		return false
+	}
+	dirs := []string{gitDir}
+	if real, err := filepath.EvalSymlinks(gitDir); err == nil && real != gitDir {
+		dirs = append(dirs, real)
+	}
+	if fold {
+		pattern = strings.ToLower(pattern)
+	}
+	for _, dir := range dirs {
+		dir = filepath.ToSlash(dir)
+		if fold {
+			dir = strings.ToLower(dir)
+		}
+		if wildmatch(pattern, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches a glob the way git's wildmatch does for paths: '*' and '?' do not
+// match '/', "**/" matches any number of leading directories, "/**" all
+// that is below and "[...]" a set of characters (negated by '!' or '^').
+// A backslash makes the next character literal.
+func wildmatch(pattern, name string) bool {
+	for len(pattern) > 0 {
+		switch c := pattern[0]; c {
+		case '*':
+			if strings.HasPrefix(pattern, "**") {
+				rest := strings.TrimLeft(pattern, "*")
+				if rest == "" {
+					return true
+				}
+				if rest[0] == '/' {
+					// zero or more whole directories
+					rest = rest[1:]
+					for i := 0; ; {
+						if wildmatch(rest, name[i:]) {
+							return true
+						}
+						j := strings.IndexByte(name[i:], '/')
+						if j < 0 {
+							return false
+						}
+						i += j + 1
+					}
+				}
+				for i := 0; i <= len(name); i++ {
+					if wildmatch(rest, name[i:]) {
+						return true
+					}
+				}
+				return false
+			}
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if wildmatch(rest, name[i:]) {
+					return true
+				}
+				if i < len(name) && name[i] == '/' {
+					return false
+				}
+			}
+			return false
+		case '?':
+			if name == "" || name[0] == '/' {
+				return false
+			}
+		case '[':
+			if name == "" {
+				return false
+			}
+			width, ok := matchClass(pattern, name[0])
+			if width == 0 {
+				// no closing ']', a literal '['
+				if name[0] != '[' {
+					return false
+				}
+				width = 1
+			} else if !ok {
+				return false
+			}
+			pattern, name = pattern[width:], name[1:]
+			continue
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+				c = pattern[0]
+			}
+			fallthrough
+		default:
+			if name == "" || name[0] != c {
+				return false
+			}
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return name == ""
+}
+
+// Matches c against the "[...]" set at the start of pattern, returning the
+// set's length, or 0 if it is not closed.
+func matchClass(pattern string, c byte) (int, bool) {
+	i := 1
+	negate := false
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negate = true
+		i++
+	}
+	matched := false
+	for first := true; i < len(pattern); first = false {
+		lo := pattern[i]
+		if lo == ']' && !first {
+			return i + 1, matched != negate && c != '/'
+		}
+		if lo == '\\' && i+1 < len(pattern) {
+			i++
+			lo = pattern[i]
+		}
+		hi := lo
+		if i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']' {
+			hi = pattern[i+2]
+			i += 2
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+		i++
+	}
+	return 0, false
+}
+
+// Gets every file values were read from: the file the config was read
+// from and any it included, in the order first read, or for a merged config
+// those of each config merged. Useful to know which files to watch for
+// changes; ReloadFromFile only parses again those which have changed.
+// The result is nil for a config not read from any file.
+func (self *Config) SourceFiles() []string {
+	if len(self.sources) > 0 {
+		return append([]string(nil), self.sources...)
+	}
+	if self.source != "" {
+		return []string{self.source}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config":          "[user]\n\tname = Main\n\temail = main@x\n[include]\n\tpath = inc/user\n\tpath = missing\n[user]\n\temail = last@x\n",
+		"inc/user":        "[user]\n\tname = Included\n\temail = inc@x\n[include]\n\tpath = ../more\n",
+		"more":            "[core]\n\teditor = vim\n",
+		"looped/config":   "[include]\n\tpath = config\n",
+		"relative/config": "[include]\n\tpath = inc\n",
+		"relative/inc":    "[a]\n\tb = c\n",
+	})
+	main := filepath.Join(dir, "config")
+	config, err := NewConfigFromFileOptions(main, ParseOptions{Includes: &IncludeOptions{}})
+	if err != nil {
+		t.Fatalf("Failed to read config: %s", err)
+	}
+	testValue(t, config, "user.name", "Included", true)
+	testValue(t, config, "user.email", "last@x", true)
+	testValue(t, config, "core.editor", "vim", true)
+	if got := config.GetKeyValuesStrings("user.email"); !reflect.DeepEqual(got, []string{"main@x", "inc@x", "last@x"}) {
+		t.Errorf("Expect included values in place of the include, but got %v", got)
+	}
+	if e, _ := config.GetKeyValuesRaw("core.editor").At(0); e.Origin.File != filepath.Join(dir, "more") || e.Origin.Line != 2 {
+		t.Errorf("Expect included value's origin to be its file, but got %+v", e.Origin)
+	}
+	expect := []string{main, filepath.Join(dir, "inc/user"), filepath.Join(dir, "more")}
+	if got := config.SourceFiles(); !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expect source files %v, but got %v", expect, got)
+	}
+
+	plain, _ := NewConfigFromFile(main)
+	testValue(t, plain, "user.name", "Main", true)
+	if got := plain.SourceFiles(); !reflect.DeepEqual(got, []string{main}) {
+		t.Errorf("Expect only the main file without includes, but got %v", got)
+	}
+
+	_, err = NewConfigFromFileOptions(filepath.Join(dir, "looped/config"), ParseOptions{Includes: &IncludeOptions{}})
+	if !errors.Is(err, ErrIncludeDepth) {
+		t.Errorf("Expect include loop to fail, but got: %v", err)
+	}
+	if _, err := NewConfigFromStringOptions("[include]\n\tpath = inc\n", ParseOptions{Includes: &IncludeOptions{}}); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expect relative include from a string to fail, but got: %v", err)
+	}
+	config, err = NewConfigFromStringOptions("[include]\n\tpath = "+filepath.Join(dir, "more")+"\n", ParseOptions{Includes: &IncludeOptions{}})
+	if err != nil {
+		t.Fatalf("Failed to read config: %s", err)
+	}
+	testValue(t, config, "core.editor", "vim", true)
+}
+
+func TestIncludeReload(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config": "[include]\n\tpath = inc\n",
+		"inc":    "[user]\n\tname = Joe\n",
+	})
+	main := filepath.Join(dir, "config")
+	config, err := NewConfigFromFileOptions(main, ParseOptions{Includes: &IncludeOptions{}})
+	if err != nil {
+		t.Fatalf("Failed to read config: %s", err)
+	}
+	mainParse := config.includes.cache[main]
+	writeFiles(t, dir, map[string]string{"inc": "[user]\n\tname = Joseph\n"})
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "inc"), future, future)
+	changes, err := config.ReloadFromFile()
+	if err != nil {
+		t.Fatalf("Failed to reload: %s", err)
+	}
+	if len(changes) != 1 || changes[0].Key != "user.name" {
+		t.Errorf("Expect user.name to change, but got %v", changes)
+	}
+	testValue(t, config, "user.name", "Joseph", true)
+	if config.includes.cache[main] != mainParse {
+		t.Errorf("Expect unchanged main file not to be parsed again")
+	}
+}
+
+func TestIncludeIf(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config": "[includeIf \"gitdir:work/\"]\n\tpath = work\n" +
+			"[includeIf \"gitdir/i:~/CASE/.git\"]\n\tpath = case\n" +
+			"[includeIf \"gitdir:./local/**/.git\"]\n\tpath = local\n" +
+			"[includeIf \"onbranch:release/\"]\n\tpath = release\n" +
+			"[includeIf \"unknown:x\"]\n\tpath = never\n",
+		"work":    "[user]\n\temail = me@work\n",
+		"case":    "[user]\n\tname = Case\n",
+		"local":   "[core]\n\tlocal = true\n",
+		"release": "[push]\n\tdefault = none\n",
+		"never":   "[never]\n\tx = 1\n",
+	})
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	tests := []struct {
+		gitDir, branch string
+		expect         []string
+	}{
+		{"/src/work/app/.git", "", []string{"user.email"}},
+		{"/src/play/app/.git", "", nil},
+		{filepath.Join(home, "case", ".git"), "", []string{"user.name"}},
+		{filepath.Join(dir, "local", "a", "b", ".git"), "release/1.0", []string{"core.local", "push.default"}},
+		{"", "release", nil},
+	}
+	all := []string{"user.email", "user.name", "core.local", "push.default", "never.x"}
+	for _, test := range tests {
+		config, err := NewConfigFromFileOptions(filepath.Join(dir, "config"), ParseOptions{Includes: &IncludeOptions{GitDir: test.gitDir, Branch: test.branch}})
+		if err != nil {
+			t.Fatalf("Failed to read config: %s", err)
+		}
+		for _, key := range all {
+			want := false
+			for _, e := range test.expect {
+				want = want || e == key
+			}
+			if got := config.GetKeyValuesRaw(key) != nil; got != want {
+				t.Errorf("Expect %s set %v for gitdir '%s' branch '%s', but got %v", key, want, test.gitDir, test.branch, got)
+			}
+		}
+	}
+}
+
+func TestWildmatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		expect        bool
+	}{
+		{"**/work/**", "/src/work/app/.git", true},
+		{"**/work/**", "/src/homework/app/.git", false},
+		{"/src/*/.git", "/src/app/.git", true},
+		{"/src/*/.git", "/src/a/b/.git", false},
+		{"/src/**/.git", "/src/.git", true},
+		{"/src/**/.git", "/src/a/b/.git", true},
+		{"https://*.example.com/**", "https://git.example.com/org/repo.git", true},
+		{"https://*.example.com/**", "https://git.example.org/org/repo.git", false},
+		{"release/v?.[0-9]", "release/v1.2", true},
+		{"release/v?.[!0-9]", "release/v1.2", false},
+		{"a\\*b", "a*b", true},
+		{"a\\*b", "axb", false},
+		{"[x", "[x", true},
+	}
+	for _, test := range tests {
+		if got := wildmatch(test.pattern, test.name); got != test.expect {
+			t.Errorf("Expect '%s' matching '%s' to be %v", test.pattern, test.name, test.expect)
+		}
+	}
+}
+
+func TestIncludeIfHasConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config": "[includeIf \"hasconfig:remote.*.url:https://github.com/org/**\"]\n\tpath = org\n" +
+			"[includeIf \"hasconfig:remote.*.url:git@*:fork/*\"]\n\tpath = fork\n" +
+			"[remote \"origin\"]\n\turl = https://github.com/org/repo.git\n",
+		"org":      "[user]\n\temail = me@org\n",
+		"fork":     "[user]\n\temail = me@fork\n",
+		"bad":      "[includeIf \"hasconfig:remote.*.url:*\"]\n\tpath = sets-url\n[remote \"a\"]\n\turl = x\n",
+		"sets-url": "[remote \"b\"]\n\turl = y\n",
+	})
+	opts := ParseOptions{Includes: &IncludeOptions{}}
+	config, err := NewConfigFromFileOptions(filepath.Join(dir, "config"), opts)
+	if err != nil {
+		t.Fatalf("Failed to read config: %s", err)
+	}
+	// the remote is set after the condition, git matches the whole config
+	testValue(t, config, "user.email", "me@org", true)
+
+	config, err = NewConfigFromStringOptions("[includeIf \"hasconfig:remote.*.url:git@*:fork/*\"]\n\tpath = "+filepath.Join(dir, "fork")+"\n[remote \"mine\"]\n\turl = git@example.com:fork/repo\n", opts)
+	if err != nil {
+		t.Fatalf("Failed to read config: %s", err)
+	}
+	testValue(t, config, "user.email", "me@fork", true)
+
+	if _, err := NewConfigFromFileOptions(filepath.Join(dir, "bad"), opts); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expect remote url in file included by hasconfig to fail, but got: %v", err)
+	}
+}
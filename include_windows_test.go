@@ -0,0 +1,36 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package gitconfig
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIncludeWindowsPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		`abs.inc`:     "[user]\n\tname = Abs\n",
+		`sub\rel.inc`: "[user]\n\temail = rel@x\n",
+		`work\x.inc`:  "[core]\n\teditor = notepad\n",
+	})
+	abs := filepath.Join(dir, "abs.inc") // e.g. C:\Users\me\AppData\Local\Temp\...\abs.inc
+	fwd := filepath.ToSlash(filepath.Join(dir, "work", "x.inc"))
+	gitDir := filepath.Join(dir, "repo", ".git")
+	data := "[include]\n\tpath = " + EscapeValueString(abs) + "\n\tpath = sub\\\\rel.inc\n" +
+		"[includeIf \"gitdir:" + strings.ReplaceAll(filepath.Join(dir, "repo"), `\`, `\\`) + `\\` + "\"]\n\tpath = " + fwd + "\n"
+	main := filepath.Join(dir, "main.gitconfig")
+	writeFiles(t, dir, map[string]string{"main.gitconfig": data})
+	cfg, err := NewConfigFromFileOptions(main, ParseOptions{Includes: &IncludeOptions{GitDir: gitDir}})
+	if err != nil {
+		t.Fatalf("Failed to load with includes: %s", err)
+	}
+	testValue(t, cfg, "user.name", "Abs", true)
+	testValue(t, cfg, "user.email", "rel@x", true)
+	testValue(t, cfg, "core.editor", "notepad", true)
+}
@@ -0,0 +1,233 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Key is a key name already split into its parts, with the section and
+// name lower cased, so it can be parsed once and reused for lookups that
+// do no further work on the name. Keys made by hand must use lower case
+// sections and names to be found.
+type Key struct {
+	Section    string // lower case, empty for keys outside any section
+	SubSection string
+	Name       string // lower case
+}
+
+// Splits a key in format section.subsection.key the same way as
+// ParseSectionKey.
+func ParseKey(key string) (Key, error) {
+	s, ss, k := ParseSectionKey(key)
+	if k == "" {
+		return Key{}, fmt.Errorf("Cannot parse key '%s': %w", key, ErrInvalidKey)
+	}
+	return Key{Section: s, SubSection: ss, Name: k}, nil
+}
+
+// Splits a key in format section.subsection.key, or section.key, the way
+// git does, lower casing the section and name but not the subsection.
+// Unlike ParseKey it rejects anything git would, returning a *KeyError
+// naming the offending part.
+func NormalizeKey(key string) (Key, error) {
+	return normalizeKey(key, "")
+}
+
+// Splits a key as NormalizeKey does, also allowing the
+// ParseOptions.ExtraKeyChars the config was read with in the name, so keys
+// read with them can be given back.
+func (self *Config) NormalizeKey(key string) (Key, error) {
+	return normalizeKey(key, self.options.ExtraKeyChars)
+}
+
+// See NormalizeKey, extra are the characters allowed in names besides
+// git's.
+func normalizeKey(key, extra string) (Key, error) {
+	first := strings.IndexByte(key, '.')
+	if first < 0 {
+		return Key{}, &KeyError{Key: key, Part: "section", Err: fmt.Errorf("Key must be of form section.key or section.subsection.key: %w", ErrInvalidKey)}
+	}
+	last := strings.LastIndexByte(key, '.')
+	if err := validateSectionName(key[:first]); err != nil {
+		return Key{}, &KeyError{Key: key, Part: "section", Err: err}
+	}
+	if err := validateKeyName(key[last+1:], extra); err != nil {
+		return Key{}, &KeyError{Key: key, Part: "name", Err: err}
+	}
+	out := Key{Section: strings.ToLower(key[:first]), Name: strings.ToLower(key[last+1:])}
+	if first != last {
+		out.SubSection = key[first+1 : last]
+		if out.SubSection == "" {
+			return Key{}, &KeyError{Key: key, Part: "subsection", Err: fmt.Errorf("Sub-section name must not be empty: %w", ErrInvalidKey)}
+		}
+		if err := validateSubSectionName(out.SubSection); err != nil {
+			return Key{}, &KeyError{Key: key, Part: "subsection", Err: err}
+		}
+	}
+	return out, nil
+}
+
+// Makes a Key from its parts as NormalizeKey would from the joined key:
+// the section and name are checked and lower cased, the sub-section, which
+// may hold dots, is kept exactly. An empty section makes a key outside any
+// section, and an empty subSection one directly in the section. Fails with
+// a *KeyError naming the offending part.
+func NewKey(section, subSection, name string) (Key, error) {
+	joined := joinKey(section, subSection, name)
+	if section != "" {
+		err := validateSectionName(section)
+		if err == nil && strings.Contains(section, ".") {
+			err = fmt.Errorf("Section name '%s' must not contain '.', give the sub-section on its own: %w", section, ErrInvalidKey)
+		}
+		if err != nil {
+			return Key{}, &KeyError{Key: joined, Part: "section", Err: err}
+		}
+	}
+	if err := validateKeyName(name, ""); err != nil {
+		return Key{}, &KeyError{Key: joined, Part: "name", Err: err}
+	}
+	if subSection != "" {
+		if section == "" {
+			return Key{}, &KeyError{Key: joined, Part: "section", Err: fmt.Errorf("Sub-section '%s' given without a section: %w", subSection, ErrInvalidKey)}
+		}
+		if err := validateSubSectionName(subSection); err != nil {
+			return Key{}, &KeyError{Key: joined, Part: "subsection", Err: err}
+		}
+	}
+	return Key{Section: strings.ToLower(section), SubSection: subSection, Name: strings.ToLower(name)}, nil
+}
+
+// Splits a key which may give its sub-section in double quotes, e.g.
+// `url."https://a.b/".insteadOf`, as well as in the plain form
+// NormalizeKey reads. A quoted sub-section is read up to its closing quote,
+// with \" and \\ escaping a quote or backslash, and must be followed by
+// ".name"; the quoted form takes precedence, so dots inside it are never
+// split on. Otherwise the key is split at its first and last dots as git
+// does. A '"' anywhere else makes the key ambiguous and it is rejected.
+// Fails with a *KeyError naming the offending part.
+func ParseQuotedKey(key string) (Key, error) {
+	section, rest, ok := strings.Cut(key, ".")
+	if !ok || !strings.HasPrefix(rest, "\"") {
+		if strings.Contains(key, "\"") {
+			return Key{}, &KeyError{Key: key, Part: "subsection", Err: fmt.Errorf("Unexpected '\"' outside a quoted sub-section in key '%s': %w", key, ErrInvalidKey)}
+		}
+		return NormalizeKey(key)
+	}
+	var sub strings.Builder
+	for i := 1; i < len(rest); i++ {
+		c := rest[i]
+		if c == '\\' && i+1 < len(rest) && (rest[i+1] == '"' || rest[i+1] == '\\') {
+			i++
+			sub.WriteByte(rest[i])
+			continue
+		}
+		if c != '"' {
+			sub.WriteByte(c)
+			continue
+		}
+		name, ok := strings.CutPrefix(rest[i+1:], ".")
+		if !ok || strings.ContainsAny(name, ".\"") {
+			return Key{}, &KeyError{Key: key, Part: "name", Err: fmt.Errorf("Expected '.name' after the quoted sub-section in key '%s': %w", key, ErrInvalidKey)}
+		}
+		if sub.Len() == 0 {
+			return Key{}, &KeyError{Key: key, Part: "subsection", Err: fmt.Errorf("Sub-section name must not be empty: %w", ErrInvalidKey)}
+		}
+		return NewKey(section, sub.String(), name)
+	}
+	return Key{}, &KeyError{Key: key, Part: "subsection", Err: fmt.Errorf("Missing '\"' closing the sub-section in key '%s': %w", key, ErrInvalidKey)}
+}
+
+// Like ParseKey, but panics if the key is invalid, for keys fixed at compile time.
+func MustParseKey(key string) Key {
+	k, err := ParseKey(key)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+func (self Key) String() string {
+	return joinKey(self.Section, self.SubSection, self.Name)
+}
+
+// Get all the values of the key, or nil if it does not exist, as for
+// LookupValues. Unlike GetKeyValuesRaw this never allocates.
+func (self *Config) GetValuesForKey(k Key) *ConfigValue {
+	return self.LookupValues(k.Section, k.SubSection, k.Name)
+}
+
+// Get the last specified value of the key as a string.
+// If the *key* does not exist, the second return value will be false.
+func (self *Config) GetStringForKey(k Key) (string, bool) {
+	cvs := self.GetValuesForKey(k)
+	if cvs == nil || len(cvs.Entries) == 0 {
+		return "", false
+	}
+	return cvs.Entries[len(cvs.Entries)-1].Value, true
+}
+
+// Checks a section name only uses the characters git allows:
+// ascii letters and digits, '-' and '.'.
+func validateSectionName(name string) error {
+	if name == "" {
+		return fmt.Errorf("Section name must not be empty: %w", ErrInvalidKey)
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; !isLetter(c) && !isDigit(c) && c != '-' && c != '.' {
+			return fmt.Errorf("Unexpected '%s' in section name '%s', expected a ascii letter, digit, hyphen or dot: %w", charAt(name, i), name, ErrInvalidKey)
+		}
+	}
+	return nil
+}
+
+// Checks a sub-section name can be written out, which only rules out
+// newlines and NUL.
+func validateSubSectionName(name string) error {
+	if i := strings.IndexAny(name, "\n\x00"); i >= 0 {
+		return fmt.Errorf("Sub-section name %q must not contain newlines or NUL: %w", name, ErrInvalidKey)
+	}
+	return nil
+}
+
+// Checks the sub-section of a key can be written, giving a *KeyError if
+// not.
+func checkSubSection(key, subSection string) error {
+	if err := validateSubSectionName(subSection); err != nil {
+		return &KeyError{Key: key, Part: "subsection", Err: err}
+	}
+	return nil
+}
+
+// Checks a value holds no NUL or other control characters git would not
+// read back the same, e.g. a carriage return taken as part of a line end.
+// Tab and newline are allowed, as they are written escaped.
+func validateValue(key, value string) error {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; (c < 0x20 && c != '\t' && c != '\n') || c == 0x7f {
+			return &ValueError{Key: key, Pos: i, Char: c}
+		}
+	}
+	return nil
+}
+
+// Checks a key name starts with an ascii letter and otherwise only uses
+// ascii letters, digits and '-', as the parser requires. The characters in
+// extra are allowed anywhere, as for ParseOptions.ExtraKeyChars.
+func validateKeyName(name, extra string) error {
+	if name == "" {
+		return fmt.Errorf("Key name must not be empty: %w", ErrInvalidKey)
+	}
+	if !isLetter(name[0]) && strings.IndexByte(extra, name[0]) < 0 {
+		return fmt.Errorf("Unexpected '%s' starting key '%s', expected a letter: %w", charAt(name, 0), name, ErrInvalidKey)
+	}
+	for i := 1; i < len(name); i++ {
+		if c := name[i]; !isLetter(c) && !isDigit(c) && c != '-' && strings.IndexByte(extra, c) < 0 {
+			return fmt.Errorf("Unexpected '%s' in key '%s', expected a ascii letter, hyphen or digit: %w", charAt(name, i), name, ErrInvalidKey)
+		}
+	}
+	return nil
+}
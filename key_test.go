@@ -0,0 +1,276 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	config, err := NewConfigFromString("top = level\n[Remote \"Origin\"]\n\tURL = a\n[core]\n\tbare = false\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	k := MustParseKey("remote.Origin.Url")
+	if k != (Key{Section: "remote", SubSection: "Origin", Name: "url"}) || k.String() != "remote.Origin.url" {
+		t.Errorf("Expect key remote.Origin.url but got %#v\n", k)
+	}
+	for key, expect := range map[string]string{"remote.Origin.Url": "a", "CORE.bare": "false", "top": "level"} {
+		if got, ok := config.GetStringForKey(MustParseKey(key)); !ok || got != expect {
+			t.Errorf("Expect %s to be '%s' but got '%s' (exists: %v)\n", key, expect, got, ok)
+		}
+	}
+	if _, ok := config.GetStringForKey(MustParseKey("remote.origin.url")); ok {
+		t.Errorf("Expect sub-section lookups to be case sensitive\n")
+	}
+	if _, err := ParseKey("core."); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expect ErrInvalidKey for key without name but got %v\n", err)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		config.GetStringForKey(k)
+		config.GetKeyValuesRaw("core.bare")
+	})
+	if allocs != 0 {
+		t.Errorf("Expect lookups not to allocate but got %v allocations\n", allocs)
+	}
+}
+
+func BenchmarkGetKeyValuesRaw(b *testing.B) {
+	config, _ := NewConfigFromString(smallConfig)
+	b.ReportAllocs()
+	for b.Loop() {
+		config.GetKeyValuesRaw("remote.origin.URL")
+	}
+}
+
+func BenchmarkGetValuesForKey(b *testing.B) {
+	config, _ := NewConfigFromString(smallConfig)
+	k := MustParseKey("remote.origin.URL")
+	b.ReportAllocs()
+	for b.Loop() {
+		config.GetValuesForKey(k)
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	valid := map[string]Key{
+		"Core.Bare":               {Section: "core", Name: "bare"},
+		"remote.Origin.URL":       {Section: "remote", SubSection: "Origin", Name: "url"},
+		"url.git@a.b:c.insteadOf": {Section: "url", SubSection: "git@a.b:c", Name: "insteadof"},
+		"my-sect.sub sect.k-2":    {Section: "my-sect", SubSection: "sub sect", Name: "k-2"},
+	}
+	for in, expect := range valid {
+		if got, err := NormalizeKey(in); err != nil || got != expect {
+			t.Errorf("Expect '%s' to normalize to %#v but got %#v (err: %v)", in, expect, got, err)
+		}
+	}
+	invalid := map[string]string{
+		"bare":            "section",
+		".bare":           "section",
+		"co re.bare":      "section",
+		"core.":           "name",
+		"core.1bare":      "name",
+		"core.ba_re":      "name",
+		"remote..url":     "subsection",
+		"remote.a\nb.url": "subsection",
+	}
+	for in, part := range invalid {
+		_, err := NormalizeKey(in)
+		var kerr *KeyError
+		if !errors.As(err, &kerr) || kerr.Part != part || !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expect '%s' to fail with a KeyError for the %s but got %v", in, part, err)
+		}
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	for _, tc := range []struct {
+		section, subSection, name string
+		expect                    Key
+	}{
+		{"Core", "", "Bare", Key{Section: "core", Name: "bare"}},
+		{"url", "https://a.b/c.d", "insteadOf", Key{Section: "url", SubSection: "https://a.b/c.d", Name: "insteadof"}},
+		{"", "", "top", Key{Name: "top"}},
+	} {
+		if got, err := NewKey(tc.section, tc.subSection, tc.name); err != nil || got != tc.expect {
+			t.Errorf("Expect %#v but got %#v (err: %v)", tc.expect, got, err)
+		}
+	}
+	for _, tc := range []struct{ section, subSection, name, part string }{
+		{"remote.origin", "", "url", "section"},
+		{"co re", "", "bare", "section"},
+		{"core", "", "1bare", "name"},
+		{"", "sub", "k", "section"},
+		{"remote", "a\nb", "url", "subsection"},
+	} {
+		_, err := NewKey(tc.section, tc.subSection, tc.name)
+		var kerr *KeyError
+		if !errors.As(err, &kerr) || kerr.Part != tc.part || !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expect %q %q %q to fail with a KeyError for the %s but got %v", tc.section, tc.subSection, tc.name, tc.part, err)
+		}
+	}
+
+	config, err := NewConfigFromString("top = 1\n[URL \"https://a.b/c.d\"]\n\tinsteadOf = ab:\n[remote \"Origin\"]\n\turl = x\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	if cv := config.LookupValues("url", "https://a.b/c.d", "INSTEADOF"); cv == nil || cv.Entries[0].Value != "ab:" {
+		t.Errorf("Expect the url value found by its parts, but got %v", cv)
+	}
+	if cv := config.LookupValues("", "", "Top"); cv == nil {
+		t.Errorf("Expect a key outside any section found")
+	}
+	if cv := config.LookupValues("remote", "origin", "url"); cv != nil {
+		t.Errorf("Expect sub-sections matched exactly, but found %v", cv)
+	}
+	config.FoldSubSections = true
+	if cv := config.LookupValues("remote", "origin", "url"); cv == nil {
+		t.Errorf("Expect sub-sections folded with FoldSubSections")
+	}
+}
+
+func TestParseQuotedKey(t *testing.T) {
+	valid := map[string]Key{
+		`url."https://a.b/c.d".insteadOf`: {Section: "url", SubSection: "https://a.b/c.d", Name: "insteadof"},
+		`remote."my.fork".URL`:            {Section: "remote", SubSection: "my.fork", Name: "url"},
+		`a."say \"hi\" \\o/".k`:           {Section: "a", SubSection: `say "hi" \o/`, Name: "k"},
+		"url.https://a.b/.insteadOf":      {Section: "url", SubSection: "https://a.b/", Name: "insteadof"},
+		"Core.Bare":                       {Section: "core", Name: "bare"},
+	}
+	for in, expect := range valid {
+		if got, err := ParseQuotedKey(in); err != nil || got != expect {
+			t.Errorf("Expect '%s' to parse to %#v but got %#v (err: %v)", in, expect, got, err)
+		}
+	}
+	invalid := map[string]string{
+		`url."https://a.b/insteadOf`: "subsection",
+		`url."a".b.c`:                "name",
+		`url."a"b`:                   "name",
+		`url."".k`:                   "subsection",
+		`remote.a"b.url`:             "subsection",
+		`co re."x".k`:                "section",
+		`remote."x".1url`:            "name",
+	}
+	for in, part := range invalid {
+		_, err := ParseQuotedKey(in)
+		var kerr *KeyError
+		if !errors.As(err, &kerr) || kerr.Part != part || !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expect '%s' to fail with a KeyError for the %s but got %v", in, part, err)
+		}
+	}
+}
+
+func TestValueControlCharacters(t *testing.T) {
+	cfg := NewConfig()
+	var ve *ValueError
+	for _, value := range []string{"a\x00b", "crlf\r", "bell\a", "del\x7f"} {
+		if err := cfg.Set("core.editor", value); !errors.As(err, &ve) || !errors.Is(err, ErrInvalidValue) || ve.Key != "core.editor" {
+			t.Errorf("Expect a ValueError setting %q, but got: %v", value, err)
+		}
+	}
+	if ve.Pos != 3 || ve.Char != 0x7f {
+		t.Errorf("Expect the DEL at byte 3, but got %q at %d", ve.Char, ve.Pos)
+	}
+	if err := cfg.Set("core.editor", "tab\tand\nnewline ünïcode"); err != nil {
+		t.Errorf("Expect tab, newline and unicode to be allowed, but got: %s", err)
+	}
+	if err := cfg.InsertKeyValue("core.editor", "x\x01", 0); !errors.As(err, &ve) {
+		t.Errorf("Expect a ValueError inserting, but got: %v", err)
+	}
+	if err := cfg.ApplyOverrides([]string{"core.pager=less\x00"}); !errors.As(err, &ve) {
+		t.Errorf("Expect a ValueError for an override, but got: %v", err)
+	}
+	bad := "x\r"
+	if err := cfg.AddKeyValueE("core", "", "editor", &bad); !errors.As(err, &ve) || ve.Key != "core.editor" {
+		t.Errorf("Expect a ValueError adding, but got: %v", err)
+	}
+	if err := cfg.GetKeyValuesRaw("core.editor").ReplaceAt(0, bad); !errors.As(err, &ve) {
+		t.Errorf("Expect a ValueError replacing, but got: %v", err)
+	}
+	if got := cfg.GetKeyValuesRaw("core.editor").ValuesAsStrings(); len(got) != 1 || got[0] != "tab\tand\nnewline ünïcode" {
+		t.Errorf("Expect refused values to leave the key alone, but got %q", got)
+	}
+	// AddKeyValue checks nothing, as it always has
+	cfg.AddKeyValue("core", "", "editor", &bad)
+	if got := cfg.GetKeyValuesRaw("core.editor").ValuesAsStrings(); len(got) != 2 || got[1] != bad {
+		t.Errorf("Expect AddKeyValue to add the value unchecked, but got %q", got)
+	}
+
+	data := "[core]\n\tpager = less\x00more\n"
+	if _, err := NewConfigFromString(data); err != nil {
+		t.Errorf("Expect control characters to be read when not strict, but got: %s", err)
+	}
+	_, err := NewConfigFromStringOptions(data, ParseOptions{Strict: true})
+	var pe *ParseError
+	if !errors.As(err, &pe) || !errors.As(err, &ve) || ve.Key != "core.pager" || pe.LineNo != 2 || pe.Column() != 21 {
+		t.Errorf("Expect a strict parse to fail at core.pager line 2 column 21, but got: %v", err)
+	}
+}
+
+func TestSubSectionNames(t *testing.T) {
+	cfg := NewConfig()
+	var ke *KeyError
+	for _, key := range []string{"remote.a\nb.url", "remote.a\x00b.url"} {
+		if err := cfg.Set(key, "x"); !errors.As(err, &ke) || ke.Part != "subsection" || !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expect a subsection KeyError setting %q, but got: %v", key, err)
+		}
+		if err := cfg.InsertKeyValue(key, "x", 0); !errors.As(err, &ke) {
+			t.Errorf("Expect a KeyError inserting %q, but got: %v", key, err)
+		}
+	}
+	if cfg.GetSubSection("remote", "a\nb", true) != nil || cfg.HasSection("remote") {
+		t.Errorf("Expect no sub-section created for a name holding a newline")
+	}
+	v := "x"
+	if err := cfg.AddKeyValueE("remote", "a\nb", "url", &v); !errors.As(err, &ke) || ke.Part != "subsection" {
+		t.Errorf("Expect a subsection KeyError adding a value, but got: %v", err)
+	}
+	if _, err := cfg.EnsureSubSectionE("remote", "a\nb"); !errors.As(err, &ke) {
+		t.Errorf("Expect a KeyError creating the sub-section, but got: %v", err)
+	}
+	if ss, err := cfg.EnsureSubSectionE("remote", "ok"); err != nil || ss == nil {
+		t.Errorf("Expect a valid sub-section created, but got %v (%v)", ss, err)
+	}
+	tx := cfg.Begin()
+	tx.Add("remote.a\nb.url", "x")
+	if err := tx.Commit(); !errors.As(err, &ke) {
+		t.Errorf("Expect a KeyError adding in a transaction, but got: %v", err)
+	}
+	if _, err := cfg.MigrateKeys(map[string]string{"old.url": "remote.a\nb.url"}); !errors.As(err, &ke) {
+		t.Errorf("Expect a KeyError migrating to a bad sub-section, but got: %v", err)
+	}
+	other := NewConfig()
+	other.AddKeyValue("remote", "a\nb", "url", &v)
+	if got, _ := other.GetKeyValueAsString("remote.a\nb.url"); got != "x" {
+		t.Errorf("Expect AddKeyValue to add under any sub-section name, but got %q", got)
+	}
+
+	// quotes, backslashes and tabs must read back as written
+	name := "we\"ird\\ \tname"
+	if err := cfg.Set("remote."+name+".url", "x"); err != nil {
+		t.Fatalf("Failed to set: %s", err)
+	}
+	if !strings.Contains(cfg.String(), "[remote \"we\\\"ird\\\\ \tname\"]") {
+		t.Errorf("Expect only quote and backslash escaped in:\n%s", cfg)
+	}
+	reread, err := NewConfigFromString(cfg.String())
+	if err != nil {
+		t.Fatalf("Failed to read back: %s", err)
+	}
+	testValue(t, reread, "remote."+name+".url", "x", true)
+
+	// git keeps any escaped character, e.g. \t is 't'
+	cfg, err = NewConfigFromString("[remote \"a\\tb\\x\"]\n\turl = y\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	testValue(t, cfg, "remote.atbx.url", "y", true)
+	if _, err := NewConfigFromString("[remote \"a\x00b\"]\n\turl = y\n"); err == nil {
+		t.Errorf("Expect error for a NUL in a sub-section name")
+	}
+}
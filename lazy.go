@@ -0,0 +1,226 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+package gitconfig
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// A read-only config which only notes where each section is when created,
+// parsing a section's keys the first time one of them is asked for.
+// Useful when reading a handful of keys from a very large file.
+//
+// Syntax errors in section headers are found up front, errors in keys are
+// only returned once the section holding them is read.
+// It is safe for concurrent use.
+type LazyConfig struct {
+	data        string
+	file        string
+	ranges      map[string][]lazyRange // by lazySectionId
+	subSections map[string][]string    // lowercase section -> sub-section names, in file order
+	mu          sync.Mutex
+	cfg         *Config         // sections parsed so far
+	done        map[string]bool // by lazySectionId, sections already in cfg
+}
+
+// Part of the data belonging to a section, including its header line.
+type lazyRange struct {
+	start, end int
+	line       uint64 // line number of the first line, 1-based
+}
+
+func lazySectionId(section, subSection string) string {
+	return strings.ToLower(section) + "\x00" + subSection
+}
+
+func NewLazyConfigFromString(data string) (*LazyConfig, error) {
+	return newLazyConfig(data, "")
+}
+
+// Reads the whole file, but does not parse it beyond finding the sections.
+func NewLazyConfigFromFile(file string) (*LazyConfig, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return newLazyConfig(string(data), file)
+}
+
+func newLazyConfig(data, file string) (*LazyConfig, error) {
+	self := &LazyConfig{
+		data:        data,
+		file:        file,
+		ranges:      make(map[string][]lazyRange, 10),
+		subSections: make(map[string][]string, 10),
+		cfg:         NewConfig(),
+		done:        make(map[string]bool, 10),
+	}
+	self.cfg.source = file
+	cur := lazySectionId("", "")
+	start := 0
+	startLine := uint64(1)
+	lineNo := uint64(0)
+	continued := false
+	// headers are parsed with the real parser, any keys following them on
+	// the same line end up in a throwaway config
+	p := Parser{Config: NewConfig(), file: file}
+	for pos := 0; pos < len(data); {
+		end := strings.IndexByte(data[pos:], '\n')
+		next := len(data)
+		if end >= 0 {
+			end += pos
+			next = end + 1
+		} else {
+			end = len(data)
+		}
+		line := strings.TrimSuffix(data[pos:end], "\r")
+		lineNo++
+		wasContinued := continued
+		continued = lazyLineContinues(line)
+		trimmed := strings.TrimLeft(line, " \t\v\f\r")
+		if !wasContinued && strings.HasPrefix(trimmed, "[") {
+			p.lineNo, p.charPos, p.curLine = lineNo, 0, line
+			if err := p.readKeyOrSection(); err != nil {
+				return nil, err
+			}
+			if pos > start {
+				self.ranges[cur] = append(self.ranges[cur], lazyRange{start: start, end: pos, line: startLine})
+			}
+			cur = lazySectionId(p.section, p.subSection)
+			if _, ok := self.ranges[cur]; !ok {
+				self.ranges[cur] = nil
+				if p.subSection != "" {
+					slc := strings.ToLower(p.section)
+					self.subSections[slc] = append(self.subSections[slc], p.subSection)
+				}
+			}
+			start = pos
+			startLine = lineNo
+		}
+		pos = next
+	}
+	if len(data) > start {
+		self.ranges[cur] = append(self.ranges[cur], lazyRange{start: start, end: len(data), line: startLine})
+	}
+	return self, nil
+}
+
+// Reports whether a line ends with a backslash continuing its value onto
+// the next, ignoring any in comments.
+func lazyLineContinues(line string) bool {
+	quoted := false
+	escaped := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if escaped {
+			escaped = false
+			continue
+		}
+		switch c {
+		case '\\':
+			escaped = true
+		case '"':
+			quoted = !quoted
+		case ';', '#':
+			if !quoted {
+				return false
+			}
+		}
+	}
+	return escaped
+}
+
+// Parses the given section if that has not already been done.
+// Must be called with the lock held.
+func (self *LazyConfig) materialize(section, subSection string) error {
+	id := lazySectionId(section, subSection)
+	if self.done[id] {
+		return nil
+	}
+	for _, r := range self.ranges[id] {
+		p := Parser{
+			Reader: bufio.NewScanner(strings.NewReader(self.data[r.start:r.end])),
+			Config: self.cfg,
+			file:   self.file,
+			lineNo: r.line - 1,
+		}
+		if err := p.Read(); err != nil {
+			return err
+		}
+	}
+	self.done[id] = true
+	return nil
+}
+
+// Get all the values of the key, parsing its section if needed.
+// The value is nil if the key does not exist.
+func (self *LazyConfig) GetKeyValuesRaw(key string) (*ConfigValue, error) {
+	s, ss, k := ParseSectionKey(key)
+	if k == "" {
+		return nil, fmt.Errorf("Cannot get key '%s': %w", key, ErrInvalidKey)
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if err := self.materialize(s, ss); err != nil {
+		return nil, err
+	}
+	cvs := self.cfg.LookupValues(s, ss, k)
+	if cvs == nil {
+		return nil, nil
+	}
+	return cvs.copy(), nil
+}
+
+// Get the last specified value of the key as a string.
+// If the *key* does not exist, the second return value will be false.
+func (self *LazyConfig) GetKeyValueAsString(key string) (string, bool, error) {
+	cvs, err := self.GetKeyValuesRaw(key)
+	if cvs == nil || err != nil {
+		return "", false, err
+	}
+	s, ok := cvs.GetString()
+	return s, ok, nil
+}
+
+// Reports whether the section (or section.subsection) appears at all.
+func (self *LazyConfig) HasSection(section, subSection string) bool {
+	_, ok := self.ranges[lazySectionId(section, subSection)]
+	return ok
+}
+
+// Gets the sub-section names of a section, in the order first seen.
+func (self *LazyConfig) SubSectionNames(section string) []string {
+	return append([]string(nil), self.subSections[strings.ToLower(section)]...)
+}
+
+// Parses every section not yet read, returning a Config with everything.
+// The Config is a copy, changes to it are not seen by the LazyConfig.
+func (self *LazyConfig) Config() (*Config, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if len(self.done) != len(self.ranges) {
+		// parse as a whole, so keys keep their file order
+		p := Parser{
+			Reader: bufio.NewScanner(strings.NewReader(self.data)),
+			Config: NewConfig(),
+			file:   self.file,
+		}
+		if err := p.Read(); err != nil {
+			return nil, err
+		}
+		p.Config.source = self.file
+		self.cfg = p.Config
+		for id := range self.ranges {
+			self.done[id] = true
+		}
+	}
+	out := NewConfig()
+	out.Merge(self.cfg)
+	out.source = self.file
+	return out, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"testing"
+)
+
+func TestLazyConfig(t *testing.T) {
+	s := "top = level\n" +
+		"[core]\n" +
+		"\tbare = false\n" +
+		"[remote \"origin\"] url = a\n" +
+		"\tfetch = x\n" +
+		"\tlong = first \\\n" +
+		"[not a header]\n" +
+		"[remote \"other\"]\n" +
+		"\turl = b\n" +
+		"\tbroken = \\q\n" +
+		"[Remote \"origin\"]\n" +
+		"\tfetch = y\n"
+	lazy, err := NewLazyConfigFromString(s)
+	if err != nil {
+		t.Fatalf("Failed to index config: %s", err.Error())
+	}
+	if got, ok, err := lazy.GetKeyValueAsString("remote.origin.url"); err != nil || !ok || got != "a" {
+		t.Errorf("Expect remote.origin.url to be 'a' but got '%s' (exists: %v, err: %v)", got, ok, err)
+	}
+	if got, _, _ := lazy.GetKeyValueAsString("remote.origin.long"); got != "first [not a header]" {
+		t.Errorf("Expect continued value to include the next line but got '%s'", got)
+	}
+	cvs, err := lazy.GetKeyValuesRaw("remote.origin.fetch")
+	if err != nil || len(cvs.Entries) != 2 || cvs.Entries[1].Value != "y" || cvs.Entries[1].Origin.Line != 12 {
+		t.Errorf("Expect remote.origin.fetch from both headers, the second from line 12, but got %v (err: %v)", cvs, err)
+	}
+	if got, ok, _ := lazy.GetKeyValueAsString("top"); !ok || got != "level" {
+		t.Errorf("Expect base value 'level' but got '%s'", got)
+	}
+	if len(lazy.done) != 2 {
+		t.Errorf("Expect only the base and remote.origin sections to be parsed but %d were", len(lazy.done))
+	}
+	if _, _, err := lazy.GetKeyValueAsString("remote.other.url"); err == nil {
+		t.Errorf("Expect reading a section with a bad key to fail")
+	}
+	if !lazy.HasSection("REMOTE", "other") || lazy.HasSection("remote", "Other") {
+		t.Errorf("Expect HasSection to match section case-insensitively and sub-sections exactly")
+	}
+	if names := lazy.SubSectionNames("remote"); len(names) != 2 || names[0] != "origin" || names[1] != "other" {
+		t.Errorf("Expect sub-sections origin, other but got %v", names)
+	}
+	if _, err := NewLazyConfigFromString("[core\n"); err == nil {
+		t.Errorf("Expect a bad section header to fail up front")
+	}
+}
+
+func BenchmarkLazySingleKey(b *testing.B) {
+	s := benchConfig()
+	b.SetBytes(int64(len(s)))
+	b.ReportAllocs()
+	for b.Loop() {
+		lazy, err := NewLazyConfigFromString(s)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, ok, err := lazy.GetKeyValueAsString("remote.origin42.url"); !ok || err != nil {
+			b.Fatal("missing key", err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+)
+
+// Bounds on what a parse may read, for configs from untrusted sources,
+// see ParseOptions.Limits. Zero means no limit. Reading stops at the first
+// limit exceeded with a *PartialParseError holding what was read before.
+// With includes followed each file is limited on its own.
+type ParseLimits struct {
+	MaxBytes      int // bytes read, counting each line end as one
+	MaxLineLength int // bytes in any one line, 64KiB less the line end if zero
+	MaxValues     int // values read, counting each value of multi-valued keys
+	MaxSections   int // section headers read, counting repeated ones
+}
+
+// Which of the ParseLimits was exceeded.
+type ParseLimit int
+
+const (
+	LimitBytes ParseLimit = iota
+	LimitLineLength
+	LimitValues
+	LimitSections
+)
+
+var parseLimitNames = []string{"bytes", "bytes in a line", "values", "sections"}
+
+func (self ParseLimit) String() string {
+	if int(self) < 0 || int(self) >= len(parseLimitNames) {
+		return fmt.Sprintf("ParseLimit(%d)", int(self))
+	}
+	return parseLimitNames[self]
+}
+
+// A parse stopped by one of its ParseLimits. Config holds every value read
+// before the limit was reached, e.g. to keep for diagnostics; a value
+// being read over several lines when it was reached is not included.
+// It wraps ErrLimitExceeded.
+type PartialParseError struct {
+	Limit  ParseLimit
+	Max    int
+	File   string  // empty if not parsing a file
+	LineNo uint64  // the line being read when the limit was reached
+	Bytes  int     // bytes read before that line
+	Config *Config // what was read before the limit was reached
+}
+
+func (self *PartialParseError) Error() string {
+	where := fmt.Sprintf("line %d", self.LineNo)
+	if self.File != "" {
+		where = fmt.Sprintf("%s:%d", self.File, self.LineNo)
+	}
+	return fmt.Sprintf("Stopped reading config at %s, more than %d %s\n", where, self.Max, self.Limit)
+}
+
+func (self *PartialParseError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// Stops the parse, returning the error for it.
+func (self *Parser) tripLimit(limit ParseLimit, max int) error {
+	self.limit = &PartialParseError{
+		Limit:  limit,
+		Max:    max,
+		File:   self.file,
+		LineNo: self.lineNo,
+		Bytes:  self.bytes,
+		Config: self.Config,
+	}
+	return self.limit
+}
+
+// Counts a value about to be added.
+func (self *Parser) countValue() error {
+	self.values++
+	if max := self.Options.Limits.MaxValues; max > 0 && self.values > max {
+		return self.tripLimit(LimitValues, max)
+	}
+	return nil
+}
+
+// Counts a section header about to be added.
+func (self *Parser) countSection() error {
+	self.sections++
+	if max := self.Options.Limits.MaxSections; max > 0 && self.sections > max {
+		return self.tripLimit(LimitSections, max)
+	}
+	return nil
+}
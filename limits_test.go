@@ -0,0 +1,145 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLimits(t *testing.T) {
+	data := "[user]\n\tname = Joe\n\temail = joe@x\n[core]\n\teditor = vi\n\tpager = less \\\n -R\n[alias]\n\tst = status\n"
+	for _, tc := range []struct {
+		limits ParseLimits
+		limit  ParseLimit
+		line   uint64
+		keys   []string // set in the partial config
+	}{
+		{ParseLimits{MaxValues: 2}, LimitValues, 5, []string{"user.name", "user.email"}},
+		{ParseLimits{MaxSections: 1}, LimitSections, 4, []string{"user.name", "user.email"}},
+		{ParseLimits{MaxBytes: 40}, LimitBytes, 4, []string{"user.name", "user.email"}},
+		// reached on the continuation line, so core.pager is not kept
+		{ParseLimits{MaxBytes: 72}, LimitBytes, 7, []string{"user.name", "user.email", "core.editor"}},
+		{ParseLimits{MaxLineLength: 13}, LimitLineLength, 3, []string{"user.name"}},
+	} {
+		cfg, err := NewConfigFromStringOptions(data, ParseOptions{Limits: tc.limits})
+		var pe *PartialParseError
+		if cfg != nil || !errors.As(err, &pe) || !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("Expect PartialParseError for %+v, but got %v", tc.limits, err)
+			continue
+		}
+		if pe.Limit != tc.limit || pe.LineNo != tc.line {
+			t.Errorf("Expect %s limit at line %d, but got %s at %d", tc.limit, tc.line, pe.Limit, pe.LineNo)
+		}
+		if n := pe.Config.Stats().Values; n != len(tc.keys) {
+			t.Errorf("Expect %d values read before the %s limit, but got %d:\n%s", len(tc.keys), tc.limit, n, pe.Config)
+		}
+		for _, key := range tc.keys {
+			if _, ok := pe.Config.GetKeyValueAsString(key); !ok {
+				t.Errorf("Expect %s kept in the partial config", key)
+			}
+		}
+	}
+
+	cfg, err := NewConfigFromStringOptions(data, ParseOptions{Limits: ParseLimits{MaxBytes: len(data), MaxValues: 5, MaxSections: 3, MaxLineLength: 20}})
+	if err != nil {
+		t.Fatalf("Expect config within its limits to parse, but got %s", err)
+	}
+	testValue(t, cfg, "core.pager", "less  -R", true)
+
+	long := "[core]\n\tpath = " + strings.Repeat("x", 70000) + "\n"
+	var pe *PartialParseError
+	if _, err := NewConfigFromStringOptions(long, ParseOptions{Limits: ParseLimits{MaxLineLength: 1000}}); !errors.As(err, &pe) || pe.Limit != LimitLineLength || pe.LineNo != 2 {
+		t.Errorf("Expect line length limit at line 2, but got %v", err)
+	}
+	if _, err := NewConfigFromStringOptions(long, ParseOptions{Limits: ParseLimits{MaxLineLength: 80000}}); err != nil {
+		t.Errorf("Expect long line within a raised limit to parse, but got %s", err)
+	}
+	if msg := (&PartialParseError{Limit: LimitValues, Max: 2, File: "f", LineNo: 5}).Error(); msg != "Stopped reading config at f:5, more than 2 values\n" {
+		t.Errorf("Unexpected message %q", msg)
+	}
+}
+
+func FuzzParseLimits(f *testing.F) {
+	for _, seed := range []string{
+		"[user]\n\tname = Joe\n",
+		"[a \"b\"]\n\tc = \"d\\\n e\" ; x\n[a]\n[a]\n\tf\n",
+		"k = v\n[s] k = v\n",
+	} {
+		f.Add(seed)
+	}
+	limits := ParseLimits{MaxBytes: 200, MaxLineLength: 40, MaxValues: 4, MaxSections: 2}
+	f.Fuzz(func(t *testing.T, data string) {
+		cfg, err := NewConfigFromStringOptions(data, ParseOptions{Limits: limits})
+		var pe *PartialParseError
+		if errors.As(err, &pe) {
+			cfg = pe.Config
+		} else if err != nil {
+			return
+		}
+		stats := cfg.Stats()
+		if stats.Values > limits.MaxValues || stats.Sections > limits.MaxSections {
+			t.Errorf("Expect limits kept, but got %+v from %q", stats, data)
+		}
+	})
+}
+
+func TestPartialParsePooled(t *testing.T) {
+	pool := NewParserPool()
+	opts := ParseOptions{Limits: ParseLimits{MaxValues: 2}}
+	p := pool.Get(strings.NewReader("[a]\n\tb\n\tc\n\td\n"))
+	p.Options = opts
+	err := p.Read()
+	pool.Put(p)
+	var pe *PartialParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expect a PartialParseError, but got %v", err)
+	}
+	partial := pe.Config
+	for i := 0; i < 4; i++ {
+		pool.Parse(strings.NewReader("[x]\n\ty = 9\n"), func(*Config) error { return nil })
+	}
+	if got := partial.String(); got != "[a]\n\tb\n\tc\n" {
+		t.Errorf("Expect the partial config kept after the parser is reused, but got:\n%s", got)
+	}
+}
+
+func TestPartialParseIncludes(t *testing.T) {
+	dir := t.TempDir()
+	inc := filepath.Join(dir, "inc")
+	if err := os.WriteFile(inc, []byte("[b]\n\tx = 1\n\ty = 2\n\tz = 3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// reached in the included file
+	data := "[a]\n\tone\n[include]\n\tpath = " + inc + "\n"
+	_, err := NewConfigFromStringOptions(data, ParseOptions{Includes: &IncludeOptions{}, Limits: ParseLimits{MaxValues: 2}})
+	var pe *PartialParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expect a PartialParseError, but got %v", err)
+	}
+	for _, key := range []string{"a.one", "include.path", "b.x", "b.y"} {
+		if _, ok := pe.Config.GetKeyValueAsString(key); !ok {
+			t.Errorf("Expect %s in the partial config, but got:\n%s", key, pe.Config)
+		}
+	}
+	if _, ok := pe.Config.GetKeyValueAsString("b.z"); ok {
+		t.Errorf("Expect b.z past the limit left out")
+	}
+
+	// reached after an include, in the including file
+	data += "[c]\n\tafter = 1\n\tmore = 2\n"
+	_, err = NewConfigFromStringOptions(data, ParseOptions{Includes: &IncludeOptions{}, Limits: ParseLimits{MaxValues: 3}})
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expect a PartialParseError, but got %v", err)
+	}
+	for _, key := range []string{"a.one", "include.path", "c.after"} {
+		if _, ok := pe.Config.GetKeyValueAsString(key); !ok {
+			t.Errorf("Expect %s in the partial config, but got:\n%s", key, pe.Config)
+		}
+	}
+}
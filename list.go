@@ -0,0 +1,73 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"sort"
+	"strings"
+)
+
+// Serializes the config as `git config --list` does, one "key=value" line
+// per value with keys in canonical form. A key given without a value is
+// listed without '='. Sections come in the order first seen, keys sorted
+// within them, as for String.
+// Values are written as they are, so one holding a newline spans several
+// lines; use ListStringNul where that can happen.
+func (self *Config) ListString() string {
+	var sb strings.Builder
+	self.eachEntry(func(key string, e *ValueEntry) {
+		sb.WriteString(key)
+		if e.HasValue {
+			sb.WriteByte('=')
+			sb.WriteString(e.Value)
+		}
+		sb.WriteByte('\n')
+	})
+	return sb.String()
+}
+
+// Serializes the config as `git config --list -z` does: each value as
+// "key\nvalue\x00", or "key\x00" for a key given without a value. Keys
+// cannot hold a newline or NUL, and values cannot hold a NUL, so the output
+// can always be split apart again, whatever the values hold.
+func (self *Config) ListStringNul() string {
+	var sb strings.Builder
+	self.eachEntry(func(key string, e *ValueEntry) {
+		sb.WriteString(key)
+		if e.HasValue {
+			sb.WriteByte('\n')
+			sb.WriteString(e.Value)
+		}
+		sb.WriteByte(0)
+	})
+	return sb.String()
+}
+
+// Calls fn for every value in the order String writes them, with the key
+// in canonical form.
+func (self *Config) eachEntry(fn func(key string, e *ValueEntry)) {
+	each := func(section, subSection string, values ConfigValueSet) {
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			key := joinKey(section, subSection, name)
+			cv := values[name]
+			for i := range cv.Entries {
+				fn(key, &cv.Entries[i])
+			}
+		}
+	}
+	each("", "", self.BaseValues)
+	for _, name := range self.SectionNames() {
+		s := self.Sections[name]
+		each(name, "", s.Values)
+		for _, ssName := range s.subSectionNames() {
+			each(name, ssName, s.SubSections[ssName].Values)
+		}
+	}
+}
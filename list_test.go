@@ -0,0 +1,24 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"testing"
+)
+
+func TestListString(t *testing.T) {
+	config, err := NewConfigFromString("[User]\n\tName = Joe\n[core]\n\tbare\n[alias]\n\tmsg = \"one\\ntwo\"\n[remote \"Origin\"]\n\tfetch = a\n\tfetch = b\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	expect := "user.name=Joe\ncore.bare\nalias.msg=one\ntwo\nremote.Origin.fetch=a\nremote.Origin.fetch=b\n"
+	if out := config.ListString(); out != expect {
+		t.Errorf("Expect list:\n%s\nbut got:\n%s", expect, out)
+	}
+	expect = "user.name\nJoe\x00core.bare\x00alias.msg\none\ntwo\x00remote.Origin.fetch\na\x00remote.Origin.fetch\nb\x00"
+	if out := config.ListStringNul(); out != expect {
+		t.Errorf("Expect NUL list %q, but got %q", expect, out)
+	}
+}
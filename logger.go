@@ -0,0 +1,137 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Receives an event for each change made to a Config through its methods,
+// e.g. for an audit trail of machine-wide configuration. See SetLogger.
+type Logger interface {
+	LogMutation(m Mutation)
+}
+
+// Adapts a function to a Logger.
+type LoggerFunc func(m Mutation)
+
+func (self LoggerFunc) LogMutation(m Mutation) {
+	self(m)
+}
+
+type MutationOp int
+
+const (
+	MutationSet    MutationOp = iota // Set, replacing every value
+	MutationAdd                      // InsertKeyValue, ApplyOverrides or Tx.Add, adding a value
+	MutationUnset                    // Unset
+	MutationRename                   // MigrateKeys or Tx.Rename, once for each key moved
+	MutationPrune                    // Prune, dropping values given without '='
+)
+
+func (self MutationOp) String() string {
+	switch self {
+	case MutationSet:
+		return "set"
+	case MutationAdd:
+		return "add"
+	case MutationUnset:
+		return "unset"
+	case MutationRename:
+		return "rename"
+	case MutationPrune:
+		return "prune"
+	}
+	return "unknown"
+}
+
+// One change, given to a Logger. Values of secret keys are RedactedValue.
+type Mutation struct {
+	Op  MutationOp
+	Key string // canonical form; for renames the old name
+	To  string // for renames the new name, otherwise empty
+	Old []string
+	New []string // for renames the values under the new name afterwards
+}
+
+type mutationLogger struct {
+	logger  Logger
+	secrets keyPatterns
+}
+
+// Sends the changes made through Set, InsertKeyValue, Unset, MigrateKeys,
+// ApplyOverrides, Prune and transactions to l, with the values of keys
+// matching DefaultSecretPatterns or any of the extra patterns replaced by
+// RedactedValue; patterns are as for RedactedString. Changes from
+// reloading are not logged, see OnChange for those. A nil l stops logging.
+func (self *Config) SetLogger(l Logger, secrets ...string) {
+	if l == nil {
+		self.logger = nil
+		return
+	}
+	patterns := append(DefaultSecretPatterns(), secrets...)
+	self.logger = &mutationLogger{logger: l, secrets: newKeyPatterns(patterns)}
+}
+
+// Reports changes made through the Config's methods, logging each as a
+// Mutation of kind op, then telling listeners of them all. Renames come
+// in pairs, the old key then the new, each pair logged as one Mutation.
+func (self *Config) record(op MutationOp, changes ...KeyChange) {
+	if op == MutationRename {
+		for i := 0; i+1 < len(changes); i += 2 {
+			from, to := changes[i], changes[i+1]
+			self.logMutation(Mutation{Op: op, Key: from.Key, To: to.Key, Old: from.Old, New: to.New})
+		}
+	} else {
+		for _, c := range changes {
+			self.logMutation(Mutation{Op: op, Key: c.Key, Old: c.Old, New: c.New})
+		}
+	}
+	self.notify(changes)
+}
+
+// Logs a change, redacting its values if need be.
+func (self *Config) logMutation(m Mutation) {
+	if self.logger == nil {
+		return
+	}
+	if self.logger.secrets.match(m.Key) || (m.To != "" && self.logger.secrets.match(m.To)) {
+		m.Old, m.New = redactValues(m.Old), redactValues(m.New)
+	}
+	self.logger.logger.LogMutation(m)
+}
+
+func redactValues(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	out := make([]string, len(values))
+	for i := range out {
+		out[i] = RedactedValue
+	}
+	return out
+}
+
+// A Logger writing each change to l at info level, with the attributes
+// "op", "key", "to" (for renames), "old" and "new".
+func SlogLogger(l *slog.Logger) Logger {
+	return LoggerFunc(func(m Mutation) {
+		attrs := make([]slog.Attr, 0, 5)
+		attrs = append(attrs, slog.String("op", m.Op.String()), slog.String("key", m.Key))
+		if m.To != "" {
+			attrs = append(attrs, slog.String("to", m.To))
+		}
+		attrs = append(attrs, slog.Any("old", m.Old), slog.Any("new", m.New))
+		l.LogAttrs(context.Background(), slog.LevelInfo, "gitconfig mutation", attrs...)
+	})
+}
+
+// Collects the changes made in a transaction, logged once it commits.
+type mutationBuffer []Mutation
+
+func (self *mutationBuffer) LogMutation(m Mutation) {
+	*self = append(*self, m)
+}
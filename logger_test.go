@@ -0,0 +1,99 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	cfg, err := NewConfigFromString("[user]\n\tname = Joe\n[remote \"origin\"]\n\turl = a\n[vault]\n\tkey = s3cr3t\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	var got []Mutation
+	cfg.SetLogger(LoggerFunc(func(m Mutation) { got = append(got, m) }), "vault.*")
+
+	cfg.Set("user.name", "Jane")
+	cfg.Set("user.name", "Jane") // unchanged, not logged
+	cfg.Set("http.https://host.extraHeader", "Authorization: Bearer x")
+	cfg.PrependKeyValue("remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*")
+	cfg.Unset("vault.key")
+	cfg.Unset("missing.key")
+	cfg.MigrateKeys(map[string]string{"remote.origin.url": "remote.upstream.url"})
+	expect := []Mutation{
+		{Op: MutationSet, Key: "user.name", Old: []string{"Joe"}, New: []string{"Jane"}},
+		{Op: MutationSet, Key: "http.https://host.extraheader", New: []string{RedactedValue}},
+		{Op: MutationAdd, Key: "remote.origin.fetch", New: []string{"+refs/heads/*:refs/remotes/origin/*"}},
+		{Op: MutationUnset, Key: "vault.key", Old: []string{RedactedValue}},
+		{Op: MutationRename, Key: "remote.origin.url", To: "remote.upstream.url", Old: []string{"a"}, New: []string{"a"}},
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expect mutations:\n%+v\nbut got:\n%+v", expect, got)
+	}
+
+	// overrides and pruning are logged; "*token*" matches variable names only
+	got = nil
+	cfg.ApplyOverrides([]string{"remote.tokens.url=b", "github.token=t"})
+	cfg.ApplyOverrides([]string{"core.bare"})
+	cfg.Prune(true)
+	expect = []Mutation{
+		{Op: MutationAdd, Key: "remote.tokens.url", New: []string{"b"}},
+		{Op: MutationAdd, Key: "github.token", New: []string{RedactedValue}},
+		{Op: MutationAdd, Key: "core.bare", New: []string{""}},
+		{Op: MutationPrune, Key: "core.bare", Old: []string{""}},
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expect mutations:\n%+v\nbut got:\n%+v", expect, got)
+	}
+	DefaultSecretPatterns()[0] = "changed"
+	if DefaultSecretPatterns()[0] != "*password*" {
+		t.Errorf("Expect DefaultSecretPatterns to give a copy")
+	}
+
+	// a transaction logs only once committed
+	got = nil
+	tx := cfg.Begin()
+	tx.Add("user.password", "hunter2")
+	tx.Set("core.editor", "vi")
+	if len(got) != 0 {
+		t.Errorf("Expect nothing logged before commit, but got %+v", got)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %s", err)
+	}
+	expect = []Mutation{
+		{Op: MutationAdd, Key: "user.password", New: []string{RedactedValue}},
+		{Op: MutationSet, Key: "core.editor", New: []string{"vi"}},
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expect mutations:\n%+v\nbut got:\n%+v", expect, got)
+	}
+	got = nil
+	tx = cfg.Begin()
+	tx.Set("core.editor", "emacs")
+	tx.Validate(func(*Config) error { return ErrInvalidValue })
+	tx.Commit()
+	if len(got) != 0 {
+		t.Errorf("Expect nothing logged for a failed commit, but got %+v", got)
+	}
+
+	var buf bytes.Buffer
+	cfg.SetLogger(SlogLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	cfg.Set("core.editor", "nano")
+	if out := buf.String(); !strings.Contains(out, `msg="gitconfig mutation" op=set key=core.editor old=[vi] new=[nano]`) {
+		t.Errorf("Unexpected slog output: %s", out)
+	}
+	buf.Reset()
+	cfg.SetLogger(nil)
+	cfg.Set("core.editor", "ed")
+	if buf.Len() != 0 {
+		t.Errorf("Expect nothing logged once the logger is removed")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"time"
+)
+
+// The Lookup* getters mirror the GetKeyValue* getters but report a missing
+// key as an error wrapping ErrKeyNotFound rather than a boolean, with the
+// key name included for context.
+
+// Gets the last specified value of the key as a string.
+// The empty/unset value is the same as an empty string.
+func (self *Config) LookupString(key string) (string, error) {
+	cvs, err := self.lookupRaw(key)
+	if err != nil {
+		return "", err
+	}
+	s, _ := cvs.GetString()
+	return s, nil
+}
+
+// Gets the last specified value of the key as an integer.
+func (self *Config) LookupInt(key string) (int64, error) {
+	cvs, err := self.lookupRaw(key)
+	if err != nil {
+		return 0, err
+	}
+	i, _, err := cvs.GetInt()
+	if err != nil {
+		return 0, fmt.Errorf("key %q: %w", key, err)
+	}
+	return i, nil
+}
+
+// Gets the last specified value of the key as an unsigned integer.
+func (self *Config) LookupUint(key string) (uint64, error) {
+	cvs, err := self.lookupRaw(key)
+	if err != nil {
+		return 0, err
+	}
+	i, _, err := cvs.GetUint()
+	if err != nil {
+		return 0, fmt.Errorf("key %q: %w", key, err)
+	}
+	return i, nil
+}
+
+// Gets the last specified value of the key as a bool.
+// The empty/unset value is the same as false.
+func (self *Config) LookupBool(key string) (bool, error) {
+	cvs, err := self.lookupRaw(key)
+	if err != nil {
+		return false, err
+	}
+	b, _, err := cvs.GetBool()
+	if err != nil {
+		return false, fmt.Errorf("key %q: %w", key, err)
+	}
+	return b, nil
+}
+
+// Gets the last specified value of the key as a duration, see
+// GetKeyValueAsDuration.
+func (self *Config) LookupDuration(key string) (time.Duration, error) {
+	cvs, err := self.lookupRaw(key)
+	if err != nil {
+		return 0, err
+	}
+	d, _, err := cvs.GetDuration()
+	if err != nil {
+		return 0, fmt.Errorf("key %q: %w", key, err)
+	}
+	return d, nil
+}
+
+// Gets the last specified value of the key as a path, with any leading
+// "~" expanded, see ExpandPath.
+func (self *Config) LookupPath(key string) (string, error) {
+	s, err := self.LookupString(key)
+	if err != nil {
+		return "", err
+	}
+	path, err := ExpandPath(s)
+	if err != nil {
+		return "", fmt.Errorf("key %q: %w", key, err)
+	}
+	return path, nil
+}
+
+// Gets all the values of the key as strings, in file order.
+func (self *Config) LookupStrings(key string) ([]string, error) {
+	cvs, err := self.lookupRaw(key)
+	if err != nil {
+		return nil, err
+	}
+	return cvs.ValuesAsStrings(), nil
+}
+
+func (self *Config) lookupRaw(key string) (*ConfigValue, error) {
+	cvs := self.GetKeyValuesRaw(key)
+	if cvs == nil || !cvs.HasValues() {
+		return nil, fmt.Errorf("key %q: %w", key, ErrKeyNotFound)
+	}
+	return cvs, nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"time"
+)
+
+// The gc.* settings, with git's defaults for those not set, see
+// Config.GCSettings. Per-ref gc.<pattern>.reflogExpire values are not read.
+type GCSettings struct {
+	Auto             int   `gcKey:"gc.auto" gcDefault:"6700"`
+	AutoPackLimit    int   `gcKey:"gc.autopacklimit" gcDefault:"50"`
+	AutoDetach       bool  `gcKey:"gc.autodetach" gcDefault:"true"`
+	BigPackThreshold int64 `gcKey:"gc.bigpackthreshold" gcDefault:"0" gcUnit:"b"`
+	AggressiveDepth  int   `gcKey:"gc.aggressivedepth" gcDefault:"50"`
+	AggressiveWindow int   `gcKey:"gc.aggressivewindow" gcDefault:"250"`
+	WriteCommitGraph bool  `gcKey:"gc.writecommitgraph" gcDefault:"true"`
+
+	// Expiry dates, see ParseExpiry
+	PruneExpire             Expiry // gc.pruneExpire, 2.weeks.ago
+	WorktreePruneExpire     Expiry // gc.worktreePruneExpire, 3.months.ago
+	ReflogExpire            Expiry // gc.reflogExpire, 90.days.ago
+	ReflogExpireUnreachable Expiry // gc.reflogExpireUnreachable, 30.days.ago
+	RerereResolved          Expiry // gc.rerereResolved, 60.days.ago
+	RerereUnresolved        Expiry // gc.rerereUnresolved, 15.days.ago
+	LogExpiry               Expiry // gc.logExpiry, 1.day.ago
+}
+
+// Reads the gc.* settings, taking expiry dates back from the current time.
+func (self *Config) GCSettings() (*GCSettings, error) {
+	return self.gcSettings(time.Now())
+}
+
+func (self *Config) gcSettings(now time.Time) (*GCSettings, error) {
+	out := &GCSettings{}
+	if err := self.Load(out); err != nil {
+		return nil, err
+	}
+	for _, e := range []struct {
+		key, def string
+		dst      *Expiry
+	}{
+		{"gc.pruneexpire", "2.weeks.ago", &out.PruneExpire},
+		{"gc.worktreepruneexpire", "3.months.ago", &out.WorktreePruneExpire},
+		{"gc.reflogexpire", "90.days.ago", &out.ReflogExpire},
+		{"gc.reflogexpireunreachable", "30.days.ago", &out.ReflogExpireUnreachable},
+		{"gc.rerereresolved", "60.days.ago", &out.RerereResolved},
+		{"gc.rerereunresolved", "15.days.ago", &out.RerereUnresolved},
+		{"gc.logexpiry", "1.day.ago", &out.LogExpiry},
+	} {
+		spec := e.def
+		if s, ok := self.GetKeyValueAsString(e.key); ok {
+			spec = s
+		}
+		exp, err := ParseExpiry(spec, now)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", e.key, err)
+		}
+		*e.dst = exp
+	}
+	return out, nil
+}
+
+// One task of git maintenance, from maintenance.<task>.*.
+type MaintenanceTask struct {
+	Enabled  bool   // maintenance.<task>.enabled, only gc by default
+	Schedule string // maintenance.<task>.schedule: "hourly", "daily", "weekly" or "" if not scheduled
+	Auto     int    // maintenance.<task>.auto, the task's threshold for --auto runs
+}
+
+// The maintenance.* settings, see Config.MaintenanceSettings.
+type MaintenanceSettings struct {
+	Auto     bool     `gcKey:"maintenance.auto" gcDefault:"true"`
+	Strategy string   `gcKey:"maintenance.strategy" gcDefault:"none"` // "none" or "incremental"
+	Repos    []string `gcKey:"maintenance.repo"`                      // the repositories registered for scheduled maintenance
+
+	// Every task git knows and any other configured, by name
+	Tasks map[string]MaintenanceTask
+}
+
+// The tasks git knows, with their schedule under the incremental strategy
+// and their default threshold for --auto.
+var maintenanceTasks = map[string]MaintenanceTask{
+	"gc":                 {Enabled: true},
+	"commit-graph":       {Schedule: "hourly", Auto: 100},
+	"prefetch":           {Schedule: "hourly"},
+	"loose-objects":      {Schedule: "daily", Auto: 100},
+	"incremental-repack": {Schedule: "daily", Auto: 10},
+	"pack-refs":          {Schedule: "weekly"},
+}
+
+var maintenanceSchedules = map[string]bool{"": true, "hourly": true, "daily": true, "weekly": true}
+
+// Reads the maintenance.* settings. Each task's schedule defaults to that
+// of maintenance.strategy, and gc's threshold to gc.auto. An unknown
+// strategy or schedule gives an error wrapping ErrInvalidValue.
+func (self *Config) MaintenanceSettings() (*MaintenanceSettings, error) {
+	out := &MaintenanceSettings{}
+	if err := self.Load(out); err != nil {
+		return nil, err
+	}
+	if out.Strategy != "none" && out.Strategy != "incremental" {
+		return nil, fmt.Errorf("Unknown maintenance strategy '%s', expected none or incremental: %w", out.Strategy, ErrInvalidValue)
+	}
+	gcAuto, ok, err := self.GetKeyValueAsInt("gc.auto")
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", "gc.auto", err)
+	} else if !ok {
+		gcAuto = 6700
+	}
+	out.Tasks = map[string]MaintenanceTask{}
+	names := []string{}
+	for name := range maintenanceTasks {
+		names = append(names, name)
+	}
+	if s := self.LookupSection("maintenance"); s != nil {
+		for _, name := range s.subSectionNames() {
+			if _, ok := maintenanceTasks[name]; !ok {
+				names = append(names, name)
+			}
+		}
+	}
+	for _, name := range names {
+		task := maintenanceTasks[name]
+		if out.Strategy != "incremental" {
+			task.Schedule = ""
+		}
+		if name == "gc" {
+			task.Auto = int(gcAuto)
+		}
+		if err := self.loadMaintenanceTask(name, &task); err != nil {
+			return nil, err
+		}
+		out.Tasks[name] = task
+	}
+	return out, nil
+}
+
+func (self *Config) loadMaintenanceTask(name string, task *MaintenanceTask) error {
+	prefix := "maintenance." + name + "."
+	if v, ok, err := self.GetKeyValueAsBool(prefix + "enabled"); err != nil {
+		return fmt.Errorf("key %q: %w", prefix+"enabled", err)
+	} else if ok {
+		task.Enabled = v
+	}
+	if s, ok := self.GetKeyValueAsString(prefix + "schedule"); ok {
+		if !maintenanceSchedules[s] {
+			return fmt.Errorf("Unknown schedule '%s' for %sschedule, expected hourly, daily or weekly: %w", s, prefix, ErrInvalidValue)
+		}
+		task.Schedule = s
+	}
+	if v, ok, err := self.GetKeyValueAsInt(prefix + "auto"); err != nil {
+		return fmt.Errorf("key %q: %w", prefix+"auto", err)
+	} else if ok {
+		task.Auto = int(v)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGCSettings(t *testing.T) {
+	now := time.Date(2019, 3, 31, 12, 0, 0, 0, time.UTC)
+	cfg, _ := NewConfigFromString("")
+	gc, err := cfg.gcSettings(now)
+	if err != nil {
+		t.Fatalf("Failed to read gc settings: %s", err)
+	}
+	if gc.Auto != 6700 || gc.AutoPackLimit != 50 || !gc.AutoDetach || !gc.WriteCommitGraph ||
+		gc.PruneExpire != (Expiry{Before: now.AddDate(0, 0, -14)}) || gc.LogExpiry != (Expiry{Before: now.AddDate(0, 0, -1)}) {
+		t.Errorf("Expect git's defaults, but got %+v", gc)
+	}
+
+	cfg, _ = NewConfigFromString("[gc]\n\tauto = 0\n\tbigPackThreshold = 2g\n\tpruneExpire = never\n\treflogExpire = 1.year.ago\n")
+	gc, err = cfg.gcSettings(now)
+	if err != nil {
+		t.Fatalf("Failed to read gc settings: %s", err)
+	}
+	if gc.Auto != 0 || gc.BigPackThreshold != 2<<30 || !gc.PruneExpire.Never || gc.ReflogExpire.Before != now.AddDate(-1, 0, 0) {
+		t.Errorf("Unexpected settings %+v", gc)
+	}
+	cfg, _ = NewConfigFromString("[gc]\n\tpruneExpire = later\n")
+	if _, err := cfg.GCSettings(); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expect ErrTypeMismatch for a bad expiry date, but got %v", err)
+	}
+}
+
+func TestMaintenanceSettings(t *testing.T) {
+	cfg, _ := NewConfigFromString("[gc]\n\tauto = 100\n")
+	m, err := cfg.MaintenanceSettings()
+	if err != nil {
+		t.Fatalf("Failed to read maintenance settings: %s", err)
+	}
+	if !m.Auto || m.Strategy != "none" || m.Tasks["gc"] != (MaintenanceTask{Enabled: true, Auto: 100}) ||
+		m.Tasks["commit-graph"] != (MaintenanceTask{Auto: 100}) || len(m.Tasks) != len(maintenanceTasks) {
+		t.Errorf("Expect git's defaults, but got %+v", m)
+	}
+
+	cfg, _ = NewConfigFromString(`[maintenance]
+	strategy = incremental
+	repo = /src/a
+	repo = /src/b
+[maintenance "prefetch"]
+	enabled = true
+	schedule = daily
+[maintenance "loose-objects"]
+	auto = 0
+[maintenance "custom"]
+	enabled = true
+`)
+	m, err = cfg.MaintenanceSettings()
+	if err != nil {
+		t.Fatalf("Failed to read maintenance settings: %s", err)
+	}
+	if !reflect.DeepEqual(m.Repos, []string{"/src/a", "/src/b"}) {
+		t.Errorf("Expect both repos, but got %v", m.Repos)
+	}
+	for name, expect := range map[string]MaintenanceTask{
+		"prefetch":      {Enabled: true, Schedule: "daily"},
+		"loose-objects": {Schedule: "daily"},
+		"commit-graph":  {Schedule: "hourly", Auto: 100},
+		"custom":        {Enabled: true},
+	} {
+		if got := m.Tasks[name]; got != expect {
+			t.Errorf("Expect task %s %+v, but got %+v", name, expect, got)
+		}
+	}
+
+	for _, data := range []string{"[maintenance]\n\tstrategy = often\n", "[maintenance \"gc\"]\n\tschedule = monthly\n"} {
+		cfg, _ = NewConfigFromString(data)
+		if _, err := cfg.MaintenanceSettings(); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Expect ErrInvalidValue for %q, but got %v", data, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Writes the config as the block of the file managed by owner, e.g.
+//
+//	# BEGIN managed by corp-policy
+//	[http]
+//		sslVerify = true
+//	# END managed by corp-policy
+//
+// replacing what the block held before and leaving the rest of the file,
+// comments included, as it was. A file without the block gets it at the
+// end, and is created if it does not exist. A bare "# END" closes a block
+// too, and ';' may be used in place of '#'. The owner must fit on one line.
+// The file is written and locked as for SetInFile, keeping its permissions
+// unless SaveMode says otherwise.
+func (self *Config) SaveManagedBlock(path, owner string, opts ...SaveOption) error {
+	return editManagedBlock(path, owner, self, opts)
+}
+
+// Removes the block of the file managed by owner, see SaveManagedBlock.
+// It is not an error if the file has no such block.
+func RemoveManagedBlock(path, owner string) error {
+	return editManagedBlock(path, owner, nil, nil)
+}
+
+// Reads the values in the block of the file managed by owner, see
+// SaveManagedBlock. The result is nil if the file or block does not exist.
+func ReadManagedBlock(path, owner string) (*Config, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	begin, end, err := findManagedBlock(lines, owner, path)
+	if err != nil || begin < 0 {
+		return nil, err
+	}
+	return NewConfigFromString(strings.Join(lines[begin+1:end], "\n"))
+}
+
+func editManagedBlock(path, owner string, cfg *Config, opts []SaveOption) error {
+	if err := checkOwner(owner); err != nil {
+		return err
+	}
+	var o saveOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	lock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer lock.abort()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	out, err := setManagedBlock(string(data), path, owner, cfg)
+	if err != nil {
+		return err
+	}
+	return lock.commit([]byte(out), o.perm)
+}
+
+func checkOwner(owner string) error {
+	if strings.TrimSpace(owner) == "" || strings.ContainsAny(owner, "\n\r\x00") {
+		return fmt.Errorf("Invalid managed block owner '%s': %w", owner, ErrInvalidValue)
+	}
+	return nil
+}
+
+// Puts the config in place of the block managed by owner in the config
+// text, or removes the block if cfg is nil.
+func setManagedBlock(data, file, owner string, cfg *Config) (string, error) {
+	lines := strings.Split(data, "\n")
+	begin, end, err := findManagedBlock(lines, owner, file)
+	if err != nil {
+		return "", err
+	}
+	var block []string
+	if cfg != nil {
+		block = append(block, "# BEGIN managed by "+owner)
+		if body := strings.TrimSuffix(cfg.String(), "\n"); body != "" {
+			block = append(block, strings.Split(body, "\n")...)
+		}
+		block = append(block, "# END managed by "+owner)
+	}
+	if begin >= 0 {
+		lines = slices.Replace(lines, begin, end+1, block...)
+		return strings.Join(lines, "\n"), nil
+	}
+	if block == nil {
+		return data, nil
+	}
+	if lines[len(lines)-1] != "" {
+		lines = append(lines, "") // end the last line
+	}
+	lines = slices.Insert(lines, len(lines)-1, block...)
+	return strings.Join(lines, "\n"), nil
+}
+
+// Finds the lines beginning and ending the block managed by owner, which
+// are -1 if there is none.
+func findManagedBlock(lines []string, owner, file string) (begin, end int, err error) {
+	begin, end = -1, -1
+	for i, line := range lines {
+		text, ok := managedMarker(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case text == "BEGIN managed by "+owner:
+			if begin >= 0 {
+				return -1, -1, fmt.Errorf("Block managed by '%s' begins twice in '%s', at lines %d and %d: %w", owner, file, begin+1, i+1, ErrInvalidValue)
+			}
+			begin = i
+		case begin >= 0 && end < 0 && (text == "END" || text == "END managed by "+owner):
+			end = i
+		}
+	}
+	if begin >= 0 && end < 0 {
+		return -1, -1, fmt.Errorf("Block managed by '%s' in '%s' at line %d is not ended: %w", owner, file, begin+1, ErrInvalidValue)
+	}
+	return begin, end, nil
+}
+
+// Gets the text of a comment line, without its comment character.
+func managedMarker(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || (line[0] != '#' && line[0] != ';') {
+		return "", false
+	}
+	return strings.TrimSpace(line[1:]), true
+}
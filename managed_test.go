@@ -0,0 +1,80 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManagedBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	mine := "[user]\n\tname = Joe ; me\n\n"
+	os.WriteFile(path, []byte(mine), 0600)
+
+	cfg, _ := NewBuilder().Section("http", "").Set("sslVerify", "true").Build()
+	if err := cfg.SaveManagedBlock(path, "corp"); err != nil {
+		t.Fatalf("Failed to save block: %s", err)
+	}
+	expect := mine + "# BEGIN managed by corp\n[http]\n\tsslVerify = true\n# END managed by corp\n"
+	testFileContents(t, path, expect)
+
+	// hand edits around the block are kept, its contents are regenerated
+	edited := "# top\n" + expect[:len(expect)-len("# END managed by corp\n")] + "\tsslVerify = false\n; END\n[core]\n\tbare = false\n"
+	os.WriteFile(path, []byte(edited), 0600)
+	cfg, _ = NewBuilder().Section("http", "").Set("proxy", "p").Build()
+	if err := cfg.SaveManagedBlock(path, "corp"); err != nil {
+		t.Fatalf("Failed to save block again: %s", err)
+	}
+	testFileContents(t, path, "# top\n"+mine+"# BEGIN managed by corp\n[http]\n\tproxy = p\n# END managed by corp\n[core]\n\tbare = false\n")
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expect permissions kept, but got %v", info.Mode().Perm())
+	}
+
+	got, err := ReadManagedBlock(path, "corp")
+	if err != nil || got == nil {
+		t.Fatalf("Failed to read block: %v", err)
+	}
+	testValue(t, got, "http.proxy", "p", true)
+	testValue(t, got, "core.bare", "", false)
+	if got, err := ReadManagedBlock(path, "other"); got != nil || err != nil {
+		t.Errorf("Expect no block for another owner, but got %v (%v)", got, err)
+	}
+
+	if err := RemoveManagedBlock(path, "corp"); err != nil {
+		t.Fatalf("Failed to remove block: %s", err)
+	}
+	testFileContents(t, path, "# top\n"+mine+"[core]\n\tbare = false\n")
+	if err := RemoveManagedBlock(path, "corp"); err != nil {
+		t.Errorf("Expect removing a missing block to succeed, but got %s", err)
+	}
+
+	for name, data := range map[string]string{
+		"not ended":    "# BEGIN managed by corp\n[a]\n\tb = c\n",
+		"begins twice": "# BEGIN managed by corp\n# END\n# BEGIN managed by corp\n# END\n",
+	} {
+		os.WriteFile(path, []byte(data), 0600)
+		if err := cfg.SaveManagedBlock(path, "corp"); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Expect ErrInvalidValue for a block %s, but got %v", name, err)
+		}
+		testFileContents(t, path, data)
+	}
+	if err := cfg.SaveManagedBlock(path, "a\nb"); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expect ErrInvalidValue for an owner over several lines, but got %v", err)
+	}
+}
+
+func testFileContents(t *testing.T, path, expect string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != expect {
+		t.Errorf("Expect file:\n%s\nbut got:\n%s", expect, data)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"sort"
+)
+
+// A key changed differently on both sides of a Merge3. A nil Base, Ours or
+// Theirs means the key was not set there.
+type MergeConflict struct {
+	Key    string // canonical, as for Diff
+	Base   []string
+	Ours   []string
+	Theirs []string
+}
+
+// Merges the changes made to base in ours and in theirs, key by key, as for
+// a dotfile edited locally while an updated copy is pushed centrally. A key
+// changed on one side only takes that side's values (or is removed); a key
+// changed the same way on both is kept. A key changed differently on both
+// sides is a conflict: the merged config keeps ours for it, and it is
+// listed, sorted by key, for the caller to resolve. All the values of a
+// multi-valued key are taken together.
+//
+// The merged config starts as a copy of ours, so keeps its order; keys
+// taken from theirs are added at the end of their section, and sections
+// new to ours come after its own in the order theirs has them. Sections
+// left empty by removing keys are removed too.
+func Merge3(base, ours, theirs *Config) (*Config, []MergeConflict) {
+	baseVals, ourVals, theirVals := base.keyValues(), ours.keyValues(), theirs.keyValues()
+	seen := make(map[string]bool, len(ourVals)+len(theirVals))
+	keys := make([]string, 0, len(ourVals)+len(theirVals))
+	for _, vals := range []map[string]*ConfigValue{baseVals, ourVals, theirVals} {
+		for key := range vals {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := theirs.keyOrder(keys[i]), theirs.keyOrder(keys[j])
+		if a != b {
+			return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+		}
+		return keys[i] < keys[j]
+	})
+	out := ours.clone()
+	var conflicts []MergeConflict
+	for _, key := range keys {
+		b, o, t := baseVals[key], ourVals[key], theirVals[key]
+		switch {
+		case sameMergeValues(o, t), sameMergeValues(t, b):
+			// nothing to take from theirs
+		case sameMergeValues(o, b):
+			out.takeValues(key, theirs, t)
+			if t == nil {
+				section, subSection, _ := ParseSectionKey(key)
+				out.pruneEmpty(section, subSection)
+			}
+		default:
+			conflicts = append(conflicts, MergeConflict{Key: key, Base: mergeStrings(b), Ours: mergeStrings(o), Theirs: mergeStrings(t)})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Key < conflicts[j].Key })
+	return out, conflicts
+}
+
+// Gets where the section and sub-section of a key come in the config, as
+// their seq; keys not in it come first.
+func (self *Config) keyOrder(key string) [2]int {
+	section, subSection, _ := ParseSectionKey(key)
+	s := self.LookupSection(section)
+	if s == nil {
+		return [2]int{}
+	}
+	out := [2]int{s.seq, 0}
+	if ss := self.LookupSubSection(section, subSection); subSection != "" && ss != nil {
+		out[1] = ss.seq
+	}
+	return out
+}
+
+// Reports whether a key has the same values in both, nil being unset.
+func sameMergeValues(a, b *ConfigValue) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return sameValues(a, b)
+}
+
+func mergeStrings(cv *ConfigValue) []string {
+	if cv == nil {
+		return nil
+	}
+	return cv.ValuesAsStrings()
+}
+
+// Sets the key to the values of cv, read from other, or removes it if nil.
+func (self *Config) takeValues(key string, other *Config, cv *ConfigValue) {
+	section, subSection, name := ParseSectionKey(key)
+	if cv == nil {
+		if values := self.LookupValueSet(section, subSection); values != nil {
+			delete(*values, name)
+		}
+		return
+	}
+	if orig, ok := other.OrigCaseKey(key); ok {
+		section, subSection, _ = ParseSectionKey(orig)
+		if s := other.LookupSection(section); s != nil {
+			section = s.OrigCaseName
+		}
+	}
+	values := self.EnsureValueSet(section, subSection)
+	if values == nil {
+		return
+	}
+	target := values.EnsureConfigValues(cv.OrigCaseName)
+	target.Entries = target.Entries[:0]
+	for _, e := range cv.Entries {
+		e.block = nil
+		target.Entries = append(target.Entries, e)
+	}
+	target.syncValue()
+}
@@ -0,0 +1,56 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	parse := func(data string) *Config {
+		cfg, err := NewConfigFromString(data)
+		if err != nil {
+			t.Fatalf("Failed to parse: %s", err)
+		}
+		return cfg
+	}
+	base := parse("[user]\n\tname = Joe\n\temail = joe@old\n[core]\n\teditor = vi\n\tpager = less\n[alias]\n\tst = status\n")
+	// locally: a new alias, editor changed, pager removed
+	ours := parse("[user]\n\tname = Joe\n\temail = joe@old\n[core]\n\teditor = vim\n[alias]\n\tst = status\n\tco = checkout\n")
+	// centrally: email changed, a new remote, the same alias added, editor changed otherwise, st removed
+	theirs := parse("[user]\n\tname = Joe\n\temail = joe@new\n[core]\n\teditor = nano\n\tpager = less\n[alias]\n\tco = checkout\n[Remote \"Origin\"]\n\tfetch = a\n\tfetch = b\n")
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	expect := []MergeConflict{{Key: "core.editor", Base: []string{"vi"}, Ours: []string{"vim"}, Theirs: []string{"nano"}}}
+	if !reflect.DeepEqual(conflicts, expect) {
+		t.Errorf("Expect conflicts %+v, but got %+v", expect, conflicts)
+	}
+	want := "[user]\n\temail = joe@new\n\tname = Joe\n[core]\n\teditor = vim\n[alias]\n\tco = checkout\n[Remote \"Origin\"]\n\tfetch = a\n\tfetch = b\n"
+	if out := merged.String(); out != want {
+		t.Errorf("Expect merged:\n%s\nbut got:\n%s", want, out)
+	}
+	if got, _ := ours.GetKeyValueAsString("user.email"); got != "joe@old" {
+		t.Errorf("Expect ours left untouched, but got email %s", got)
+	}
+
+	// both adding the key differently conflicts with no base
+	merged, conflicts = Merge3(parse(""), parse("[a]\n\tb = 1\n"), parse("[a]\n\tb = 2\n"))
+	if len(conflicts) != 1 || conflicts[0].Base != nil {
+		t.Errorf("Expect a conflict with no base, but got %+v", conflicts)
+	}
+	testValue(t, merged, "a.b", "1", true)
+
+	// new sections follow theirs' order every time, and emptied ones go
+	base = parse("[gone]\n\tx = 1\n[keep]\n\ty = 1\n")
+	theirs = parse("[keep]\n\ty = 1\n[zeta]\n\ta = 1\n[alpha]\n\tb = 1\n[mid \"s\"]\n\tc = 1\n[mid \"r\"]\n\td = 1\n")
+	want = "[keep]\n\ty = 1\n[zeta]\n\ta = 1\n[alpha]\n\tb = 1\n[mid \"s\"]\n\tc = 1\n[mid \"r\"]\n\td = 1\n"
+	for i := 0; i < 20; i++ {
+		merged, _ = Merge3(base, parse("[gone]\n\tx = 1\n[keep]\n\ty = 1\n"), theirs)
+		if out := merged.String(); out != want {
+			t.Fatalf("Expect merged:\n%s\nbut got:\n%s", want, out)
+		}
+	}
+}
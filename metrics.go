@@ -0,0 +1,141 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Callbacks told about config loading, for exporting metrics (e.g. to
+// Prometheus) from long running services. Any may be nil. They are called
+// synchronously from whichever goroutine is loading, so must be quick and
+// safe for concurrent use. They are process wide, see SetMetrics and
+// AddMetrics.
+type Metrics struct {
+	// After each file or string is parsed, including each included file.
+	Parsed func(ParseEvent)
+	// For each include.path or includeIf.<condition>.path followed, whether
+	// or not the file exists.
+	Included func(from, file string)
+	// For each lookup in one of the caches, named by CacheFiles,
+	// CacheIncludes or CacheValues.
+	CacheLookup func(cache string, hit bool)
+	// After a config is read again by ReloadFromFile or a Watcher.
+	Reloaded func(ReloadEvent)
+}
+
+// The caches reported to Metrics.CacheLookup.
+const (
+	CacheFiles    = "files"    // files read by a CachingLoader
+	CacheIncludes = "includes" // files read following includes, kept for reloads
+	CacheValues   = "values"   // values converted by a ValueCache
+)
+
+// One parse, see Metrics.Parsed.
+type ParseEvent struct {
+	File     string // empty for configs read from strings
+	Bytes    int    // bytes read, counting each line end as one
+	Lines    int
+	Duration time.Duration
+	Err      error // nil if the parse succeeded
+}
+
+// One reload, see Metrics.Reloaded.
+type ReloadEvent struct {
+	Files    []string // the files re-read, not counting included files
+	Changes  int      // keys whose values changed
+	Duration time.Duration
+	Err      error // nil if the reload succeeded, when the old config is kept
+}
+
+// The callbacks of every package or program using SetMetrics or AddMetrics
+// in the process, replaced whole on change so reports need no lock.
+var (
+	metricsMu sync.Mutex
+	metrics   atomic.Pointer[[]*Metrics]
+)
+
+// Sets the callbacks told about config loading by every Config in the
+// process, replacing any set before, including those added with
+// AddMetrics. nil stops reporting. Libraries, which share the process with
+// others, should use AddMetrics instead. Safe for concurrent use.
+func SetMetrics(m *Metrics) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	if m == nil {
+		metrics.Store(nil)
+		return
+	}
+	metrics.Store(&[]*Metrics{m})
+}
+
+// Adds callbacks told about config loading by every Config in the process,
+// alongside any already set, returning a function removing them again.
+// Safe for concurrent use.
+func AddMetrics(m *Metrics) func() {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	var list []*Metrics
+	if cur := metrics.Load(); cur != nil {
+		list = slices.Clone(*cur)
+	}
+	list = append(list, m)
+	metrics.Store(&list)
+	return func() {
+		metricsMu.Lock()
+		defer metricsMu.Unlock()
+		cur := metrics.Load()
+		if cur == nil {
+			return
+		}
+		if i := slices.Index(*cur, m); i >= 0 {
+			list := slices.Delete(slices.Clone(*cur), i, i+1)
+			metrics.Store(&list)
+		}
+	}
+}
+
+// Gets the callbacks to report to.
+func allMetrics() []*Metrics {
+	if list := metrics.Load(); list != nil {
+		return *list
+	}
+	return nil
+}
+
+func reportParse(ev ParseEvent) {
+	for _, m := range allMetrics() {
+		if m.Parsed != nil {
+			m.Parsed(ev)
+		}
+	}
+}
+
+func reportInclude(from, file string) {
+	for _, m := range allMetrics() {
+		if m.Included != nil {
+			m.Included(from, file)
+		}
+	}
+}
+
+func reportCache(cache string, hit bool) {
+	for _, m := range allMetrics() {
+		if m.CacheLookup != nil {
+			m.CacheLookup(cache, hit)
+		}
+	}
+}
+
+func reportReload(files []string, changes int, start time.Time, err error) {
+	for _, m := range allMetrics() {
+		if m.Reloaded != nil {
+			m.Reloaded(ReloadEvent{Files: files, Changes: changes, Duration: time.Since(start), Err: err})
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// Counts what Metrics is told, as an exporter would.
+type testMetrics struct {
+	mu       sync.Mutex
+	parses   []ParseEvent
+	includes []string
+	hits     map[string]int
+	misses   map[string]int
+	reloads  []ReloadEvent
+}
+
+func (self *testMetrics) install(t *testing.T) {
+	self.hits, self.misses = map[string]int{}, map[string]int{}
+	SetMetrics(&Metrics{
+		Parsed: func(ev ParseEvent) {
+			self.mu.Lock()
+			defer self.mu.Unlock()
+			self.parses = append(self.parses, ev)
+		},
+		Included: func(from, file string) {
+			self.mu.Lock()
+			defer self.mu.Unlock()
+			self.includes = append(self.includes, filepath.Base(from)+" -> "+filepath.Base(file))
+		},
+		CacheLookup: func(cache string, hit bool) {
+			self.mu.Lock()
+			defer self.mu.Unlock()
+			if hit {
+				self.hits[cache]++
+			} else {
+				self.misses[cache]++
+			}
+		},
+		Reloaded: func(ev ReloadEvent) {
+			self.mu.Lock()
+			defer self.mu.Unlock()
+			self.reloads = append(self.reloads, ev)
+		},
+	})
+	t.Cleanup(func() { SetMetrics(nil) })
+}
+
+func TestMetrics(t *testing.T) {
+	var m testMetrics
+	m.install(t)
+	dir := t.TempDir()
+	main := "[include]\n\tpath = extra\n[user]\n\tname = Joe\n"
+	writeFiles(t, dir, map[string]string{"config": main, "extra": "[core]\n\teditor = vi\n"})
+	file := filepath.Join(dir, "config")
+	cfg, err := NewConfigFromFileOptions(file, ParseOptions{Includes: &IncludeOptions{}})
+	if err != nil {
+		t.Fatalf("Failed to read: %s", err)
+	}
+	if len(m.parses) != 2 {
+		t.Fatalf("Expect a parse for each file, but got %+v", m.parses)
+	}
+	if ev := m.parses[0]; ev.File != file || ev.Bytes != len(main) || ev.Lines != 4 || ev.Err != nil {
+		t.Errorf("Expect parse of %s with %d bytes in 4 lines, but got %+v", file, len(main), ev)
+	}
+	if len(m.includes) != 1 || m.includes[0] != "config -> extra" {
+		t.Errorf("Expect include of extra from config, but got %v", m.includes)
+	}
+
+	// only the changed file is parsed again
+	writeFiles(t, dir, map[string]string{"config": main + "\temail = joe@x\n"})
+	if _, err := cfg.ReloadFromFile(); err != nil {
+		t.Fatalf("Failed to reload: %s", err)
+	}
+	if len(m.parses) != 3 || m.hits[CacheIncludes] != 1 {
+		t.Errorf("Expect one more parse and an include cache hit, but got %d and %v", len(m.parses), m.hits)
+	}
+	if len(m.reloads) != 1 || m.reloads[0].Changes != 1 || m.reloads[0].Files[0] != file || m.reloads[0].Err != nil {
+		t.Errorf("Expect a reload with 1 change, but got %+v", m.reloads)
+	}
+	os.WriteFile(file, []byte("[bad"), 0644)
+	if _, err := cfg.ReloadFromFile(); err == nil {
+		t.Fatalf("Expect reload of a bad file to fail")
+	}
+	if n := len(m.parses); n != 4 || m.parses[n-1].Err == nil {
+		t.Errorf("Expect failed parse to be reported, but got %+v", m.parses)
+	}
+	if len(m.reloads) != 2 || m.reloads[1].Err == nil {
+		t.Errorf("Expect failed reload to be reported, but got %+v", m.reloads)
+	}
+
+	loader := NewCachingLoader()
+	for i := 0; i < 2; i++ {
+		loader.Load(filepath.Join(dir, "extra"))
+	}
+	if m.hits[CacheFiles] != 1 || m.misses[CacheFiles] != 1 {
+		t.Errorf("Expect 1 hit and 1 miss in the loader, but got %v and %v", m.hits, m.misses)
+	}
+	cache := cfg.NewValueCache()
+	defer cache.Close()
+	for i := 0; i < 3; i++ {
+		cache.String("core.editor")
+	}
+	if m.hits[CacheValues] != 2 || m.misses[CacheValues] != 1 {
+		t.Errorf("Expect 2 hits and 1 miss in the value cache, but got %v and %v", m.hits, m.misses)
+	}
+
+	added := 0
+	remove := AddMetrics(&Metrics{Parsed: func(ParseEvent) { added++ }})
+	n := len(m.parses)
+	NewConfigFromString("[core]\n")
+	remove()
+	NewConfigFromString("[core]\n")
+	if added != 1 || len(m.parses) != n+2 {
+		t.Errorf("Expect added metrics told alongside those set until removed, but got %d and %d", added, len(m.parses)-n)
+	}
+
+	n = len(m.parses)
+	SetMetrics(nil)
+	NewConfigFromString("[core]\n")
+	if len(m.parses) != n {
+		t.Errorf("Expect no reports once metrics are unset")
+	}
+}
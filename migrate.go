@@ -0,0 +1,208 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A key moved by MigrateKeys.
+type KeyRename struct {
+	From string
+	To   string
+}
+
+// A key pattern from a MigrateKeys mapping, split into its parts.
+// Any part may be "*", matching every name in that position.
+type migratePattern struct {
+	section, subSection, name string
+	hasSubSection             bool
+}
+
+func parseMigratePattern(pattern string) (migratePattern, error) {
+	first := strings.IndexByte(pattern, '.')
+	last := strings.LastIndexByte(pattern, '.')
+	if first <= 0 || last == len(pattern)-1 {
+		return migratePattern{}, fmt.Errorf("Pattern '%s' must be of form section.key or section.subsection.key: %w", pattern, ErrInvalidKey)
+	}
+	out := migratePattern{section: pattern[:first], name: pattern[last+1:]}
+	if first != last {
+		out.subSection = pattern[first+1 : last]
+		out.hasSubSection = true
+	}
+	if out.section == "*" {
+		return migratePattern{}, fmt.Errorf("Pattern '%s' must name a section: %w", pattern, ErrInvalidKey)
+	}
+	if err := checkSubSection(pattern, out.subSection); err != nil {
+		return migratePattern{}, err
+	}
+	return out, nil
+}
+
+// The wildcard parts of a pattern, in order.
+func (self migratePattern) wildcards() int {
+	cnt := 0
+	if self.subSection == "*" {
+		cnt++
+	}
+	if self.name == "*" {
+		cnt++
+	}
+	return cnt
+}
+
+// Matches the pattern against a key, returning the names matched by each
+// wildcard.
+func (self migratePattern) match(section, subSection, name string, hasSubSection bool) ([]string, bool) {
+	if !strings.EqualFold(self.section, section) || self.hasSubSection != hasSubSection {
+		return nil, false
+	}
+	captured := make([]string, 0, 2)
+	if self.subSection == "*" {
+		captured = append(captured, subSection)
+	} else if self.subSection != subSection {
+		return nil, false
+	}
+	if self.name == "*" {
+		captured = append(captured, name)
+	} else if !strings.EqualFold(self.name, name) {
+		return nil, false
+	}
+	return captured, true
+}
+
+// Fills the wildcards of the pattern with captured names.
+func (self migratePattern) fill(captured []string) (string, string, string) {
+	subSection, name := self.subSection, self.name
+	if subSection == "*" {
+		subSection, captured = captured[0], captured[1:]
+	}
+	if name == "*" {
+		name = captured[0]
+	}
+	return self.section, subSection, name
+}
+
+// Renames keys according to the mapping of old to new key names, keeping
+// all their values in order. Patterns may use "*" for a whole sub-section
+// or key name, which the new name must have in the same places, e.g.
+// "old.*.url" -> "new.*.url" or "old.*" -> "new.*".
+// If a new key already has values the moved ones are put before them, so
+// the values already under the new name stay in effect. Sub-sections and
+// sections left empty are removed. Returns the keys moved, by old name.
+func (self *Config) MigrateKeys(mapping map[string]string) ([]KeyRename, error) {
+	type rule struct {
+		from, to migratePattern
+	}
+	rules := make([]rule, 0, len(mapping))
+	for from, to := range mapping {
+		fromPat, err := parseMigratePattern(from)
+		if err != nil {
+			return nil, err
+		}
+		toPat, err := parseMigratePattern(to)
+		if err != nil {
+			return nil, err
+		}
+		if fromPat.wildcards() != toPat.wildcards() || (fromPat.subSection == "*") != (toPat.subSection == "*") {
+			return nil, fmt.Errorf("Pattern '%s' must use '*' in the same places as '%s': %w", to, from, ErrInvalidKey)
+		}
+		rules = append(rules, rule{from: fromPat, to: toPat})
+	}
+
+	type move struct {
+		KeyRename
+		section, subSection, name string // new key, as given in the mapping
+		hasSubSection             bool
+		values                    ConfigValueSet
+		oldSection, oldSubSection string
+		oldName                   string
+	}
+	moves := make([]move, 0, 10)
+	collect := func(section, subSection string, hasSubSection bool, values ConfigValueSet) error {
+		for name := range values {
+			var found *move
+			for _, r := range rules {
+				captured, ok := r.from.match(section, subSection, name, hasSubSection)
+				if !ok {
+					continue
+				}
+				s, ss, k := r.to.fill(captured)
+				m := move{section: s, subSection: ss, name: k, hasSubSection: r.to.hasSubSection, values: values, oldSection: section, oldSubSection: subSection, oldName: name}
+				m.From = joinKey(section, subSection, name)
+				m.To = joinKey(strings.ToLower(s), ss, strings.ToLower(k))
+				if found != nil && found.To != m.To {
+					return fmt.Errorf("Key '%s' matches patterns giving both '%s' and '%s': %w", m.From, found.To, m.To, ErrInvalidKey)
+				}
+				found = &m
+			}
+			if found != nil && found.From != found.To {
+				moves = append(moves, *found)
+			}
+		}
+		return nil
+	}
+	for sName, s := range self.Sections {
+		if err := collect(sName, "", false, s.Values); err != nil {
+			return nil, err
+		}
+		for ssName, ss := range s.SubSections {
+			if err := collect(sName, ssName, true, ss.Values); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].From < moves[j].From })
+
+	// take every moved key out before adding any, so a -> b, b -> c moves
+	// each key once
+	taken := make([]*ConfigValue, len(moves))
+	for i, m := range moves {
+		taken[i] = m.values[m.oldName]
+		delete(m.values, m.oldName)
+	}
+	out := make([]KeyRename, len(moves))
+	changes := make([]KeyChange, 0, 2*len(moves))
+	for i, m := range moves {
+		cv := taken[i]
+		subSection := ""
+		if m.hasSubSection {
+			subSection = m.subSection
+		}
+		dst := self.EnsureValues(m.section, subSection, m.name)
+		var old []string
+		if len(dst.Entries) > 0 {
+			old = dst.ValuesAsStrings()
+		}
+		dst.Entries = append(append([]ValueEntry(nil), cv.Entries...), dst.Entries...)
+		dst.syncValue()
+		changes = append(changes,
+			KeyChange{Key: m.From, Old: cv.ValuesAsStrings()},
+			KeyChange{Key: m.To, Old: old, New: dst.ValuesAsStrings()})
+		out[i] = m.KeyRename
+	}
+	for _, m := range moves {
+		self.pruneEmpty(m.oldSection, m.oldSubSection)
+	}
+	self.record(MutationRename, changes...)
+	return out, nil
+}
+
+// Removes the sub-section if it has no keys, then the section if it has
+// neither keys nor sub-sections.
+func (self *Config) pruneEmpty(section, subSection string) {
+	s := self.Sections[section]
+	if s == nil {
+		return
+	}
+	if ss := s.SubSections[subSection]; ss != nil && len(ss.Values) == 0 {
+		delete(s.SubSections, subSection)
+	}
+	if len(s.Values) == 0 && len(s.SubSections) == 0 {
+		delete(self.Sections, section)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrateKeys(t *testing.T) {
+	config, err := NewConfigFromString("[oldtool]\n\tlevel = 1\n\tcolor = red\n" +
+		"[oldtool \"a\"]\n\turl = x\n\turl = y\n[oldtool \"B\"]\n\turl = z\n\tkeep = k\n" +
+		"[newtool \"B\"]\n\turl = already\n[empty]\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	var notified []string
+	config.OnChange("*", func(key string, old, new []string) {
+		notified = append(notified, key)
+	})
+	moved, err := config.MigrateKeys(map[string]string{
+		"oldtool.*.url": "newTool.*.URL",
+		"oldtool.*":     "newtool.*",
+	})
+	if err != nil {
+		t.Fatalf("Failed to migrate: %s", err)
+	}
+	expect := []KeyRename{
+		{From: "oldtool.B.url", To: "newtool.B.url"},
+		{From: "oldtool.a.url", To: "newtool.a.url"},
+		{From: "oldtool.color", To: "newtool.color"},
+		{From: "oldtool.level", To: "newtool.level"},
+	}
+	if !reflect.DeepEqual(moved, expect) {
+		t.Errorf("Expect moves %+v but got %+v", expect, moved)
+	}
+	if got := strings.Join(config.GetKeyValuesStrings("newtool.a.url"), ","); got != "x,y" {
+		t.Errorf("Expect both url values moved in order but got %s", got)
+	}
+	if got := strings.Join(config.GetKeyValuesStrings("newtool.B.url"), ","); got != "z,already" {
+		t.Errorf("Expect moved values before existing ones but got %s", got)
+	}
+	testValue(t, config, "newtool.level", "1", true)
+	testValue(t, config, "oldtool.B.keep", "k", true)
+	if config.HasSubSection("oldtool", "a") || !config.HasSection("oldtool") || !config.HasSection("empty") {
+		t.Errorf("Expect only emptied sections to be removed but got:\n%s", config)
+	}
+	if len(notified) != 8 {
+		t.Errorf("Expect old and new keys of each move to be notified but got %v", notified)
+	}
+
+	for _, bad := range []map[string]string{
+		{"a.*.url": "b.url"},
+		{"a.*.url": "b.x.*"},
+		{"*.url": "b.url"},
+		{"a": "b.c"},
+	} {
+		if _, err := config.MigrateKeys(bad); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expect mapping %v to fail with ErrInvalidKey but got %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"strings"
+	"sync"
+)
+
+// Called when the values of a key change. old is nil if the key was added,
+// new is nil if it was removed.
+type ChangeFunc func(key string, old, new []string)
+
+type changeListener struct {
+	id      uint64
+	pattern string
+	fn      ChangeFunc
+}
+
+// The listeners registered on a Config, made with it. Shared by pointer so
+// they carry over when a Config is replaced by a re-read copy (e.g. by a
+// Watcher), and locked as listeners may be added from several goroutines.
+type changeHooks struct {
+	mu        sync.Mutex
+	nextId    uint64
+	listeners []changeListener
+}
+
+// Registers fn to be called whenever a key matching pattern changes, after
+// a Set/Unset or when the Config is reloaded. The pattern is a full key in
+// which '*' matches any run of characters, e.g. "core.*" or "remote.*.url".
+// Section and key names in the pattern are case insensitive, as ever.
+// The returned function removes the registration.
+func (self *Config) OnChange(pattern string, fn ChangeFunc) func() {
+	hooks := self.changeHooks()
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.nextId++
+	id := hooks.nextId
+	s, ss, k := ParseSectionKey(pattern)
+	hooks.listeners = append(hooks.listeners, changeListener{id: id, pattern: joinKey(s, ss, k), fn: fn})
+	return func() {
+		hooks.mu.Lock()
+		defer hooks.mu.Unlock()
+		for i, l := range hooks.listeners {
+			if l.id == id {
+				hooks.listeners = append(hooks.listeners[:i:i], hooks.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Guards making the hooks of a Config not made by NewConfig.
+var hooksInit sync.Mutex
+
+func (self *Config) changeHooks() *changeHooks {
+	hooksInit.Lock()
+	defer hooksInit.Unlock()
+	if self.hooks == nil {
+		self.hooks = &changeHooks{}
+	}
+	return self.hooks
+}
+
+// Calls any listeners matching the changed keys.
+func (self *Config) notify(changes []KeyChange) {
+	if self.hooks == nil || len(changes) == 0 {
+		return
+	}
+	self.hooks.mu.Lock()
+	listeners := append([]changeListener(nil), self.hooks.listeners...)
+	self.hooks.mu.Unlock()
+	for _, c := range changes {
+		for _, l := range listeners {
+			if matchKeyPattern(l.pattern, c.Key) {
+				l.fn(c.Key, c.Old, c.New)
+			}
+		}
+	}
+}
+
+// Simple glob match where '*' matches any (possibly empty) run of characters.
+func matchKeyPattern(pattern, key string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == key
+	}
+	if !strings.HasPrefix(key, parts[0]) {
+		return false
+	}
+	key = key[len(parts[0]):]
+	last := len(parts) - 1
+	for _, p := range parts[1:last] {
+		i := strings.Index(key, p)
+		if i < 0 {
+			return false
+		}
+		key = key[i+len(p):]
+	}
+	return strings.HasSuffix(key, parts[last])
+}
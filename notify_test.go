@@ -0,0 +1,51 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchKeyPattern(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		match        bool
+	}{
+		{"core.bare", "core.bare", true},
+		{"core.*", "core.bare", true},
+		{"core.*", "core", false},
+		{"remote.*.url", "remote.https://x/y.url", true},
+		{"remote.*.url", "remote.origin.pushurl", false},
+		{"*", "anything.at.all", true},
+	}
+	for _, test := range tests {
+		if got := matchKeyPattern(test.pattern, test.key); got != test.match {
+			t.Errorf("Expect pattern '%s' on key '%s' to give %t but got %t\n", test.pattern, test.key, test.match, got)
+		}
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	config, _ := NewConfigFromString("[core]\n    pager = less\n")
+	seen := make([]string, 0, 5)
+	remove := config.OnChange("Core.*", func(key string, old, new []string) {
+		seen = append(seen, key+":"+strings.Join(old, ",")+"->"+strings.Join(new, ","))
+	})
+	config.Set("core.pager", "more")
+	config.Set("core.pager", "more") // no change, no call
+	config.Set("user.name", "Joe")   // not matched
+	config.Set("core.editor", "vi")
+	config.Unset("core.pager")
+	remove()
+	config.Set("core.editor", "emacs")
+	expect := "core.pager:less->more|core.editor:->vi|core.pager:more->"
+	if got := strings.Join(seen, "|"); got != expect {
+		t.Errorf("Expect change calls '%s' but got '%s'\n", expect, got)
+	}
+	if err := config.Set("", "x"); err == nil {
+		t.Errorf("Expect error setting an empty key\n")
+	}
+}
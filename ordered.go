@@ -0,0 +1,53 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"reflect"
+)
+
+// One entry of a map-like field which must keep the order sub-sections are
+// declared in. Load fills a []KV[K, V] field just as it would a map[K]V
+// one, but in file order, e.g.
+//
+//	Remotes []gitconfig.KV[string, Remote] `gcKey:"remote"`
+//
+// Sub-sections given in more than one block keep the place of their first.
+type KV[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+func (KV[K, V]) isKV() {}
+
+// Implemented only by KV types, so they can be spotted by reflection.
+type kvEntry interface {
+	isKV()
+}
+
+var kvEntryType = reflect.TypeOf((*kvEntry)(nil)).Elem()
+
+// Whether the type is a KV, giving its key and value types if so.
+func kvTypes(tp reflect.Type) (reflect.Type, reflect.Type, bool) {
+	if tp.Kind() != reflect.Struct || !tp.Implements(kvEntryType) {
+		return nil, nil, false
+	}
+	return tp.Field(0).Type, tp.Field(1).Type, true
+}
+
+// Fills a []KV field from the sub-sections of the section the key names,
+// see loadSubSections.
+func (self *Config) loadKVSlice(retval reflect.Value, key, defVal string, required, haveDefault bool, tags fieldTags) error {
+	tp := retval.Type()
+	kTp, elemtp, _ := kvTypes(tp.Elem())
+	return self.loadSubSections(key, tp.String(), kTp, elemtp, defVal, required, haveDefault, tags,
+		func(n int) { retval.Set(reflect.MakeSlice(tp, 0, n)) },
+		func(k, v reflect.Value) {
+			entry := reflect.New(tp.Elem()).Elem()
+			entry.Field(0).Set(k)
+			entry.Field(1).Set(v)
+			retval.Set(reflect.Append(retval, entry))
+		})
+}
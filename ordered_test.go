@@ -0,0 +1,70 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadKV(t *testing.T) {
+	names := []string{"zeta", "alpha", "mid", "beta", "omega", "delta", "kappa", "gamma"}
+	var sb strings.Builder
+	for i, name := range names {
+		fmt.Fprintf(&sb, "[remote \"%s\"]\n\turl = https://host/%s\n\tprune = %v\n", name, name, i%2 == 0)
+	}
+	sb.WriteString("[remote \"zeta\"]\n\tfetch = x\n") // a later block keeps zeta's place
+	config, err := NewConfigFromString(sb.String())
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	type Remote struct {
+		URL   string `gcKey:"url"`
+		Prune bool   `gcKey:"prune"`
+	}
+	var target struct {
+		Remotes []KV[string, Remote]              `gcKey:"remote"`
+		URLs    []KV[string, string]              `gcKey:"remote.*.url"`
+		Keys    []KV[string, map[string][]string] `gcKey:"remote.*"`
+		Missing []KV[string, Remote]              `gcKey:"branch"`
+	}
+	if err := config.Load(&target); err != nil {
+		t.Fatalf("Failed to load: %s", err)
+	}
+	if len(target.Remotes) != len(names) || len(target.URLs) != len(names) || len(target.Keys) != len(names) {
+		t.Fatalf("Expect %d entries each, but got %+v", len(names), target)
+	}
+	for i, name := range names {
+		r, u, k := target.Remotes[i], target.URLs[i], target.Keys[i]
+		if r.Key != name || r.Value != (Remote{URL: "https://host/" + name, Prune: i%2 == 0}) {
+			t.Errorf("Expect remote %d to be %s, but got %+v", i, name, r)
+		}
+		if u.Key != name || u.Value != "https://host/"+name {
+			t.Errorf("Expect url %d to be for %s, but got %+v", i, name, u)
+		}
+		if k.Key != name || !reflect.DeepEqual(k.Value["url"], []string{"https://host/" + name}) {
+			t.Errorf("Expect keys %d to be for %s, but got %+v", i, name, k)
+		}
+	}
+	if target.Missing != nil {
+		t.Errorf("Expect missing section to leave the field nil, but got %+v", target.Missing)
+	}
+
+	var required struct {
+		Branches []KV[string, string] `gcKey:"branch.*.merge" gcRequired:"true"`
+	}
+	if err := config.Load(&required); !errors.Is(err, ErrRequiredMissing) || strings.Contains(err.Error(), "map[") {
+		t.Errorf("Expect a required missing error naming the KV type, but got: %v", err)
+	}
+	var bad struct {
+		Remotes []KV[[2]int, string] `gcKey:"remote.*.url"`
+	}
+	if err := CheckStruct(&bad); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expect an unsupported key type error, but got: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"iter"
+	"sort"
+)
+
+// Iterates over every key with its values, giving the full key as first
+// written, e.g. "Remote.origin.pushURL". Keys outside any section come
+// first, then each section and its sub-sections in the order first seen;
+// the keys of each are sorted as for KeyNames. Keys with no entries left
+// are skipped.
+func (self *Config) OrigCaseValues() iter.Seq2[string, *ConfigValue] {
+	return func(yield func(string, *ConfigValue) bool) {
+		for key, cv := range self.BaseValues.OrigCaseValues() {
+			if !yield(key, cv) {
+				return
+			}
+		}
+		for _, s := range self.orderedSections() {
+			for key, cv := range s.Values.OrigCaseValues() {
+				if !yield(joinKey(s.OrigCaseName, "", key), cv) {
+					return
+				}
+			}
+			for _, name := range s.subSectionNames() {
+				for key, cv := range s.SubSections[name].Values.OrigCaseValues() {
+					if !yield(joinKey(s.OrigCaseName, name, key), cv) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// Iterates over the keys of the set sorted by their lower case names,
+// giving each as first written with its values. Keys with no entries left
+// are skipped.
+func (self *ConfigValueSet) OrigCaseValues() iter.Seq2[string, *ConfigValue] {
+	return func(yield func(string, *ConfigValue) bool) {
+		names := make([]string, 0, len(*self))
+		for name := range *self {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			cv := (*self)[name]
+			if len(cv.Entries) == 0 {
+				continue
+			}
+			if !yield(cv.OrigCaseName, cv) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrigCaseValues(t *testing.T) {
+	config, err := NewConfigFromString("Top = 1\n[Remote \"Origin\"]\n\tpushURL = a\n\tURL = b\n[core]\n\tfileMode = false\n\tbare\n[REMOTE]\n\tPruneTags = true\n[remote \"Origin\"]\n\tPUSHURL = c\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	var keys []string
+	var counts []int
+	for key, cv := range config.OrigCaseValues() {
+		keys = append(keys, key)
+		counts = append(counts, len(cv.Entries))
+	}
+	expect := []string{"Top", "Remote.PruneTags", "Remote.Origin.pushURL", "Remote.Origin.URL", "core.bare", "core.fileMode"}
+	if !reflect.DeepEqual(keys, expect) || !reflect.DeepEqual(counts, []int{1, 1, 2, 1, 1, 1}) {
+		t.Errorf("Expect keys %v, but got %v with counts %v", expect, keys, counts)
+	}
+
+	config.GetKeyValuesRaw("core.bare").Remove(0)
+	for key := range config.OrigCaseValues() {
+		if key == "core.bare" {
+			t.Errorf("Expect a key with no entries skipped")
+		}
+	}
+	n := 0
+	for range config.OrigCaseValues() {
+		if n++; n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("Expect iteration to stop when asked, but got %d keys", n)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Splits a `git -c` argument: "key=value" sets the key to value, "key="
+// to the empty string and a bare "key" gives it no value, which git reads
+// as true. The key must be valid as for NormalizeKey.
+func ParseOverride(pair string) (Key, ValueEntry, error) {
+	name, value, hasValue := strings.Cut(pair, "=")
+	key, err := NormalizeKey(name)
+	if err != nil {
+		return Key{}, ValueEntry{}, fmt.Errorf("Bad override '%s': %w", pair, err)
+	}
+	if err := validateValue(name, value); err != nil {
+		return Key{}, ValueEntry{}, err
+	}
+	return key, ValueEntry{Value: value, HasValue: hasValue}, nil
+}
+
+// Adds the values of `git -c` style "key=value" pairs (see ParseOverride)
+// after all others, so they take precedence over anything read from files
+// as the command scope does for git. Nothing is added if any pair is bad.
+func (self *Config) ApplyOverrides(pairs []string) error {
+	keys, entries, err := parseOverrides(pairs)
+	if err != nil {
+		return err
+	}
+	changes := make([]KeyChange, 0, len(keys))
+	for i, k := range keys {
+		cv := self.EnsureValues(k.Section, k.SubSection, k.Name)
+		var old []string
+		if len(cv.Entries) > 0 {
+			old = cv.ValuesAsStrings()
+		}
+		cv.Entries = append(cv.Entries, entries[i])
+		cv.syncValue()
+		changes = append(changes, KeyChange{Key: k.String(), Old: old, New: cv.ValuesAsStrings()})
+	}
+	self.record(MutationAdd, changes...)
+	return nil
+}
+
+// Adds the values of `git -c` style pairs to the command scope, creating
+// it if needed, see Config.ApplyOverrides.
+func (self *ConfigSet) ApplyOverrides(pairs []string) error {
+	if _, _, err := parseOverrides(pairs); err != nil {
+		return err
+	}
+	cfg := self.layers[ScopeCommand]
+	if cfg == nil {
+		cfg = NewConfig()
+		self.layers[ScopeCommand] = cfg
+	}
+	return cfg.ApplyOverrides(pairs)
+}
+
+func parseOverrides(pairs []string) ([]Key, []ValueEntry, error) {
+	keys := make([]Key, len(pairs))
+	entries := make([]ValueEntry, len(pairs))
+	for i, pair := range pairs {
+		var err error
+		if keys[i], entries[i], err = ParseOverride(pair); err != nil {
+			return nil, nil, err
+		}
+	}
+	return keys, entries, nil
+}
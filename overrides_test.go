@@ -0,0 +1,50 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	config, _ := NewConfigFromString("[core]\n\teditor = vim\n[remote \"origin\"]\n\tfetch = a\n")
+	changed := make([]string, 0, 3)
+	config.OnChange("*", func(key string, old, new []string) { changed = append(changed, key) })
+	err := config.ApplyOverrides([]string{"core.editor=nano", "Remote.origin.Fetch=b", "core.bare", "user.name=", "alias.x=!echo a=b"})
+	if err != nil {
+		t.Fatalf("Failed to apply overrides: %s", err)
+	}
+	testValue(t, config, "core.editor", "nano", true)
+	testValue(t, config, "user.name", "", true)
+	testValue(t, config, "alias.x", "!echo a=b", true)
+	if bare, _, err := config.GetKeyValueAsBool("core.bare"); err != nil || !bare {
+		t.Errorf("Expect bare key override to be true, but got %v (%v)", bare, err)
+	}
+	if got := config.GetKeyValuesStrings("remote.origin.fetch"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Expect override to add to existing values, but got %v", got)
+	}
+	if len(changed) != 5 {
+		t.Errorf("Expect 5 changes, but got %v", changed)
+	}
+
+	for _, bad := range []string{"nodot=1", "core.=1", "co re.x=1", "=value", "core.bad_name=1"} {
+		if err := config.ApplyOverrides([]string{"core.pager=less", bad}); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expect override '%s' to fail, but got: %v", bad, err)
+		}
+	}
+	testValue(t, config, "core.pager", "", false)
+
+	set := NewConfigSet()
+	set.Add(ScopeLocal, config)
+	if err := set.ApplyOverrides([]string{"core.editor=ed"}); err != nil {
+		t.Fatalf("Failed to apply overrides: %s", err)
+	}
+	testValue(t, set.Merged(), "core.editor", "ed", true)
+	if got := set.Scopes(); !reflect.DeepEqual(got, []Scope{ScopeLocal, ScopeCommand}) {
+		t.Errorf("Expect overrides in command scope, but got %v", got)
+	}
+}
@@ -5,31 +5,202 @@ package gitconfig
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
-	"unicode"
+	"io"
+	"strings"
+	"sync"
+	"time"
 )
 
+// The parser works on the bytes of each line. Everything with a meaning to
+// the syntax is ASCII, so multi-byte UTF-8 sequences are only ever copied.
+// charPos is the byte offset of the next unread byte of the current line.
 type Parser struct {
-	Reader           *bufio.Scanner
-	Config           *Config
-	lineNo           uint64
-	charPos          uint64
-	curLine          string
-	hadNonWhiteSpace bool
-	inEscape         bool
-	inQuote          bool
-	section          string
-	subSection       string
-}
-
-// advance to the next line
+	Reader     *bufio.Scanner
+	Config     *Config
+	Options    ParseOptions
+	lineNo     uint64
+	charPos    uint64
+	curLine    string
+	section    string
+	subSection string
+	file       string             // recorded as the origin of values, if known
+	buf        []byte             // scratch space for values needing unescaping
+	scanBuf    []byte             // initial buffer for Reader, kept over Resets
+	block      *sectionBlock      // the section header values are being read under
+	spans      *[]entrySpan       // where each key was read, if wanted
+	parts      *[]filePart        // if following includes, the values before each
+	comment    string             // trailing comment of the value last read, from its comment character
+	quoted     bool               // the value last read had quotes, see ValueEntry.Quoted
+	bytes      int                // read so far, for Metrics and ParseLimits
+	values     int                // read so far, for ParseLimits
+	sections   int                // headers read so far, for ParseLimits
+	limit      *PartialParseError // set once a limit is reached
+}
+
+// Where a key and its value were read, for editing the file in place.
+type entrySpan struct {
+	block   *sectionBlock // nil outside any section
+	name    string        // as written
+	line    uint64        // 1-based line the key is on
+	col     int           // byte offset of the key in its line
+	endLine uint64        // last line of the value, after any continuations
+	comment string        // trailing comment as written, from its comment character
+}
+
+// Settings changing how a config is read. The zero value reads as git does
+// when given a single file, e.g. with --file.
+type ParseOptions struct {
+	DuplicateSections DuplicateSectionMode
+	// If not nil include.path and includeIf.<condition>.path are followed,
+	// see IncludeOptions.
+	Includes *IncludeOptions
+	// Fail with a *ParseError wrapping a *ValueError on values holding NUL
+	// or control characters, which git reads but which are almost always
+	// corruption, and which Set would refuse.
+	Strict bool
+	// Bounds on what is read, see ParseLimits.
+	Limits ParseLimits
+	// What to do with escapes in values other than \", \\, \n and \t.
+	UnknownEscapes EscapePolicy
+	// When writing the config out, quote values that were quoted when read
+	// even where it is not needed, so rewriting a file after an edit does
+	// not change the lines of values left alone. Changed values are quoted
+	// only as needed.
+	KeepQuoting bool
+	// ASCII characters to allow in keys besides git's letters, digits and '-',
+	// anywhere including the start, for config-like files from other tools:
+	// e.g. "_" for some_key, or "0123456789" for 9key. Empty, the default,
+	// keeps to git's rules. Whitespace and the characters the syntax uses
+	// (=;#[]".\) cannot be allowed.
+	ExtraKeyChars string
+}
+
+// What to do when a section header appears more than once, e.g.
+//
+//	[user]
+//		name = Joe
+//	[core]
+//		bare
+//	[user]
+//		email = joe@example.com
+//
+// Lookups always see the values of every block, as git does; the mode
+// decides whether the blocks are written out separately and whether they
+// are allowed at all. Config.DuplicateSections lists any seen.
+type DuplicateSectionMode int
+
+const (
+	// Write all the section's values in one block, where it first appears.
+	DuplicateSectionsMerge DuplicateSectionMode = iota
+	// Write each block where it was in the file with the values read in it.
+	// Values added later go in the last block of their section.
+	DuplicateSectionsKeep
+	// Fail with a *ParseError wrapping ErrDuplicateSection.
+	DuplicateSectionsError
+)
+
+// What to do with an unknown escape in a value, e.g. the \d of
+// path = C:\dir, see ParseOptions.UnknownEscapes. The policies other than
+// EscapeError add an "unknown-escape" warning for each one.
+type EscapePolicy int
+
+const (
+	// Fail with a *ParseError, as git does when reading the file.
+	EscapeError EscapePolicy = iota
+	// Drop the backslash and keep the character, so \d is read as d.
+	EscapeStrip
+	// Keep the backslash and the character, so \d is read as \d.
+	EscapePreserve
+)
+
+// Prepares the parser to read a new config from r.
+// The Config from any previous read is cleared and filled again, so set
+// Config to nil beforehand to keep it. Options and Config.FoldSubSections
+// are kept.
+func (self *Parser) Reset(r io.Reader) {
+	if self.Config == nil {
+		self.Config = NewConfig()
+	} else {
+		self.Config.reset()
+	}
+	if self.scanBuf == nil {
+		self.scanBuf = make([]byte, 4096)
+	}
+	self.Reader = bufio.NewScanner(r)
+	self.Reader.Buffer(self.scanBuf, bufio.MaxScanTokenSize)
+	self.lineNo = 0
+	self.bytes = 0
+	self.values = 0
+	self.sections = 0
+	self.limit = nil
+	self.charPos = 0
+	self.curLine = ""
+	self.section = ""
+	self.subSection = ""
+	self.file = ""
+	self.block = nil
+}
+
+// A ParserPool keeps Parsers, and the Configs they fill, for reuse by
+// services parsing many configs, saving most per-parse allocations.
+// It is safe for concurrent use.
+type ParserPool struct {
+	pool sync.Pool
+}
+
+func NewParserPool() *ParserPool {
+	return &ParserPool{
+		pool: sync.Pool{New: func() any { return &Parser{} }},
+	}
+}
+
+// Gets a parser ready to read from r. Return it with Put when done with it
+// and its Config.
+func (self *ParserPool) Get(r io.Reader) *Parser {
+	p := self.pool.Get().(*Parser)
+	p.Reset(r)
+	return p
+}
+
+func (self *ParserPool) Put(p *Parser) {
+	p.Reader = nil // don't hold on to the reader
+	if p.limit != nil {
+		p.Config = nil // held by the PartialParseError, so must not be reused
+	}
+	self.pool.Put(p)
+}
+
+// Parses the config in r and passes it to fn. The Config is reused once fn
+// returns, so must not be kept; copy what is needed (e.g. with Merge).
+func (self *ParserPool) Parse(r io.Reader, fn func(*Config) error) error {
+	p := self.Get(r)
+	defer self.Put(p)
+	if err := p.Read(); err != nil {
+		return err
+	}
+	return fn(p.Config)
+}
+
+// advance to the next line, unless a ParseLimits limit is reached
 func (self *Parser) ReadLine() bool {
-	if !self.Reader.Scan() {
+	if self.limit != nil || !self.Reader.Scan() {
 		return false
 	}
 	self.lineNo = self.lineNo + 1
 	self.charPos = 0
 	self.curLine = self.Reader.Text()
+	limits := self.Options.Limits
+	if limits.MaxLineLength > 0 && len(self.curLine) > limits.MaxLineLength {
+		self.tripLimit(LimitLineLength, limits.MaxLineLength)
+		return false
+	}
+	if limits.MaxBytes > 0 && self.bytes+len(self.curLine)+1 > limits.MaxBytes {
+		self.tripLimit(LimitBytes, limits.MaxBytes)
+		return false
+	}
+	self.bytes += len(self.curLine) + 1
 	return true
 }
 
@@ -41,44 +212,92 @@ func (self *Parser) GetCurLine() string {
 }
 
 func (self *Parser) Read() error {
+	if metrics.Load() == nil {
+		return self.read()
+	}
+	start := time.Now()
+	err := self.read()
+	reportParse(ParseEvent{File: self.file, Bytes: self.bytes, Lines: int(self.lineNo), Duration: time.Since(start), Err: err})
+	return err
+}
+
+func (self *Parser) read() error {
+	self.Config.options = self.Options
+	if i := strings.IndexAny(self.Options.ExtraKeyChars, " \t\r\n\v\f=;#[]\".\\"); i >= 0 {
+		return fmt.Errorf("Cannot allow %q in keys, it is part of the config syntax: %w", self.Options.ExtraKeyChars[i], ErrInvalidValue)
+	}
+	if max := self.Options.Limits.MaxLineLength; max > bufio.MaxScanTokenSize-2 && self.lineNo == 0 {
+		buf := self.scanBuf
+		if buf == nil {
+			buf = make([]byte, 4096)
+		}
+		self.Reader.Buffer(buf, max+2) // room for the line end
+	}
 	for self.ReadLine() {
 		if self.curLine == "" {
 			continue
 		}
 		if err := self.readKeyOrSection(); err != nil {
+			if self.limit != nil {
+				// reached reading continuation lines
+				return self.limit
+			}
 			return err
 		}
 	}
+	if self.limit != nil {
+		return self.limit
+	}
+	if err := self.Reader.Err(); err != nil {
+		if max := self.Options.Limits.MaxLineLength; max > 0 && errors.Is(err, bufio.ErrTooLong) {
+			self.lineNo++
+			return self.tripLimit(LimitLineLength, max)
+		}
+		return self.makeError(fmt.Sprintf("Could not read line: %s", err.Error()))
+	}
 	return nil
 }
 
+// git only treats ASCII white space as space
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\v', '\f', '\r':
+		return true
+	}
+	return false
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Gets the (possibly multi-byte) character starting at byte i of the line,
+// for use in error messages.
+func charAt(line string, i int) string {
+	for j, r := range line[i:] {
+		if j == 0 {
+			return string(r)
+		}
+	}
+	return ""
+}
+
 func (self *Parser) readKeyOrSection() error {
-	hadNonWhiteSpace := false
-	inQuote := false
-	inEscape := false
-	out := ""
-	text := self.GetCurLine()
-	for _, r := range text {
-		self.charPos++
-		if unicode.IsSpace(r) {
-			if !hadNonWhiteSpace {
-				continue
-			}
-			if inEscape {
-				return self.makeError(fmt.Sprintf("Unrecognised escape character: '%s'", string(r)))
-			}
-			if inQuote {
-				out += string(r)
-			}
+	line := self.curLine
+	for i := int(self.charPos); i < len(line); i++ {
+		c := line[i]
+		if isSpace(c) {
 			continue
 		}
-		if r == ';' || r == '#' {
+		if c == ';' || c == '#' {
 			return nil // dead line
 		}
-		hadNonWhiteSpace = true
-		// backup the char again
-		self.charPos--
-		if r == '[' {
+		self.charPos = uint64(i)
+		if c == '[' {
 			return self.readSection()
 		}
 		return self.readKeyValue()
@@ -90,211 +309,423 @@ func (self *Parser) readSection() error {
 	inSection := false
 	self.section = ""
 	self.subSection = ""
-	text := self.GetCurLine()
-	for _, r := range text {
+	line := self.curLine
+	for int(self.charPos) < len(line) {
+		c := line[self.charPos]
 		self.charPos++
-		if unicode.IsSpace(r) {
+		if isSpace(c) {
 			continue
 		}
-		if r == ';' || r == '#' {
+		if c == ';' || c == '#' {
 			if inSection {
-				return self.makeError(fmt.Sprintf("Unexpected %s in section name '%s", string(r), self.section))
+				return self.makeError(fmt.Sprintf("Unexpected %s in section name '%s", string(c), self.section))
 			}
 			return nil // comments the line
 		}
-		if r == '[' {
+		if c == '[' {
 			if self.section != "" || inSection {
 				return self.makeError(fmt.Sprintf("Unexpected [ in section name '%s'", self.section))
 			}
 			inSection = true
 			continue
 		}
-		if r == ']' {
+		if c == ']' {
 			if !inSection {
 				return self.makeError(fmt.Sprintf("Unexpected ] in section name '%s'", self.section))
 			}
+			if self.subSection == "" {
+				// deprecated [section.subsection] syntax, git lower cases the subsection
+				if dot := strings.IndexByte(self.section, '.'); dot >= 0 {
+					self.section, self.subSection = self.section[:dot], strings.ToLower(self.section[dot+1:])
+				}
+			}
+			if err := self.countSection(); err != nil {
+				return err
+			}
+			// record the section even if it turns out to be empty
+			self.Config.EnsureValueSet(self.section, self.subSection)
+			if err := self.startBlock(); err != nil {
+				return err
+			}
 			// section declarations may be immediately followed by key = value on the same line
 			return self.readKeyValue()
 		}
-		if r == '"' {
+		if c == '"' {
 			if self.subSection == "" {
 				if self.section == "" {
-					return self.makeError(fmt.Sprintf("Unexpected \" before section name"))
+					return self.makeError("Unexpected \" before section name")
 				}
 				self.charPos--
-				return self.readSubsection()
+				if err := self.readSubsection(); err != nil {
+					return err
+				}
+				// as for git, the closing quote must end the header
+				if int(self.charPos) < len(line) && line[self.charPos] != ']' {
+					return self.makeError(fmt.Sprintf("Unexpected %q after subsection name '%s'", line[self.charPos], self.subSection))
+				}
+				continue
 			}
 			return self.makeError(fmt.Sprintf("Unexpected \" in section name '%s'", self.section))
 		}
-		self.section += string(r)
+		// copy any multi-byte character whole
+		start := self.charPos - 1
+		for int(self.charPos) < len(line) && line[self.charPos] >= 0x80 && c >= 0x80 {
+			self.charPos++
+		}
+		self.section += line[start:self.charPos]
 	}
-	return self.makeError(fmt.Sprintf("Unexpected end of line when reading section"))
+	return self.makeError("Unexpected end of line when reading section")
 }
 
 // looks for a quoted string inside a section name e.g. "foo" from [bar "foo"]
 func (self *Parser) readSubsection() error {
 	inSubSection := false
-	inEscape := false
-	self.subSection = ""
-	text := self.GetCurLine()
-	for _, r := range text {
+	line := self.curLine
+	self.buf = self.buf[:0]
+	for int(self.charPos) < len(line) {
+		c := line[self.charPos]
 		self.charPos++
-		if inEscape {
-			inEscape = false
-			switch r {
-			case '"':
-				self.subSection += "\""
-			case 't':
-				self.subSection += "\t"
-			case 'n':
-				self.subSection += "\n"
-			case '\\':
-				self.subSection += "\\"
+		if c == '\\' && inSubSection {
+			if int(self.charPos) >= len(line) {
+				break
 			}
-			continue // all escaped chars get lost in subsection names...
-		}
-		if unicode.IsSpace(r) {
-			if inSubSection {
-				self.subSection += string(r)
+			// as git does, keep whatever character is escaped, so \t is 't'
+			c = line[self.charPos]
+			self.charPos++
+			if c == 0 {
+				return self.makeError("Unexpected NUL in subsection name\n")
 			}
+			self.buf = append(self.buf, c)
 			continue
 		}
-		if r == '"' {
+		if c == 0 && inSubSection {
+			return self.makeError("Unexpected NUL in subsection name\n")
+		}
+		if c == '"' {
 			if inSubSection {
+				self.subSection = string(self.buf)
 				return nil
 			}
 			inSubSection = true
 			continue
 		}
-		if r == '\\' {
-			inEscape = true
-			continue
+		if !inSubSection {
+			if isSpace(c) {
+				continue
+			}
 		}
-		self.subSection += string(r)
+		self.buf = append(self.buf, c)
 	}
-	return self.makeError(fmt.Sprintf("Unexpected end of line when reading subsection"))
+	return self.makeError("Unexpected end of line when reading subsection")
 }
 
 func (self *Parser) readKeyValue() error {
-	hadNonWhiteSpace := false
-	doneKey := false
-	text := self.GetCurLine()
-	key := ""
-	for _, r := range text {
+	line := self.curLine
+	start, end := -1, -1
+	for int(self.charPos) < len(line) {
+		i := int(self.charPos)
+		c := line[i]
 		self.charPos++
-		if unicode.IsSpace(r) {
-			if hadNonWhiteSpace {
-				doneKey = true
+		if isSpace(c) {
+			if start >= 0 && end < 0 {
+				end = i
 			}
 			continue
 		}
-		if r == '=' {
+		if c == '=' {
+			if start < 0 {
+				return self.makeError("Unexpected '=' starting key, expected a letter\n")
+			}
+			if end < 0 {
+				end = i
+			}
+			origin := self.origin() // before any continuation lines
+			self.comment = ""
+			self.quoted = false
 			value, err := self.readValue(false, "")
 			if err != nil {
 				return err
 			}
-			self.Config.AddKeyValue(self.section, self.subSection, key, &value)
+			if self.limit != nil {
+				// reached reading continuation lines, the value is cut short
+				return self.limit
+			}
+			if self.Options.Strict {
+				if err := self.checkValue(line[start:end], value); err != nil {
+					return err
+				}
+			}
+			if err := self.countValue(); err != nil {
+				return err
+			}
+			self.Config.addEntry(self.section, self.subSection, line[start:end], ValueEntry{Value: value, HasValue: true, Quoted: self.quoted, Comment: commentText(self.comment), Origin: origin, block: self.block, commentChar: commentChar(self.comment)})
+			if self.section == "" {
+				self.warnOutsideSection(line[start:end], origin)
+			}
+			self.addSpan(line[start:end], origin.Line, start)
+			self.checkInclude(line[start:end], value)
 			return nil
 		}
-		if doneKey {
-			return self.makeError(fmt.Sprintf("Unexpected '%s' after key '%s', expected =, whitespace or newline\n", string(r), key))
+		if end >= 0 {
+			return self.makeError(fmt.Sprintf("Unexpected '%s' after key '%s', expected =, whitespace or newline\n", charAt(line, i), line[start:end]))
 		}
 		// config keys must start with an ascii letter, after that they can contain '-' and digits too
-		if !unicode.IsLetter(r) {
-			if !hadNonWhiteSpace {
-				return self.makeError(fmt.Sprintf("Unexpected '%s' starting key, expected a letter\n", string(r)))
-			} else if r != '-' && !unicode.IsDigit(r) {
-				return self.makeError(fmt.Sprintf("Unexpected '%s' in key, expected a ascii letter, hyphen or digit\n", string(r)))
+		if !isLetter(c) && strings.IndexByte(self.Options.ExtraKeyChars, c) < 0 {
+			if start < 0 {
+				return self.makeError(fmt.Sprintf("Unexpected '%s' starting key, expected a letter\n", charAt(line, i)))
+			} else if c != '-' && !isDigit(c) {
+				return self.makeError(fmt.Sprintf("Unexpected '%s' in key, expected a ascii letter, hyphen or digit\n", charAt(line, i)))
 			}
 		}
-		hadNonWhiteSpace = true
-		key += string(r)
+		if start < 0 {
+			start = i
+		}
 	}
-	if key != "" {
-		self.Config.AddKeyValue(self.section, self.subSection, key, nil)
+	if start >= 0 {
+		if end < 0 {
+			end = len(line)
+		}
+		self.comment = ""
+		if err := self.countValue(); err != nil {
+			return err
+		}
+		self.Config.addEntry(self.section, self.subSection, line[start:end], ValueEntry{Origin: self.origin(), block: self.block})
+		if self.section == "" {
+			self.warnOutsideSection(line[start:end], self.origin())
+		}
+		self.addSpan(line[start:end], self.lineNo, start)
 	}
 	return nil
 }
 
+// Reads the value from the current position to the end of the line (or
+// further if the line ends with a continuation backslash).
+// hadNonWhiteSpace and spaceRun carry state over continued lines.
 func (self *Parser) readValue(hadNonWhiteSpace bool, spaceRun string) (string, error) {
+	line := self.curLine
+	if !hadNonWhiteSpace {
+		// fast path: values without quotes or escapes are just a slice of the line
+		rest := line[self.charPos:]
+		if end := strings.IndexAny(rest, "\"\\;#"); end < 0 || rest[end] == ';' || rest[end] == '#' {
+			if end < 0 {
+				end = len(rest)
+			} else {
+				if end > 0 && !isSpace(rest[end-1]) {
+					self.warnComment(rest[end])
+				}
+				self.comment = trimComment(rest[end:])
+			}
+			self.charPos += uint64(len(rest))
+			return strings.TrimFunc(rest[:end], func(r rune) bool { return r < 0x80 && isSpace(byte(r)) }), nil
+		}
+	}
+
 	inEscape := false
-	value := ""
 	quoted := false
-	text := self.GetCurLine()
-	for _, r := range text {
+	value := self.buf[:0]
+	for int(self.charPos) < len(line) {
+		c := line[self.charPos]
 		self.charPos++
-		if unicode.IsSpace(r) {
+		if isSpace(c) {
 			if hadNonWhiteSpace {
-				spaceRun += string(r)
+				spaceRun += string(c)
 			}
 			continue
 		}
-		if r == '\\' && !inEscape {
+		if c == '\\' && !inEscape {
 			inEscape = true
 			continue
 		}
-		if !quoted && (r == ';' || r == '#') {
+		if !quoted && (c == ';' || c == '#') {
+			if hadNonWhiteSpace && self.charPos >= 2 && !isSpace(line[self.charPos-2]) {
+				self.warnComment(c)
+			}
 			// finish line?
-			return value, nil
+			self.comment = trimComment(line[self.charPos-1:])
+			self.charPos = uint64(len(line))
+			self.buf = value
+			return string(value), nil
 		}
 		hadNonWhiteSpace = true
 		if spaceRun != "" {
 			// append any extra spaces
-			value += spaceRun
+			value = append(value, spaceRun...)
 			spaceRun = ""
 		}
 		// deal with line comment characters
-		if r == ';' || r == '#' {
-			value += string(r)
+		if c == ';' || c == '#' {
+			value = append(value, c)
 			continue
 		}
 		if inEscape {
 			inEscape = false
 
-			switch r {
+			switch c {
 			case '"':
-				value += "\""
-			case 't':
-				value += "\t"
-			case 'n':
-				value += "\n"
+				value = append(value, '"')
+			case 't', 'n':
+				if len(value) > 0 && value[len(value)-1] == ':' {
+					self.warnEscape(c)
+				}
+				if c == 't' {
+					value = append(value, '\t')
+				} else {
+					value = append(value, '\n')
+				}
 			case '\\':
-				value += "\\"
+				value = append(value, '\\')
 			default:
-				return value, self.makeError(fmt.Sprintf("Unexpected '%s' in escape only double-quote, n, t and \\ are allowed to be escaped.\n", string(r)))
+				switch self.Options.UnknownEscapes {
+				case EscapeStrip:
+					value = append(value, c)
+				case EscapePreserve:
+					value = append(value, '\\', c)
+				default:
+					self.buf = value
+					return string(value), self.makeError(fmt.Sprintf("Unexpected '%s' in escape only double-quote, n, t and \\ are allowed to be escaped.\n", charAt(line, int(self.charPos)-1)))
+				}
+				self.warnUnknownEscape(charAt(line, int(self.charPos)-1))
 			}
 			continue
 		}
-		if r == '"' {
-			if quoted {
-				quoted = false
-			} else {
-				quoted = true
-			}
+		if c == '"' {
+			quoted = !quoted
+			self.quoted = true
 			continue
 		}
-		value += string(r)
+		value = append(value, c)
 	}
+	self.buf = value
 	if quoted {
-		return value, self.makeError(fmt.Sprintf("Unexpected newline in quoted value string: '%s'.\n", value))
+		return string(value), self.makeError(fmt.Sprintf("Unexpected newline in quoted value string: '%s'.\n", string(value)))
 	}
 	if inEscape {
+		out := string(value)
 		if self.ReadLine() {
 			next, err := self.readValue(hadNonWhiteSpace, spaceRun)
 			if err != nil {
-				return value, err
+				return out, err
 			}
-			return value + next, nil
+			return out + next, nil
 		}
+		return out, nil
+	}
+	return string(value), nil
+}
+
+func trimComment(comment string) string {
+	return strings.TrimFunc(comment, func(r rune) bool { return r < 0x80 && isSpace(byte(r)) })
+}
+
+// Gets the text of a comment after its comment character.
+func commentText(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	return trimComment(comment[1:])
+}
+
+// Gets the comment character a comment starts with, 0 if there is none.
+func commentChar(comment string) byte {
+	if comment == "" {
+		return 0
 	}
-	return value, nil
+	return comment[0]
+}
+
+func (self *Parser) addSpan(name string, line uint64, col int) {
+	if self.spans != nil {
+		*self.spans = append(*self.spans, entrySpan{block: self.block, name: name, line: line, col: col, endLine: self.lineNo, comment: self.comment})
+	}
+}
+
+// Notes the start of a block of values under the section header just read.
+func (self *Parser) startBlock() error {
+	id := lazySectionId(self.section, self.subSection)
+	if self.Config.blockCount[id] > 0 {
+		if self.Options.DuplicateSections == DuplicateSectionsError {
+			err := self.makeError(fmt.Sprintf("Section %s already given\n", sectionHeader(self.section, self.subSection)))
+			err.Err = ErrDuplicateSection
+			return err
+		}
+		self.warn(Warning{Code: "duplicate-section", Origin: self.origin(), Message: fmt.Sprintf("Section %s given again, its values are added to the earlier ones", sectionHeader(self.section, self.subSection))})
+	}
+	self.block = self.Config.addBlock(self.section, self.subSection, self.origin())
+	return nil
+}
+
+// Checks a value read in strict mode, see ParseOptions.Strict.
+func (self *Parser) checkValue(name, value string) error {
+	err := validateValue(joinKey(self.section, self.subSection, name), value)
+	if err == nil {
+		return nil
+	}
+	ve := err.(*ValueError)
+	// point at the character if it is on the line being read
+	if i := strings.IndexByte(self.curLine, ve.Char); i >= 0 {
+		self.charPos = uint64(i) + 1
+	}
+	pe := self.makeError(err.Error() + "\n")
+	pe.Err = err
+	return pe
+}
+
+func (self *Parser) origin() ValueOrigin {
+	return ValueOrigin{File: self.file, Line: self.lineNo}
 }
 
 func (self *Parser) makeError(reason string) *ParseError {
 	return &ParseError{
 		Message: reason,
+		File:    self.file,
 		Line:    self.curLine,
 		LineNo:  self.lineNo,
 		CharPos: self.charPos,
+		Err:     ErrSyntax,
+	}
+}
+
+// Reads a value as it appears after "key =" in a config file, applying the
+// same quoting, escape and comment rules as the parser, e.g.
+// `"a b" \t# note` gives "a b\t". The value may continue over several
+// lines with a trailing backslash, but must not otherwise contain a newline.
+func UnescapeValueString(value string) (string, error) {
+	p := Parser{Reader: bufio.NewScanner(strings.NewReader(value)), Config: NewConfig()}
+	if !p.ReadLine() {
+		return "", nil
+	}
+	out, err := p.readValue(false, "")
+	if err != nil {
+		return "", err
+	}
+	if p.ReadLine() {
+		return "", p.makeError("Unexpected newline in value, expected a backslash before it\n")
+	}
+	return out, nil
+}
+
+// Reads a single "key = value" line (a key without a section, as found
+// inside one) the way the parser would, returning the key as written and
+// its value. A line holding just a key gives an entry with no value. Like
+// UnescapeValueString the value may be continued over several lines.
+func ParseValueLine(line string) (string, ValueEntry, error) {
+	p := Parser{Reader: bufio.NewScanner(strings.NewReader(line)), Config: NewConfig()}
+	if !p.ReadLine() {
+		return "", ValueEntry{}, fmt.Errorf("Cannot read key from an empty line: %w", ErrInvalidKey)
+	}
+	if trimmed := strings.TrimLeft(p.curLine, " \t\v\f\r"); strings.HasPrefix(trimmed, "[") {
+		return "", ValueEntry{}, fmt.Errorf("Cannot read key from section header '%s': %w", line, ErrInvalidKey)
+	}
+	if err := p.readKeyOrSection(); err != nil {
+		return "", ValueEntry{}, err
+	}
+	if p.ReadLine() {
+		return "", ValueEntry{}, p.makeError("Unexpected newline in value, expected a backslash before it\n")
+	}
+	for _, cv := range p.Config.BaseValues {
+		entry := cv.Entries[0]
+		entry.Origin = ValueOrigin{}
+		return cv.OrigCaseName, entry, nil
 	}
+	return "", ValueEntry{}, fmt.Errorf("Cannot read key from a line holding only a comment: %w", ErrInvalidKey)
 }
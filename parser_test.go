@@ -0,0 +1,286 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// builds a config of roughly 40k lines mixing plain, quoted and escaped values
+func benchConfig() string {
+	var sb strings.Builder
+	for i := 0; i < 4000; i++ {
+		fmt.Fprintf(&sb, "[remote \"origin%d\"]\n", i)
+		fmt.Fprintf(&sb, "\turl = https://example.com/repo%d.git\n", i)
+		sb.WriteString("\tfetch = +refs/heads/*:refs/remotes/origin/*\n")
+		sb.WriteString("\t# a comment line\n")
+		sb.WriteString("\tpushurl = git@example.com:repo.git ; trailing comment\n")
+		sb.WriteString("\tmirror\n")
+		sb.WriteString("\tdescription = \"quoted # value\" with \\\"escapes\\\"\n")
+		sb.WriteString("\tlong = first \\\n")
+		sb.WriteString("\t\tsecond\n")
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func BenchmarkParse(b *testing.B) {
+	s := benchConfig()
+	b.SetBytes(int64(len(s)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := NewConfigFromString(s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParserReset(t *testing.T) {
+	pool := NewParserPool()
+	var p *Parser
+	for i, s := range []string{"[a]\n\tk = 1\n", "[b]\n\tk = 2\n"} {
+		p = pool.Get(strings.NewReader(s))
+		if err := p.Read(); err != nil {
+			t.Fatalf("Failed to parse config %d: %s", i, err)
+		}
+		pool.Put(p)
+	}
+	// a reused parser must not leak the previous config's values
+	p.Reset(strings.NewReader("[c]\n\tk = 3\n"))
+	if err := p.Read(); err != nil {
+		t.Fatalf("Failed to parse after reset: %s", err)
+	}
+	testValue(t, p.Config, "a.k", "", false)
+	testValue(t, p.Config, "b.k", "", false)
+	testValue(t, p.Config, "c.k", "3", true)
+
+	p.Config.FoldSubSections = true
+	p.Reset(strings.NewReader("[c \"Sub\"]\n\tk = 4\n"))
+	if err := p.Read(); err != nil {
+		t.Fatalf("Failed to parse after reset: %s", err)
+	}
+	testValue(t, p.Config, "c.sub.k", "4", true)
+
+	err := pool.Parse(strings.NewReader("[d]\n\tk = 4\n"), func(cfg *Config) error {
+		testValue(t, cfg, "d.k", "4", true)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Failed to parse with pool: %s", err)
+	}
+	if err := pool.Parse(strings.NewReader("[d\n"), func(cfg *Config) error { return nil }); err == nil {
+		t.Errorf("Expect pool parse of bad config to fail")
+	}
+}
+
+const smallConfig = "[core]\n\tbare = false\n\tfilemode = true\n[remote \"origin\"]\n\turl = https://example.com/repo.git\n\tfetch = +refs/heads/*:refs/remotes/origin/*\n[branch \"main\"]\n\tremote = origin\n\tmerge = refs/heads/main\n"
+
+func BenchmarkParseSmall(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := NewConfigFromString(smallConfig); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseSmallPooled(b *testing.B) {
+	pool := NewParserPool()
+	noop := func(*Config) error { return nil }
+	r := strings.NewReader(smallConfig)
+	b.ReportAllocs()
+	for b.Loop() {
+		r.Reset(smallConfig)
+		if err := pool.Parse(r, noop); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestUnescapeValueString(t *testing.T) {
+	tests := map[string]string{
+		"plain":                     "plain",
+		"  padded  ":                "padded",
+		"\"  kept  \"":              "  kept  ",
+		"a \"b ; c\" d ; comment":   "a b ; c d",
+		"tab\\there\\nnewline \\\\": "tab\there\nnewline \\",
+		"say \\\"hi\\\" # greet":    "say \"hi\"",
+		"long \\\n  line":           "long   line", // spaces kept, as git does
+		"":                          "",
+	}
+	for in, expect := range tests {
+		out, err := UnescapeValueString(in)
+		if err != nil {
+			t.Errorf("Failed to unescape '%s': %s", in, err)
+		} else if out != expect {
+			t.Errorf("Expect '%s' to unescape to '%s', but got '%s'", in, expect, out)
+		}
+	}
+	for _, in := range []string{"\"open", "bad \\x", "two\nlines"} {
+		if _, err := UnescapeValueString(in); err == nil {
+			t.Errorf("Expect error unescaping '%s'", in)
+		}
+	}
+	for _, value := range []string{" lead", "trail ", "a#b", "semi;colon", "q\"uote", "back\\slash", "multi\nline\ttab"} {
+		if out, err := UnescapeValueString(formatValue(value)); err != nil || out != value {
+			t.Errorf("Expect written value '%s' to read back, but got '%s' (%v)", value, out, err)
+		}
+	}
+	// values the parser warns about, with no config to hold the warnings
+	warned := []struct{ in, expect string }{
+		{"a#b", "a"},            // comment-in-value
+		{"\"x\"y;z", "xy"},      // comment-in-value after a quote
+		{"C:\\temp", "C:\temp"}, // suspicious-escape
+	}
+	for _, test := range warned {
+		if out, err := UnescapeValueString(test.in); err != nil || out != test.expect {
+			t.Errorf("Expect '%s' to unescape to '%s', but got '%s' (%v)", test.in, test.expect, out, err)
+		}
+	}
+}
+
+func TestParseValueLine(t *testing.T) {
+	key, entry, err := ParseValueLine("\tpushURL = \"git@host:x\" ; mirror")
+	if err != nil || key != "pushURL" || entry != (ValueEntry{Value: "git@host:x", HasValue: true, Quoted: true, Comment: "mirror", commentChar: ';'}) {
+		t.Errorf("Expect pushURL = git@host:x, but got %s = %+v (%v)", key, entry, err)
+	}
+	key, entry, err = ParseValueLine("bare")
+	if err != nil || key != "bare" || entry.HasValue {
+		t.Errorf("Expect bare with no value, but got %s = %+v (%v)", key, entry, err)
+	}
+	for _, in := range []string{"", "# just a comment", "[core] bare", "1st = x", "a = b\nc = d"} {
+		if _, _, err := ParseValueLine(in); err == nil {
+			t.Errorf("Expect error parsing line '%s'", in)
+		}
+	}
+}
+
+func TestParseErrorCaret(t *testing.T) {
+	tests := []struct {
+		config string
+		column int
+		shown  string
+	}{
+		{"[core]\n\tname = \"héllo wörld\" \\q\n", 31, "        name = \"héllo wörld\" \\q\n                              ^\n"},
+		{"[core]\n\tnäme = x\n", 10, "        näme = x\n         ^\n"},
+		{"[core]\nab\tc = d\n", 9, "ab      c = d\n        ^\n"},
+	}
+	for _, test := range tests {
+		_, err := NewConfigFromString(test.config)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("Expect a ParseError for %q, but got: %v", test.config, err)
+			continue
+		}
+		if pe.Column() != test.column {
+			t.Errorf("Expect column %d for %q, but got %d", test.column, test.config, pe.Column())
+		}
+		if !strings.Contains(pe.Error(), test.shown) {
+			t.Errorf("Expect error for %q to show:\n%s\nbut got:\n%s", test.config, test.shown, pe.Error())
+		}
+	}
+	pe := &ParseError{Line: "[a", CharPos: 4}
+	if pe.Column() != 4 {
+		t.Errorf("Expect column past the end of the line to be 4, but got %d", pe.Column())
+	}
+}
+
+func TestUnknownEscapes(t *testing.T) {
+	data := "[core]\n\tpath = C:\\dir\\x\\\\y\n"
+	if _, err := NewConfigFromString(data); err == nil {
+		t.Errorf("Expect an unknown escape to fail by default")
+	}
+	for policy, want := range map[EscapePolicy]string{EscapeStrip: "C:dirx\\y", EscapePreserve: "C:\\dir\\x\\y"} {
+		config, err := NewConfigFromStringOptions(data, ParseOptions{UnknownEscapes: policy})
+		if err != nil {
+			t.Fatalf("Failed to parse with policy %d: %s", policy, err)
+		}
+		testValue(t, config, "core.path", want, true)
+		ws := config.Warnings()
+		if len(ws) != 2 || ws[0].Code != "unknown-escape" || ws[0].Origin.Line != 2 {
+			t.Errorf("Expect two unknown-escape warnings, but got %+v", ws)
+		}
+	}
+}
+
+func TestKeepQuoting(t *testing.T) {
+	data := "[core]\n\teditor = \"vim\"\n\tpager = less\n\tpath = a\" b \"c\n[user]\n\tname = \"Joe\"\n"
+	config, err := NewConfigFromStringOptions(data, ParseOptions{KeepQuoting: true})
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	if e, _ := config.GetKeyValuesRaw("core.editor").At(0); !e.Quoted {
+		t.Errorf("Expect core.editor recorded as quoted")
+	}
+	if e, _ := config.GetKeyValuesRaw("core.pager").At(0); e.Quoted {
+		t.Errorf("Expect core.pager recorded as not quoted")
+	}
+	config.GetKeyValuesRaw("user.name").ReplaceAt(0, "Jane")
+	expect := "[core]\n\teditor = \"vim\"\n\tpager = less\n\tpath = \"a b c\"\n[user]\n\tname = Jane\n"
+	if out := config.String(); out != expect {
+		t.Errorf("Expect quoting kept for unchanged values:\n%s\nbut got:\n%s", expect, out)
+	}
+
+	config, _ = NewConfigFromString(data)
+	if out := config.String(); strings.Contains(out, "\"vim\"") {
+		t.Errorf("Expect quotes only where needed by default, but got:\n%s", out)
+	}
+}
+
+func TestExtraKeyChars(t *testing.T) {
+	data := "[tool]\n\tsome_key = a\n\t9lives = b\n\t_x = c\n"
+	if _, err := NewConfigFromString(data); err == nil {
+		t.Errorf("Expect keys with '_' or starting with a digit to fail by default")
+	}
+	config, err := NewConfigFromStringOptions(data, ParseOptions{ExtraKeyChars: "_0123456789"})
+	if err != nil {
+		t.Fatalf("Failed to parse with extra key characters: %s", err)
+	}
+	testValue(t, config, "tool.some_key", "a", true)
+	testValue(t, config, "tool.9lives", "b", true)
+	testValue(t, config, "tool._x", "c", true)
+	if _, err := NewConfigFromStringOptions("[tool]\n\tsome$key = a\n", ParseOptions{ExtraKeyChars: "_"}); err == nil {
+		t.Errorf("Expect characters not allowed still to fail")
+	}
+	for _, chars := range []string{"=", "_.", " ", "\\"} {
+		if _, err := NewConfigFromStringOptions(data, ParseOptions{ExtraKeyChars: chars}); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Expect ErrInvalidValue allowing %q, but got %v", chars, err)
+		}
+	}
+
+	if _, err := NormalizeKey("tool.some_key"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expect NormalizeKey to keep to git's rules, but got %v", err)
+	}
+	if k, err := config.NormalizeKey("tool._X"); err != nil || k.Name != "_x" {
+		t.Errorf("Expect the config to normalize keys it was read with, but got %v, %v", k, err)
+	}
+	file := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(file, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetInFile(file, "tool.some_key", "z"); err == nil {
+		t.Errorf("Expect SetInFile to keep to git's rules by default")
+	}
+	if err := SetInFile(file, "tool.some_key", "z", SetExtraKeyChars("_0123456789")); err != nil {
+		t.Fatalf("Failed to set key with extra characters: %s", err)
+	}
+	config, err = NewConfigFromFileOptions(file, ParseOptions{ExtraKeyChars: "_0123456789"})
+	if err != nil {
+		t.Fatalf("Failed to read back: %s", err)
+	}
+	testValue(t, config, "tool.some_key", "z", true)
+	plan, err := config.Plan([]Edit{{Key: "tool.other_key", Value: "y"}, {Key: "tool._x", Unset: true}})
+	if err != nil {
+		t.Fatalf("Failed to plan edits of keys with extra characters: %s", err)
+	}
+	if !strings.Contains(plan.Diff, "+\tother_key = y\n") || !strings.Contains(plan.Diff, "-\t_x = c\n") || len(plan.Changes) != 2 {
+		t.Errorf("Unexpected plan:\n%s%v", plan.Diff, plan.Changes)
+	}
+}
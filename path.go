@@ -0,0 +1,180 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Expands a leading "~" in a path as git does for pathname values such as
+// core.excludesFile: "~" alone or before a separator is the home
+// directory, "~name" that of the user name. The home directory is $HOME,
+// or on Windows if that is not set %HOMEDRIVE%%HOMEPATH% if it is a
+// directory, then %USERPROFILE%, as for Git for Windows. On Windows '\' separates too, so "~\.gitignore"
+// expands as well. The rest of the path is left as written.
+func ExpandPath(path string) (string, error) {
+	return hostPaths.expand(path)
+}
+
+// Gives the path with every '\' replaced by '/', e.g. for comparing paths
+// written on Windows, where git accepts either. Unlike filepath.ToSlash
+// this is done whatever the OS, so only use it where '\' cannot be part
+// of a name.
+func ToSlashPath(path string) string {
+	return strings.ReplaceAll(path, `\`, "/")
+}
+
+// The parts of the OS path expansion depends on, so Windows rules can be
+// tested anywhere.
+type pathEnv struct {
+	goos       string
+	getenv     func(string) string
+	lookupUser func(name string) (string, error) // gives the user's home directory
+	isDir      func(path string) bool            // nil takes every path as one
+}
+
+var hostPaths = pathEnv{goos: runtime.GOOS, getenv: os.Getenv, lookupUser: userHome, isDir: isDir}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func userHome(name string) (string, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return "", err
+	}
+	return u.HomeDir, nil
+}
+
+func (self pathEnv) isSep(c byte) bool {
+	return c == '/' || (c == '\\' && self.goos == "windows")
+}
+
+func (self pathEnv) expand(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	end := 1
+	for end < len(path) && !self.isSep(path[end]) {
+		end++
+	}
+	var home string
+	var err error
+	if name := path[1:end]; name == "" {
+		home, err = self.home()
+	} else if home, err = self.lookupUser(name); err != nil {
+		err = fmt.Errorf("Cannot expand '%s', no user '%s': %w: %w", path, name, ErrInvalidValue, err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return home + path[end:], nil
+}
+
+func (self pathEnv) home() (string, error) {
+	if home := self.getenv("HOME"); home != "" {
+		return home, nil
+	}
+	if self.goos == "windows" {
+		drive, path := self.getenv("HOMEDRIVE"), self.getenv("HOMEPATH")
+		if drive != "" && path != "" && (self.isDir == nil || self.isDir(drive+path)) {
+			return drive + path, nil
+		}
+		if home := self.getenv("USERPROFILE"); home != "" {
+			return home, nil
+		}
+	}
+	return "", fmt.Errorf("Cannot expand '~', no home directory is set: %w", ErrInvalidValue)
+}
+
+// Whether the path is absolute as git sees it. On Windows that includes
+// drive letter paths ("C:\x" or "C:/x"), UNC paths ("\\server\share") and
+// those starting with either separator, which are relative only to the
+// current drive.
+func (self pathEnv) isAbs(path string) bool {
+	if path == "" {
+		return false
+	}
+	if self.isSep(path[0]) {
+		return true
+	}
+	return self.goos == "windows" && len(path) >= 3 && isLetter(path[0]) && path[1] == ':' && self.isSep(path[2])
+}
+
+// Gives the path with '/' for each separator.
+func (self pathEnv) toSlash(p string) string {
+	if self.goos == "windows" {
+		return strings.ReplaceAll(p, "\\", "/")
+	}
+	return p
+}
+
+// Turns an includeIf gitdir pattern into a glob over a slashed git
+// directory, as git does: "~/" and "./" (relative to the including file
+// from) are expanded, patterns not absolute match at any depth and those
+// ending in '/' match all below. On Windows '\\' separates as '/' does,
+// rather than escaping, so "gitdir:C:\\work\\" works. Reports false if the
+// pattern cannot be expanded.
+func (self pathEnv) gitDirPattern(pattern, from string) (string, bool) {
+	pattern = self.toSlash(pattern)
+	switch {
+	case strings.HasPrefix(pattern, "~/"):
+		home, err := self.home()
+		if err != nil {
+			return "", false
+		}
+		pattern = self.toSlash(filepath.ToSlash(home)) + pattern[1:]
+	case strings.HasPrefix(pattern, "./"):
+		if from == "" {
+			return "", false
+		}
+		pattern = path.Dir(self.toSlash(filepath.ToSlash(from))) + pattern[1:]
+	case !self.isAbs(pattern):
+		pattern = "**/" + pattern
+	}
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return pattern, true
+}
+
+// Checks a gcPath tag: "true" expands string values with ExpandPath,
+// "slash" does that then uses ToSlashPath.
+func checkPathTag(tp reflect.Type, tag string) error {
+	for tp.Kind() == reflect.Ptr || tp.Kind() == reflect.Slice || tp.Kind() == reflect.Array {
+		tp = tp.Elem()
+	}
+	switch {
+	case tag != "true" && tag != "false" && tag != "slash":
+		return fmt.Errorf("Path '%s' must be true, false or slash: %w", tag, ErrInvalidTag)
+	case tp.Kind() != reflect.String:
+		return fmt.Errorf("Path given for %s, only strings can be paths: %w", tp.String(), ErrInvalidTag)
+	}
+	return nil
+}
+
+// Expands a string value as its gcPath tag says.
+func (self fieldTags) pathString(s string) (string, error) {
+	if self.path == "" || self.path == "false" {
+		return s, nil
+	}
+	s, err := ExpandPath(s)
+	if err != nil {
+		return "", err
+	}
+	if self.path == "slash" {
+		s = ToSlashPath(s)
+	}
+	return s, nil
+}
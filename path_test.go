@@ -0,0 +1,136 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpandPath(t *testing.T) {
+	users := func(name string) (string, error) {
+		if name == "joe" {
+			return `C:\Users\joe`, nil
+		}
+		return "", errors.New("unknown user")
+	}
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+	windows := pathEnv{goos: "windows", getenv: env(map[string]string{"USERPROFILE": `C:\Users\me`}), lookupUser: users}
+	msys := pathEnv{goos: "windows", getenv: env(map[string]string{"HOME": "/c/home/me", "USERPROFILE": `C:\Users\me`}), lookupUser: users}
+	drive := pathEnv{goos: "windows", getenv: env(map[string]string{"HOMEDRIVE": "D:", "HOMEPATH": `\me`}), lookupUser: users}
+	both := map[string]string{"HOMEDRIVE": "D:", "HOMEPATH": `\me`, "USERPROFILE": `C:\Users\me`}
+	driveFirst := pathEnv{goos: "windows", getenv: env(both), lookupUser: users}
+	noDrive := pathEnv{goos: "windows", getenv: env(both), lookupUser: users, isDir: func(string) bool { return false }}
+	linux := pathEnv{goos: "linux", getenv: env(map[string]string{"HOME": "/home/me", "USERPROFILE": `C:\Users\me`}), lookupUser: users}
+	for _, tc := range []struct {
+		env        pathEnv
+		path, want string
+	}{
+		{windows, "~", `C:\Users\me`},
+		{windows, `~\.gitignore`, `C:\Users\me\.gitignore`},
+		{windows, "~/.gitignore", `C:\Users\me/.gitignore`},
+		{windows, `~joe\.gitignore`, `C:\Users\joe\.gitignore`},
+		{windows, `C:\x\~`, `C:\x\~`},
+		{msys, "~/.gitignore", "/c/home/me/.gitignore"},
+		{drive, "~/x", `D:\me/x`},
+		{driveFirst, "~/x", `D:\me/x`},
+		{noDrive, "~/x", `C:\Users\me/x`},
+		{linux, "~/x", "/home/me/x"},
+	} {
+		got, err := tc.env.expand(tc.path)
+		if err != nil || got != tc.want {
+			t.Errorf("Expect %s expanded on %s to %q, but got %q (%v)", tc.path, tc.env.goos, tc.want, got, err)
+		}
+	}
+	if _, err := (pathEnv{goos: "windows", getenv: env(nil)}).expand("~/x"); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expect error expanding with no home, but got %v", err)
+	}
+	if _, err := windows.expand("~bob/x"); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expect error expanding an unknown user, but got %v", err)
+	}
+	// '\' only separates on windows, elsewhere this names a user "\x"
+	if got, err := linux.expand(`~\x`); err == nil {
+		t.Errorf("Expect no user '\\x' on linux, but got %q", got)
+	}
+
+	for path, abs := range map[string]bool{
+		`C:\x`: true, "C:/x": true, `\\server\share`: true, "//server/share": true, `\x`: true,
+		"C:x": false, "x/y": false, "": false,
+	} {
+		if windows.isAbs(path) != abs {
+			t.Errorf("Expect %q absolute on windows to be %v", path, abs)
+		}
+	}
+	if linux.isAbs(`C:\x`) || linux.isAbs(`\x`) || !linux.isAbs("/x") {
+		t.Errorf("Expect only '/' paths absolute on linux")
+	}
+	if got := ToSlashPath(`\\server\share\x`); got != "//server/share/x" {
+		t.Errorf("Unexpected slash path %q", got)
+	}
+}
+
+func TestLoadPath(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	cfg, err := NewConfigFromString("[core]\n\texcludesFile = ~/.gitignore\n\thooksPath = ~/hooks\\\\dir\n\tattributesFile = /etc/attrs\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	if path, err := cfg.LookupPath("core.excludesfile"); err != nil || path != "/home/me/.gitignore" {
+		t.Errorf("Expect /home/me/.gitignore but got %q (%v)", path, err)
+	}
+	var s struct {
+		Excludes   string `gcKey:"core.excludesfile" gcPath:"true"`
+		Attributes string `gcKey:"core.attributesfile" gcPath:"true"`
+		Hooks      string `gcKey:"core.hookspath" gcPath:"slash"`
+		Default    string `gcKey:"core.missing" gcPath:"true" gcDefault:"~/default"`
+		Raw        string `gcKey:"core.excludesfile"`
+	}
+	if err := cfg.Load(&s); err != nil {
+		t.Fatalf("Failed to load: %s", err)
+	}
+	if s.Excludes != "/home/me/.gitignore" || s.Attributes != "/etc/attrs" || s.Hooks != "/home/me/hooks/dir" || s.Default != "/home/me/default" || s.Raw != "~/.gitignore" {
+		t.Errorf("Unexpected paths loaded: %+v", s)
+	}
+
+	var bad struct {
+		N int `gcKey:"core.n" gcPath:"true"`
+	}
+	if err := cfg.Load(&bad); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("Expect ErrInvalidTag for a path tag on an int, but got %v", err)
+	}
+}
+
+func TestGitDirPattern(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+	windows := pathEnv{goos: "windows", getenv: env(map[string]string{"USERPROFILE": `C:\Users\me`})}
+	linux := pathEnv{goos: "linux", getenv: env(map[string]string{"HOME": "/home/me"})}
+	for _, tc := range []struct {
+		env                 pathEnv
+		pattern, from, want string
+	}{
+		{windows, `C:\work\`, "", "C:/work/**"},
+		{windows, `~\src\`, "", "C:/Users/me/src/**"},
+		{windows, `.\repo`, `C:\Users\me\.gitconfig`, "C:/Users/me/repo"},
+		{windows, `\\server\share\`, "", "//server/share/**"},
+		{windows, `work\`, "", "**/work/**"},
+		{linux, "~/src/", "", "/home/me/src/**"},
+		{linux, "./repo/", "/home/me/.gitconfig", "/home/me/repo/**"},
+		{linux, `a\*b`, "", `**/a\*b`}, // an escape, not a separator
+	} {
+		if got, ok := tc.env.gitDirPattern(tc.pattern, tc.from); !ok || got != tc.want {
+			t.Errorf("Expect %q on %s to be %q, but got %q", tc.pattern, tc.env.goos, tc.want, got)
+		}
+	}
+	if _, ok := windows.gitDirPattern("./x", ""); ok {
+		t.Errorf("Expect ./ with no including file not to expand")
+	}
+	if !wildmatch("c:/users/me/src/**", "c:/users/me/src/app/.git") {
+		t.Errorf("Expect a Windows gitdir to match its slashed pattern")
+	}
+}
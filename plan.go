@@ -0,0 +1,248 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// One change to a config file, see Config.Plan.
+type Edit struct {
+	Key     string
+	Value   string
+	Unset   bool        // remove every value of the key; Value is not used
+	Options []SetOption // as for SetInFile
+}
+
+// What a series of edits would do to a config file, see Config.Plan.
+// The values of keys matching DefaultSecretPatterns are RedactedValue in
+// Changes and Diff, so they are safe to show.
+type Plan struct {
+	Path    string
+	Old     string      // the file as it was read, empty if it does not exist
+	New     string      // the file as it would be written
+	Changes []KeyChange // keys whose values would change
+	Diff    string      // unified diff of Old and New, empty if they are the same
+}
+
+// Works out what making the edits, in order, to the file the config was
+// read from would do, without writing anything, e.g. to show users before
+// changing their dotfiles. Each edit is made as SetInFile would make it,
+// so comments and layout are kept; unsetting a key which is not in the
+// file fails with ErrKeyNotFound. Plan.Apply then writes the result.
+// Keys may use the ParseOptions.ExtraKeyChars the config was read with.
+func (self *Config) Plan(edits []Edit) (*Plan, error) {
+	if self.source == "" {
+		return nil, fmt.Errorf("Cannot plan edits: %w", ErrNoSource)
+	}
+	return planFile(self.source, edits, self.options.ExtraKeyChars)
+}
+
+// Works out what making the edits to the file would do, see Config.Plan.
+func PlanFile(path string, edits []Edit) (*Plan, error) {
+	return planFile(path, edits, "")
+}
+
+// See PlanFile, extraChars are allowed in keys as for
+// ParseOptions.ExtraKeyChars.
+func planFile(path string, edits []Edit, extraChars string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	out := &Plan{Path: path, Old: string(data), New: string(data)}
+	for _, e := range edits {
+		opts := append([]SetOption{SetExtraKeyChars(extraChars)}, e.Options...)
+		if e.Unset {
+			o := makeSetOptions(opts)
+			if _, err := normalizeKey(e.Key, o.extraChars); err != nil {
+				return nil, err
+			}
+			out.New, err = unsetInData(out.New, path, e.Key, o.extraChars)
+		} else {
+			var o setOptions
+			if o, err = checkSet(e.Key, e.Value, opts); err != nil {
+				return nil, err
+			}
+			out.New, err = setInData(out.New, path, e.Key, e.Value, o)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	opts := ParseOptions{ExtraKeyChars: extraChars}
+	oldCfg, err := NewConfigFromStringOptions(out.Old, opts)
+	if err != nil {
+		return nil, err
+	}
+	newCfg, err := NewConfigFromStringOptions(out.New, opts)
+	if err != nil {
+		return nil, err
+	}
+	secret := newKeyPatterns(DefaultSecretPatterns()).match
+	out.Changes = Diff(oldCfg, newCfg)
+	for i, c := range out.Changes {
+		if secret(c.Key) {
+			out.Changes[i].Old, out.Changes[i].New = redactValues(c.Old), redactValues(c.New)
+		}
+	}
+	out.Diff = shownDiff(path, out.Old, out.New, redactText(out.Old, extraChars, secret), redactText(out.New, extraChars, secret))
+	return out, nil
+}
+
+// Writes the planned contents, locking the file as SetInFile does. Fails
+// with ErrStalePlan if the file has changed since the plan was made, so
+// edits made meanwhile are not lost. Nothing is written if the plan
+// changes nothing.
+func (self *Plan) Apply() error {
+	if self.New == self.Old {
+		return nil
+	}
+	lock, err := lockFile(self.Path)
+	if err != nil {
+		return err
+	}
+	defer lock.abort()
+	data, err := os.ReadFile(self.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if string(data) != self.Old {
+		return fmt.Errorf("Cannot apply edits to '%s': %w", self.Path, ErrStalePlan)
+	}
+	return lock.commit([]byte(self.New), nil)
+}
+
+// Lines of context around each change in unifiedDiff.
+const diffContext = 3
+
+// One line of an edit script: kept (' '), removed ('-') or added ('+'),
+// with its index in the old and new lines.
+type diffLine struct {
+	kind byte
+	a, b int
+}
+
+// Gives the differences between two texts in the unified format of
+// `diff -u`, naming both name, or "" if they are the same.
+func UnifiedDiff(name, a, b string) string {
+	return unifiedDiff(name, a, b)
+}
+
+// See UnifiedDiff. Config files are small, so the plain longest common
+// subsequence is quick enough.
+func unifiedDiff(name, a, b string) string {
+	return shownDiff(name, a, b, a, b)
+}
+
+// Diffs a and b as unifiedDiff, but shows the lines of showA and showB,
+// which must have the same number of lines, e.g. with secrets redacted.
+func shownDiff(name, a, b, showA, showB string) string {
+	if a == b {
+		return ""
+	}
+	al, bl := diffSplit(a), diffSplit(b)
+	// lcs[i][j] is the length of the longest common subsequence of al[i:]
+	// and bl[j:]
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	script := make([]diffLine, 0, len(al)+len(bl))
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			script = append(script, diffLine{' ', i, j})
+			i, j = i+1, j+1
+		case i < len(al) && (j == len(bl) || lcs[i+1][j] >= lcs[i][j+1]):
+			// removals before additions, as diff shows them
+			script = append(script, diffLine{'-', i, j})
+			i++
+		default:
+			script = append(script, diffLine{'+', i, j})
+			j++
+		}
+	}
+
+	al, bl = diffSplit(showA), diffSplit(showB)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", name, name)
+	for start := 0; start < len(script); {
+		for start < len(script) && script[start].kind == ' ' {
+			start++
+		}
+		if start == len(script) {
+			break
+		}
+		// take changes until a run of unchanged lines too long to join
+		end, kept := start, 0
+		for k := start; k < len(script) && kept <= 2*diffContext; k++ {
+			if script[k].kind == ' ' {
+				kept++
+			} else {
+				end, kept = k+1, 0
+			}
+		}
+		from, to := max(start-diffContext, 0), min(end+diffContext, len(script))
+		aCount, bCount := 0, 0
+		for _, l := range script[from:to] {
+			if l.kind != '+' {
+				aCount++
+			}
+			if l.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(script[from].a, aCount), hunkRange(script[from].b, bCount))
+		for _, l := range script[from:to] {
+			line := ""
+			if l.kind == '+' {
+				line = bl[l.b]
+			} else {
+				line = al[l.a]
+			}
+			sb.WriteByte(l.kind)
+			sb.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+	return sb.String()
+}
+
+// Splits text into lines, each keeping its newline.
+func diffSplit(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Formats the start and length of a hunk as diff does: 1-based, with the
+// length left out if 1, and an empty range given as the line before it.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
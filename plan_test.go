@@ -0,0 +1,107 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config")
+	orig := "# mine\n[user]\n\tname = Joe\n\temail = joe@x ; work\n[core]\n\teditor = vi\n\tpager = less\n"
+	writeFiles(t, dir, map[string]string{"config": orig})
+	cfg, err := NewConfigFromFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read: %s", err)
+	}
+	plan, err := cfg.Plan([]Edit{
+		{Key: "user.email", Value: "joe@y"},
+		{Key: "core.pager", Unset: true},
+		{Key: "push.default", Value: "simple"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to plan: %s", err)
+	}
+	expectNew := "# mine\n[user]\n\tname = Joe\n\temail = joe@y ; work\n[core]\n\teditor = vi\n[push]\n\tdefault = simple\n"
+	if plan.New != expectNew {
+		t.Errorf("Expect planned file:\n%s\nbut got:\n%s", expectNew, plan.New)
+	}
+	expectDiff := "--- " + file + "\n+++ " + file + "\n" +
+		"@@ -1,7 +1,8 @@\n" +
+		" # mine\n [user]\n \tname = Joe\n-\temail = joe@x ; work\n+\temail = joe@y ; work\n" +
+		" [core]\n \teditor = vi\n-\tpager = less\n+[push]\n+\tdefault = simple\n"
+	if plan.Diff != expectDiff {
+		t.Errorf("Expect diff:\n%s\nbut got:\n%s", expectDiff, plan.Diff)
+	}
+	if len(plan.Changes) != 3 {
+		t.Errorf("Expect 3 changed keys, but got %+v", plan.Changes)
+	}
+	if data, _ := os.ReadFile(file); string(data) != orig {
+		t.Errorf("Expect the file untouched by planning")
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Failed to apply: %s", err)
+	}
+	if data, _ := os.ReadFile(file); string(data) != expectNew {
+		t.Errorf("Expect planned file written, but got:\n%s", data)
+	}
+	// the file has changed since
+	if err := plan.Apply(); !errors.Is(err, ErrStalePlan) {
+		t.Errorf("Expect ErrStalePlan, but got %v", err)
+	}
+
+	if _, err := cfg.Plan([]Edit{{Key: "core.missing", Unset: true}}); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expect ErrKeyNotFound unsetting a missing key, but got %v", err)
+	}
+	if _, err := cfg.Plan([]Edit{{Key: "nodot", Value: "x"}}); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expect ErrInvalidKey, but got %v", err)
+	}
+	if _, err := NewConfig().Plan(nil); !errors.Is(err, ErrNoSource) {
+		t.Errorf("Expect ErrNoSource, but got %v", err)
+	}
+	newFile := filepath.Join(dir, "new")
+	if plan, err := PlanFile(newFile, []Edit{{Key: "a.b", Value: "c"}}); err != nil || plan.Diff != "--- "+newFile+"\n+++ "+newFile+"\n@@ -0,0 +1,2 @@\n+[a]\n+\tb = c\n" {
+		t.Errorf("Unexpected plan for a new file: %+v (%v)", plan, err)
+	}
+
+	// secrets are hidden from the changes and diff, but still written
+	secretFile := filepath.Join(dir, "secret")
+	writeFiles(t, dir, map[string]string{"secret": "[github]\n\tuser = joe\n\ttoken = old \\\n\t\tmore\n"})
+	plan, err = PlanFile(secretFile, []Edit{{Key: "github.token", Value: "new"}})
+	if err != nil {
+		t.Fatalf("Failed to plan: %s", err)
+	}
+	expectDiff = "--- " + secretFile + "\n+++ " + secretFile + "\n" +
+		"@@ -1,4 +1,3 @@\n" +
+		" [github]\n \tuser = joe\n-\ttoken = <redacted>\n-\t<redacted>\n+\ttoken = <redacted>\n"
+	if plan.Diff != expectDiff {
+		t.Errorf("Expect diff:\n%s\nbut got:\n%s", expectDiff, plan.Diff)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].Old[0] != RedactedValue || plan.Changes[0].New[0] != RedactedValue {
+		t.Errorf("Expect redacted changes, but got %+v", plan.Changes)
+	}
+	if !strings.Contains(plan.New, "token = new") {
+		t.Errorf("Expect the real value planned, but got:\n%s", plan.New)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n"
+	b := "1\nTWO\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\nSIXTEEN"
+	expect := "--- f\n+++ f\n" +
+		"@@ -1,5 +1,5 @@\n 1\n-2\n+TWO\n 3\n 4\n 5\n" +
+		"@@ -13,4 +13,4 @@\n 13\n 14\n 15\n-16\n+SIXTEEN\n\\ No newline at end of file\n"
+	if got := unifiedDiff("f", a, b); got != expect {
+		t.Errorf("Expect diff:\n%s\nbut got:\n%s", expect, got)
+	}
+	if got := unifiedDiff("f", a, a); got != "" {
+		t.Errorf("Expect no diff for the same text, but got %s", got)
+	}
+}
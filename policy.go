@@ -0,0 +1,254 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Rules for git settings, e.g. those an IT team enforces on developer
+// machines:
+//
+//	policy := gitconfig.Policy{
+//		Forbidden:       []string{"credential.helper"},
+//		ForbiddenValues: map[string][]string{"http.sslVerify": {"false"}},
+//		Forced:          map[string]string{"transfer.fsckObjects": "true"},
+//	}
+//
+// Keys are of form section.key or section.subsection.key. Values compare
+// as git reads them where both are booleans, so banning "false" bans "no",
+// "off" and "0" too; other values must match exactly.
+type Policy struct {
+	// Keys which must not be set at all.
+	Forbidden []string
+	// Values which keys must not be set to.
+	ForbiddenValues map[string][]string
+	// Values which keys must be set to, and only to.
+	Forced map[string]string
+}
+
+// Which rule of a Policy a value breaks.
+type PolicyRule string
+
+const (
+	PolicyForbidden      PolicyRule = "forbidden"       // the key is set
+	PolicyForbiddenValue PolicyRule = "forbidden-value" // the key is set to a banned value
+	PolicyForced         PolicyRule = "forced"          // the key is not set, or not only, to its forced value
+)
+
+// A value breaking a Policy.
+type PolicyViolation struct {
+	Rule   PolicyRule
+	Key    string      // canonical form
+	Value  string      // the value breaking the rule; for forced keys the last, "" if unset
+	Want   string      // for forced keys, the value required
+	Origin ValueOrigin // where Value was set, zero if unset or set by code
+}
+
+func (self PolicyViolation) String() string {
+	where := ""
+	if self.Origin.File != "" {
+		where = fmt.Sprintf(" at %s:%d", self.Origin.File, self.Origin.Line)
+	}
+	switch self.Rule {
+	case PolicyForbidden:
+		return fmt.Sprintf("%s must not be set, but is '%s'%s", self.Key, self.Value, where)
+	case PolicyForbiddenValue:
+		return fmt.Sprintf("%s must not be '%s'%s", self.Key, self.Value, where)
+	}
+	if self.Origin == (ValueOrigin{}) && self.Value == "" {
+		return fmt.Sprintf("%s must be '%s', but is not set", self.Key, self.Want)
+	}
+	return fmt.Sprintf("%s must be '%s', but is '%s'%s", self.Key, self.Want, self.Value, where)
+}
+
+// Gets the ways the config breaks the policy, sorted by key, or nil if it
+// keeps to it. A key in the policy which is not valid gives a *KeyError.
+// Values read in place of a deprecated key (see DeprecateKey) are reported
+// under the key they are set under, so Apply fixes that one.
+func (self *Policy) Check(cfg *Config) ([]PolicyViolation, error) {
+	rules, err := self.rules()
+	if err != nil {
+		return nil, err
+	}
+	var out []PolicyViolation
+	for _, r := range rules {
+		s, ss, k := ParseSectionKey(r.key)
+		key, cvs := cfg.resolveValues(s, ss, k)
+		if key == "" {
+			key = r.key
+		}
+		var entries []ValueEntry
+		if cvs != nil {
+			entries = cvs.Entries
+		}
+		switch r.rule {
+		case PolicyForbidden:
+			for _, e := range entries {
+				out = append(out, PolicyViolation{Rule: r.rule, Key: key, Value: e.Value, Origin: e.Origin})
+			}
+		case PolicyForbiddenValue:
+			for _, e := range entries {
+				if policyValueIs(e, r.value) {
+					out = append(out, PolicyViolation{Rule: r.rule, Key: key, Value: e.Value, Origin: e.Origin})
+				}
+			}
+		case PolicyForced:
+			if !forcedHeld(entries, r.value) {
+				v := PolicyViolation{Rule: r.rule, Key: r.key, Want: r.value}
+				if n := len(entries); n > 0 {
+					v.Value, v.Origin = entries[n-1].Value, entries[n-1].Origin
+				}
+				out = append(out, v)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Changes the config to keep to the policy, unsetting forbidden keys,
+// removing forbidden values and setting forced ones, and returns the
+// violations fixed, as Check would have given them. OnChange listeners and
+// any logger are told of each change.
+func (self *Policy) Apply(cfg *Config) ([]PolicyViolation, error) {
+	found, err := self.Check(cfg)
+	if err != nil || len(found) == 0 {
+		return found, err
+	}
+	done := make(map[string]bool, len(found))
+	for _, v := range found {
+		if done[v.Key] && v.Rule != PolicyForbiddenValue {
+			continue
+		}
+		done[v.Key] = true
+		switch v.Rule {
+		case PolicyForbidden:
+			cfg.Unset(v.Key)
+		case PolicyForbiddenValue:
+			cfg.removeValue(v.Key, v.Value)
+		case PolicyForced:
+			if err := cfg.Set(v.Key, v.Want); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return found, nil
+}
+
+type policyRule struct {
+	rule  PolicyRule
+	key   string
+	value string
+}
+
+// Gets the policy's rules with canonical keys, in the order checked.
+// A key forced to a value its other rules forbid fails with
+// ErrInvalidValue, as Apply could not keep to both.
+func (self *Policy) rules() ([]policyRule, error) {
+	out := make([]policyRule, 0, len(self.Forbidden)+len(self.ForbiddenValues)+len(self.Forced))
+	for _, key := range self.Forbidden {
+		k, err := NormalizeKey(key)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, policyRule{rule: PolicyForbidden, key: k.String()})
+	}
+	for key, values := range self.ForbiddenValues {
+		k, err := NormalizeKey(key)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			out = append(out, policyRule{rule: PolicyForbiddenValue, key: k.String(), value: value})
+		}
+	}
+	for key, value := range self.Forced {
+		k, err := NormalizeKey(key)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, policyRule{rule: PolicyForced, key: k.String(), value: value})
+	}
+	forced := make(map[string]string, len(self.Forced))
+	for _, r := range out {
+		if r.rule == PolicyForced {
+			forced[r.key] = r.value
+		}
+	}
+	for _, r := range out {
+		want, ok := forced[r.key]
+		if !ok {
+			continue
+		}
+		if r.rule == PolicyForbidden {
+			return nil, fmt.Errorf("Policy key '%s' cannot be both forbidden and forced: %w", r.key, ErrInvalidValue)
+		}
+		if r.rule == PolicyForbiddenValue && policyValueIs(ValueEntry{Value: want, HasValue: true}, r.value) {
+			return nil, fmt.Errorf("Policy key '%s' cannot be forced to the forbidden value '%s': %w", r.key, want, ErrInvalidValue)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].key != out[j].key {
+			return out[i].key < out[j].key
+		}
+		if out[i].rule != out[j].rule {
+			return out[i].rule < out[j].rule
+		}
+		return out[i].value < out[j].value
+	})
+	return out, nil
+}
+
+// Whether every value is the forced one, and there is at least one.
+func forcedHeld(entries []ValueEntry, want string) bool {
+	for _, e := range entries {
+		if !policyValueIs(e, want) {
+			return false
+		}
+	}
+	return len(entries) > 0
+}
+
+// Whether the value is the one given, comparing as booleans if both are.
+func policyValueIs(e ValueEntry, value string) bool {
+	if e.HasValue && e.Value == value {
+		return true
+	}
+	have, _, err := (&ConfigValue{Entries: []ValueEntry{e}}).GetBool()
+	if err != nil {
+		return false
+	}
+	want, _, err := (&ConfigValue{Entries: []ValueEntry{{Value: value, HasValue: true}}}).GetBool()
+	return err == nil && have == want
+}
+
+// Removes the values of the key which are the one given, as compared by
+// policyValueIs, unsetting the key if none are left.
+func (self *Config) removeValue(key, value string) {
+	s, ss, k := ParseSectionKey(key)
+	cvs := self.lookupValues(s, ss, k)
+	if cvs == nil {
+		return
+	}
+	old := cvs.ValuesAsStrings()
+	kept := cvs.Entries[:0]
+	for _, e := range cvs.Entries {
+		if !policyValueIs(e, value) {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == len(old) {
+		return
+	}
+	if len(kept) == 0 {
+		self.Unset(key)
+		return
+	}
+	cvs.Entries = kept
+	cvs.syncValue()
+	key = joinKey(s, ss, k)
+	self.record(MutationSet, KeyChange{Key: key, Old: old, New: cvs.ValuesAsStrings()})
+}
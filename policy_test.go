@@ -0,0 +1,86 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	data := "[http]\n\tsslVerify = no\n[credential]\n\thelper = store\n\thelper = cache\n[transfer]\n\tfsckObjects = false\n[url \"x\"]\n\tinsteadOf = a\n\tinsteadOf = b\n"
+	cfg, err := NewConfigFromString(data)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	policy := Policy{
+		Forbidden:       []string{"credential.helper", "core.askPass"},
+		ForbiddenValues: map[string][]string{"http.sslVerify": {"false"}, "url.x.insteadOf": {"a"}},
+		Forced:          map[string]string{"transfer.fsckObjects": "true", "fetch.fsckObjects": "true"},
+	}
+	found, err := policy.Check(cfg)
+	if err != nil {
+		t.Fatalf("Failed to check: %s", err)
+	}
+	expect := []string{
+		"credential.helper must not be set, but is 'store'",
+		"credential.helper must not be set, but is 'cache'",
+		"fetch.fsckobjects must be 'true', but is not set",
+		"http.sslverify must not be 'no'",
+		"transfer.fsckobjects must be 'true', but is 'false'",
+		"url.x.insteadof must not be 'a'",
+	}
+	lines := []uint64{4, 5, 0, 2, 7, 9}
+	if len(found) != len(expect) {
+		t.Fatalf("Expect %d violations, but got %v", len(expect), found)
+	}
+	for i, v := range found {
+		if s := v.String(); s != expect[i] || v.Origin.Line != lines[i] {
+			t.Errorf("Expect violation '%s' from line %d, but got '%s' from line %d", expect[i], lines[i], s, v.Origin.Line)
+		}
+	}
+	v := PolicyViolation{Rule: PolicyForbiddenValue, Key: "http.sslverify", Value: "no", Origin: ValueOrigin{File: "/etc/gitconfig", Line: 2}}
+	if s := v.String(); s != "http.sslverify must not be 'no' at /etc/gitconfig:2" {
+		t.Errorf("Expect the file and line shown, but got '%s'", s)
+	}
+
+	var changed []string
+	cfg.OnChange("*", func(key string, old, new []string) { changed = append(changed, key) })
+	fixed, err := policy.Apply(cfg)
+	if err != nil || len(fixed) != len(found) {
+		t.Fatalf("Expect %d violations fixed, but got %v (%v)", len(found), fixed, err)
+	}
+	if len(changed) != 5 {
+		t.Errorf("Expect 5 keys changed, but got %v", changed)
+	}
+	testValue(t, cfg, "credential.helper", "", false)
+	testValue(t, cfg, "http.sslverify", "", false)
+	testValue(t, cfg, "transfer.fsckobjects", "true", true)
+	testValue(t, cfg, "fetch.fsckobjects", "true", true)
+	if got := cfg.GetKeyValuesStrings("url.x.insteadof"); len(got) != 1 || got[0] != "b" {
+		t.Errorf("Expect only url.x.insteadof = b kept, but got %v", got)
+	}
+	if found, err := policy.Check(cfg); len(found) != 0 || err != nil {
+		t.Errorf("Expect no violations once applied, but got %v (%v)", found, err)
+	}
+
+	cfg, _ = NewConfigFromString("[transfer]\n\tfsckObjects\n")
+	if found, _ := policy.Check(cfg); len(found) != 1 || found[0].Key != "fetch.fsckobjects" {
+		t.Errorf("Expect a key with no value to be true, but got %v", found)
+	}
+	bad := Policy{Forced: map[string]string{"nodot": "x"}}
+	var ke *KeyError
+	if _, err := bad.Check(cfg); !errors.As(err, &ke) {
+		t.Errorf("Expect a KeyError for an invalid policy key, but got %v", err)
+	}
+	for _, bad := range []Policy{
+		{Forbidden: []string{"core.editor"}, Forced: map[string]string{"Core.Editor": "vi"}},
+		{ForbiddenValues: map[string][]string{"http.sslVerify": {"false"}}, Forced: map[string]string{"http.sslverify": "no"}},
+	} {
+		if _, err := bad.Apply(cfg); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Expect ErrInvalidValue for contradictory rules %v, but got %v", bad, err)
+		}
+	}
+}
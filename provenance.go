@@ -0,0 +1,63 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Starts the comment of a value tagged with its provenance, so that it is
+// written as e.g. `url = x # provenance: set by corp-policy v1.2`.
+const provenanceMarker = "provenance: "
+
+// Gets what the value was tagged as set by, e.g. "set by corp-policy v1.2",
+// or "" if it was not tagged. The tag is kept in the value's comment so it
+// is written with it and read back from the file.
+func (self ValueEntry) Provenance() string {
+	p, ok := strings.CutPrefix(self.Comment, provenanceMarker)
+	if !ok {
+		return ""
+	}
+	return p
+}
+
+// Tags the last value of the key with what set it, replacing any comment
+// it had; an empty provenance removes the tag. As for SetValueComment the
+// key must have a value and the provenance must fit on one line.
+func (self *Config) SetValueProvenance(key, provenance string) error {
+	provenance = strings.TrimSpace(provenance)
+	if provenance == "" {
+		s, ss, k := ParseSectionKey(key)
+		if cvs := self.lookupValues(s, ss, k); cvs != nil && len(cvs.Entries) > 0 && cvs.Entries[len(cvs.Entries)-1].Provenance() == "" {
+			return nil // leave any other comment alone
+		}
+		return self.SetValueComment(key, "")
+	}
+	return self.SetValueComment(key, provenanceMarker+provenance)
+}
+
+// Tags the values set or added after this with the given provenance, e.g.
+// "set by corp-policy v1.2", written as a comment after each so that people
+// editing the file later know which lines are machine-managed. An empty
+// provenance stops tagging. Keys added with Flag are not tagged.
+func (self *Builder) Provenance(provenance string) *Builder {
+	if self.err != nil {
+		return self
+	}
+	if strings.ContainsAny(provenance, "\n\r\x00") {
+		return self.fail(fmt.Errorf("Provenance '%s' over several lines: %w", provenance, ErrInvalidValue))
+	}
+	self.provenance = strings.TrimSpace(provenance)
+	return self
+}
+
+func (self *Builder) entry(value string) ValueEntry {
+	entry := ValueEntry{Value: value, HasValue: true}
+	if self.provenance != "" {
+		entry.Comment = provenanceMarker + self.provenance
+	}
+	return entry
+}
@@ -0,0 +1,68 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestProvenance(t *testing.T) {
+	cfg, err := NewBuilder().
+		Section("core", "").Set("editor", "vim").
+		Provenance("set by corp-policy v1.2").
+		Section("http", "").Set("sslVerify", "true").Add("proxy", "p").Flag("noProxy").
+		Provenance("").
+		Section("user", "").Set("name", "Joe").
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build: %s", err)
+	}
+	out := cfg.String()
+	if !strings.Contains(out, "sslVerify = true # provenance: set by corp-policy v1.2\n") {
+		t.Errorf("Expect provenance written after the value, but got:\n%s", out)
+	}
+
+	cfg, err = NewConfigFromString(out)
+	if err != nil {
+		t.Fatalf("Failed to read back: %s", err)
+	}
+	for key, expect := range map[string]string{"http.sslverify": "set by corp-policy v1.2", "http.proxy": "set by corp-policy v1.2", "http.noproxy": "", "core.editor": "", "user.name": ""} {
+		if e, _ := cfg.GetKeyValuesRaw(key).At(0); e.Provenance() != expect {
+			t.Errorf("Expect provenance of %s to be '%s', but got '%s'", key, expect, e.Provenance())
+		}
+	}
+
+	cfg.Set("http.sslVerify", "true")
+	if e, _ := cfg.GetKeyValuesRaw("http.sslverify").At(0); e.Provenance() == "" {
+		t.Errorf("Expect provenance kept when the same value is set")
+	}
+	cfg.Set("http.sslVerify", "false")
+	if e, _ := cfg.GetKeyValuesRaw("http.sslverify").At(0); e.Comment != "" {
+		t.Errorf("Expect provenance dropped when the value changes, but got '%s'", e.Comment)
+	}
+	if err := cfg.SetValueProvenance("user.name", "set by setup"); err != nil {
+		t.Fatalf("Failed to set provenance: %s", err)
+	}
+	if e, _ := cfg.GetKeyValuesRaw("user.name").At(0); e.Comment != "provenance: set by setup" {
+		t.Errorf("Expect provenance comment, but got '%s'", e.Comment)
+	}
+	cfg.SetValueComment("core.editor", "mine")
+	if err := cfg.SetValueProvenance("core.editor", ""); err != nil {
+		t.Fatalf("Failed to remove provenance: %s", err)
+	}
+	if e, _ := cfg.GetKeyValuesRaw("core.editor").At(0); e.Comment != "mine" {
+		t.Errorf("Expect removing provenance to keep other comments, but got '%s'", e.Comment)
+	}
+	cfg.SetValueProvenance("user.name", "")
+	if e, _ := cfg.GetKeyValuesRaw("user.name").At(0); e.Comment != "" {
+		t.Errorf("Expect provenance removed, but got '%s'", e.Comment)
+	}
+
+	if _, err := NewBuilder().Provenance("a\nb").Build(); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expect ErrInvalidValue for provenance over several lines, but got %v", err)
+	}
+}
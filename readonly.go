@@ -0,0 +1,148 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"time"
+)
+
+// A view of a Config which can only be read, to hand to code which must not
+// change it. Unlike a *Config it has no getters creating what they are
+// asked for, and values it gives out are copies, so changing them does not
+// change the config. Changes made through the Config are seen by the view.
+type ReadOnlyConfig struct {
+	cfg *Config
+}
+
+// Gets a read-only view of the config.
+func (self *Config) ReadOnly() ReadOnlyConfig {
+	return ReadOnlyConfig{cfg: self}
+}
+
+// Gets a copy of the config which can be changed without changing the
+// one viewed.
+func (self ReadOnlyConfig) Clone() *Config {
+	return self.cfg.clone()
+}
+
+func (self ReadOnlyConfig) String() string {
+	return self.cfg.String()
+}
+
+// See Config.Load.
+func (self ReadOnlyConfig) Load(v interface{}) error {
+	return self.cfg.Load(v)
+}
+
+// See Config.LoadSection.
+func (self ReadOnlyConfig) LoadSection(section string, v interface{}) error {
+	return self.cfg.LoadSection(section, v)
+}
+
+func (self ReadOnlyConfig) HasSection(section string) bool {
+	return self.cfg.HasSection(section)
+}
+
+func (self ReadOnlyConfig) HasSubSection(section, subSection string) bool {
+	return self.cfg.HasSubSection(section, subSection)
+}
+
+func (self ReadOnlyConfig) SectionNames() []string {
+	return self.cfg.SectionNames()
+}
+
+func (self ReadOnlyConfig) SubSectionNames(section string) []string {
+	return self.cfg.SubSectionNames(section)
+}
+
+func (self ReadOnlyConfig) KeyNames(section, subSection string) []string {
+	return self.cfg.KeyNames(section, subSection)
+}
+
+// Gets a copy of all the values of the key, or nil if it does not exist.
+func (self ReadOnlyConfig) GetKeyValuesRaw(key string) *ConfigValue {
+	if cvs := self.cfg.GetKeyValuesRaw(key); cvs != nil {
+		return cvs.copy()
+	}
+	return nil
+}
+
+// Gets a copy of all the values of the key, or nil if it does not exist.
+func (self ReadOnlyConfig) GetValuesForKey(k Key) *ConfigValue {
+	if cvs := self.cfg.GetValuesForKey(k); cvs != nil {
+		return cvs.copy()
+	}
+	return nil
+}
+
+func (self ReadOnlyConfig) GetKeyValuesStrings(key string) []string {
+	return self.cfg.GetKeyValuesStrings(key)
+}
+
+func (self ReadOnlyConfig) GetKeyValueAsString(key string) (string, bool) {
+	return self.cfg.GetKeyValueAsString(key)
+}
+
+func (self ReadOnlyConfig) GetKeyValueAsInt(key string) (int64, bool, error) {
+	return self.cfg.GetKeyValueAsInt(key)
+}
+
+func (self ReadOnlyConfig) GetKeyValueAsUint(key string) (uint64, bool, error) {
+	return self.cfg.GetKeyValueAsUint(key)
+}
+
+func (self ReadOnlyConfig) GetKeyValueAsFloat(key string) (float64, bool, error) {
+	return self.cfg.GetKeyValueAsFloat(key)
+}
+
+func (self ReadOnlyConfig) GetKeyValueAsDuration(key string) (time.Duration, bool, error) {
+	return self.cfg.GetKeyValueAsDuration(key)
+}
+
+func (self ReadOnlyConfig) GetKeyValueAsBool(key string) (bool, bool, error) {
+	return self.cfg.GetKeyValueAsBool(key)
+}
+
+func (self ReadOnlyConfig) LookupString(key string) (string, error) {
+	return self.cfg.LookupString(key)
+}
+
+func (self ReadOnlyConfig) LookupInt(key string) (int64, error) {
+	return self.cfg.LookupInt(key)
+}
+
+func (self ReadOnlyConfig) LookupUint(key string) (uint64, error) {
+	return self.cfg.LookupUint(key)
+}
+
+func (self ReadOnlyConfig) LookupBool(key string) (bool, error) {
+	return self.cfg.LookupBool(key)
+}
+
+func (self ReadOnlyConfig) LookupDuration(key string) (time.Duration, error) {
+	return self.cfg.LookupDuration(key)
+}
+
+func (self ReadOnlyConfig) LookupPath(key string) (string, error) {
+	return self.cfg.LookupPath(key)
+}
+
+func (self ReadOnlyConfig) LookupStrings(key string) ([]string, error) {
+	return self.cfg.LookupStrings(key)
+}
+
+func (self ReadOnlyConfig) Warnings() []Warning {
+	return self.cfg.Warnings()
+}
+
+func (self ReadOnlyConfig) SourceFiles() []string {
+	return self.cfg.SourceFiles()
+}
+
+// Registers a listener for changes made through the Config, see
+// Config.OnChange. This changes no values, so is allowed on a view.
+func (self ReadOnlyConfig) OnChange(pattern string, fn ChangeFunc) func() {
+	return self.cfg.OnChange(pattern, fn)
+}
@@ -0,0 +1,64 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	cfg, err := NewConfigFromString("[core]\n\tbare = true\n[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	view := cfg.ReadOnly()
+	if b, ok, err := view.GetKeyValueAsBool("core.bare"); !b || !ok || err != nil {
+		t.Errorf("Expect core.bare true through the view, but got %v (%v, %v)", b, ok, err)
+	}
+
+	cvs := view.GetKeyValuesRaw("remote.origin.fetch")
+	cvs.Entries[0].Value = "changed"
+	cvs.Entries = append(cvs.Entries, ValueEntry{Value: "c", HasValue: true})
+	if got := cfg.GetKeyValuesStrings("remote.origin.fetch"); len(got) != 2 || got[0] != "a" {
+		t.Errorf("Expect changing values from the view to leave the config alone, but got %v", got)
+	}
+	if view.GetKeyValuesRaw("user.name") != nil || view.HasSection("user") {
+		t.Errorf("Expect no values for a missing key")
+	}
+	if cfg.HasSection("user") {
+		t.Errorf("Expect reading a missing key through the view not to create its section")
+	}
+
+	clone := view.Clone()
+	clone.Set("core.bare", "false")
+	if v, _ := view.LookupString("core.bare"); v != "true" {
+		t.Errorf("Expect changing a clone to leave the config alone, but got '%s'", v)
+	}
+	cfg.DeprecateKey("core.old", "core.new")
+	clone = view.Clone()
+	clone.DeprecateKey("core.bare", "core.isbare")
+	if cfg.LookupValues("core", "", "isbare") != nil || len(cfg.Warnings()) != 0 {
+		t.Errorf("Expect deprecating keys in a clone to leave the config alone")
+	}
+	if clone.LookupValues("core", "", "isbare") == nil {
+		t.Errorf("Expect the clone to read the deprecated key")
+	}
+	cfg.Set("core.bare", "false")
+	if v, _ := view.LookupString("core.bare"); v != "false" {
+		t.Errorf("Expect the view to see changes to the config, but got '%s'", v)
+	}
+
+	tp := reflect.TypeOf(view)
+	for i := 0; i < tp.NumMethod(); i++ {
+		name := tp.Method(i).Name
+		for _, prefix := range []string{"Set", "Unset", "Add", "Insert", "Prepend", "Merge", "Prune", "Save", "Migrate", "Apply", "Begin", "GetSection", "GetSubSection", "GetConfigValue"} {
+			if strings.HasPrefix(name, prefix) {
+				t.Errorf("Expect the view to have no method %s which could change the config", name)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"strings"
+)
+
+// Written in place of values hidden by RedactedString.
+const RedactedValue = "<redacted>"
+
+var defaultSecretPatterns = []string{"*password*", "*token*", "*secret*", "http.*extraheader"}
+
+// Gets the patterns of keys whose values are always redacted in Mutations,
+// see SetLogger. The slice is a copy, changing it has no effect.
+func DefaultSecretPatterns() []string {
+	return append([]string(nil), defaultSecretPatterns...)
+}
+
+// Serializes the config like String, but with the values of any key
+// matching one of the patterns replaced by RedactedValue, so the result is
+// safe to log. Patterns are full key names where '*' matches any run of
+// characters, e.g. "*.password" or "http.*.extraheader", and are matched
+// ignoring case. A pattern with no '.' is matched against the variable
+// name alone, so "*token*" does not catch a sub-section named "tokens".
+func (self *Config) RedactedString(patterns ...string) string {
+	return self.redacted(newKeyPatterns(patterns).match).String()
+}
+
+// Lower case key patterns, as taken by RedactedString, for secrets or the
+// keys a struct loads.
+type keyPatterns []string
+
+func newKeyPatterns(patterns []string) keyPatterns {
+	out := make(keyPatterns, len(patterns))
+	for i, p := range patterns {
+		out[i] = strings.ToLower(p)
+	}
+	return out
+}
+
+// A "*" section or sub-section segment also matches none, so "*.*" and
+// "remote.*.password" match base values and "remote.password" as they do
+// the keys of any sub-section.
+func (self keyPatterns) match(key string) bool {
+	key = strings.ToLower(key)
+	name := key[strings.LastIndexByte(key, '.')+1:]
+	for _, p := range self {
+		if !strings.Contains(p, ".") {
+			if matchKeyPattern(p, name) {
+				return true
+			}
+			continue
+		}
+		if matchKeyPattern(p, key) {
+			return true
+		}
+		if i := strings.Index(p, ".*."); i >= 0 && matchKeyPattern(p[:i]+p[i+2:], key) {
+			return true
+		}
+		if strings.HasPrefix(p, "*.") && matchKeyPattern(p[2:], key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Gets a copy of the config with the values of keys for which secret
+// returns true replaced, and their comments, which may mention the
+// secret, removed. secret is given canonical full key names.
+func (self *Config) redacted(secret func(key string) bool) *Config {
+	out := NewConfig()
+	out.Merge(self)
+	redact := func(section, subSection string, values ConfigValueSet) {
+		for name, cv := range values {
+			if !secret(joinKey(section, subSection, name)) {
+				continue
+			}
+			for i := range cv.Entries {
+				if cv.Entries[i].HasValue {
+					cv.Entries[i].Value = RedactedValue
+				}
+				cv.Entries[i].Comment = ""
+			}
+			cv.syncValue()
+		}
+	}
+	redact("", "", out.BaseValues)
+	for sName, s := range out.Sections {
+		redact(sName, "", s.Values)
+		for ssName, ss := range s.SubSections {
+			redact(sName, ssName, ss.Values)
+		}
+	}
+	return out
+}
+
+// Replaces the values of secret keys in config text, along with any
+// comments after them, keeping its layout and number of lines so a diff of
+// it lines up with the real one. Text which
+// does not parse is given back as is. extraChars are allowed in keys as
+// for ParseOptions.ExtraKeyChars.
+func redactText(data, extraChars string, secret func(key string) bool) string {
+	_, spans, err := parseSpans(data, "", extraChars)
+	if err != nil {
+		return data
+	}
+	lines := strings.SplitAfter(data, "\n")
+	for _, sp := range spans {
+		if sp.block == nil || !secret(joinKey(strings.ToLower(sp.block.section), sp.block.subSection, strings.ToLower(sp.name))) {
+			continue
+		}
+		first := lines[sp.line-1]
+		rest := strings.TrimLeft(first[sp.col+len(sp.name):], " \t")
+		if !strings.HasPrefix(rest, "=") {
+			continue // no value to hide
+		}
+		lines[sp.line-1] = first[:sp.col+len(sp.name)] + " = " + RedactedValue + lineEnd(first)
+		for n := sp.line; n < sp.endLine; n++ {
+			lines[n] = "\t" + RedactedValue + lineEnd(lines[n])
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+func lineEnd(line string) string {
+	switch {
+	case strings.HasSuffix(line, "\r\n"):
+		return "\r\n"
+	case strings.HasSuffix(line, "\n"):
+		return "\n"
+	}
+	return ""
+}
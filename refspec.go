@@ -0,0 +1,139 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A fetch or push refspec such as "+refs/heads/*:refs/remotes/origin/*",
+// see ParseFetchRefspec and ParsePushRefspec.
+type Refspec struct {
+	Src      string // empty for HEAD when fetching, or to delete Dst when pushing
+	Dst      string // empty when fetching without storing the ref
+	Force    bool   // given with a leading '+'
+	Negative bool   // given with a leading '^', excluding the refs Src matches
+	Matching bool   // the push refspec ":", pushing branches both sides have
+}
+
+// Parses a remote.<name>.fetch value.
+func ParseFetchRefspec(spec string) (Refspec, error) {
+	return parseRefspec(spec, false)
+}
+
+// Parses a remote.<name>.push value.
+func ParsePushRefspec(spec string) (Refspec, error) {
+	return parseRefspec(spec, true)
+}
+
+func parseRefspec(spec string, push bool) (Refspec, error) {
+	var out Refspec
+	bad := func(why string) (Refspec, error) {
+		return Refspec{}, fmt.Errorf("Invalid refspec '%s', %s: %w", spec, why, ErrInvalidValue)
+	}
+	rest := spec
+	if strings.HasPrefix(rest, "+") {
+		out.Force, rest = true, rest[1:]
+	} else if strings.HasPrefix(rest, "^") {
+		out.Negative, rest = true, rest[1:]
+	}
+	src, dst, hasDst := rest, "", false
+	if i := strings.LastIndexByte(rest, ':'); i >= 0 {
+		src, dst, hasDst = rest[:i], rest[i+1:], true
+	}
+	if out.Negative {
+		if hasDst || src == "" {
+			return bad("a negative refspec takes only a source")
+		}
+	}
+	if push && hasDst && src == "" && dst == "" {
+		out.Matching = true
+		return out, nil
+	}
+	if push && !hasDst && src == "" {
+		return bad("nothing to push")
+	}
+	if push && src == "" && out.Force {
+		return bad("a deletion cannot be forced")
+	}
+	srcStars, dstStars := strings.Count(src, "*"), strings.Count(dst, "*")
+	if srcStars > 1 || dstStars > 1 {
+		return bad("more than one '*' on a side")
+	}
+	if dst != "" && srcStars != dstStars {
+		return bad("a '*' on only one side")
+	}
+	if push && srcStars == 0 && dstStars == 1 {
+		return bad("a '*' on only one side")
+	}
+	for _, ref := range []string{src, dst} {
+		if why := checkRefspecRef(ref); why != "" {
+			return bad(why)
+		}
+	}
+	out.Src, out.Dst = src, dst
+	return out, nil
+}
+
+// Checks one side of a refspec against git's rules for ref names, returning
+// why it is not valid or "".
+func checkRefspecRef(ref string) string {
+	switch {
+	case strings.Contains(ref, ".."):
+		return "'..' in a ref name"
+	case strings.Contains(ref, "@{"):
+		return "'@{' in a ref name"
+	case strings.Contains(ref, "//"):
+		return "an empty part in a ref name"
+	case strings.HasSuffix(ref, ".") || strings.HasSuffix(ref, "/") || strings.HasSuffix(ref, ".lock"):
+		return "a ref name ending in '.', '/' or '.lock'"
+	}
+	for _, c := range ref {
+		if c < 0x20 || c == 0x7f || strings.ContainsRune(" ~^:?[\\", c) {
+			return fmt.Sprintf("%q in a ref name", c)
+		}
+	}
+	return ""
+}
+
+// Reports whether the refspec maps every ref matching Src, using '*'.
+func (self Refspec) IsWildcard() bool {
+	return strings.Contains(self.Src, "*")
+}
+
+// Maps a ref through the refspec, e.g. "refs/heads/main" to
+// "refs/remotes/origin/main" for the default fetch refspec. Reports false
+// if Src does not match the ref.
+func (self Refspec) Map(ref string) (string, bool) {
+	if self.Matching {
+		return ref, true
+	}
+	i := strings.IndexByte(self.Src, '*')
+	if i < 0 {
+		return self.Dst, ref == self.Src
+	}
+	prefix, suffix := self.Src[:i], self.Src[i+1:]
+	if len(ref) < len(prefix)+len(suffix) || !strings.HasPrefix(ref, prefix) || !strings.HasSuffix(ref, suffix) {
+		return "", false
+	}
+	return strings.Replace(self.Dst, "*", ref[len(prefix):len(ref)-len(suffix)], 1), true
+}
+
+// Gives the refspec as written in config.
+func (self Refspec) String() string {
+	var sb strings.Builder
+	if self.Force {
+		sb.WriteByte('+')
+	} else if self.Negative {
+		sb.WriteByte('^')
+	}
+	sb.WriteString(self.Src)
+	if self.Matching || self.Dst != "" {
+		sb.WriteByte(':')
+		sb.WriteString(self.Dst)
+	}
+	return sb.String()
+}
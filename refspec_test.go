@@ -0,0 +1,68 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRefspec(t *testing.T) {
+	for _, tc := range []struct {
+		spec   string
+		push   bool
+		expect Refspec
+	}{
+		{"+refs/heads/*:refs/remotes/origin/*", false, Refspec{Src: "refs/heads/*", Dst: "refs/remotes/origin/*", Force: true}},
+		{"refs/heads/main", false, Refspec{Src: "refs/heads/main"}},
+		{"^refs/heads/wip/*", false, Refspec{Src: "refs/heads/wip/*", Negative: true}},
+		{"refs/heads/*-dev:refs/remotes/dev/*", false, Refspec{Src: "refs/heads/*-dev", Dst: "refs/remotes/dev/*"}},
+		{":", true, Refspec{Matching: true}},
+		{":refs/heads/old", true, Refspec{Dst: "refs/heads/old"}},
+		{"HEAD:refs/heads/main", true, Refspec{Src: "HEAD", Dst: "refs/heads/main"}},
+	} {
+		parse := ParseFetchRefspec
+		if tc.push {
+			parse = ParsePushRefspec
+		}
+		got, err := parse(tc.spec)
+		if err != nil || got != tc.expect {
+			t.Errorf("Expect %+v for %q, but got %+v (%v)", tc.expect, tc.spec, got, err)
+		}
+		if s := got.String(); s != tc.spec {
+			t.Errorf("Expect %q back, but got %q", tc.spec, s)
+		}
+	}
+	for _, tc := range []struct {
+		spec string
+		push bool
+	}{
+		{"refs/heads/*:refs/remotes/origin/main", false},
+		{"refs/heads/main:refs/remotes/*", false},
+		{"refs/*/x/*:refs/*", false},
+		{"^refs/heads/x:refs/y", false},
+		{"refs/heads/a b", false},
+		{"refs/heads/a..b", false},
+		{"refs/heads/x.lock", false},
+		{"+:refs/heads/x", true},
+		{"", true},
+	} {
+		parse := ParseFetchRefspec
+		if tc.push {
+			parse = ParsePushRefspec
+		}
+		if _, err := parse(tc.spec); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Expect ErrInvalidValue for %q, but got %v", tc.spec, err)
+		}
+	}
+
+	spec, _ := ParseFetchRefspec("+refs/heads/*:refs/remotes/origin/*")
+	if dst, ok := spec.Map("refs/heads/feature/x"); !ok || dst != "refs/remotes/origin/feature/x" || !spec.IsWildcard() {
+		t.Errorf("Expect refs/heads/feature/x mapped, but got %q %v", dst, ok)
+	}
+	if _, ok := spec.Map("refs/tags/v1"); ok {
+		t.Errorf("Expect refs/tags/v1 not to match")
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A [remote "<name>"] section, see Config.Remotes. url.<base>.insteadOf
+// rewriting is not applied to the URLs.
+type Remote struct {
+	Name     string
+	URLs     []string  // remote.<name>.url
+	PushURLs []string  // remote.<name>.pushurl, the URLs to push to if set
+	Fetch    []Refspec // remote.<name>.fetch
+	Push     []Refspec // remote.<name>.push
+}
+
+// Gets every remote in file order, with its refspecs parsed. A refspec
+// which is not valid gives an error wrapping ErrInvalidValue.
+func (self *Config) Remotes() ([]*Remote, error) {
+	s := self.LookupSection("remote")
+	if s == nil {
+		return nil, nil
+	}
+	var out []*Remote
+	for _, name := range s.subSectionNames() {
+		r, err := newRemote(name, s.SubSections[name].Values)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// Gets the named remote, or fails with ErrSectionNotFound.
+func (self *Config) Remote(name string) (*Remote, error) {
+	if ss := self.LookupSubSection("remote", name); ss != nil {
+		return newRemote(name, ss.Values)
+	}
+	return nil, fmt.Errorf("remote %q: %w", name, ErrSectionNotFound)
+}
+
+func newRemote(name string, values ConfigValueSet) (*Remote, error) {
+	out := &Remote{Name: name}
+	out.URLs = remoteStrings(values["url"])
+	out.PushURLs = remoteStrings(values["pushurl"])
+	var err error
+	if out.Fetch, err = remoteRefspecs(name, "fetch", values["fetch"], ParseFetchRefspec); err != nil {
+		return nil, err
+	}
+	if out.Push, err = remoteRefspecs(name, "push", values["push"], ParsePushRefspec); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func remoteStrings(cv *ConfigValue) []string {
+	if cv == nil {
+		return nil
+	}
+	var out []string
+	for _, e := range cv.Entries {
+		if e.HasValue {
+			out = append(out, e.Value)
+		}
+	}
+	return out
+}
+
+func remoteRefspecs(name, key string, cv *ConfigValue, parse func(string) (Refspec, error)) ([]Refspec, error) {
+	if cv == nil {
+		return nil, nil
+	}
+	var out []Refspec
+	for _, e := range cv.Entries {
+		full := "remote." + name + "." + key
+		if !e.HasValue {
+			return nil, fmt.Errorf("Missing refspec for %s: %w", full, ErrInvalidValue)
+		}
+		spec, err := parse(strings.TrimSpace(e.Value))
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", full, err)
+		}
+		out = append(out, spec)
+	}
+	return out, nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRemotes(t *testing.T) {
+	cfg, err := NewConfigFromString(`[remote "upstream"]
+	url = https://example.com/up.git
+	fetch = +refs/heads/*:refs/remotes/upstream/*
+	fetch = ^refs/heads/wip/*
+[remote "origin"]
+	url = git@example.com:me/repo.git
+	pushurl = git@example.com:me/push.git
+	push = refs/heads/main:refs/heads/main
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	remotes, err := cfg.Remotes()
+	if err != nil {
+		t.Fatalf("Failed to read remotes: %s", err)
+	}
+	expect := []*Remote{
+		{Name: "upstream", URLs: []string{"https://example.com/up.git"}, Fetch: []Refspec{
+			{Src: "refs/heads/*", Dst: "refs/remotes/upstream/*", Force: true},
+			{Src: "refs/heads/wip/*", Negative: true},
+		}},
+		{Name: "origin", URLs: []string{"git@example.com:me/repo.git"}, PushURLs: []string{"git@example.com:me/push.git"},
+			Push: []Refspec{{Src: "refs/heads/main", Dst: "refs/heads/main"}}},
+	}
+	if !reflect.DeepEqual(remotes, expect) {
+		t.Errorf("Expect remotes:\n%+v\nbut got:\n%+v", expect, remotes)
+	}
+	if r, err := cfg.Remote("origin"); err != nil || r.Name != "origin" {
+		t.Errorf("Expect origin, but got %v (%v)", r, err)
+	}
+	if _, err := cfg.Remote("Origin"); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("Expect ErrSectionNotFound for another case, but got %v", err)
+	}
+
+	cfg, _ = NewConfigFromString("[remote \"bad\"]\n\tfetch = refs/heads/*:refs/x\n")
+	if _, err := cfg.Remotes(); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expect ErrInvalidValue for a bad refspec, but got %v", err)
+	}
+}
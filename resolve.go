@@ -0,0 +1,148 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// A Resolver picks the machine specific variants of values in a config
+// shared between machines.
+//
+// Variants are written as sections whose sub-section ends with
+// "@<selector>", holding values which replace those of the same keys in
+// the section without the suffix:
+//
+//	[core]
+//		editor = notepad
+//	[core "@linux"]
+//		editor = vim
+//	[remote "origin@host:build01"]
+//		url = /mnt/mirror/repo.git
+//
+// A selector is "os:<GOOS>" (or just "<GOOS>"), "arch:<GOARCH>" or
+// "host:<hostname>"; sub-sections with any other '@' suffix are not
+// variants, nor are URLs such as [credential "https://joe@linux"] ending
+// in a bare "@<GOOS>", which must use "@os:<GOOS>". Where several
+// variants set the same key, host beats arch beats os. Nothing is done
+// unless Resolve is called; the parser reads variants as ordinary
+// sub-sections.
+type Resolver struct {
+	OS       string // e.g. "linux", see runtime.GOOS
+	Arch     string // e.g. "arm64", see runtime.GOARCH
+	Hostname string
+}
+
+// Gets a Resolver for the machine running the program.
+func NewResolver() (*Resolver, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	return &Resolver{OS: runtime.GOOS, Arch: runtime.GOARCH, Hostname: host}, nil
+}
+
+// Variant selectors, in increasing order of precedence.
+var selectorKinds = []string{"os", "arch", "host"}
+
+// The operating systems a bare "@<GOOS>" selector may name, see
+// `go tool dist list`.
+var knownOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true,
+	"freebsd": true, "illumos": true, "ios": true, "js": true, "linux": true,
+	"netbsd": true, "openbsd": true, "plan9": true, "solaris": true,
+	"wasip1": true, "windows": true,
+}
+
+// Splits a sub-section name into the name it is a variant of and its
+// selector kind and value. ok is false if it is not a variant; sub-sections
+// such as [url "git@example.com:"] or [credential "https://joe@host"] often
+// contain '@', so anything not exactly matching a selector is left alone.
+func splitVariant(subSection string) (base, kind, value string, ok bool) {
+	at := strings.LastIndexByte(subSection, '@')
+	if at < 0 {
+		return "", "", "", false
+	}
+	base = subSection[:at]
+	kind, value, found := strings.Cut(subSection[at+1:], ":")
+	if !found {
+		kind, value = "os", kind
+		// a URL's user name, not a selector
+		if !knownOS[value] || strings.Contains(base, "://") {
+			return "", "", "", false
+		}
+	}
+	switch kind {
+	case "os", "arch", "host":
+	default:
+		return "", "", "", false
+	}
+	if value == "" {
+		return "", "", "", false
+	}
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; !isLetter(c) && !isDigit(c) && c != '-' && c != '.' && c != '_' {
+			return "", "", "", false
+		}
+	}
+	return base, kind, value, true
+}
+
+func (self *Resolver) matches(kind, value string) bool {
+	switch kind {
+	case "os":
+		return value == self.OS
+	case "arch":
+		return value == self.Arch
+	case "host":
+		return strings.EqualFold(value, self.Hostname)
+	}
+	return false
+}
+
+// Gets a copy of the config with the variants matching this machine
+// applied and all variant sections removed.
+func (self *Resolver) Resolve(cfg *Config) *Config {
+	out := NewConfig()
+	out.Merge(cfg)
+	out.source = cfg.source
+	out.FoldSubSections = cfg.FoldSubSections
+	type variant struct {
+		section, base, name string
+		values              ConfigValueSet
+	}
+	byKind := make(map[string][]variant, len(selectorKinds))
+	for _, s := range out.Sections {
+		for ssName, ss := range s.SubSections {
+			base, kind, value, ok := splitVariant(ssName)
+			if !ok {
+				continue
+			}
+			delete(s.SubSections, ssName)
+			if self.matches(kind, value) {
+				byKind[kind] = append(byKind[kind], variant{section: s.OrigCaseName, base: base, name: ssName, values: ss.Values})
+			}
+		}
+	}
+	for _, kind := range selectorKinds {
+		variants := byKind[kind]
+		// map order is random, keep the outcome of clashes (e.g. "@linux"
+		// and "@os:linux") deterministic
+		sort.Slice(variants, func(i, j int) bool {
+			return variants[i].name < variants[j].name
+		})
+		for _, v := range variants {
+			for _, cv := range v.values {
+				dst := out.EnsureValues(v.section, v.base, cv.OrigCaseName)
+				dst.Entries = append([]ValueEntry(nil), cv.Entries...)
+				dst.syncValue()
+			}
+		}
+	}
+	return out
+}
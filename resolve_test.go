@@ -0,0 +1,45 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"testing"
+)
+
+func TestResolver(t *testing.T) {
+	config, err := NewConfigFromString("[core]\n\teditor = notepad\n\tpager = less\n" +
+		"[core \"@linux\"]\n\teditor = vim\n\tpager = more\n" +
+		"[core \"@darwin\"]\n\teditor = mate\n" +
+		"[core \"@host:Build01\"]\n\tpager = cat\n" +
+		"[remote \"origin\"]\n\turl = a\n\tfetch = x\n" +
+		"[remote \"origin@arch:arm64\"]\n\turl = b\n\turl = c\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	linux := &Resolver{OS: "linux", Arch: "amd64", Hostname: "build01"}
+	out := linux.Resolve(config)
+	testValue(t, out, "core.editor", "vim", true)
+	testValue(t, out, "core.pager", "cat", true)
+	testValue(t, out, "remote.origin.url", "a", true)
+	testValue(t, out, "core.@linux.editor", "", false)
+
+	mac := &Resolver{OS: "darwin", Arch: "arm64", Hostname: "laptop"}
+	out = mac.Resolve(config)
+	testValue(t, out, "core.editor", "mate", true)
+	testValue(t, out, "core.pager", "less", true)
+	if urls := out.GetKeyValuesStrings("remote.origin.url"); len(urls) != 2 || urls[0] != "b" || urls[1] != "c" {
+		t.Errorf("Expect variant to replace all url values with b, c but got %v", urls)
+	}
+	testValue(t, out, "remote.origin.fetch", "x", true)
+	testValue(t, config, "core.editor", "notepad", true)
+
+	// sub-sections which merely contain '@' are not variants
+	other, _ := NewConfigFromString("[url \"git@example.com:\"]\n\tinsteadOf = gh:\n[credential \"https://joe@host:8080/\"]\n\tusername = joe\n[credential \"https://joe@linux\"]\n\tusername = joe\n[core \"@planet:mars\"]\n\teditor = ed\n")
+	out = linux.Resolve(other)
+	testValue(t, out, "url.git@example.com:.insteadof", "gh:", true)
+	testValue(t, out, "credential.https://joe@host:8080/.username", "joe", true)
+	testValue(t, out, "credential.https://joe@linux.username", "joe", true)
+	testValue(t, out, "core.@planet:mars.editor", "ed", true)
+}
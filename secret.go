@@ -0,0 +1,151 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The sentinel errors kept when the rest of an error about a secret field
+// is withheld.
+var secretSentinels = []error{
+	ErrKeyNotFound, ErrInvalidKey, ErrSectionNotFound, ErrTypeMismatch,
+	ErrRequiredMissing, ErrUnsupportedType, ErrInvalidTag, ErrInvalidScope,
+	ErrInvalidValue, ErrUndefinedVariable, ErrOutOfRange,
+}
+
+// Reads a field's gcSecret tag.
+func secretTag(ft reflect.StructField) (bool, error) {
+	tag := ft.Tag.Get("gcSecret")
+	if tag == "" {
+		return false, nil
+	}
+	secret, err := strconv.ParseBool(tag)
+	if err != nil {
+		return false, fmt.Errorf("Could not parse secret:\"%s\" as boolean in field %q: %w\n", tag, ft.Name, ErrInvalidTag)
+	}
+	return secret, nil
+}
+
+// Replaces an error loading a secret field, whose message may include the
+// value, with one that only wraps the sentinel errors it did.
+func withholdSecret(tpName, name string, err error) error {
+	sentinels := make([]any, 0, 2)
+	for _, s := range secretSentinels {
+		if errors.Is(err, s) {
+			sentinels = append(sentinels, s)
+		}
+	}
+	// a missing key holds no value, keep it for LoadError.Required
+	var missing *missingError
+	if errors.As(err, &missing) {
+		for i, s := range sentinels {
+			if s == ErrRequiredMissing {
+				sentinels[i] = missing
+			}
+		}
+	}
+	format := "Could not populate secret %s field %q, details withheld"
+	if len(sentinels) > 0 {
+		format += ": " + strings.TrimSuffix(strings.Repeat("%w: ", len(sentinels)), ": ")
+	}
+	return fmt.Errorf(format, append([]any{tpName, name}, sentinels...)...)
+}
+
+// Lists the keys the gcSecret fields of a struct (or pointer to one) would
+// be loaded from, as patterns suitable for RedactedString.
+func SecretKeys(v interface{}) ([]string, error) {
+	tp := reflect.TypeOf(v)
+	for tp != nil && tp.Kind() == reflect.Ptr {
+		tp = tp.Elem()
+	}
+	if tp == nil || tp.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Passed a non-struct: %v: %w\n", v, ErrUnsupportedType)
+	}
+	out := make([]string, 0, 5)
+	if err := structKeys(tp, "", true, &out, make(map[reflect.Type]bool, 5)); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Lists the keys the fields of a struct type would be loaded from, as
+// patterns suitable for RedactedString, or only those of gcSecret fields
+// if secretOnly. A struct field is listed as its whole section only when
+// secret.
+func structKeys(tp reflect.Type, ns string, secretOnly bool, out *[]string, seen map[reflect.Type]bool) error {
+	if seen[tp] {
+		return nil
+	}
+	seen[tp] = true
+	defer delete(seen, tp)
+	for i := 0; i < tp.NumField(); i++ {
+		ft := tp.Field(i)
+		key := ft.Tag.Get("gcKey")
+		if key == "" || !ft.IsExported() {
+			continue
+		}
+		if ns != "" {
+			key = ns + "." + key
+		}
+		secret, err := secretTag(ft)
+		if err != nil {
+			return err
+		}
+		if ft.Type == configValueSetType {
+			if secret || !secretOnly {
+				// a whole section, or the unclaimed keys of this one
+				*out = append(*out, strings.TrimSuffix(key, ".*")+".*")
+			}
+			continue
+		}
+		ftp := ft.Type
+		for ftp.Kind() == reflect.Ptr || ftp.Kind() == reflect.Slice || ftp.Kind() == reflect.Array {
+			ftp = ftp.Elem()
+		}
+		isMap := ftp.Kind() == reflect.Map
+		if isMap {
+			ftp = ftp.Elem()
+			for ftp.Kind() == reflect.Ptr || ftp.Kind() == reflect.Slice {
+				ftp = ftp.Elem()
+			}
+		}
+		isStruct := ftp.Kind() == reflect.Struct && ftp != timeType && ftp != regexpType && ftp != addrType && ftp != prefixType && ftp != configValueType
+		if isStruct && !secret {
+			subNs := key
+			if isMap && !strings.HasSuffix(subNs, ".*") {
+				subNs += ".*"
+			}
+			if err := structKeys(ftp, subNs, secretOnly, out, seen); err != nil {
+				return err
+			}
+			continue
+		}
+		if !secret && secretOnly {
+			continue
+		}
+		if isStruct || (isMap && !strings.Contains(key, ".*.")) {
+			// every key under the section(s)
+			*out = append(*out, strings.TrimSuffix(key, ".*")+".*")
+		} else {
+			*out = append(*out, key)
+		}
+	}
+	return nil
+}
+
+// Serializes the config like String, masking the values of any keys the
+// gcSecret fields of v would be loaded from.
+func (self *Config) RedactedStringFor(v interface{}) (string, error) {
+	keys, err := SecretKeys(v)
+	if err != nil {
+		return "", err
+	}
+	return self.RedactedString(keys...), nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"strings"
+)
+
+// A section or sub-section found by Config.SectionsMatching, with helpers
+// to read its keys without building the full key names.
+type SectionHandle struct {
+	Section    string // lower case
+	SubSection string // empty for the section itself
+	Values     ConfigValueSet
+}
+
+// Gets the name as in a full key, e.g. "branch" or "branch.main".
+func (self SectionHandle) Name() string {
+	if self.SubSection == "" {
+		return self.Section
+	}
+	return self.Section + "." + self.SubSection
+}
+
+// Gets the Key for a name in the section.
+func (self SectionHandle) Key(name string) Key {
+	return Key{Section: self.Section, SubSection: self.SubSection, Name: strings.ToLower(name)}
+}
+
+// Gets all the values of a key in the section, or nil if it is not set.
+func (self SectionHandle) GetKeyValuesRaw(name string) *ConfigValue {
+	return self.Values[strings.ToLower(name)]
+}
+
+// Gets the last value of a key in the section as a string.
+// If the key does not exist, the second return value will be false.
+func (self SectionHandle) GetKeyValueAsString(name string) (string, bool) {
+	cv := self.GetKeyValuesRaw(name)
+	if cv == nil {
+		return "", false
+	}
+	return cv.GetString()
+}
+
+// Finds a section and its sub-sections, in the order first seen, e.g.
+// "branch" for [branch] and each [branch "<name>"]. A prefix holding a
+// dot finds only the sub-sections whose names start with what follows it,
+// e.g. "url.https://" for each [url "https://..."]. The section name is
+// matched ignoring case, sub-section names are not. The result is nil if
+// nothing matches.
+func (self *Config) SectionsMatching(prefix string) []SectionHandle {
+	name, subPrefix, hasSub := strings.Cut(prefix, ".")
+	s := self.LookupSection(name)
+	if s == nil {
+		return nil
+	}
+	var out []SectionHandle
+	if !hasSub {
+		out = append(out, SectionHandle{Section: s.Name, Values: s.Values})
+	}
+	for _, ssName := range s.subSectionNames() {
+		if strings.HasPrefix(ssName, subPrefix) {
+			out = append(out, SectionHandle{Section: s.Name, SubSection: ssName, Values: s.SubSections[ssName].Values})
+		}
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"testing"
+)
+
+func TestSectionsMatching(t *testing.T) {
+	config, err := NewConfigFromString("[Branch]\n\tautoSetupRebase = always\n[branch \"main\"]\n\tremote = origin\n\tmerge = refs/heads/main\n[url \"https://git.example.com/\"]\n\tinsteadOf = ex:\n[branch \"feature/x\"]\n\tremote = fork\n[url \"ssh://git@example.com/\"]\n\tpushInsteadOf = ex:\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	got := config.SectionsMatching("BRANCH")
+	names := []string{}
+	for _, h := range got {
+		names = append(names, h.Name())
+	}
+	if len(got) != 3 || names[0] != "branch" || names[1] != "branch.main" || names[2] != "branch.feature/x" {
+		t.Fatalf("Expect branch and its sub-sections in file order, but got %v", names)
+	}
+	if v, ok := got[1].GetKeyValueAsString("Remote"); !ok || v != "origin" {
+		t.Errorf("Expect branch.main.remote origin, but got %q", v)
+	}
+	if k := got[2].Key("Merge"); k.String() != "branch.feature/x.merge" {
+		t.Errorf("Unexpected key %s", k)
+	}
+	if cv := config.GetValuesForKey(got[0].Key("autoSetupRebase")); cv == nil {
+		t.Errorf("Expect the handle's key to look up the value")
+	}
+
+	urls := config.SectionsMatching("url.https://")
+	if len(urls) != 1 || urls[0].SubSection != "https://git.example.com/" {
+		t.Errorf("Expect only the https url sub-section, but got %+v", urls)
+	}
+	if got := config.SectionsMatching("user"); got != nil {
+		t.Errorf("Expect nil for a missing section, but got %+v", got)
+	}
+	if got := config.SectionsMatching("branch.none"); len(got) != 0 {
+		t.Errorf("Expect nothing for an unmatched prefix, but got %+v", got)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The settings git send-email uses for an identity, see
+// Config.SendEmailSettings.
+type SendEmailSettings struct {
+	Identity          string   // the identity used, "" for none
+	SMTPServer        string   `gcKey:"sendemail.smtpserver"` // a host name, or a sendmail-like program if a path
+	SMTPServerPort    string   `gcKey:"sendemail.smtpserverport"`
+	SMTPServerOptions []string `gcKey:"sendemail.smtpserveroption"`
+	SMTPEncryption    string   `gcKey:"sendemail.smtpencryption"` // "ssl", "tls" (STARTTLS) or "" for none
+	SMTPUser          string   `gcKey:"sendemail.smtpuser"`
+	SMTPPass          string   `gcKey:"sendemail.smtppass" gcSecret:"true"`
+	SMTPDomain        string   `gcKey:"sendemail.smtpdomain"`
+	SMTPAuth          string   `gcKey:"sendemail.smtpauth"`
+	SMTPSSLCertPath   string   `gcKey:"sendemail.smtpsslcertpath" gcPath:"true"`
+	From              string   `gcKey:"sendemail.from"`
+	EnvelopeSender    string   `gcKey:"sendemail.envelopesender"`
+	To                []string `gcKey:"sendemail.to"`
+	Cc                []string `gcKey:"sendemail.cc"`
+	Bcc               []string `gcKey:"sendemail.bcc"`
+	SuppressCc        []string `gcKey:"sendemail.suppresscc"`
+	Annotate          bool     `gcKey:"sendemail.annotate" gcDefault:"false"`
+	Confirm           string   `gcKey:"sendemail.confirm" gcDefault:"auto"`
+	ChainReplyTo      bool     `gcKey:"sendemail.chainreplyto" gcDefault:"false"`
+	Thread            bool     `gcKey:"sendemail.thread" gcDefault:"true"`
+	Validate          bool     `gcKey:"sendemail.validate" gcDefault:"true"`
+	TransferEncoding  string   `gcKey:"sendemail.transferencoding" gcDefault:"auto"`
+}
+
+// Works out the send-email settings for an identity, or for
+// sendemail.identity if identity is "", as git send-email does: each key
+// set in [sendemail "<identity>"] is used over the same key in [sendemail],
+// including every value of multi-valued keys such as to and cc. The
+// deprecated sendemail.smtpssl is read as smtpEncryption ssl. As with git,
+// an encryption other than ssl or tls means none.
+func (self *Config) SendEmailSettings(identity string) (*SendEmailSettings, error) {
+	if identity == "" {
+		identity, _ = self.GetKeyValueAsString("sendemail.identity")
+	}
+	layered := NewConfig()
+	values := layered.EnsureSection("sendemail").Values
+	if s := self.LookupSection("sendemail"); s != nil {
+		for name, cv := range s.Values {
+			values[name] = cv.copy()
+		}
+	}
+	if ss := self.LookupSubSection("sendemail", identity); identity != "" && ss != nil {
+		for name, cv := range ss.Values {
+			values[name] = cv.copy()
+		}
+	}
+	out := &SendEmailSettings{}
+	if err := layered.Load(out); err != nil {
+		return nil, err
+	}
+	out.Identity = identity
+	if out.SMTPEncryption == "" {
+		if ssl, _, err := layered.GetKeyValueAsBool("sendemail.smtpssl"); err != nil {
+			return nil, fmt.Errorf("key %q: %w", "sendemail.smtpssl", err)
+		} else if ssl {
+			out.SMTPEncryption = "ssl"
+		}
+	}
+	switch out.SMTPEncryption = strings.ToLower(out.SMTPEncryption); out.SMTPEncryption {
+	case "ssl", "tls":
+	default:
+		out.SMTPEncryption = ""
+	}
+	return out, nil
+}
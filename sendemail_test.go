@@ -0,0 +1,58 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSendEmailSettings(t *testing.T) {
+	cfg, err := NewConfigFromString(`[sendemail]
+	identity = work
+	smtpServer = smtp.home.example
+	smtpServerPort = 587
+	smtpEncryption = TLS
+	from = Joe <joe@home.example>
+	to = list@home.example
+	cc = a@home.example
+	thread = false
+[sendemail "work"]
+	smtpServer = smtp.work.example
+	smtpUser = joe
+	cc = b@work.example
+	cc = c@work.example
+[sendemail "old"]
+	smtpEncryption =
+	smtpSsl = true
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	work, err := cfg.SendEmailSettings("")
+	if err != nil {
+		t.Fatalf("Failed to read sendemail settings: %s", err)
+	}
+	if work.Identity != "work" || work.SMTPServer != "smtp.work.example" || work.SMTPServerPort != "587" || work.SMTPEncryption != "tls" ||
+		work.SMTPUser != "joe" || work.From != "Joe <joe@home.example>" || work.Thread || !work.Validate || work.Confirm != "auto" {
+		t.Errorf("Unexpected settings for work %+v", work)
+	}
+	if !reflect.DeepEqual(work.Cc, []string{"b@work.example", "c@work.example"}) || !reflect.DeepEqual(work.To, []string{"list@home.example"}) {
+		t.Errorf("Expect the identity's cc to replace the section's, but got to %v cc %v", work.To, work.Cc)
+	}
+
+	home, _ := cfg.SendEmailSettings("none")
+	if home.SMTPServer != "smtp.home.example" || !reflect.DeepEqual(home.Cc, []string{"a@home.example"}) {
+		t.Errorf("Expect the section's settings for an unknown identity, but got %+v", home)
+	}
+	if old, err := cfg.SendEmailSettings("old"); err != nil || old.SMTPEncryption != "ssl" {
+		t.Errorf("Expect smtpSsl read as ssl, but got %+v (%v)", old, err)
+	}
+
+	cfg, _ = NewConfigFromString("[sendemail]\n\tsmtpEncryption = starttls\n")
+	if plain, err := cfg.SendEmailSettings(""); err != nil || plain.SMTPEncryption != "" {
+		t.Errorf("Expect an unknown encryption to mean none, but got %+v (%v)", plain, err)
+	}
+}
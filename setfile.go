@@ -0,0 +1,383 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// An option changing what SetInFile does.
+type SetOption func(*setOptions)
+
+type setOptions struct {
+	add        bool
+	replaceAll bool
+	comment    string
+	extraChars string // see ParseOptions.ExtraKeyChars
+}
+
+// Adds the value after any existing ones, like `git config --add`.
+func SetAdd() SetOption {
+	return func(o *setOptions) { o.add = true }
+}
+
+// Replaces every value of the key, like `git config --replace-all`.
+// Without it setting a key which has several values fails with
+// ErrMultipleValues.
+func SetReplaceAll() SetOption {
+	return func(o *setOptions) { o.replaceAll = true }
+}
+
+// Puts a comment after the value, like `git config --comment`. It starts
+// with the file's comment character (see Config.CommentChar) unless it
+// already does. The comment must fit on one line.
+func SetComment(comment string) SetOption {
+	return func(o *setOptions) { o.comment = comment }
+}
+
+// Allows the characters in keys besides git's, both in the key set and in
+// those read from the file, as ParseOptions.ExtraKeyChars does.
+func SetExtraKeyChars(chars string) SetOption {
+	return func(o *setOptions) { o.extraChars = chars }
+}
+
+// Sets a key in a config file as `git config --file path key value` does,
+// leaving the rest of the file, comments included, as it was. Values
+// holding control characters are refused as by Config.Set.
+// An existing value is replaced where it is, a new key goes at the end of
+// the last block of its section, or in a new section at the end of the
+// file. The file is created if it does not exist.
+// As git does, the new contents are written to "<path>.lock", which is then
+// renamed over the file, so readers never see a partly written file. The
+// lock file also keeps out other writers; if it already exists the error
+// wraps ErrLocked.
+func SetInFile(path, key, value string, opts ...SetOption) error {
+	o, err := checkSet(key, value, opts)
+	if err != nil {
+		return err
+	}
+	lock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer lock.abort()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	out, err := setInData(string(data), path, key, value, o)
+	if err != nil {
+		return err
+	}
+	return lock.commit([]byte(out), nil)
+}
+
+// Checks the arguments of SetInFile.
+func checkSet(key, value string, opts []SetOption) (setOptions, error) {
+	o := makeSetOptions(opts)
+	if _, err := normalizeKey(key, o.extraChars); err != nil {
+		return o, err
+	}
+	if err := validateValue(key, value); err != nil {
+		return o, err
+	}
+	if strings.ContainsAny(o.comment, "\n\r\x00") {
+		return o, fmt.Errorf("Cannot set key '%s' with a comment over several lines: %w", key, ErrInvalidValue)
+	}
+	return o, nil
+}
+
+func makeSetOptions(opts []SetOption) setOptions {
+	var o setOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Reads the config text noting where each key is.
+func parseSpans(data, file, extraChars string) (*Config, []entrySpan, error) {
+	spans := make([]entrySpan, 0, 20)
+	p := Parser{
+		Reader:  bufio.NewScanner(strings.NewReader(data)),
+		Config:  NewConfig(),
+		Options: ParseOptions{ExtraKeyChars: extraChars},
+		file:    file,
+		spans:   &spans,
+	}
+	if err := p.Read(); err != nil {
+		return nil, nil, err
+	}
+	return p.Config, spans, nil
+}
+
+// Splits a valid key, keeping the case of the names as given.
+func splitFileKey(key string) (section, subSection, name string) {
+	first := strings.IndexByte(key, '.')
+	last := strings.LastIndexByte(key, '.')
+	section, name = key[:first], key[last+1:]
+	if first != last {
+		subSection = key[first+1 : last]
+	}
+	return section, subSection, name
+}
+
+// Whether the span is of the key.
+func spanIs(sp entrySpan, section, subSection, name string) bool {
+	return sp.block != nil && strings.EqualFold(sp.block.section, section) && sp.block.subSection == subSection && strings.EqualFold(sp.name, name)
+}
+
+// Sets the key in the config text, changing only the lines needed.
+// The key must already be valid.
+func setInData(data, file, key, value string, o setOptions) (string, error) {
+	cfg, spans, err := parseSpans(data, file, o.extraChars)
+	if err != nil {
+		return "", err
+	}
+	section, subSection, name := splitFileKey(key)
+	inSection := func(b *sectionBlock) bool {
+		return b != nil && strings.EqualFold(b.section, section) && b.subSection == subSection
+	}
+
+	// the last line of each block, so new keys can go after it
+	blockEnd := make(map[*sectionBlock]uint64, len(cfg.blocks))
+	var lastBlock *sectionBlock
+	for _, b := range cfg.blocks {
+		blockEnd[b] = b.origin.Line
+		if inSection(b) {
+			lastBlock = b
+		}
+	}
+	indent := "\t"
+	var matches []entrySpan
+	for _, sp := range spans {
+		if sp.block != nil && sp.endLine > blockEnd[sp.block] {
+			blockEnd[sp.block] = sp.endLine
+		}
+		if spanIs(sp, section, subSection, name) {
+			matches = append(matches, sp)
+		}
+	}
+
+	valueText := formatValue(value)
+	if o.comment != "" {
+		cc := cfg.CommentChar()
+		if o.comment[0] == cc {
+			valueText += " " + o.comment
+		} else {
+			valueText += " " + string(cc) + " " + o.comment
+		}
+	}
+	lines := strings.Split(data, "\n")
+	if len(matches) > 0 && !o.add {
+		if len(matches) > 1 && !o.replaceAll {
+			return "", fmt.Errorf("Cannot set key '%s' which has %d values: %w", key, len(matches), ErrMultipleValues)
+		}
+		// from the last, so the line numbers of earlier ones stay right
+		for i := len(matches) - 1; i >= 0; i-- {
+			sp := matches[i]
+			prefix := lines[sp.line-1][:sp.col]
+			cr := ""
+			if strings.HasSuffix(lines[sp.endLine-1], "\r") {
+				cr = "\r"
+			}
+			var repl []string
+			if i == len(matches)-1 {
+				text := valueText
+				if o.comment == "" && sp.comment != "" {
+					// keep the note on the value replaced
+					text += " " + sp.comment
+				}
+				repl = []string{prefix + sp.name + " = " + text + cr}
+			} else if strings.TrimLeft(prefix, " \t") != "" {
+				// keep the section header the key shared a line with
+				repl = []string{strings.TrimRight(prefix, " \t") + cr}
+			}
+			lines = slices.Replace(lines, int(sp.line-1), int(sp.endLine), repl...)
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+
+	if lines[len(lines)-1] != "" {
+		lines = append(lines, "") // end the last line
+	}
+	switch {
+	case len(matches) > 0:
+		sp := matches[len(matches)-1]
+		if prefix := lines[sp.line-1][:sp.col]; strings.TrimLeft(prefix, " \t") == "" {
+			indent = prefix
+		}
+		lines = slices.Insert(lines, int(sp.endLine), indent+name+" = "+valueText)
+	case lastBlock != nil:
+		lines = slices.Insert(lines, int(blockEnd[lastBlock]), indent+name+" = "+valueText)
+	default:
+		lines = slices.Insert(lines, len(lines)-1, sectionHeader(section, subSection), indent+name+" = "+valueText)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Removes every value of the key from the config text, changing only the
+// lines needed. The key must already be valid.
+func unsetInData(data, file, key, extraChars string) (string, error) {
+	_, spans, err := parseSpans(data, file, extraChars)
+	if err != nil {
+		return "", err
+	}
+	section, subSection, name := splitFileKey(key)
+	lines := strings.Split(data, "\n")
+	found := false
+	// from the last, so the line numbers of earlier ones stay right
+	for i := len(spans) - 1; i >= 0; i-- {
+		sp := spans[i]
+		if !spanIs(sp, section, subSection, name) {
+			continue
+		}
+		found = true
+		var repl []string
+		if prefix := lines[sp.line-1][:sp.col]; strings.TrimLeft(prefix, " \t") != "" {
+			// keep the section header the key shared a line with
+			cr := ""
+			if strings.HasSuffix(lines[sp.endLine-1], "\r") {
+				cr = "\r"
+			}
+			repl = []string{strings.TrimRight(prefix, " \t") + cr}
+		}
+		lines = slices.Replace(lines, int(sp.line-1), int(sp.endLine), repl...)
+	}
+	if !found {
+		return "", fmt.Errorf("Cannot unset key '%s': %w", key, ErrKeyNotFound)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// A config file locked for writing, the way git does, by creating
+// "<path>.lock" which is renamed over the file once written.
+type lockedFile struct {
+	path string
+	fh   *os.File
+	done bool
+}
+
+func lockFile(path string) (*lockedFile, error) {
+	fh, err := os.OpenFile(path+".lock", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("Cannot lock '%s', '%s.lock' exists: %w", path, path, ErrLocked)
+		}
+		return nil, err
+	}
+	return &lockedFile{path: path, fh: fh}, nil
+}
+
+// Writes the new contents and puts them in place of the file, with the
+// given permissions, or if nil those of the file being replaced. The file
+// keeps its owner where allowed to. The directory is synced too, so the
+// rename survives a crash.
+func (self *lockedFile) commit(data []byte, perm *os.FileMode) error {
+	if _, err := self.fh.Write(data); err != nil {
+		return err
+	}
+	info, err := os.Stat(self.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if perm == nil && info != nil {
+		mode := info.Mode().Perm()
+		perm = &mode
+	}
+	if perm != nil {
+		if err := self.fh.Chmod(*perm); err != nil {
+			return err
+		}
+	}
+	if info != nil {
+		if err := chownLike(self.fh, info); err != nil {
+			return err
+		}
+	}
+	if err := self.fh.Sync(); err != nil {
+		return err
+	}
+	if err := self.fh.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(self.path+".lock", self.path); err != nil {
+		return err
+	}
+	self.done = true
+	return syncDir(filepath.Dir(self.path))
+}
+
+// Flushes a directory's entries to disk. Windows cannot sync directories,
+// there renames are durable once done.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	fh, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	return fh.Sync()
+}
+
+// Removes the lock file, leaving the file untouched, unless committed.
+func (self *lockedFile) abort() {
+	if self.done {
+		return
+	}
+	self.fh.Close()
+	os.Remove(self.path + ".lock")
+}
+
+// An option changing how SaveToFile writes.
+type SaveOption func(*saveOptions)
+
+type saveOptions struct {
+	perm *os.FileMode // nil to keep the file's
+}
+
+// Gives the file these permissions, rather than keeping those of the file
+// being replaced (or the default 0666 less umask for a new file). Even 0
+// is used as given.
+func SaveMode(perm os.FileMode) SaveOption {
+	perm = perm.Perm()
+	return func(o *saveOptions) { o.perm = &perm }
+}
+
+// Writes the config, as given by String, to the file, replacing it.
+// The file keeps its permissions unless SaveMode says otherwise, so e.g. a
+// 0600 ~/.gitconfig holding tokens stays private, and its owner unless not
+// allowed to give it away, e.g. when root edits a user's file. It is written and locked
+// as for SetInFile, and synced to disk along with its directory.
+// Comments and layout of the original file are not kept; use SetInFile to
+// change single keys.
+func (self *Config) SaveToFile(path string, opts ...SaveOption) error {
+	var o saveOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	lock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer lock.abort()
+	return lock.commit([]byte(self.String()), o.perm)
+}
+
+// Writes the config back to the file it was read from, see SaveToFile.
+func (self *Config) Save(opts ...SaveOption) error {
+	if self.source == "" {
+		return fmt.Errorf("Cannot save config: %w", ErrNoSource)
+	}
+	return self.SaveToFile(self.source, opts...)
+}
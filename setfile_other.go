@@ -0,0 +1,14 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package gitconfig
+
+import "os"
+
+// Files have no owner to keep here.
+func chownLike(fh *os.File, info os.FileInfo) error {
+	return nil
+}
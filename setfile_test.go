@@ -0,0 +1,145 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSetInData(t *testing.T) {
+	base := "# user settings\n[user]\n    name = Joe ; who\n[core]\n\tbare = false\n[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n"
+	tests := []struct {
+		key, value string
+		opts       setOptions
+		in, expect string
+	}{
+		{"user.name", "Joseph", setOptions{}, base,
+			"# user settings\n[user]\n    name = Joseph ; who\n[core]\n\tbare = false\n[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n"},
+		{"User.Email", "joe@example.com", setOptions{}, base,
+			"# user settings\n[user]\n    name = Joe ; who\n\tEmail = joe@example.com\n[core]\n\tbare = false\n[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n"},
+		{"remote.origin.fetch", "c", setOptions{add: true}, base,
+			"# user settings\n[user]\n    name = Joe ; who\n[core]\n\tbare = false\n[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n\tfetch = c\n"},
+		{"remote.origin.fetch", "c", setOptions{replaceAll: true}, base,
+			"# user settings\n[user]\n    name = Joe ; who\n[core]\n\tbare = false\n[remote \"origin\"]\n\tfetch = c\n"},
+		{"remote.Upstream.url", "x y ", setOptions{}, base,
+			base + "[remote \"Upstream\"]\n\turl = \"x y \"\n"},
+		{"core.editor", "vim", setOptions{}, "[core] bare\n[user]\n\tname = Joe\n[core]\n\tpager = less \\\n\t\tmore\n[alias]\n",
+			"[core] bare\n[user]\n\tname = Joe\n[core]\n\tpager = less \\\n\t\tmore\n\teditor = vim\n[alias]\n"},
+		{"core.pager", "less", setOptions{}, "[core]\n\tpager = less \\\n\t\tmore\n[alias]\n",
+			"[core]\n\tpager = less\n[alias]\n"},
+		{"core.bare", "true", setOptions{replaceAll: true}, "[core] bare\n[core]\n\tbare = false\n",
+			"[core]\n[core]\n\tbare = true\n"},
+		{"a.b", "c", setOptions{}, "", "[a]\n\tb = c\n"},
+		{"a.b", "c", setOptions{}, "[x]\n\ty = z", "[x]\n\ty = z\n[a]\n\tb = c\n"},
+		{"a.b", "c", setOptions{}, "[a]\r\n\tb = x\r\n", "[a]\r\n\tb = c\r\n"},
+		{"a.b", "c", setOptions{comment: "set by tool"}, "[a]\n", "[a]\n\tb = c # set by tool\n"},
+		{"a.b", "c#d", setOptions{comment: "# as is"}, "[a]\n\tb = x\n", "[a]\n\tb = \"c#d\" # as is\n"},
+		{"a.b", "c", setOptions{comment: "note"}, "[core]\n\tcommentChar = \";\"\n", "[core]\n\tcommentChar = \";\"\n[a]\n\tb = c ; note\n"},
+		{"a.b", "c", setOptions{comment: "note"}, "[core]\n\tcommentChar = %\n", "[core]\n\tcommentChar = %\n[a]\n\tb = c # note\n"},
+	}
+	for _, test := range tests {
+		out, err := setInData(test.in, "", test.key, test.value, test.opts)
+		if err != nil {
+			t.Errorf("Failed to set %s in:\n%s\n%s", test.key, test.in, err)
+			continue
+		}
+		if out != test.expect {
+			t.Errorf("Expect setting %s = %s to give:\n%s\nbut got:\n%s", test.key, test.value, test.expect, out)
+		}
+	}
+	if _, err := setInData(base, "", "remote.origin.fetch", "c", setOptions{}); !errors.Is(err, ErrMultipleValues) {
+		t.Errorf("Expect multiple values error, but got: %v", err)
+	}
+}
+
+func TestSetInFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(file, []byte("[user]\n\tname = Joe # me\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetInFile(file, "user.email", "joe@example.com"); err != nil {
+		t.Fatalf("Failed to set key: %s", err)
+	}
+	if err := SetInFile(file, "credential.helper", "store", SetAdd()); err != nil {
+		t.Fatalf("Failed to add key: %s", err)
+	}
+	data, _ := os.ReadFile(file)
+	expect := "[user]\n\tname = Joe # me\n\temail = joe@example.com\n[credential]\n\thelper = store\n"
+	if string(data) != expect {
+		t.Errorf("Expect file:\n%s\nbut got:\n%s", expect, data)
+	}
+	if info, err := os.Stat(file); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expect file mode kept as 0600, but got %v (%v)", info.Mode(), err)
+	}
+	if _, err := os.Stat(file + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("Expect lock file removed, but got: %v", err)
+	}
+
+	if err := SetInFile(file, "user.name", "x", SetComment("two\nlines")); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expect invalid value error for multi-line comment, but got: %v", err)
+	}
+	if err := SetInFile(file, "user..name", "x"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expect invalid key error, but got: %v", err)
+	}
+	if err := os.WriteFile(file+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetInFile(file, "user.name", "x"); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expect locked error, but got: %v", err)
+	}
+	if _, err := os.Stat(file + ".lock"); err != nil {
+		t.Errorf("Expect someone else's lock file left alone, but got: %v", err)
+	}
+
+	created := filepath.Join(t.TempDir(), "new")
+	if err := SetInFile(created, "core.bare", "true"); err != nil {
+		t.Fatalf("Failed to create file: %s", err)
+	}
+	if data, _ := os.ReadFile(created); string(data) != "[core]\n\tbare = true\n" {
+		t.Errorf("Expect new file with core.bare, but got:\n%s", data)
+	}
+}
+
+func TestSaveToFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(file, []byte("[credential]\n\ttoken = secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := NewConfigFromFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read config: %s", err)
+	}
+	config.Set("user.name", "Joe")
+	if err := config.Save(); err != nil {
+		t.Fatalf("Failed to save: %s", err)
+	}
+	if data, _ := os.ReadFile(file); string(data) != config.String() {
+		t.Errorf("Expect file to hold:\n%s\nbut got:\n%s", config.String(), data)
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0600 {
+		t.Errorf("Expect mode 0600 kept, but got %v", info.Mode())
+	}
+	if err := config.SaveToFile(file, SaveMode(0640)); err != nil {
+		t.Fatalf("Failed to save: %s", err)
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0640 {
+		t.Errorf("Expect mode 0640 set, but got %v", info.Mode())
+	}
+	if runtime.GOOS != "windows" {
+		if err := config.SaveToFile(file, SaveMode(0)); err != nil {
+			t.Fatalf("Failed to save: %s", err)
+		}
+		if info, _ := os.Stat(file); info.Mode().Perm() != 0 {
+			t.Errorf("Expect mode 0 set, but got %v", info.Mode())
+		}
+	}
+	if err := NewConfig().Save(); !errors.Is(err, ErrNoSource) {
+		t.Errorf("Expect saving a config with no file to fail, but got: %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package gitconfig
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Gives the file the owner and group of the one it replaces. Only root may
+// give files away, so a file we may not chown keeps ours.
+func chownLike(fh *os.File, info os.FileInfo) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if err := fh.Chown(int(st.Uid), int(st.Gid)); err != nil && !errors.Is(err, syscall.EPERM) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package gitconfig
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestSetInFileKeepsOwner(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(file, []byte("[user]\n\tname = Joe\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chown(file, 1234, 1235); err != nil {
+		t.Skipf("Cannot give the file away: %s", err)
+	}
+	if err := SetInFile(file, "user.name", "Joanne"); err != nil {
+		t.Fatalf("Failed to set: %s", err)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := info.Sys().(*syscall.Stat_t); st.Uid != 1234 || st.Gid != 1235 {
+		t.Errorf("Expect owner 1234:1235 kept, but got %d:%d", st.Uid, st.Gid)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"sort"
+)
+
+// A value hidden by a later value of the same key, see Config.Shadowed.
+type ShadowedValue struct {
+	Key     string     // canonical form, as given by Diff
+	Value   ValueEntry // the hidden value
+	By      ValueEntry // the value in effect, the last of the key
+	Scope   Scope      // where Value was set, if Scoped
+	ByScope Scope      // where By was set, if Scoped
+	Scoped  bool       // whether the config came from a ConfigSet, so scopes are known
+}
+
+// Lists the values of keys given more than once which are hidden by the
+// key's last value, sorted by key then in the order given. Their origins
+// say where each was set; for a Config from ConfigSet.Merged the scopes of
+// the values are known too, so e.g. a user.email in the global file
+// overridden by the repository's can be told apart from a repeat within
+// one file.
+// Keys meant to hold several values (e.g. remote.<name>.fetch) are listed
+// as well, it is up to the caller to know which those are.
+func (self *Config) Shadowed() []ShadowedValue {
+	if self.set != nil {
+		return self.set.Shadowed()
+	}
+	out := make([]ShadowedValue, 0, 5)
+	for key, cv := range self.keyValues() {
+		last := len(cv.Entries) - 1
+		for _, e := range cv.Entries[:last] {
+			out = append(out, ShadowedValue{Key: key, Value: e, By: cv.Entries[last]})
+		}
+	}
+	sortShadowed(out)
+	return out
+}
+
+// Lists the values hidden by later ones across and within the scopes,
+// see Config.Shadowed.
+func (self *ConfigSet) Shadowed() []ShadowedValue {
+	type scopedEntry struct {
+		entry ValueEntry
+		scope Scope
+	}
+	byKey := make(map[string][]scopedEntry, 20)
+	for _, scope := range self.Scopes() {
+		for key, cv := range self.layers[scope].keyValues() {
+			for _, e := range cv.Entries {
+				byKey[key] = append(byKey[key], scopedEntry{entry: e, scope: scope})
+			}
+		}
+	}
+	out := make([]ShadowedValue, 0, 5)
+	for key, entries := range byKey {
+		last := entries[len(entries)-1]
+		for _, e := range entries[:len(entries)-1] {
+			out = append(out, ShadowedValue{Key: key, Value: e.entry, By: last.entry, Scope: e.scope, ByScope: last.scope, Scoped: true})
+		}
+	}
+	sortShadowed(out)
+	return out
+}
+
+// Sorts by key, keeping the order of values within each key.
+func sortShadowed(values []ShadowedValue) {
+	sort.SliceStable(values, func(i, j int) bool { return values[i].Key < values[j].Key })
+}
@@ -0,0 +1,49 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"testing"
+)
+
+func TestShadowed(t *testing.T) {
+	config, err := NewConfigFromString("[user]\n\temail = a@x\n\tname = Joe\n\temail = b@x\n[core]\n\tbare\n")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	got := config.Shadowed()
+	if len(got) != 1 {
+		t.Fatalf("Expect one shadowed value, but got %v", got)
+	}
+	s := got[0]
+	if s.Key != "user.email" || s.Value.Value != "a@x" || s.Value.Origin.Line != 2 || s.By.Value != "b@x" || s.By.Origin.Line != 4 || s.Scoped {
+		t.Errorf("Expect user.email a@x on line 2 hidden by b@x on line 4, but got %+v", s)
+	}
+}
+
+func TestShadowedScopes(t *testing.T) {
+	set := testConfigSet(t,
+		"[user]\n    email = sys@x\n",
+		"[user]\n    email = global@x\n    name = Joe\n",
+		"[user]\n    email = repo@x\n[core]\n    bare = false\n    bare = true\n")
+	expect := []struct {
+		key, value, by string
+		scope, byScope Scope
+	}{
+		{"core.bare", "false", "true", ScopeLocal, ScopeLocal},
+		{"user.email", "sys@x", "repo@x", ScopeSystem, ScopeLocal},
+		{"user.email", "global@x", "repo@x", ScopeGlobal, ScopeLocal},
+	}
+	got := set.Merged().Shadowed()
+	if len(got) != len(expect) {
+		t.Fatalf("Expect %d shadowed values, but got %+v", len(expect), got)
+	}
+	for i, e := range expect {
+		s := got[i]
+		if s.Key != e.key || s.Value.Value != e.value || s.By.Value != e.by || s.Scope != e.scope || s.ByScope != e.byScope || !s.Scoped {
+			t.Errorf("Expect %s = %s (%s) hidden by %s (%s), but got %+v", e.key, e.value, e.scope, e.by, e.byScope, s)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+)
+
+// The kind of signature git makes, from gpg.format.
+type SigningFormat string
+
+const (
+	SigningOpenPGP SigningFormat = "openpgp" // the default
+	SigningX509    SigningFormat = "x509"
+	SigningSSH     SigningFormat = "ssh"
+)
+
+// The program git runs for each signing format when gpg.<format>.program
+// is not set.
+var defaultSigningPrograms = map[SigningFormat]string{
+	SigningOpenPGP: "gpg",
+	SigningX509:    "gpgsm",
+	SigningSSH:     "ssh-keygen",
+}
+
+// The settings git signs commits and tags with, see Config.SigningSettings.
+type SigningSettings struct {
+	Key                string        `gcKey:"user.signingkey"` // empty to use the committer's identity
+	Format             SigningFormat `gcKey:"gpg.format" gcDefault:"openpgp"`
+	Program            string        // gpg.<format>.program, or the format's default program
+	AllowedSignersFile string        `gcKey:"gpg.ssh.allowedsignersfile" gcPath:"true"`
+	SignCommits        bool          `gcKey:"commit.gpgsign" gcDefault:"false"`
+	SignTags           bool          `gcKey:"tag.gpgsign" gcDefault:"false"`
+}
+
+// Reads the signing settings. gpg.format must be one of the SigningFormat
+// constants, as git requires, or ErrInvalidValue is returned. For openpgp
+// the older gpg.program is used when gpg.openpgp.program is not set.
+func (self *Config) SigningSettings() (*SigningSettings, error) {
+	out := &SigningSettings{}
+	if err := self.Load(out); err != nil {
+		return nil, err
+	}
+	program, ok := defaultSigningPrograms[out.Format]
+	if !ok {
+		return nil, fmt.Errorf("Unknown signing format '%s' for gpg.format, expected openpgp, x509 or ssh: %w", out.Format, ErrInvalidValue)
+	}
+	keys := []string{"gpg." + string(out.Format) + ".program"}
+	if out.Format == SigningOpenPGP {
+		keys = append(keys, "gpg.program")
+	}
+	out.Program = program
+	for _, key := range keys {
+		if s, ok := self.GetKeyValueAsString(key); ok && s != "" {
+			out.Program = s
+			break
+		}
+	}
+	return out, nil
+}
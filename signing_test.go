@@ -0,0 +1,44 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSigningSettings(t *testing.T) {
+	for _, tc := range []struct {
+		data   string
+		expect SigningSettings
+	}{
+		{"", SigningSettings{Format: SigningOpenPGP, Program: "gpg"}},
+		{"[user]\n\tsigningKey = ABCD1234\n[gpg]\n\tprogram = gpg2\n[commit]\n\tgpgSign\n",
+			SigningSettings{Key: "ABCD1234", Format: SigningOpenPGP, Program: "gpg2", SignCommits: true}},
+		{"[gpg]\n\tprogram = gpg2\n\tformat = x509\n[tag]\n\tgpgSign = true\n",
+			SigningSettings{Format: SigningX509, Program: "gpgsm", SignTags: true}},
+		{"[gpg]\n\tformat = ssh\n[gpg \"ssh\"]\n\tprogram = /opt/ssh-keygen\n\tallowedSignersFile = /etc/allowed\n[user]\n\tsigningkey = key::ssh-ed25519 AAAA\n",
+			SigningSettings{Key: "key::ssh-ed25519 AAAA", Format: SigningSSH, Program: "/opt/ssh-keygen", AllowedSignersFile: "/etc/allowed"}},
+	} {
+		cfg, _ := NewConfigFromString(tc.data)
+		got, err := cfg.SigningSettings()
+		if err != nil {
+			t.Errorf("Failed to read signing settings from %q: %s", tc.data, err)
+		} else if !reflect.DeepEqual(*got, tc.expect) {
+			t.Errorf("Expect settings:\n%+v\nbut got:\n%+v", tc.expect, *got)
+		}
+	}
+	for _, data := range []string{"[gpg]\n\tformat = pgp\n", "[gpg]\n\tformat = SSH\n"} {
+		cfg, _ := NewConfigFromString(data)
+		if _, err := cfg.SigningSettings(); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Expect ErrInvalidValue for %q, but got %v", data, err)
+		}
+	}
+	cfg, _ := NewConfigFromString("[commit]\n\tgpgSign = sometimes\n")
+	if _, err := cfg.SigningSettings(); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expect ErrTypeMismatch for a bad commit.gpgSign, but got %v", err)
+	}
+}
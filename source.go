@@ -0,0 +1,216 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Somewhere a config can be loaded from. Besides the built in sources
+// (FileSource, StringSource, EnvSource and GitSource) anything holding
+// config, e.g. a key value store, can be made one and put in a Chain.
+type Source interface {
+	// Reads the config, giving up once ctx is done.
+	Load(ctx context.Context) (*Config, error)
+	// A short description for errors, e.g. the file name.
+	Name() string
+}
+
+// Loads each source in order and merges them, so later sources take
+// precedence. Chains may be nested.
+func Chain(sources ...Source) Source {
+	return &chainSource{sources: sources}
+}
+
+type chainSource struct {
+	sources []Source
+}
+
+func (self *chainSource) Name() string {
+	names := make([]string, len(self.sources))
+	for i, s := range self.sources {
+		names[i] = s.Name()
+	}
+	return "chain(" + strings.Join(names, ", ") + ")"
+}
+
+// Stops at the first source to fail, returning its error prefixed with the
+// source's name.
+func (self *chainSource) Load(ctx context.Context) (*Config, error) {
+	out := NewConfig()
+	for _, s := range self.sources {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cfg, err := s.Load(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load %s: %w", s.Name(), err)
+		}
+		out.Merge(cfg)
+	}
+	return out, nil
+}
+
+// A config file. A file which does not exist loads as an empty config, as
+// for LoadFilesContext.
+func FileSource(path string) Source {
+	return fileSource(path)
+}
+
+type fileSource string
+
+func (self fileSource) Name() string {
+	return string(self)
+}
+
+func (self fileSource) Load(ctx context.Context) (*Config, error) {
+	cfg, err := NewConfigFromFileContext(ctx, string(self))
+	if os.IsNotExist(err) {
+		return NewConfig(), nil
+	}
+	return cfg, err
+}
+
+// Config held in a string, e.g. defaults compiled into a program. The name
+// is used only in errors.
+func StringSource(name, data string) Source {
+	return &stringSource{name: name, data: data}
+}
+
+type stringSource struct {
+	name, data string
+}
+
+func (self *stringSource) Name() string {
+	return self.name
+}
+
+func (self *stringSource) Load(ctx context.Context) (*Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return NewConfigFromString(self.data)
+}
+
+// Values given in the environment the way git reads them:
+// GIT_CONFIG_COUNT=<n> and a GIT_CONFIG_KEY_<i> and GIT_CONFIG_VALUE_<i>
+// for each i below n. environ is in the form of os.Environ, which is used
+// if it is nil.
+func EnvSource(environ []string) Source {
+	return &envSource{environ: environ}
+}
+
+type envSource struct {
+	environ []string
+}
+
+func (self *envSource) Name() string {
+	return "environment"
+}
+
+func (self *envSource) Load(ctx context.Context) (*Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	environ := self.environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(k, "GIT_CONFIG_") {
+			env[k] = v
+		}
+	}
+	out := NewConfig()
+	count, ok := env["GIT_CONFIG_COUNT"]
+	if !ok || count == "" {
+		return out, nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("GIT_CONFIG_COUNT '%s' is not a count: %w", count, ErrInvalidValue)
+	}
+	for i := 0; i < n; i++ {
+		name, ok := env["GIT_CONFIG_KEY_"+strconv.Itoa(i)]
+		if !ok {
+			return nil, fmt.Errorf("GIT_CONFIG_KEY_%d is not set: %w", i, ErrInvalidKey)
+		}
+		value, ok := env["GIT_CONFIG_VALUE_"+strconv.Itoa(i)]
+		if !ok {
+			return nil, fmt.Errorf("GIT_CONFIG_VALUE_%d is not set: %w", i, ErrInvalidValue)
+		}
+		k, err := NormalizeKey(name)
+		if err != nil {
+			return nil, fmt.Errorf("GIT_CONFIG_KEY_%d: %w", i, err)
+		}
+		cv := out.EnsureValues(k.Section, k.SubSection, k.Name)
+		cv.Entries = append(cv.Entries, ValueEntry{Value: value, HasValue: true})
+		cv.syncValue()
+	}
+	return out, nil
+}
+
+// The config git itself sees, read by running `git config --list` in dir
+// (the current directory if empty) with any extra arguments, such as
+// "--global" or "--file", "path". Includes and every scope are resolved by
+// git, so the config returned is already merged.
+func GitSource(dir string, args ...string) Source {
+	return &gitSource{dir: dir, args: args}
+}
+
+type gitSource struct {
+	dir  string
+	args []string
+}
+
+func (self *gitSource) Name() string {
+	return strings.Join(append([]string{"git config --list"}, self.args...), " ")
+}
+
+func (self *gitSource) Load(ctx context.Context) (*Config, error) {
+	args := append([]string{"config", "--list", "-z"}, self.args...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = self.dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w", msg, err)
+		}
+		return nil, err
+	}
+	return parseGitList(data)
+}
+
+// Reads the output of `git config --list -z`: each entry ends in a NUL,
+// with a newline between the key and its value if it has one.
+func parseGitList(data []byte) (*Config, error) {
+	out := NewConfig()
+	for _, entry := range strings.Split(string(data), "\x00") {
+		if entry == "" {
+			continue
+		}
+		name, value, hasValue := strings.Cut(entry, "\n")
+		k, err := NormalizeKey(name)
+		if err != nil {
+			return nil, err
+		}
+		cv := out.EnsureValues(k.Section, k.SubSection, k.Name)
+		cv.Entries = append(cv.Entries, ValueEntry{Value: value, HasValue: hasValue})
+		cv.syncValue()
+	}
+	return out, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// A source of the kind users would write for their own stores.
+type mapSource map[string]string
+
+func (self mapSource) Name() string {
+	return "map"
+}
+
+func (self mapSource) Load(ctx context.Context) (*Config, error) {
+	pairs := make([]string, 0, len(self))
+	for k, v := range self {
+		pairs = append(pairs, k+"="+v)
+	}
+	cfg := NewConfig()
+	return cfg, cfg.ApplyOverrides(pairs)
+}
+
+func TestChain(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config": "[user]\n\tname = File\n\temail = file@x\n"})
+	src := Chain(
+		StringSource("defaults", "[core]\n\teditor = vi\n[user]\n\tname = Default\n"),
+		FileSource(filepath.Join(dir, "config")),
+		FileSource(filepath.Join(dir, "missing")),
+		mapSource{"core.editor": "emacs"},
+		EnvSource([]string{"GIT_CONFIG_COUNT=2", "GIT_CONFIG_KEY_0=user.email", "GIT_CONFIG_VALUE_0=env@x", "GIT_CONFIG_KEY_1=Remote.Origin.URL", "GIT_CONFIG_VALUE_1=https://host/repo"}),
+	)
+	cfg, err := src.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to load %s: %s", src.Name(), err)
+	}
+	testValue(t, cfg, "user.name", "File", true)
+	testValue(t, cfg, "user.email", "env@x", true)
+	testValue(t, cfg, "core.editor", "emacs", true)
+	testValue(t, cfg, "remote.Origin.url", "https://host/repo", true)
+
+	for _, environ := range [][]string{
+		{"GIT_CONFIG_COUNT=x"},
+		{"GIT_CONFIG_COUNT=1", "GIT_CONFIG_VALUE_0=v"},
+		{"GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=k"},
+		{"GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=bad", "GIT_CONFIG_VALUE_0=v"},
+	} {
+		if _, err := Chain(EnvSource(environ)).Load(context.Background()); err == nil {
+			t.Errorf("Expect error loading environment %v", environ)
+		}
+	}
+	if cfg, err := EnvSource([]string{"HOME=/"}).Load(context.Background()); err != nil || len(cfg.Sections) != 0 {
+		t.Errorf("Expect empty config with no GIT_CONFIG_COUNT, but got %v (%v)", cfg, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := src.Load(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expect cancelled load to fail with context.Canceled but got %v", err)
+	}
+}
+
+func TestGitSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config": "[user]\n\tname = Joe\n[remote \"Origin\"]\n\turl = a\n\turl = b\n[core]\n\tbare\n\tpath = \"multi\\nline\"\n"})
+	cfg, err := GitSource(dir, "--file", "config").Load(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to run git: %s", err)
+	}
+	testValue(t, cfg, "user.name", "Joe", true)
+	testValue(t, cfg, "remote.Origin.url", "b", true)
+	testValue(t, cfg, "core.path", "multi\nline", true)
+	if cv := cfg.GetKeyValuesRaw("core.bare"); cv == nil || cv.Entries[0].HasValue {
+		t.Errorf("Expect core.bare with no value, but got %+v", cv)
+	}
+	if _, err := GitSource(dir, "--file", "missing").Load(context.Background()); err == nil {
+		t.Errorf("Expect error reading a missing file with git")
+	}
+}
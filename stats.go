@@ -0,0 +1,56 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"unsafe"
+)
+
+// Sizes of a Config, see Config.Stats.
+type ConfigStats struct {
+	Sections    int // sections, not counting sub-sections
+	SubSections int
+	Keys        int // distinct keys, including those outside any section
+	Values      int // every value of every key, including valueless ones
+	Bytes       int // rough memory footprint of the names and values
+}
+
+// Per-item overheads used by Stats, approximating map entries and the
+// structs they point to.
+const (
+	statsMapEntry       = 48
+	statsSectionSize    = int(unsafe.Sizeof(ConfigSection{}))
+	statsSubSectSize    = int(unsafe.Sizeof(ConfigSubSection{}))
+	statsValueSize      = int(unsafe.Sizeof(ConfigValue{}))
+	statsValueEntrySize = int(unsafe.Sizeof(ValueEntry{}))
+)
+
+// Counts what the config holds. The memory footprint is an estimate, good
+// for comparing configs and enforcing limits rather than exact accounting.
+func (self *Config) Stats() ConfigStats {
+	var out ConfigStats
+	countValues := func(values ConfigValueSet) {
+		for name, cv := range values {
+			out.Keys++
+			out.Values += len(cv.Entries)
+			out.Bytes += statsMapEntry + statsValueSize + len(name) + len(cv.OrigCaseName)
+			for _, e := range cv.Entries {
+				out.Bytes += statsValueEntrySize + len(e.Value) + len(e.Origin.File)
+			}
+		}
+	}
+	countValues(self.BaseValues)
+	for name, s := range self.Sections {
+		out.Sections++
+		out.Bytes += statsMapEntry + statsSectionSize + len(name) + len(s.OrigCaseName)
+		countValues(s.Values)
+		for ssName, ss := range s.SubSections {
+			out.SubSections++
+			out.Bytes += statsMapEntry + statsSubSectSize + len(ssName) + len(ss.Name)
+			countValues(ss.Values)
+		}
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"text/template"
+)
+
+// Gets functions for text/template (or html/template) reading values from
+// the config, converted as by the Config getters:
+//
+//	cfg "user.name"         the last value, or "" if not set
+//	cfg "core.pager" "less" the last value, or the default given if not set
+//	cfgBool "core.bare"     the last value as a bool, false if not set
+//	cfgList "log.exclude"   the items of a list, see GetKeyValuesList
+//
+// A value which cannot be converted stops the template with an error.
+//
+//	tmpl := template.New("msg").Funcs(cfg.Funcs())
+func (self *Config) Funcs() template.FuncMap {
+	return template.FuncMap{
+		"cfg": func(key string, def ...string) string {
+			if v, ok := self.GetKeyValueAsString(key); ok {
+				return v
+			}
+			if len(def) > 0 {
+				return def[0]
+			}
+			return ""
+		},
+		"cfgBool": func(key string) (bool, error) {
+			v, _, err := self.GetKeyValueAsBool(key)
+			return v, err
+		},
+		"cfgList": self.GetKeyValuesList,
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestFuncs(t *testing.T) {
+	config, err := NewConfigFromString("[user]\n\tname = Joe\n[core]\n\tbare = yes\n\tfilemode = maybe\n[log]\n\texclude = a, b\n\texclude = c\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	tests := map[string]string{
+		`{{cfg "user.name"}}`:                           "Joe",
+		`{{cfg "user.email"}}`:                          "",
+		`{{cfg "user.email" "none"}}`:                   "none",
+		`{{if cfgBool "core.bare"}}bare{{end}}`:         "bare",
+		`{{cfgBool "core.missing"}}`:                    "false",
+		`{{range cfgList "log.exclude"}}[{{.}}]{{end}}`: "[a][b][c]",
+	}
+	for text, expect := range tests {
+		tmpl := template.Must(template.New("t").Funcs(config.Funcs()).Parse(text))
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, nil); err != nil {
+			t.Errorf("Failed to run %s: %s", text, err)
+		} else if sb.String() != expect {
+			t.Errorf("Expect %s to give '%s', but got '%s'", text, expect, sb.String())
+		}
+	}
+	tmpl := template.Must(template.New("t").Funcs(config.Funcs()).Parse(`{{cfgBool "core.filemode"}}`))
+	if err := tmpl.Execute(&strings.Builder{}, nil); err == nil {
+		t.Errorf("Expect error for a value which is not a bool")
+	}
+}
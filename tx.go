@@ -0,0 +1,141 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+)
+
+// A Tx collects changes to a Config which are made all at once by Commit,
+// or not at all. Nothing is changed until then, so a mistake part way
+// through a series of edits never leaves the Config half updated:
+//
+//	tx := cfg.Begin()
+//	tx.Rename("remote.origin.url", "remote.upstream.url")
+//	tx.Set("branch.main.remote", "upstream")
+//	tx.Validate(checkRemotes)
+//	if err := tx.Commit(); err != nil {
+//		// cfg is as it was
+//	}
+//
+// A Tx is not safe for concurrent use, nor is the Config while committing.
+type Tx struct {
+	cfg        *Config
+	ops        []func(*Config) error
+	validators []func(*Config) error
+	done       bool
+}
+
+// Starts a transaction on the config.
+func (self *Config) Begin() *Tx {
+	return &Tx{cfg: self}
+}
+
+// Records replacing any values of the key with value, see Config.Set.
+func (self *Tx) Set(key, value string) {
+	self.ops = append(self.ops, func(cfg *Config) error {
+		return cfg.Set(key, value)
+	})
+}
+
+// Records adding a value after any existing ones of the key.
+func (self *Tx) Add(key, value string) {
+	self.ops = append(self.ops, func(cfg *Config) error {
+		s, ss, k := ParseSectionKey(key)
+		if k == "" {
+			return fmt.Errorf("Cannot add to key '%s': %w", key, ErrInvalidKey)
+		}
+		if err := checkSubSection(key, ss); err != nil {
+			return err
+		}
+		if err := validateValue(key, value); err != nil {
+			return err
+		}
+		cv := cfg.EnsureValues(s, ss, k)
+		var old []string
+		if len(cv.Entries) > 0 {
+			old = cv.ValuesAsStrings()
+		}
+		cv.Entries = append(cv.Entries, ValueEntry{Value: value, HasValue: true})
+		cv.syncValue()
+		cfg.record(MutationAdd, KeyChange{Key: joinKey(s, ss, k), Old: old, New: cv.ValuesAsStrings()})
+		return nil
+	})
+}
+
+// Records removing all values of the key. Unsetting a key which does not
+// exist is not an error.
+func (self *Tx) Unset(key string) {
+	self.ops = append(self.ops, func(cfg *Config) error {
+		cfg.Unset(key)
+		return nil
+	})
+}
+
+// Records renaming keys as Config.MigrateKeys does with the single mapping
+// from -> to. Commit fails if no key matches from.
+func (self *Tx) Rename(from, to string) {
+	self.ops = append(self.ops, func(cfg *Config) error {
+		moved, err := cfg.MigrateKeys(map[string]string{from: to})
+		if err != nil {
+			return err
+		}
+		if len(moved) == 0 {
+			return fmt.Errorf("Cannot rename '%s', no such key: %w", from, ErrKeyNotFound)
+		}
+		return nil
+	})
+}
+
+// Adds a check run on the config as it would be after the changes, before
+// any are made. Returning an error stops the commit.
+func (self *Tx) Validate(fn func(*Config) error) {
+	self.validators = append(self.validators, fn)
+}
+
+// Makes the changes, in the order given, then runs the validators. If any
+// change or validator fails its error is returned and the Config is left
+// as it was; otherwise it is updated and OnChange listeners told.
+// The Tx cannot be used again either way.
+func (self *Tx) Commit() error {
+	if self.done {
+		return fmt.Errorf("Cannot commit: %w", ErrTxDone)
+	}
+	self.done = true
+	next := self.cfg.clone()
+	var logged mutationBuffer
+	if self.cfg.logger != nil {
+		next.logger = &mutationLogger{logger: &logged, secrets: self.cfg.logger.secrets}
+	}
+	for _, op := range self.ops {
+		if err := op(next); err != nil {
+			return err
+		}
+	}
+	for _, fn := range self.validators {
+		if err := fn(next); err != nil {
+			return err
+		}
+	}
+	changes := Diff(self.cfg, next)
+	self.cfg.Sections = next.Sections
+	self.cfg.BaseValues = next.BaseValues
+	self.cfg.seq = next.seq
+	for _, m := range logged {
+		self.cfg.logger.logger.LogMutation(m)
+	}
+	self.cfg.notify(changes)
+	return nil
+}
+
+// Drops the changes. The Tx cannot be used again.
+func (self *Tx) Rollback() error {
+	if self.done {
+		return fmt.Errorf("Cannot roll back: %w", ErrTxDone)
+	}
+	self.done = true
+	self.ops = nil
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"testing"
+)
+
+const txConfig = "[remote \"origin\"]\n\turl = x\n[branch \"main\"]\n\tremote = origin\n[core]\n\tbare = false\n"
+
+func TestTxCommit(t *testing.T) {
+	config, err := NewConfigFromString(txConfig)
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	var changed []string
+	config.OnChange("*", func(key string, old, new []string) { changed = append(changed, key) })
+	tx := config.Begin()
+	tx.Rename("remote.origin.url", "remote.upstream.url")
+	tx.Set("branch.main.remote", "upstream")
+	tx.Add("remote.upstream.fetch", "a")
+	tx.Unset("core.bare")
+	validated := false
+	tx.Validate(func(cfg *Config) error {
+		validated = true
+		if v, _ := cfg.GetKeyValueAsString("remote.upstream.url"); v != "x" {
+			t.Errorf("Expect validator to see the changes, but remote.upstream.url is '%s'", v)
+		}
+		if v, _ := config.GetKeyValueAsString("remote.origin.url"); v != "x" {
+			t.Errorf("Expect config unchanged while validating")
+		}
+		return nil
+	})
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %s", err)
+	}
+	if !validated {
+		t.Errorf("Expect validator to be run")
+	}
+	expect := "[remote \"upstream\"]\n\tfetch = a\n\turl = x\n[branch \"main\"]\n\tremote = upstream\n"
+	if out := config.String(); out != expect {
+		t.Errorf("Expect config:\n%s\nbut got:\n%s", expect, out)
+	}
+	if len(changed) != 5 {
+		t.Errorf("Expect 5 changed keys, but got %v", changed)
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Expect second commit to fail, but got: %v", err)
+	}
+}
+
+func TestTxAllOrNothing(t *testing.T) {
+	config, err := NewConfigFromString(txConfig)
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	notified := false
+	config.OnChange("*", func(key string, old, new []string) { notified = true })
+
+	tx := config.Begin()
+	tx.Set("core.bare", "true")
+	tx.Rename("remote.nope.url", "remote.other.url")
+	if err := tx.Commit(); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expect failed rename to stop the commit, but got: %v", err)
+	}
+
+	tx = config.Begin()
+	tx.Set("core.bare", "true")
+	invalid := errors.New("bare repositories not allowed")
+	tx.Validate(func(cfg *Config) error {
+		if b, _, _ := cfg.GetKeyValueAsBool("core.bare"); b {
+			return invalid
+		}
+		return nil
+	})
+	if err := tx.Commit(); err != invalid {
+		t.Errorf("Expect validator error, but got: %v", err)
+	}
+
+	tx = config.Begin()
+	tx.Set("core.bare", "true")
+	if err := tx.Rollback(); err != nil {
+		t.Errorf("Failed to roll back: %s", err)
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Expect commit after rollback to fail, but got: %v", err)
+	}
+
+	if out := config.String(); out != txConfig || notified {
+		t.Errorf("Expect config untouched and no notifications, but got:\n%s", out)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The size units a gcUnit tag on an integer field may give, which may also
+// end a value as git allows "10k" or "2g". All are powers of 1024, as git's.
+var sizeUnits = map[string]int64{
+	"b": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+}
+
+// Checks a gcUnit tag suits the field's type: a unit of time.ParseDuration
+// (e.g. "s" or "ms") for durations, or a size unit for integers.
+func checkUnitTag(tp reflect.Type, unit string) error {
+	for tp.Kind() == reflect.Ptr || tp.Kind() == reflect.Slice || tp.Kind() == reflect.Array {
+		tp = tp.Elem()
+	}
+	switch {
+	case tp == durationType:
+		if _, err := time.ParseDuration("1" + unit); err == nil {
+			return nil
+		}
+	case isIntKind(tp.Kind()) || isUintKind(tp.Kind()):
+		if _, ok := sizeUnits[strings.ToLower(unit)]; ok {
+			return nil
+		}
+	default:
+		return fmt.Errorf("Unit '%s' given for %s, only durations and integers take units: %w", unit, tp.String(), ErrInvalidTag)
+	}
+	return fmt.Errorf("Unknown unit '%s' for %s: %w", unit, tp.String(), ErrInvalidTag)
+}
+
+func isIntKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUintKind(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uint64
+}
+
+// Adds the field's unit to a bare number given for a duration.
+func (self fieldTags) durationString(s string) string {
+	if self.unit == "" {
+		return s
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return s + self.unit
+	}
+	return s
+}
+
+// Parses an integer for a field: in the field's base, then a bare number is
+// in the field's unit, while one ending in a size unit is in that.
+func (self fieldTags) parseInt(s string) (int64, error) {
+	if self.unit == "" {
+		return strconv.ParseInt(s, self.intBase, 64)
+	}
+	num, mult, err := self.splitUnit(s)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseInt(num, self.intBase, 64)
+	if err != nil {
+		return 0, err
+	}
+	if i > math.MaxInt64/mult || i < math.MinInt64/mult {
+		return 0, &strconv.NumError{Func: "ParseInt", Num: s, Err: strconv.ErrRange}
+	}
+	return i * mult, nil
+}
+
+// As parseInt, for unsigned integers.
+func (self fieldTags) parseUint(s string) (uint64, error) {
+	if self.unit == "" {
+		return strconv.ParseUint(s, self.intBase, 64)
+	}
+	num, mult, err := self.splitUnit(s)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseUint(num, self.intBase, 64)
+	if err != nil {
+		return 0, err
+	}
+	if i > math.MaxUint64/uint64(mult) {
+		return 0, &strconv.NumError{Func: "ParseUint", Num: s, Err: strconv.ErrRange}
+	}
+	return i * uint64(mult), nil
+}
+
+// Splits a size unit off the end of s, giving the field's unit if it has
+// none. Values which parse whole in the field's base (e.g. "ab" in base
+// 16) are taken to have none.
+func (self fieldTags) splitUnit(s string) (string, int64, error) {
+	mult := sizeUnits[strings.ToLower(self.unit)]
+	if _, err := strconv.ParseInt(s, self.intBase, 64); err == nil {
+		return s, mult, nil
+	}
+	end := len(s)
+	for end > 0 && isLetter(s[end-1]) {
+		end--
+	}
+	if end == len(s) {
+		return s, mult, nil
+	}
+	suffix, ok := sizeUnits[strings.ToLower(s[end:])]
+	if !ok {
+		return "", 0, fmt.Errorf("Unknown unit '%s' in '%s': %w", s[end:], s, strconv.ErrSyntax)
+	}
+	return s[:end], suffix, nil
+}
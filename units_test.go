@@ -0,0 +1,61 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestUnitTag(t *testing.T) {
+	config, _ := NewConfigFromString("[net]\n\ttimeout = 30\n\tretry = 1.5\n\tidle = 2m\n\tbuffer = 64\n\twindow = 2m\n\tlimit = 1G\n\tsteps = 1\n\tsteps = 250ms\n")
+	type Net struct {
+		Timeout time.Duration   `gcKey:"timeout" gcUnit:"s"`
+		Retry   time.Duration   `gcKey:"retry" gcUnit:"s"`
+		Idle    time.Duration   `gcKey:"idle" gcUnit:"s"`
+		Linger  time.Duration   `gcKey:"linger" gcUnit:"ms" gcDefault:"500"`
+		Steps   []time.Duration `gcKey:"steps" gcUnit:"s"`
+		Buffer  int             `gcKey:"buffer" gcUnit:"kb"`
+		Window  uint32          `gcKey:"window" gcUnit:"kb"`
+		Limit   *int64          `gcKey:"limit" gcUnit:"b"`
+		Max     uint64          `gcKey:"max" gcUnit:"MiB" gcDefault:"4"`
+	}
+	var got struct {
+		Net Net `gcKey:"net"`
+	}
+	if err := config.Load(&got); err != nil {
+		t.Fatalf("Failed to load: %s", err)
+	}
+	limit := int64(1 << 30)
+	expect := Net{
+		Timeout: 30 * time.Second, Retry: 1500 * time.Millisecond, Idle: 2 * time.Minute,
+		Linger: 500 * time.Millisecond, Steps: []time.Duration{time.Second, 250 * time.Millisecond},
+		Buffer: 64 << 10, Window: 2 << 20, Limit: &limit, Max: 4 << 20,
+	}
+	if !reflect.DeepEqual(got.Net, expect) {
+		t.Errorf("Expect %+v, but got %+v", expect, got.Net)
+	}
+
+	config, _ = NewConfigFromString("[net]\n\tbuffer = 10x\n\thuge = 9000000000000000000\n")
+	for _, key := range []string{"buffer", "huge"} {
+		tag := reflect.StructTag(`gcKey:"net.` + key + `" gcUnit:"k"`)
+		tp := reflect.StructOf([]reflect.StructField{{Name: "V", Type: reflect.TypeOf(0), Tag: tag}})
+		err := config.Load(reflect.New(tp).Interface())
+		if !errors.Is(err, ErrTypeMismatch) || !errors.Is(err, strconv.ErrSyntax) && !errors.Is(err, strconv.ErrRange) {
+			t.Errorf("Expect net.%s to fail, but got: %v", key, err)
+		}
+	}
+	bad := map[string]reflect.Type{"fortnight": reflect.TypeOf(time.Duration(0)), "s": reflect.TypeOf(0), "kb": reflect.TypeOf("")}
+	for unit, ft := range bad {
+		tag := reflect.StructTag(`gcKey:"net.buffer" gcUnit:"` + unit + `"`)
+		tp := reflect.StructOf([]reflect.StructField{{Name: "V", Type: ft, Tag: tag}})
+		if err := config.Load(reflect.New(tp).Interface()); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("Expect gcUnit:\"%s\" on %s to fail, but got: %v", unit, ft, err)
+		}
+	}
+}
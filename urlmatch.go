@@ -0,0 +1,216 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// A sub-section of a section read per URL, e.g. http.<url>.*, which
+// matches a URL, or the section's own values, which match every URL.
+type urlMatch struct {
+	values  ConfigValueSet
+	hostLen int  // of the pattern's host, longer (more exact) hosts win
+	pathLen int  // of the pattern's path, longer paths win
+	user    bool // the pattern gave a user, which wins over none
+}
+
+// Orders matches as git does, least specific first.
+func (self urlMatch) less(other urlMatch) bool {
+	if self.hostLen != other.hostLen {
+		return self.hostLen < other.hostLen
+	}
+	if self.pathLen != other.pathLen {
+		return self.pathLen < other.pathLen
+	}
+	return !self.user && other.user
+}
+
+// A URL split as urlmatch needs it.
+type matchURL struct {
+	scheme, user, host, port, path string
+}
+
+var defaultPorts = map[string]string{"http": "80", "https": "443", "ftp": "21", "ftps": "990", "ssh": "22", "git": "9418"}
+
+func parseMatchURL(raw string) (matchURL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return matchURL{}, fmt.Errorf("Cannot match config against '%s', not a URL: %w", raw, ErrInvalidValue)
+	}
+	out := matchURL{scheme: strings.ToLower(u.Scheme), host: strings.ToLower(u.Hostname()), port: u.Port(), path: u.EscapedPath()}
+	if u.User != nil {
+		out.user = u.User.Username()
+	}
+	if out.port == defaultPorts[out.scheme] {
+		out.port = ""
+	}
+	if out.path == "" {
+		out.path = "/"
+	}
+	return out, nil
+}
+
+// Reports whether the URL pattern of a sub-section matches the URL, and how
+// closely, following git's urlmatch rules: the schemes and ports must be
+// the same, the user too if the pattern has one, each '*' in the host
+// matches one dot separated label or part of one, and the pattern's path
+// must be the URL's or a parent of it.
+func (self matchURL) match(pattern matchURL) (urlMatch, bool) {
+	var out urlMatch
+	if pattern.scheme != self.scheme || pattern.port != self.port {
+		return out, false
+	}
+	if pattern.user != "" {
+		if pattern.user != self.user {
+			return out, false
+		}
+		out.user = true
+	}
+	patLabels, labels := strings.Split(pattern.host, "."), strings.Split(self.host, ".")
+	if len(patLabels) != len(labels) {
+		return out, false
+	}
+	for i, p := range patLabels {
+		if !wildmatch(p, labels[i]) {
+			return out, false
+		}
+	}
+	out.hostLen = len(pattern.host)
+	if path := strings.TrimSuffix(pattern.path, "/"); path != "" {
+		if self.path != path && !strings.HasPrefix(self.path, path+"/") {
+			return out, false
+		}
+		out.pathLen = len(path)
+	}
+	return out, true
+}
+
+// Gets the values of the section which apply to the URL, least specific
+// first: the section's own, then those of each matching sub-section.
+func (self *Config) urlMatches(section, rawURL string) ([]urlMatch, error) {
+	target, err := parseMatchURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	s := self.LookupSection(section)
+	if s == nil {
+		return nil, nil
+	}
+	out := []urlMatch{{values: s.Values}}
+	// in file order, so that of equally close matches the last wins
+	for _, name := range s.subSectionNames() {
+		pattern, err := parseMatchURL(name)
+		if err != nil {
+			continue // not a URL, so not for urlmatch
+		}
+		if m, ok := target.match(pattern); ok {
+			m.values = s.SubSections[name].Values
+			out = append(out, m)
+		}
+	}
+	sort.SliceStable(out[1:], func(i, j int) bool { return out[1+i].less(out[1+j]) })
+	return out, nil
+}
+
+// Gets the value of section.<url>.name for the sub-section URL matching
+// rawURL most closely, as `git config --get-urlmatch` does, or of
+// section.name if none match. E.g. LookupForURL("http", "proxy",
+// "https://git.example.com/repo") is the value of
+// http.https://*.example.com.proxy over that of http.proxy.
+// Fails with ErrKeyNotFound if none is set.
+func (self *Config) LookupForURL(section, name, rawURL string) (string, error) {
+	cv, err := self.urlValue(section, name, rawURL)
+	if err != nil {
+		return "", err
+	}
+	if cv == nil {
+		return "", fmt.Errorf("key %q for url %s: %w", section+"."+name, rawURL, ErrKeyNotFound)
+	}
+	s, _ := cv.GetString()
+	return s, nil
+}
+
+// Gets the values of the key from the closest match for the URL, or nil.
+func (self *Config) urlValue(section, name, rawURL string) (*ConfigValue, error) {
+	matches, err := self.urlMatches(section, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.ToLower(name)
+	for i := len(matches) - 1; i >= 0; i-- {
+		if cv := matches[i].values[name]; cv != nil && cv.HasValues() {
+			return cv, nil
+		}
+	}
+	return nil, nil
+}
+
+// The http settings git uses when fetching from or pushing to a URL, see
+// Config.HTTPSettings.
+type HTTPSettings struct {
+	Proxy           string   // http.proxy, empty to use the environment's
+	ProxyAuthMethod string   // http.proxyAuthMethod
+	SSLVerify       bool     // http.sslVerify, true unless set otherwise
+	SSLCAInfo       string   // http.sslCAInfo, with "~" expanded
+	ExtraHeaders    []string // every http.extraHeader, an empty one clears those before it
+	Version         string   // http.version, e.g. "HTTP/2", empty to let curl choose
+	PostBuffer      int64    // http.postBuffer in bytes, 0 if not set
+}
+
+// Works out the http settings for a remote URL, combining http.* with the
+// http.<url>.* sub-sections which match the URL as git does (see
+// LookupForURL): each setting comes from the closest match setting it.
+// Extra headers are gathered from every match, least specific first.
+// Environment variables such as GIT_SSL_NO_VERIFY are not read.
+func (self *Config) HTTPSettings(rawURL string) (*HTTPSettings, error) {
+	matches, err := self.urlMatches("http", rawURL)
+	if err != nil {
+		return nil, err
+	}
+	out := &HTTPSettings{SSLVerify: true}
+	get := func(name string) (*ConfigValue, string) {
+		for i := len(matches) - 1; i >= 0; i-- {
+			if cv := matches[i].values[name]; cv != nil && cv.HasValues() {
+				s, _ := cv.GetString()
+				return cv, s
+			}
+		}
+		return nil, ""
+	}
+	_, out.Proxy = get("proxy")
+	_, out.ProxyAuthMethod = get("proxyauthmethod")
+	_, out.Version = get("version")
+	if cv, _ := get("sslverify"); cv != nil {
+		if out.SSLVerify, _, err = cv.GetBool(); err != nil {
+			return nil, fmt.Errorf("key %q for url %s: %w", "http.sslverify", rawURL, err)
+		}
+	}
+	if _, s := get("sslcainfo"); s != "" {
+		if out.SSLCAInfo, err = ExpandPath(s); err != nil {
+			return nil, err
+		}
+	}
+	if _, s := get("postbuffer"); s != "" {
+		if out.PostBuffer, err = (fieldTags{intBase: 10, unit: "b"}).parseInt(s); err != nil {
+			return nil, fmt.Errorf("Could not parse value '%s' as size for %s: %w: %w", s, "http.postbuffer", ErrTypeMismatch, err)
+		}
+	}
+	for _, m := range matches {
+		if cv := m.values["extraheader"]; cv != nil {
+			for _, e := range cv.Entries {
+				if !e.HasValue || e.Value == "" {
+					out.ExtraHeaders = nil
+				} else {
+					out.ExtraHeaders = append(out.ExtraHeaders, e.Value)
+				}
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLookupForURL(t *testing.T) {
+	cfg, err := NewConfigFromString(`[http]
+	proxy = http://default:3128
+	sslVerify = true
+	extraHeader = X-All: 1
+[http "https://*.example.com"]
+	proxy = http://wild:3128
+[http "https://git.example.com"]
+	proxy = http://exact:3128
+	extraHeader = X-Host: 1
+[http "https://git.example.com/team"]
+	sslVerify = false
+	extraHeader = X-Team: 1
+[http "https://bot@git.example.com/team/"]
+	version = HTTP/2
+[http "https://git.example.com:8443"]
+	proxy = http://port:3128
+[http "not a url"]
+	proxy = ignored
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	for _, tc := range []struct {
+		url, want string
+	}{
+		{"https://git.example.com/team/repo.git", "http://exact:3128"},
+		{"https://GIT.example.com:443/x", "http://exact:3128"}, // default port, host case
+		{"https://other.example.com/x", "http://wild:3128"},
+		{"https://a.b.example.com/x", "http://default:3128"}, // '*' is one label
+		{"https://git.example.com:8443/x", "http://port:3128"},
+		{"http://git.example.com/x", "http://default:3128"}, // other scheme
+		{"https://elsewhere.org", "http://default:3128"},
+	} {
+		if got, err := cfg.LookupForURL("http", "proxy", tc.url); err != nil || got != tc.want {
+			t.Errorf("Expect proxy %s for %s, but got %s (%v)", tc.want, tc.url, got, err)
+		}
+	}
+	if _, err := cfg.LookupForURL("http", "cookieFile", "https://git.example.com"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expect ErrKeyNotFound for an unset key, but got %v", err)
+	}
+	if _, err := cfg.LookupForURL("http", "proxy", "git.example.com:repo"); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expect ErrInvalidValue for a non-URL, but got %v", err)
+	}
+
+	settings, err := cfg.HTTPSettings("https://bot@git.example.com/team/repo.git")
+	if err != nil {
+		t.Fatalf("Failed to get http settings: %s", err)
+	}
+	expect := &HTTPSettings{
+		Proxy:        "http://exact:3128",
+		SSLVerify:    false,
+		ExtraHeaders: []string{"X-All: 1", "X-Host: 1", "X-Team: 1"},
+		Version:      "HTTP/2",
+	}
+	if !reflect.DeepEqual(settings, expect) {
+		t.Errorf("Expect settings:\n%+v\nbut got:\n%+v", expect, settings)
+	}
+	// the path is matched on whole segments, and the user must match
+	settings, _ = cfg.HTTPSettings("https://git.example.com/teamwork")
+	if !settings.SSLVerify || settings.Version != "" || len(settings.ExtraHeaders) != 2 {
+		t.Errorf("Expect /team settings not to apply to /teamwork, but got %+v", settings)
+	}
+
+	cfg, _ = NewConfigFromString("[http]\n\textraHeader = X-A: 1\n\tpostBuffer = 1m\n[http \"https://h\"]\n\textraHeader =\n\textraHeader = X-B: 1\n\tsslCAInfo = /etc/ca.pem\n")
+	settings, _ = cfg.HTTPSettings("https://h/x")
+	if !reflect.DeepEqual(settings.ExtraHeaders, []string{"X-B: 1"}) || settings.PostBuffer != 1<<20 || settings.SSLCAInfo != "/etc/ca.pem" {
+		t.Errorf("Unexpected settings %+v", settings)
+	}
+	cfg, _ = NewConfigFromString("[http \"https://h\"]\n\tsslVerify = maybe\n")
+	if _, err := cfg.HTTPSettings("https://h/x"); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expect ErrTypeMismatch for a bad sslVerify, but got %v", err)
+	}
+}
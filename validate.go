@@ -0,0 +1,231 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The outcome of checkStructType for each struct type, as types do not
+// change.
+var checkedTypes sync.Map
+
+// Checks that Load can fill v, a struct or pointer to one, whatever the
+// config holds: the gc tags of its fields parse, no gcKey tagged field is
+// unexported, and every such field's type is one Load supports. Nested
+// structs are checked too. Problems are returned as a LoadError keyed on
+// the fields' keys.
+// Load does this itself before reading any values, so mistakes in a struct
+// show up even for an empty config.
+func CheckStruct(v interface{}) error {
+	tp := reflect.TypeOf(v)
+	for tp != nil && tp.Kind() == reflect.Ptr {
+		tp = tp.Elem()
+	}
+	if tp == nil || tp.Kind() != reflect.Struct {
+		return fmt.Errorf("Passed a non-struct: %v: %w\n", v, ErrUnsupportedType)
+	}
+	return checkStructType(tp)
+}
+
+func checkStructType(tp reflect.Type) error {
+	if err, ok := checkedTypes.Load(tp); ok {
+		if err == nil {
+			return nil
+		}
+		return err.(error)
+	}
+	errs := LoadError{}
+	checkStruct(tp, "", "", errs, make(map[reflect.Type]bool, 5))
+	var err error
+	if len(errs) > 0 {
+		err = errs
+	}
+	checkedTypes.Store(tp, err)
+	return err
+}
+
+func checkStruct(tp reflect.Type, ns, fieldPath string, errs LoadError, seen map[reflect.Type]bool) {
+	if seen[tp] {
+		return
+	}
+	seen[tp] = true
+	defer delete(seen, tp)
+	for i := 0; i < tp.NumField(); i++ {
+		ft := tp.Field(i)
+		key := ft.Tag.Get("gcKey")
+		if key == "" {
+			continue
+		}
+		if ns != "" {
+			key = ns + "." + key
+		}
+		path := joinFieldPath(fieldPath, ft.Name)
+		if err := checkField(ft); err != nil {
+			errs.add(key, path, ValueOrigin{}, err)
+			continue
+		}
+		if ft.Type == configValueSetType {
+			continue
+		}
+		if err := checkFieldType(ft.Type, key, path, errs, seen); err != nil {
+			errs.add(key, path, ValueOrigin{}, fmt.Errorf("Could not populate %s field %q: %w", ft.Type.String(), ft.Name, err))
+		}
+	}
+}
+
+// Checks the tags of a field, as loadStruct reads them.
+func checkField(ft reflect.StructField) error {
+	if !ft.IsExported() {
+		return fmt.Errorf("Field %q has a gcKey but is unexported, so cannot be set: %w", ft.Name, ErrUnsupportedType)
+	}
+	if req := ft.Tag.Get("gcRequired"); req != "" {
+		if _, err := strconv.ParseBool(req); err != nil {
+			return fmt.Errorf("Could not parse required:\"%s\" as boolean in field %q: %w\n", req, ft.Name, ErrInvalidTag)
+		}
+	}
+	if scopes := ft.Tag.Get("gcScope"); scopes != "" {
+		for _, name := range strings.Split(scopes, ",") {
+			if _, err := ParseScope(name); err != nil {
+				return fmt.Errorf("Could not use scope:\"%s\" in field %q: %w", scopes, ft.Name, err)
+			}
+		}
+	}
+	if _, err := secretTag(ft); err != nil {
+		return err
+	}
+	_, err := parseFieldTags(ft)
+	return err
+}
+
+// Checks Load can fill a value of the type for the key, following the same
+// rules as loadSetValue. Errors from the fields of nested structs are
+// added to errs directly.
+func checkFieldType(tp reflect.Type, key, path string, errs LoadError, seen map[reflect.Type]bool) error {
+	switch tp {
+	case durationType, configValueType, timeType, regexpType, addrType, prefixType:
+		return nil
+	}
+	switch tp.Kind() {
+	case reflect.String, reflect.Bool:
+		return nil
+	case reflect.Ptr:
+		return checkFieldType(tp.Elem(), key, path, errs, seen)
+	case reflect.Slice, reflect.Array:
+		elemtp := tp.Elem()
+		if kTp, vTp, ok := kvTypes(elemtp); ok && tp.Kind() == reflect.Slice {
+			return checkMapType(kTp, vTp, key, path, errs, seen)
+		}
+		if !listElemSupported(tp.Kind(), elemtp) {
+			return fmt.Errorf("Slices and arrays can only contain basic types, not %s: %w", elemtp.String(), ErrUnsupportedType)
+		}
+		return checkFieldType(elemtp, key, path, errs, seen)
+	case reflect.Map:
+		return checkMapType(tp.Key(), tp.Elem(), key, path, errs, seen)
+	case reflect.Struct:
+		checkStruct(tp, key, path, errs, seen)
+		return nil
+	}
+	if isIntKind(tp.Kind()) || isUintKind(tp.Kind()) {
+		return nil
+	}
+	return fmt.Errorf("Type %s cannot be loaded: %w", tp.String(), ErrUnsupportedType)
+}
+
+// Whether Load can fill a slice or array (kind) of elemtp from a key's
+// values, one each. Lists of lists or maps never can, and arrays only hold
+// structs parsed from a single value, such as time.Time.
+func listElemSupported(kind reflect.Kind, elemtp reflect.Type) bool {
+	switch elemtp.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map:
+		return false
+	case reflect.Struct:
+		return kind == reflect.Slice || elemtp == timeType || elemtp == regexpType || elemtp == addrType || elemtp == prefixType
+	}
+	return true
+}
+
+// Checks a map, or slice of KV, with the given key and value types.
+func checkMapType(kTp, elemtp reflect.Type, key, path string, errs LoadError, seen map[reflect.Type]bool) error {
+	if err := checkMapKey(kTp); err != nil {
+		return err
+	}
+	switch {
+	case elemtp.Kind() == reflect.Map:
+		if err := checkMapKey(elemtp.Key()); err != nil {
+			return err
+		}
+		switch elemtp.Elem().Kind() {
+		case reflect.Map, reflect.Struct:
+			return fmt.Errorf("Inner map values cannot be maps or structs: %w", ErrUnsupportedType)
+		}
+		if _, err := mapSectionName(key); err != nil {
+			return err
+		}
+		return checkFieldType(elemtp.Elem(), key, path, errs, seen)
+	case elemtp.Kind() == reflect.Struct, elemtp.Kind() == reflect.Slice && elemtp.Elem().Kind() == reflect.Struct:
+		sName, err := mapSectionName(key)
+		if err != nil {
+			return err
+		}
+		if elemtp.Kind() == reflect.Slice {
+			elemtp = elemtp.Elem()
+		}
+		checkStruct(elemtp, sName+".*", path+"[*]", errs, seen)
+		return nil
+	}
+	if out := strings.Split(key, ".*."); len(out) != 2 || out[0] == "" || out[1] == "" {
+		return fmt.Errorf("Key must be of form '<section>.*.<key>'. Both <section> and <key> must be non-zero length: %w", ErrInvalidTag)
+	}
+	return checkFieldType(elemtp, key, path, errs, seen)
+}
+
+// Checks a map's keys, which are filled from sub-section or key names.
+func checkMapKey(kTp reflect.Type) error {
+	switch kTp.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map, reflect.Struct:
+		return fmt.Errorf("Map keys can only contain basic types, not %s: %w", kTp.String(), ErrUnsupportedType)
+	}
+	return checkFieldType(kTp, "", "", nil, nil)
+}
+
+// Checks that each key is set to a non-empty value, for tools which need a
+// minimal setup before they can run, e.g.
+//
+//	err := cfg.RequireKeys("user.name", "user.email", "remote.origin.url")
+//
+// Returns nil if all are, else a *RequiredError listing those missing or
+// set to an empty value (including keys given with no value). The last
+// value of a key is the one checked. Keys must be of form section.key or
+// section.subsection.key; an invalid one gives a *KeyError.
+func (self *Config) RequireKeys(keys ...string) error {
+	missing := make([]MissingKey, 0, len(keys))
+	for _, key := range keys {
+		k, err := self.NormalizeKey(key)
+		if err != nil {
+			return err
+		}
+		m := MissingKey{Key: key, Section: k.Section, SubSection: k.SubSection, Name: k.Name}
+		cv := self.GetValuesForKey(k)
+		if cv == nil || len(cv.Entries) == 0 {
+			missing = append(missing, m)
+			continue
+		}
+		if last := cv.Entries[len(cv.Entries)-1]; !last.HasValue || last.Value == "" {
+			m.Empty = true
+			missing = append(missing, m)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].Key < missing[j].Key })
+	return &RequiredError{Missing: missing}
+}
@@ -0,0 +1,141 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestCheckStruct(t *testing.T) {
+	type Remote struct {
+		URL   string   `gcKey:"url"`
+		Fetch []string `gcKey:"fetch"`
+	}
+	type Good struct {
+		Name    string                       `gcKey:"user.name" gcRequired:"false"`
+		Ints    []int                        `gcKey:"a.ints" gcIntBase:"0"`
+		Timeout *time.Duration               `gcKey:"a.timeout" gcUnit:"s"`
+		When    [2]time.Time                 `gcKey:"a.when"`
+		Re      regexp.Regexp                `gcKey:"a.re"`
+		Nets    []netip.Prefix               `gcKey:"a.nets"`
+		Remotes map[string]Remote            `gcKey:"remote.*" gcScope:"local,global"`
+		URLs    map[string]string            `gcKey:"remote.*.url"`
+		Aliases map[string]map[string]string `gcKey:"alias.*"`
+		Raw     ConfigValueSet               `gcKey:"core"`
+		Secret  string                       `gcKey:"a.token" gcSecret:"true"`
+		skipped int
+	}
+	if err := CheckStruct(&Good{}); err != nil {
+		t.Errorf("Expect struct to check, but got: %s", err)
+	}
+	// what checks also loads
+	for _, data := range []string{"", "[a]\n\twhen = 2020-01-02T03:04:05Z\n\twhen = 2021-01-02T03:04:05Z\n"} {
+		cfg, _ := NewConfigFromString(data)
+		var good Good
+		if err := cfg.Load(&good); err != nil {
+			t.Errorf("Expect %q to load into a checked struct, but got: %s", data, err)
+		}
+	}
+	if err := CheckStruct(3); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expect checking a non-struct to fail, but got: %v", err)
+	}
+
+	type Inner struct {
+		Ratio float64 `gcKey:"ratio"`
+	}
+	type Bad struct {
+		hidden  string            `gcKey:"a.hidden"`
+		Ch      chan int          `gcKey:"a.ch"`
+		Nested  [][]string        `gcKey:"a.nested"`
+		Inner   Inner             `gcKey:"inner"`
+		Flag    bool              `gcKey:"a.flag" gcRequired:"sometimes"`
+		Where   string            `gcKey:"a.where" gcScope:"galaxy"`
+		Mode    int               `gcKey:"a.mode" gcIntBase:"99"`
+		Loose   map[string]string `gcKey:"remote"`
+		Floaty  map[float64]Inner `gcKey:"b.*"`
+		Allowed map[string]int    `gcKey:"c.*.n"`
+	}
+	expect := map[string]error{
+		"a.hidden":    ErrUnsupportedType,
+		"a.ch":        ErrUnsupportedType,
+		"a.nested":    ErrUnsupportedType,
+		"inner.ratio": ErrUnsupportedType,
+		"a.flag":      ErrInvalidTag,
+		"a.where":     ErrInvalidTag,
+		"a.mode":      ErrInvalidTag,
+		"remote":      ErrInvalidTag,
+		"b.*":         ErrUnsupportedType,
+	}
+	// an empty config still reports every mistake
+	err := NewConfig().Load(&Bad{})
+	var lerr LoadError
+	if !errors.As(err, &lerr) {
+		t.Fatalf("Expect a LoadError, but got: %v", err)
+	}
+	keys := make([]string, 0, len(lerr))
+	for key, err := range lerr {
+		keys = append(keys, key)
+		if !errors.Is(err, expect[key]) {
+			t.Errorf("Expect %s to fail with %v, but got: %v", key, expect[key], err)
+		}
+	}
+	sort.Strings(keys)
+	want := make([]string, 0, len(expect))
+	for key := range expect {
+		want = append(want, key)
+	}
+	sort.Strings(want)
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expect errors for %v, but got %v", want, keys)
+	}
+
+	type Loop struct {
+		Name string `gcKey:"name"`
+		Next *Loop  `gcKey:"next"`
+	}
+	if err := CheckStruct(Loop{}); err != nil {
+		t.Errorf("Expect self referencing struct to check, but got: %s", err)
+	}
+}
+
+func TestRequireKeys(t *testing.T) {
+	config, err := NewConfigFromString("[user]\n\tname = Joe\n\temail = joe@x\n\temail =\n[remote \"origin\"]\n\tpushurl\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	if err := config.RequireKeys("user.name", "User.Name"); err != nil {
+		t.Errorf("Expect user.name to be found, but got: %s", err)
+	}
+	err = config.RequireKeys("user.name", "user.email", "remote.origin.url", "remote.origin.pushurl", "core.editor")
+	var re *RequiredError
+	if !errors.As(err, &re) || !errors.Is(err, ErrRequiredMissing) {
+		t.Fatalf("Expect a RequiredError, but got: %v", err)
+	}
+	got := make([]string, len(re.Missing))
+	for i, m := range re.Missing {
+		got[i] = m.Key
+		if m.Empty != (m.Key != "remote.origin.url" && m.Key != "core.editor") {
+			t.Errorf("Expect %s empty to be %v", m.Key, !m.Empty)
+		}
+	}
+	expect := []string{"core.editor", "remote.origin.pushurl", "remote.origin.url", "user.email"}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expect missing %v, but got %v", expect, got)
+	}
+	msg := "The following 4 required settings are missing:\n  [core]\n    editor\n  [remote \"origin\"]\n    pushurl (set, but empty)\n    url\n  [user]\n    email (set, but empty)\n"
+	if err.Error() != msg {
+		t.Errorf("Expect message:\n%s\nbut got:\n%s", msg, err)
+	}
+	var ke *KeyError
+	if err := config.RequireKeys("user"); !errors.As(err, &ke) {
+		t.Errorf("Expect a KeyError for an invalid key, but got: %v", err)
+	}
+}
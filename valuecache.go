@@ -0,0 +1,124 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"sync"
+	"time"
+)
+
+// A ValueCache remembers the typed values of keys looked up through it, so
+// keys read over and over are only parsed once. Its getters act as the
+// Lookup* ones of the Config, errors included.
+// Everything cached is dropped whenever the Config reports a change to
+// OnChange listeners: Set, Unset, ApplyOverrides, ReloadFromFile and the
+// like. Values added without notification (AddKeyValue, or changing
+// Entries directly) need a call to Reset.
+// It is safe for concurrent use, as long as the Config is not changed at
+// the same time. A value looked up while the cache is reset is returned
+// but not kept, so it never outlives the change.
+type ValueCache struct {
+	mu     sync.RWMutex
+	config func() *Config // the Config to read, see Watcher.NewValueCache
+	gen    uint64         // counts resets
+	values map[cacheKey]cachedValue
+	stop   func()
+}
+
+type cacheKind uint8
+
+const (
+	cacheString cacheKind = iota
+	cacheInt
+	cacheUint
+	cacheBool
+	cacheDuration
+)
+
+type cacheKey struct {
+	key  string // as given to the getter
+	kind cacheKind
+}
+
+type cachedValue struct {
+	value interface{}
+	err   error
+}
+
+// Creates a cache for the config's values. Call Close once done with it.
+func (self *Config) NewValueCache() *ValueCache {
+	return newValueCache(self, func() *Config { return self })
+}
+
+// Creates a cache for the values of the watcher's active Config, reading
+// each new Config once the files are re-read. Call Close once done with it.
+func (self *Watcher) NewValueCache() *ValueCache {
+	return newValueCache(self.Config(), self.Config)
+}
+
+func newValueCache(cfg *Config, config func() *Config) *ValueCache {
+	out := &ValueCache{config: config, values: make(map[cacheKey]cachedValue, 16)}
+	// listeners carry over to a watcher's new Configs
+	out.stop = cfg.OnChange("*", func(string, []string, []string) { out.Reset() })
+	return out
+}
+
+// Drops every cached value.
+func (self *ValueCache) Reset() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.gen++
+	clear(self.values)
+}
+
+// Stops the cache following changes to the config.
+func (self *ValueCache) Close() {
+	self.stop()
+	self.Reset()
+}
+
+// See Config.LookupString.
+func (self *ValueCache) String(key string) (string, error) {
+	return cachedLookup(self, key, cacheString, (*Config).LookupString)
+}
+
+// See Config.LookupInt.
+func (self *ValueCache) Int(key string) (int64, error) {
+	return cachedLookup(self, key, cacheInt, (*Config).LookupInt)
+}
+
+// See Config.LookupUint.
+func (self *ValueCache) Uint(key string) (uint64, error) {
+	return cachedLookup(self, key, cacheUint, (*Config).LookupUint)
+}
+
+// See Config.LookupBool.
+func (self *ValueCache) Bool(key string) (bool, error) {
+	return cachedLookup(self, key, cacheBool, (*Config).LookupBool)
+}
+
+// See Config.LookupDuration.
+func (self *ValueCache) Duration(key string) (time.Duration, error) {
+	return cachedLookup(self, key, cacheDuration, (*Config).LookupDuration)
+}
+
+func cachedLookup[T any](self *ValueCache, key string, kind cacheKind, lookup func(*Config, string) (T, error)) (T, error) {
+	ck := cacheKey{key: key, kind: kind}
+	self.mu.RLock()
+	cv, ok := self.values[ck]
+	gen := self.gen
+	self.mu.RUnlock()
+	reportCache(CacheValues, ok)
+	if ok {
+		return cv.value.(T), cv.err
+	}
+	value, err := lookup(self.config(), key)
+	self.mu.Lock()
+	if self.gen == gen {
+		self.values[ck] = cachedValue{value: value, err: err}
+	}
+	self.mu.Unlock()
+	return value, err
+}
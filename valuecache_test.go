@@ -0,0 +1,151 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValueCache(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(file, []byte("[http]\n\tpostBuffer = 1024\n\ttimeout = 5s\n\tsslVerify = no\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := NewConfigFromFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read: %s", err)
+	}
+	cache := config.NewValueCache()
+	defer cache.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if n, err := cache.Int("http.postbuffer"); err != nil || n != 1024 {
+					t.Errorf("Expect 1024, but got %d (%v)", n, err)
+					return
+				}
+				if d, err := cache.Duration("http.timeout"); err != nil || d != 5*time.Second {
+					t.Errorf("Expect 5s, but got %s (%v)", d, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if b, err := cache.Bool("http.sslverify"); err != nil || b {
+		t.Errorf("Expect false, but got %v (%v)", b, err)
+	}
+	if _, err := cache.Uint("http.missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expect ErrKeyNotFound, but got: %v", err)
+	}
+	if _, err := cache.Int("http.timeout"); err == nil {
+		t.Errorf("Expect error reading a duration as an int")
+	}
+
+	if err := config.Set("http.postBuffer", "2048"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := cache.Int("http.postbuffer"); n != 2048 {
+		t.Errorf("Expect cache dropped after Set, but got %d", n)
+	}
+	if err := os.WriteFile(file, []byte("[http]\n\tpostBuffer = 4096\n\tmissing = 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.ReloadFromFile(); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := cache.Int("http.postbuffer"); n != 4096 {
+		t.Errorf("Expect cache dropped after reload, but got %d", n)
+	}
+	if n, err := cache.Uint("http.missing"); err != nil || n != 1 {
+		t.Errorf("Expect a cached missing key to be found after reload, but got %d (%v)", n, err)
+	}
+
+	v := "x"
+	config.AddKeyValue("core", "", "editor", &v)
+	if _, err := cache.String("core.editor"); err != nil {
+		t.Errorf("Expect uncached key to be read, but got: %v", err)
+	}
+	config.AddKeyValue("core", "", "editor", &v)
+	cache.Reset()
+	if s, err := cache.String("core.editor"); err != nil || s != "x" {
+		t.Errorf("Expect x after Reset, but got %s (%v)", s, err)
+	}
+}
+
+func BenchmarkLookupInt(b *testing.B) {
+	config, _ := NewConfigFromString(smallConfig + "[http]\n\tpostBuffer = 1024\n")
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := config.LookupInt("http.postbuffer"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValueCacheInt(b *testing.B) {
+	config, _ := NewConfigFromString(smallConfig + "[http]\n\tpostBuffer = 1024\n")
+	cache := config.NewValueCache()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := cache.Int("http.postbuffer"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestValueCacheWatcher(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(file, []byte("[http]\n\tpostBuffer = 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWatcher(time.Hour, file)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %s", err)
+	}
+	cache := w.NewValueCache()
+	defer cache.Close()
+	if n, _ := cache.Int("http.postbuffer"); n != 1 {
+		t.Errorf("Expect 1, but got %d", n)
+	}
+	if err := os.WriteFile(file, []byte("[http]\n\tpostBuffer = 22\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := cache.Int("http.postbuffer"); n != 22 {
+		t.Errorf("Expect the reloaded config read, 22, but got %d", n)
+	}
+}
+
+func TestValueCacheResetDuringLookup(t *testing.T) {
+	config, _ := NewConfigFromString("[http]\n\tpostBuffer = 1\n")
+	cache := config.NewValueCache()
+	defer cache.Close()
+	// a value looked up before a reset must not be kept after it
+	n, err := cachedLookup(cache, "http.postbuffer", cacheInt, func(cfg *Config, key string) (int64, error) {
+		cache.Reset()
+		return 1, nil
+	})
+	if n != 1 || err != nil {
+		t.Errorf("Expect the value looked up returned, but got %d (%v)", n, err)
+	}
+	cache.mu.RLock()
+	kept := len(cache.values)
+	cache.mu.RUnlock()
+	if kept != 0 {
+		t.Errorf("Expect nothing cached from a lookup overlapping a reset, but got %d values", kept)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Something git accepts in a config but which is probably a mistake, see
+// Config.Warnings.
+type Warning struct {
+	// One of "duplicate-section", "key-outside-section", "overridden-value",
+	// "comment-in-value", "suspicious-escape", "unknown-escape" or
+	// "deprecated-key" (see Config.DeprecateKey).
+	Code    string
+	Key     string // the key concerned, if any
+	Origin  ValueOrigin
+	Message string
+}
+
+// Gets the warning as a Diagnostic of SeverityWarning.
+func (self Warning) Diagnostic() Diagnostic {
+	return Diagnostic{File: self.Origin.File, Line: self.Origin.Line, Severity: SeverityWarning, Code: self.Code, Key: self.Key, Message: self.Message}
+}
+
+// Keys which are meant to be given more than once, so are not warned about
+// when they are. Patterns are as for OnChange.
+var multiValuedKeys = []string{
+	"remote.*.url", "remote.*.pushurl", "remote.*.fetch", "remote.*.push",
+	"branch.*.merge", "include.path", "includeif.*.path",
+	"credential.helper", "credential.*.helper", "url.*.insteadof",
+	"url.*.pushinsteadof", "http.extraheader", "http.*.extraheader",
+	"transfer.hiderefs", "uploadpack.hiderefs", "receive.hiderefs",
+	"log.excludedecoration", "safe.directory", "sendemail.to",
+	"sendemail.cc", "sendemail.bcc", "notes.displayref",
+	"fetch.negotiationtip",
+}
+
+// Lists things git accepts but which are probably mistakes: sections given
+// more than once, keys outside any section, comments with no space before
+// them cutting a value short (e.g. url = http://host/#frag), tab and
+// newline escapes which look like part of a Windows path (e.g. C:\temp),
+// and keys given again later in the same file, hiding the earlier value.
+// The last is not reported for keys known to take several values, such as
+// remote.<name>.fetch. Keys deprecated with DeprecateKey are reported once
+// read. Warnings never stop a config being read; they are in the order
+// found, those for hidden values last.
+func (self *Config) Warnings() []Warning {
+	out := append([]Warning(nil), self.warnings...)
+	out = append(out, self.deprecations.Load().collected()...)
+	overridden := make([]Warning, 0, 2)
+	for key, cv := range self.keyValues() {
+		if len(cv.Entries) < 2 || isMultiValued(key) {
+			continue
+		}
+		last := cv.Entries[len(cv.Entries)-1].Origin
+		for _, e := range cv.Entries[:len(cv.Entries)-1] {
+			if e.Origin.Line == 0 || e.Origin.File != last.File || last.Line == 0 {
+				continue
+			}
+			overridden = append(overridden, Warning{Code: "overridden-value", Key: key, Origin: e.Origin, Message: fmt.Sprintf("%s is given again on line %d, which hides this value", key, last.Line)})
+		}
+	}
+	sort.Slice(overridden, func(i, j int) bool {
+		a, b := overridden[i].Origin, overridden[j].Origin
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return append(out, overridden...)
+}
+
+func isMultiValued(key string) bool {
+	for _, pattern := range multiValuedKeys {
+		if matchKeyPattern(pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *Parser) warn(w Warning) {
+	self.Config.warnings = append(self.Config.warnings, w)
+}
+
+func (self *Parser) warnOutsideSection(name string, origin ValueOrigin) {
+	self.warn(Warning{Code: "key-outside-section", Key: name, Origin: origin, Message: fmt.Sprintf("Key '%s' is not in any section, git cannot look it up", name)})
+}
+
+func (self *Parser) warnComment(c byte) {
+	self.warn(Warning{Code: "comment-in-value", Origin: self.origin(), Message: fmt.Sprintf("'%c' with no space before it starts a comment, ending the value; quote the value to keep it", c)})
+}
+
+func (self *Parser) warnEscape(c byte) {
+	self.warn(Warning{Code: "suspicious-escape", Origin: self.origin(), Message: fmt.Sprintf("'\\%c' after ':' is read as a control character, did you mean a path? Write '\\\\' for a backslash", c)})
+}
+
+func (self *Parser) warnUnknownEscape(c string) {
+	policy := "dropped the backslash"
+	if self.Options.UnknownEscapes == EscapePreserve {
+		policy = "kept the backslash"
+	}
+	self.warn(Warning{Code: "unknown-escape", Origin: self.origin(), Message: fmt.Sprintf("'\\%s' is not an escape git knows and would fail to read, %s", c, policy)})
+}
@@ -0,0 +1,64 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWarnings(t *testing.T) {
+	config, err := NewConfigFromString("orphan = 1\n" +
+		"[user]\n\temail = a@x\n" +
+		"[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n\turl = https://host/repo#main\n" +
+		"[core]\n\teditor = \"vim\";no space\n\tpath = C:\\temp\n\tfine = C:\\\\temp ; ok\n\tnl = line1\\nline2\n" +
+		"[user]\n\temail = b@x\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	type found struct {
+		code, key string
+		line      uint64
+	}
+	got := make([]found, 0, 6)
+	for _, w := range config.Warnings() {
+		got = append(got, found{w.Code, w.Key, w.Origin.Line})
+		if w.Message == "" {
+			t.Errorf("Expect a message for %+v", w)
+		}
+	}
+	expect := []found{
+		{"key-outside-section", "orphan", 1},
+		{"comment-in-value", "", 7},
+		{"comment-in-value", "", 9},
+		{"suspicious-escape", "", 10},
+		{"duplicate-section", "", 13},
+		{"overridden-value", "user.email", 3},
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expect warnings %+v, but got %+v", expect, got)
+	}
+	testValue(t, config, "remote.origin.url", "https://host/repo", true)
+
+	d := config.Warnings()[0].Diagnostic()
+	if d.Severity != SeverityWarning || d.Code != "key-outside-section" || d.Line != 1 {
+		t.Errorf("Unexpected diagnostic for warning: %+v", d)
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a": "[user]\n\temail = a@x\n",
+		"b": "[user]\n\temail = b@x\n",
+	})
+	merged, err := LoadFilesContext(context.Background(), filepath.Join(dir, "a"), filepath.Join(dir, "b"))
+	if err != nil {
+		t.Fatalf("Failed to load: %s", err)
+	}
+	if ws := merged.Warnings(); len(ws) != 0 {
+		t.Errorf("Expect no warnings for a value hidden by another file, but got %+v", ws)
+	}
+}
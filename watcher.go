@@ -0,0 +1,193 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Watcher keeps a Config built from one or more files up to date.
+// Files are polled for changes to their modification time or size, and when
+// any change the whole set is re-read, the active Config is swapped for the
+// new one, and subscribers are told which keys changed.
+// Files are merged in the order given, so later files take precedence.
+// Files which do not exist are treated as empty.
+type Watcher struct {
+	Interval time.Duration // how often Start polls, 5s if not positive
+	// Called with any error re-reading the files in the background,
+	// the previous Config stays active.
+	ErrorHandler func(error)
+
+	files       []string
+	current     atomic.Pointer[Config]
+	mu          sync.Mutex
+	stamps      map[string]fileStamp
+	subscribers []func(cfg *Config, changes []KeyChange)
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// Creates a Watcher for the given files, reading them immediately.
+func NewWatcher(interval time.Duration, files ...string) (*Watcher, error) {
+	w := &Watcher{
+		Interval: interval,
+		files:    files,
+	}
+	cfg, stamps, err := w.read()
+	if err != nil {
+		return nil, err
+	}
+	w.stamps = stamps
+	w.current.Store(cfg)
+	return w, nil
+}
+
+// Gets the active Config. It must be treated as read-only, changes are
+// made by swapping in a new Config. Listeners registered with OnChange
+// carry over to each new Config.
+func (self *Watcher) Config() *Config {
+	return self.current.Load()
+}
+
+// Registers a function to be called after the files are re-read and at
+// least one key changed.
+func (self *Watcher) Subscribe(fn func(cfg *Config, changes []KeyChange)) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.subscribers = append(self.subscribers, fn)
+}
+
+// Starts polling the files in the background every Interval, or every 5
+// seconds if that is not positive.
+func (self *Watcher) Start() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if self.stop != nil {
+		return
+	}
+	self.stop = make(chan struct{})
+	self.done = make(chan struct{})
+	go self.poll(self.stop, self.done)
+}
+
+// Stops background polling, waiting for any reload in progress to finish.
+func (self *Watcher) Stop() {
+	self.mu.Lock()
+	stop, done := self.stop, self.done
+	self.stop, self.done = nil, nil
+	self.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (self *Watcher) poll(stop, done chan struct{}) {
+	defer close(done)
+	interval := self.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := self.Check(); err != nil && self.ErrorHandler != nil {
+				self.ErrorHandler(err)
+			}
+		}
+	}
+}
+
+// Checks the files once, re-reading them if any changed. Returns the keys
+// whose values changed, which may be empty even if a file was modified.
+// Listeners and subscribers are called once the new Config is active, and
+// may themselves call Config or Subscribe.
+func (self *Watcher) Check() ([]KeyChange, error) {
+	cfg, changes, subscribers, err := self.reload()
+	if err != nil || len(changes) == 0 {
+		return changes, err
+	}
+	cfg.notify(changes)
+	for _, fn := range subscribers {
+		fn(cfg, changes)
+	}
+	return changes, nil
+}
+
+// Re-reads the files if any changed, making the new Config active, and
+// gets the subscribers to tell.
+func (self *Watcher) reload() (*Config, []KeyChange, []func(*Config, []KeyChange), error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	changed := false
+	for _, file := range self.files {
+		if statFile(file) != self.stamps[file] {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return nil, nil, nil, nil
+	}
+	start := time.Now()
+	cfg, stamps, err := self.read()
+	if err != nil {
+		reportReload(self.files, 0, start, err)
+		return nil, nil, nil, err
+	}
+	self.stamps = stamps
+	old := self.current.Load()
+	cfg.hooks = old.changeHooks()
+	cfg.deprecations.Store(old.deprecations.Load())
+	cfg.logger = old.logger
+	self.current.Store(cfg)
+	changes := Diff(old, cfg)
+	reportReload(self.files, len(changes), start, nil)
+	return cfg, changes, slices.Clone(self.subscribers), nil
+}
+
+func (self *Watcher) read() (*Config, map[string]fileStamp, error) {
+	cfg := NewConfig()
+	stamps := make(map[string]fileStamp, len(self.files))
+	for _, file := range self.files {
+		stamp := statFile(file)
+		stamps[file] = stamp
+		if !stamp.exists {
+			continue
+		}
+		fileCfg, err := NewConfigFromFile(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, nil, err
+		}
+		cfg.Merge(fileCfg)
+	}
+	return cfg, stamps, nil
+}
+
+func statFile(file string) fileStamp {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size(), exists: true}
+}
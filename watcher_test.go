@@ -0,0 +1,158 @@
+// Copyright 2018-2019 "Misato's Angel" <misatos.arngel@gmail.com>.
+// Use of this source code is governed the MIT license.
+// license that can be found in the LICENSE file.
+
+package gitconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	old, _ := NewConfigFromString("[core]\n    a = 1\n    b = 2\n[remote \"Origin\"]\n    fetch = x\n")
+	new, _ := NewConfigFromString("[core]\n    a = 1\n    c = 3\n[remote \"Origin\"]\n    fetch = x\n    fetch = y\n")
+	expect := []KeyChange{
+		{Key: "core.b", Old: []string{"2"}},
+		{Key: "core.c", New: []string{"3"}},
+		{Key: "remote.Origin.fetch", Old: []string{"x"}, New: []string{"x", "y"}},
+	}
+	if got := Diff(old, new); !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expect diff %+v but got %+v\n", expect, got)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "global")
+	local := filepath.Join(dir, "local")
+	if err := os.WriteFile(global, []byte("[user]\n    name = Joe\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWatcher(time.Hour, global, local)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %s", err)
+	}
+	testValue(t, w.Config(), "user.name", "Joe", true)
+
+	var notified []KeyChange
+	w.Subscribe(func(cfg *Config, changes []KeyChange) {
+		notified = changes
+	})
+	var onChange []string
+	w.Config().OnChange("user.*", func(key string, old, new []string) {
+		onChange = new
+	})
+	if changes, err := w.Check(); err != nil || changes != nil {
+		t.Errorf("Expect no changes before files are modified but got %v (error: %v)\n", changes, err)
+	}
+	if err := os.WriteFile(local, []byte("[user]\n    name = Joanne\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Check(); err != nil {
+		t.Errorf("Failed to check files: %s", err)
+	}
+	testValue(t, w.Config(), "user.name", "Joanne", true)
+	expect := []KeyChange{{Key: "user.name", Old: []string{"Joe"}, New: []string{"Joe", "Joanne"}}}
+	if !reflect.DeepEqual(notified, expect) {
+		t.Errorf("Expect subscriber to be told %+v but got %+v\n", expect, notified)
+	}
+	if !reflect.DeepEqual(onChange, expect[0].New) {
+		t.Errorf("Expect OnChange listener to carry over and see %v but got %v\n", expect[0].New, onChange)
+	}
+
+	if err := os.WriteFile(local, []byte("[user\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Check(); err == nil {
+		t.Errorf("Expect error on re-reading a broken file\n")
+	}
+	testValue(t, w.Config(), "user.name", "Joanne", true)
+}
+
+func TestReloadFromFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(file, []byte("[user]\n    name = Joe\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := NewConfigFromFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read config: %s", err)
+	}
+	var seen []string
+	config.OnChange("user.name", func(key string, old, new []string) {
+		seen = new
+	})
+	if err := os.WriteFile(file, []byte("[user]\n    name = Joanne\n    email = j@example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := config.ReloadFromFile()
+	if err != nil {
+		t.Fatalf("Failed to reload config: %s", err)
+	}
+	if len(changes) != 2 || changes[0].Key != "user.email" || changes[1].Key != "user.name" {
+		t.Errorf("Expect changes to user.email and user.name but got %+v\n", changes)
+	}
+	testValue(t, config, "user.name", "Joanne", true)
+	if !reflect.DeepEqual(seen, []string{"Joanne"}) {
+		t.Errorf("Expect OnChange listener to see Joanne but got %v\n", seen)
+	}
+
+	fromString, _ := NewConfigFromString("")
+	if _, err := fromString.ReloadFromFile(); !errors.Is(err, ErrNoSource) {
+		t.Errorf("Expect ErrNoSource reloading a config read from a string but got %v\n", err)
+	}
+}
+
+func TestWatcherCallbacks(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(file, []byte("[user]\n\tname = Joe\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWatcher(time.Hour, file)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %s", err)
+	}
+	var seen string
+	w.Subscribe(func(cfg *Config, changes []KeyChange) {
+		// must not deadlock
+		seen, _ = w.Config().GetKeyValueAsString("user.name")
+		w.Subscribe(func(*Config, []KeyChange) {})
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Config().OnChange("user.*", func(string, []string, []string) {})
+		}()
+	}
+	if err := os.WriteFile(file, []byte("[user]\n\tname = Joanne\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Check(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if seen != "Joanne" {
+		t.Errorf("Expect the subscriber to see the new config, but got '%s'", seen)
+	}
+	if n := len(w.Config().hooks.listeners); n != 4 {
+		t.Errorf("Expect every listener kept, but got %d", n)
+	}
+}
+
+func TestWatcherNoInterval(t *testing.T) {
+	w, err := NewWatcher(0, filepath.Join(t.TempDir(), "config"))
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %s", err)
+	}
+	// must not panic
+	w.Start()
+	w.Stop()
+}